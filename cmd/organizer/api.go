@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/avizyt/org-cli/internal/organizer"
+)
+
+// apiHandler serves the control API of the daemon and the status socket of every run:
+//
+//	POST /run      trigger an immediate run (queued if one is in progress; daemon only)
+//	GET  /progress current progress of the running (or last) run
+//	POST /pause    stop dispatching files until resumed
+//	POST /resume   continue after a pause
+//	GET  /summary  summary of the last completed run
+type apiHandler struct {
+	status  *runStatus
+	control *organizer.Controller
+	trigger chan<- struct{} // nil for one-shot runs, which cannot be triggered again
+}
+
+// routes returns the request multiplexer for the API.
+func (h *apiHandler) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /run", h.run)
+	mux.HandleFunc("GET /progress", h.progress)
+	mux.HandleFunc("POST /pause", h.pause)
+	mux.HandleFunc("POST /resume", h.resume)
+	mux.HandleFunc("GET /summary", h.summary)
+	return mux
+}
+
+func (h *apiHandler) run(w http.ResponseWriter, r *http.Request) {
+	if h.trigger == nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "not running as a daemon"})
+		return
+	}
+	select {
+	case h.trigger <- struct{}{}:
+		writeJSON(w, http.StatusAccepted, map[string]string{"result": "queued"})
+	default:
+		writeJSON(w, http.StatusAccepted, map[string]string{"result": "already queued"})
+	}
+}
+
+func (h *apiHandler) progress(w http.ResponseWriter, r *http.Request) {
+	snapshot := h.status.snapshot()
+	snapshot.Paused = h.control.Paused()
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+func (h *apiHandler) pause(w http.ResponseWriter, r *http.Request) {
+	h.control.Pause()
+	writeJSON(w, http.StatusOK, map[string]bool{"paused": true})
+}
+
+func (h *apiHandler) resume(w http.ResponseWriter, r *http.Request) {
+	h.control.Resume()
+	writeJSON(w, http.StatusOK, map[string]bool{"paused": false})
+}
+
+func (h *apiHandler) summary(w http.ResponseWriter, r *http.Request) {
+	last := h.status.lastSummary()
+	if last == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no run has completed yet"})
+		return
+	}
+	writeJSON(w, http.StatusOK, last)
+}
+
+// writeJSON encodes v as the response body with the given status code.
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// apiServer is a running control API server.
+type apiServer struct {
+	srv        *http.Server
+	socketPath string // Removed on Close when listening on a unix socket
+}
+
+// startAPI starts serving h on addr, which is either "unix:/path/to.sock" or a host:port on a
+// loopback interface. The API has no authentication, so exposing it on the network is refused.
+func startAPI(addr string, h *apiHandler) (*apiServer, error) {
+	var (
+		ln         net.Listener
+		err        error
+		socketPath string
+	)
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		// Remove a stale socket left behind by a previous instance
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove stale socket '%s': %w", path, err)
+		}
+		ln, err = net.Listen("unix", path)
+		socketPath = path
+	} else {
+		if err := requireLoopback(addr, "the unauthenticated control API"); err != nil {
+			return nil, err
+		}
+		ln, err = net.Listen("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if socketPath != "" {
+		os.Chmod(socketPath, 0600) // Only the owner may control the daemon
+	}
+
+	srv := &http.Server{Handler: h.routes()}
+	go srv.Serve(ln)
+	return &apiServer{srv: srv, socketPath: socketPath}, nil
+}
+
+// Close stops the server and cleans up its socket file.
+func (s *apiServer) Close() error {
+	err := s.srv.Close()
+	if s.socketPath != "" {
+		os.Remove(s.socketPath)
+	}
+	return err
+}
+
+// requireLoopback rejects TCP addresses that are not bound to a loopback interface, for serving
+// what on them.
+func requireLoopback(addr, what string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid listen address '%s': %w", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("refusing to expose %s on '%s'; use a loopback address", what, addr)
+}
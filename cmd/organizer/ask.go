@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/avizyt/org-cli/internal/organizer"
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// terminalAsker is the organizer.ConflictAsker of --on-conflict ask. It prompts on the terminal
+// while the other messages wait, and remembers an answer given in upper case for the rest of the
+// run.
+type terminalAsker struct {
+	printer *terminalPrinter
+	in      *bufio.Reader
+	always  string // Answer to all further collisions, once given
+}
+
+// newTerminalAsker returns an asker prompting through printer, or an error if stdin is not a
+// terminal to read the answers from. /dev/null, which cron and service managers give, is a
+// character device but no terminal: every prompt would read end of file.
+func newTerminalAsker(printer *terminalPrinter) (*terminalAsker, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, errors.New("--on-conflict ask needs an interactive terminal on stdin")
+	}
+	return &terminalAsker{printer: printer, in: bufio.NewReader(os.Stdin)}, nil
+}
+
+// conflictAnswers maps the keys of the prompt to the answers.
+var conflictAnswers = map[string]string{
+	"o": organizer.ConflictTrash,
+	"b": organizer.ConflictBackup,
+	"r": organizer.ConflictRename,
+	"s": organizer.ConflictSkip,
+}
+
+func (a *terminalAsker) Ask(c organizer.Conflict) (answer string, err error) {
+	if a.always != "" {
+		return a.always, nil
+	}
+	yellow := color.New(color.FgYellow).SprintFunc()
+	a.printer.exclusive(func() {
+		fmt.Println(yellow(i18n.Sprintf("%s '%s' already exists, from '%s'.", glyph("⚠️"), c.Dest, c.Source)))
+		for {
+			fmt.Print(i18n.T("[o]verwrite (the existing file goes to the trash), [b]ack up the existing file, [r]ename the new file, [s]kip, [i]nfo; upper case for all: "))
+			var line string
+			if line, err = a.in.ReadString('\n'); err != nil {
+				err = fmt.Errorf("no answer: %w", err)
+				return
+			}
+			key := strings.TrimSpace(line)
+			if key == "i" || key == "I" {
+				printConflictInfo(c)
+				continue
+			}
+			if answer = conflictAnswers[strings.ToLower(key)]; answer != "" {
+				if key != strings.ToLower(key) {
+					a.always = answer
+				}
+				return
+			}
+		}
+	})
+	return answer, err
+}
+
+// printConflictInfo shows how the two files of c differ.
+func printConflictInfo(c organizer.Conflict) {
+	i18n.Printf("  New:      %10s  %s\n", organizer.FormatBytes(c.Info.Size()), c.Info.ModTime().Format(time.DateTime))
+	i18n.Printf("  Existing: %10s  %s\n", organizer.FormatBytes(c.Existing.Size()), c.Existing.ModTime().Format(time.DateTime))
+	switch same, err := c.SameContent(); {
+	case err != nil:
+		i18n.Printf("  Could not compare the contents: %v\n", err)
+	case same:
+		fmt.Println(i18n.T("  The contents are identical."))
+	default:
+		fmt.Println(i18n.T("  The contents differ."))
+	}
+}
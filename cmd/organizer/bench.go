@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/avizyt/org-cli/internal/organizer"
+	"github.com/fatih/color"
+)
+
+// benchExtensions are the extensions of the generated files, so they spread over the categories.
+var benchExtensions = []string{".jpg", ".png", ".pdf", ".docx", ".txt", ".mp4", ".mp3", ".zip", ".go", ".bin"}
+
+// benchFilesPerDir is how many generated files share a directory.
+const benchFilesPerDir = 100
+
+// benchRound is the outcome of organizing the generated tree with one worker count.
+type benchRound struct {
+	workers int
+	result  organizer.Result
+}
+
+// runBench implements `organizer bench`, which organizes a generated tree in a temporary
+// directory on the storage to test with several worker counts and reports the scan and move
+// throughput of each, and returns the process exit code.
+func runBench(args []string) int {
+	blue := color.New(color.FgBlue).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	sourceDir := fs.String("source", "", "Directory on the storage to test; the generated tree is created and removed below it (required)")
+	destDir := fs.String("dest", "", "Directory to organize into, for testing moves to other storage (default: next to the generated tree)")
+	files := fs.Int("files", 1000, "Number of files to generate")
+	minSize := fs.String("min-size", "4KiB", "Size of the smallest generated file")
+	maxSize := fs.String("max-size", "4MiB", "Size of the largest generated file; sizes in between are spread evenly on a log scale, so most files are small")
+	workers := fs.String("workers", "", "Comma separated worker counts to test (default: powers of two up to twice the CPUs)")
+	addOutputFlags(fs)
+	fs.Parse(args)
+
+	if *sourceDir == "" || *files <= 0 {
+		fmt.Fprintln(os.Stderr, red(i18n.T("Usage: organizer bench --source <dir> [--dest <dir>] [--files n] [--min-size size] [--max-size size] [--workers n,n,...]")))
+		return 2
+	}
+	lo, err := parseSize(*minSize)
+	if err == nil && lo == 0 {
+		lo = 1
+	}
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: invalid --min-size '%s'\n", *minSize)))
+		return 2
+	}
+	hi, err := parseSize(*maxSize)
+	if err != nil || hi < lo {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: invalid --max-size '%s', must be at least --min-size\n", *maxSize)))
+		return 2
+	}
+	counts, err := benchWorkerCounts(*workers)
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: --workers: %v\n", err)))
+		return 2
+	}
+
+	// Ctrl-C finishes the files in flight, and the generated tree is removed all the same
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var rounds []benchRound
+	for _, n := range counts {
+		i18n.Printf("%s Organizing %d generated files with %d workers...\n", blue(glyph("⏱️")), *files, n)
+		result, err := benchOnce(ctx, *sourceDir, *destDir, n, *files, lo, hi)
+		if err != nil {
+			fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+			return 1
+		}
+		rounds = append(rounds, benchRound{workers: n, result: result})
+	}
+
+	fmt.Println()
+	fmt.Println(i18n.T("Workers       Scan     Files/s       Move     Files/s        MB/s"))
+	best := rounds[0]
+	for _, r := range rounds {
+		t := r.result.Timing
+		scan := time.Duration(t.ScanMS) * time.Millisecond
+		move := time.Duration(t.ProcessMS) * time.Millisecond
+		fmt.Printf("%7d %10s %11s %10s %11s %11s\n", r.workers,
+			scan, benchRate(float64(r.result.Scanned), scan),
+			move, benchRate(float64(r.result.Processed), move), benchRate(float64(r.result.Bytes)/(1<<20), move))
+		if t.ProcessMS < best.result.Timing.ProcessMS {
+			best = r
+		}
+	}
+	fmt.Println()
+	i18n.Printf("%s Fastest moves with %s workers; pass --workers %d when organizing on this storage.\n", green(glyph("✅")), green(strconv.Itoa(best.workers)), best.workers)
+	return 0
+}
+
+// benchWorkerCounts parses the --workers list of bench, or returns the default counts.
+func benchWorkerCounts(list string) ([]int, error) {
+	if list == "" {
+		var counts []int
+		for n := 1; n <= 2*runtime.NumCPU(); n *= 2 {
+			counts = append(counts, n)
+		}
+		return counts, nil
+	}
+	var counts []int
+	for _, s := range splitList(list) {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid worker count '%s'", s)
+		}
+		counts = append(counts, n)
+	}
+	return counts, nil
+}
+
+// benchOnce generates a tree of files below sourceDir and organizes it into destDir, or next to
+// it, with the given number of workers. Both are removed again.
+func benchOnce(ctx context.Context, sourceDir, destDir string, workers, files int, minSize, maxSize int64) (organizer.Result, error) {
+	root, err := os.MkdirTemp(sourceDir, ".organizer-bench-")
+	if err != nil {
+		return organizer.Result{}, err
+	}
+	defer os.RemoveAll(root)
+	dest := filepath.Join(root, "dest")
+	if destDir != "" {
+		if dest, err = os.MkdirTemp(destDir, ".organizer-bench-"); err != nil {
+			return organizer.Result{}, err
+		}
+		defer os.RemoveAll(dest)
+	}
+	src := filepath.Join(root, "source")
+	if err := generateBenchTree(src, files, minSize, maxSize); err != nil {
+		return organizer.Result{}, fmt.Errorf("failed to generate the files: %w", err)
+	}
+
+	org, err := organizer.New(
+		organizer.WithSource(src),
+		organizer.WithDest(dest),
+		organizer.WithRecursive(true),
+		organizer.WithWorkers(workers),
+		organizer.WithVerbosity(organizer.VerbosityQuiet),
+		organizer.WithPrinter(organizer.PlainPrinter(io.Discard)),
+	)
+	if err != nil {
+		return organizer.Result{}, err
+	}
+	return org.Run(ctx)
+}
+
+// generateBenchTree writes files files to directories of benchFilesPerDir below dir. Their sizes
+// are spread evenly between minSize and maxSize on a log scale, by a fixed seed so every round
+// gets the same tree.
+func generateBenchTree(dir string, files int, minSize, maxSize int64) error {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	data := make([]byte, maxSize)
+	for i := range data {
+		data[i] = byte(rnd.Uint32())
+	}
+	logMin, logMax := math.Log(float64(minSize)), math.Log(float64(maxSize))
+	for i := 0; i < files; i++ {
+		sub := filepath.Join(dir, fmt.Sprintf("d%03d", i/benchFilesPerDir))
+		if i%benchFilesPerDir == 0 {
+			if err := os.MkdirAll(sub, 0755); err != nil {
+				return err
+			}
+		}
+		size := int64(math.Exp(logMin + rnd.Float64()*(logMax-logMin)))
+		name := fmt.Sprintf("file%05d%s", i, benchExtensions[i%len(benchExtensions)])
+		if err := os.WriteFile(filepath.Join(sub, name), data[:min(size, maxSize)], 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// benchRate formats n per d, or "-" when d is too short to tell.
+func benchRate(n float64, d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	return strconv.FormatFloat(n/d.Seconds(), 'f', 0, 64)
+}
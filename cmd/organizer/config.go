@@ -0,0 +1,526 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/notify"
+	"github.com/avizyt/org-cli/internal/organizer"
+)
+
+// fileConfig is the contents of the --config file.
+//
+// Two layouts are accepted. The original one is a flat object of extension to category:
+//
+//	{".log": "Logs", "svg": "Graphics"}
+//
+// The structured one nests the mappings and adds named profiles:
+//
+//	{
+//	  "include": ["team.json"],
+//	  "mappings": {".log": "Logs", "@raw": "Raw Photos"},
+//	  "ext_groups": {"raw": ["cr2", "nef", "arw"]},
+//	  "profiles": {"downloads": {"skip_top_dirs": ["Keep", "In Progress"]}},
+//	  "retention": [{"category": "Archives", "older_than": "2y", "action": "trash"}],
+//	  "quotas": [{"category": "Videos", "max": "500GB", "policy": "overflow", "overflow": "/mnt/big/Videos"}],
+//	  "tiers": [{"name": "Hot", "younger_than": "30d"}, {"name": "Warm", "younger_than": "1y"}, {"name": "Cold"}],
+//	  "hooks": {"after_run": "curl -s -X POST http://plex:32400/library/sections/1/refresh"},
+//	  "email": {"host": "smtp.example.com", "username": "me", "password_env": "SMTP_PASSWORD", "from": "organizer@example.com", "to": ["me@example.com"]},
+//	  "folder_names": {"Images": "Photos"},
+//	  "folder_order": ["Documents", "Images"],
+//	  "vendors": {"Chase": "Banking/Chase", "PG&E": "Utilities/PG&E"},
+//	  "rules_file": "rules.star"
+//	}
+type fileConfig struct {
+	Include   includeList              `json:"include"` // Config files this one is layered on, see merge
+	Mappings  map[string]string        `json:"mappings"`
+	Profiles  map[string]profileConfig `json:"profiles"`
+	Retention []retentionConfig        `json:"retention"`
+	Quotas    []quotaConfig            `json:"quotas"`
+	Tiers     []tierConfig             `json:"tiers"`
+	Hooks     hooksConfig              `json:"hooks"`
+	Email     emailConfig              `json:"email"` // SMTP settings for --email-summary
+
+	ExtGroups   map[string][]string `json:"ext_groups"`   // Named extension groups, e.g. {"raw": ["cr2", "nef"]}; a default group is replaced
+	FolderNames map[string]string   `json:"folder_names"` // Folder name per category, e.g. {"Images": "Photos"}
+	FolderOrder []string            `json:"folder_order"` // Order of the categories for --numbered-folders
+	Vendors     map[string]string   `json:"vendors"`      // Folder under Documents per vendor name in file names, e.g. {"Chase": "Banking/Chase"}
+
+	RulesFile    string `json:"rules_file"`    // Starlark script defining classify(file); relative to the config file
+	Rules        string `json:"rules"`         // Or the script inline
+	RulesTimeout string `json:"rules_timeout"` // Time limit per evaluation, e.g. "500ms"
+
+	path string // Where the config was loaded from
+}
+
+// hooksConfig holds the shell commands run around the job and around each file.
+type hooksConfig struct {
+	BeforeRun  string `json:"before_run"`
+	AfterRun   string `json:"after_run"`
+	BeforeFile string `json:"before_file"` // A non-zero exit status leaves the file in place
+	AfterFile  string `json:"after_file"`
+}
+
+// emailConfig holds the SMTP server and addresses --email-summary mails the run summary with.
+type emailConfig struct {
+	Host        string   `json:"host"`
+	Port        int      `json:"port"` // 587 (STARTTLS) by default, 465 for TLS from the start
+	Username    string   `json:"username"`
+	Password    string   `json:"password"`
+	PasswordEnv string   `json:"password_env"` // Or the environment variable holding the password
+	From        string   `json:"from"`
+	To          []string `json:"to"`
+}
+
+// retentionConfig is a retention rule as written in the config file, applied by `organizer prune`.
+type retentionConfig struct {
+	Category  string `json:"category"`   // Category folder in the destination, may include subfolders
+	OlderThan string `json:"older_than"` // Age like "90d" or "2y"
+	Action    string `json:"action"`     // "trash" or "delete"
+}
+
+// profileConfig holds settings that only apply when selected with --profile.
+type profileConfig struct {
+	SkipTopDirs []string `json:"skip_top_dirs"` // First-level folders of the source to leave untouched
+	IncludeMIME []string `json:"include_mime"`  // MIME types to organize exclusively, like "video/*"
+	ExcludeMIME []string `json:"exclude_mime"`  // MIME types to leave untouched
+}
+
+// loadConfig reads a JSON configuration file in either of the supported layouts, on top of the
+// files it includes.
+func loadConfig(filePath string) (*fileConfig, error) {
+	return loadConfigChain(filePath, nil)
+}
+
+// loadConfigChain is loadConfig for a file included by the files in chain, outermost first.
+func loadConfigChain(filePath string, chain []string) (*fileConfig, error) {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(chain, abs) {
+		return nil, fmt.Errorf("config files include each other: %s", strings.Join(append(chain, abs), " -> "))
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file '%s': %w", filePath, err)
+	}
+
+	cfg := &fileConfig{}
+	flat := make(map[string]string)
+	if err := json.Unmarshal(data, &flat); err == nil && !hasStructuredKeys(flat) {
+		cfg.Mappings = flat // Original flat layout
+	} else if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON config file '%s': %w", filePath, err)
+	}
+
+	cfg.Mappings = normalizeMappings(cfg.Mappings)
+	cfg.path = filePath
+	if cfg.RulesFile, err = expandPath(cfg.RulesFile); err != nil {
+		return nil, fmt.Errorf("rules_file in config file '%s': %w", filePath, err)
+	}
+	if cfg.RulesFile != "" && !filepath.IsAbs(cfg.RulesFile) {
+		cfg.RulesFile = filepath.Join(filepath.Dir(abs), cfg.RulesFile) // Relative to this file, not the one including it
+	}
+	if len(cfg.Include) == 0 {
+		return cfg, nil
+	}
+
+	merged := &fileConfig{path: filePath}
+	for _, include := range cfg.Include {
+		if include, err = expandPath(include); err != nil {
+			return nil, fmt.Errorf("include in config file '%s': %w", filePath, err)
+		}
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(filePath), include)
+		}
+		base, err := loadConfigChain(include, append(chain, abs))
+		if err != nil {
+			return nil, err
+		}
+		merged.merge(base)
+	}
+	merged.merge(cfg)
+	return merged, nil
+}
+
+// expandPath expands a leading ~ to the home directory and $VAR and ${VAR} to the values of
+// environment variables in a path from a config file, so the file works for other users and on
+// other machines. A variable that is not set is an error rather than an empty string.
+func expandPath(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = home + path[1:]
+	} else if strings.HasPrefix(path, "~") {
+		return "", fmt.Errorf("'%s': only ~ for your own home directory is supported", path)
+	}
+	var undefined []string
+	expanded := os.Expand(path, func(name string) string {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			undefined = append(undefined, name)
+		}
+		return value
+	})
+	if len(undefined) > 0 {
+		return "", fmt.Errorf("'%s': environment variable %s is not set", path, strings.Join(undefined, ", "))
+	}
+	return expanded, nil
+}
+
+// merge layers over on top of c. Objects (mappings, profiles, ext_groups, folder_names, vendors,
+// hooks, email) are merged key by key; any other setting over has replaces the one of c, lists included.
+func (c *fileConfig) merge(over *fileConfig) {
+	c.Mappings = mergeMap(c.Mappings, over.Mappings)
+	c.Profiles = mergeMap(c.Profiles, over.Profiles)
+	c.ExtGroups = mergeMap(c.ExtGroups, over.ExtGroups)
+	c.FolderNames = mergeMap(c.FolderNames, over.FolderNames)
+	c.Vendors = mergeMap(c.Vendors, over.Vendors)
+	if over.Retention != nil {
+		c.Retention = over.Retention
+	}
+	if over.Quotas != nil {
+		c.Quotas = over.Quotas
+	}
+	if over.Tiers != nil {
+		c.Tiers = over.Tiers
+	}
+	if over.FolderOrder != nil {
+		c.FolderOrder = over.FolderOrder
+	}
+	c.Hooks.BeforeRun = cmp.Or(over.Hooks.BeforeRun, c.Hooks.BeforeRun)
+	c.Hooks.AfterRun = cmp.Or(over.Hooks.AfterRun, c.Hooks.AfterRun)
+	c.Hooks.BeforeFile = cmp.Or(over.Hooks.BeforeFile, c.Hooks.BeforeFile)
+	c.Hooks.AfterFile = cmp.Or(over.Hooks.AfterFile, c.Hooks.AfterFile)
+	c.Email.Host = cmp.Or(over.Email.Host, c.Email.Host)
+	c.Email.Port = cmp.Or(over.Email.Port, c.Email.Port)
+	c.Email.Username = cmp.Or(over.Email.Username, c.Email.Username)
+	if over.Email.Password != "" || over.Email.PasswordEnv != "" {
+		c.Email.Password, c.Email.PasswordEnv = over.Email.Password, over.Email.PasswordEnv
+	}
+	c.Email.From = cmp.Or(over.Email.From, c.Email.From)
+	if over.Email.To != nil {
+		c.Email.To = over.Email.To
+	}
+	if over.Rules != "" || over.RulesFile != "" {
+		c.Rules, c.RulesFile, c.path = over.Rules, over.RulesFile, over.path // Inline rules are named after their file
+	}
+	c.RulesTimeout = cmp.Or(over.RulesTimeout, c.RulesTimeout)
+}
+
+// mergeMap returns dst with the entries of src added, replacing those with the same key.
+func mergeMap[V any](dst, src map[string]V) map[string]V {
+	if dst == nil && src != nil {
+		dst = make(map[string]V, len(src))
+	}
+	maps.Copy(dst, src)
+	return dst
+}
+
+// includeList is the include setting of a config file: one file or a list of them.
+type includeList []string
+
+func (l *includeList) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*l = includeList{one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
+
+// structuredKeys are the top-level keys of the structured layout whose values are plain strings,
+// so a file containing only those would also parse as the flat layout.
+var structuredKeys = []string{"include", "rules", "rules_file", "rules_timeout"}
+
+// hasStructuredKeys reports whether a flat-looking config actually uses the structured layout.
+// Extension keys never collide with these names.
+func hasStructuredKeys(flat map[string]string) bool {
+	for _, key := range structuredKeys {
+		if _, ok := flat[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// profile returns the named profile, or an error listing the profiles that do exist.
+func (c *fileConfig) profile(name string) (profileConfig, error) {
+	p, ok := c.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(c.Profiles))
+		for n := range c.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return profileConfig{}, fmt.Errorf("profile '%s' not found (available: %s)", name, strings.Join(names, ", "))
+	}
+	return p, nil
+}
+
+// quotaConfig is a category size limit as written in the config file.
+type quotaConfig struct {
+	Category string `json:"category"`
+	Max      string `json:"max"`      // Size like "500GB"
+	Policy   string `json:"policy"`   // "warn" (default), "trash" or "overflow"
+	Overflow string `json:"overflow"` // Directory that receives rotated files with the overflow policy
+}
+
+// quotas validates and converts the configured category quotas.
+func (c *fileConfig) quotas() ([]organizer.Quota, error) {
+	var quotas []organizer.Quota
+	for i, q := range c.Quotas {
+		if q.Category == "" {
+			return nil, fmt.Errorf("quota %d: category is required", i+1)
+		}
+		maxBytes, err := parseSize(q.Max)
+		if err != nil || maxBytes == 0 {
+			return nil, fmt.Errorf("quota for '%s': invalid max '%s'", q.Category, q.Max)
+		}
+		policy := q.Policy
+		if policy == "" {
+			policy = organizer.QuotaWarn
+		}
+		if !organizer.ValidQuotaPolicy(policy) {
+			return nil, fmt.Errorf("quota for '%s': unknown policy '%s' (use warn, trash or overflow)", q.Category, q.Policy)
+		}
+		overflow := q.Overflow
+		if policy == organizer.QuotaOverflow {
+			if overflow == "" {
+				return nil, fmt.Errorf("quota for '%s': the overflow policy needs an overflow directory", q.Category)
+			}
+			if overflow, err = expandPath(overflow); err != nil {
+				return nil, fmt.Errorf("quota for '%s': %w", q.Category, err)
+			}
+			if overflow, err = filepath.Abs(overflow); err != nil {
+				return nil, fmt.Errorf("quota for '%s': %w", q.Category, err)
+			}
+		}
+		quotas = append(quotas, organizer.Quota{Category: q.Category, MaxBytes: maxBytes, Policy: policy, Overflow: overflow})
+	}
+	return quotas, nil
+}
+
+// tierConfig is an age band as written in the config file.
+type tierConfig struct {
+	Name        string `json:"name"`
+	YoungerThan string `json:"younger_than"` // Age like "30d" or "1y"; empty for the last tier
+}
+
+// tiers converts the configured age bands. Their order is checked by Config.Validate.
+func (c *fileConfig) tiers() ([]organizer.Tier, error) {
+	var tiers []organizer.Tier
+	for _, t := range c.Tiers {
+		age, err := parseAge(t.YoungerThan)
+		if err != nil {
+			return nil, fmt.Errorf("tier '%s': %w", t.Name, err)
+		}
+		tiers = append(tiers, organizer.Tier{Name: t.Name, YoungerThan: age})
+	}
+	return tiers, nil
+}
+
+// email returns the mailer of --email-summary, or an error if the SMTP settings are incomplete.
+func (c *fileConfig) email() (*notify.Email, error) {
+	e := c.Email
+	switch {
+	case e.Host == "":
+		return nil, fmt.Errorf("email: host is missing")
+	case e.From == "":
+		return nil, fmt.Errorf("email: from is missing")
+	case len(e.To) == 0:
+		return nil, fmt.Errorf("email: to is missing")
+	case e.Password != "" && e.PasswordEnv != "":
+		return nil, fmt.Errorf("email: use either password or password_env, not both")
+	}
+	mailer := notify.NewEmail(e.Host, e.From, e.To)
+	mailer.Port, mailer.Username, mailer.Password = e.Port, e.Username, e.Password
+	if e.PasswordEnv != "" {
+		password, ok := os.LookupEnv(e.PasswordEnv)
+		if !ok {
+			return nil, fmt.Errorf("email: environment variable %s is not set", e.PasswordEnv)
+		}
+		mailer.Password = password
+	}
+	return mailer, nil
+}
+
+// rules compiles the Starlark rules of the config, or returns nil if there are none.
+func (c *fileConfig) rules() (*organizer.StarlarkRules, error) {
+	var timeout time.Duration
+	if c.RulesTimeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(c.RulesTimeout); err != nil {
+			return nil, fmt.Errorf("invalid rules_timeout '%s'", c.RulesTimeout)
+		}
+	}
+	switch {
+	case c.Rules != "" && c.RulesFile != "":
+		return nil, fmt.Errorf("use either rules or rules_file, not both")
+	case c.Rules != "":
+		return organizer.NewStarlarkRules(filepath.Base(c.path)+":rules", c.Rules, timeout)
+	case c.RulesFile != "":
+		path := c.RulesFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(c.path), path)
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rules file: %w", err)
+		}
+		return organizer.NewStarlarkRules(path, string(src), timeout)
+	}
+	return nil, nil
+}
+
+// retentionRules validates and converts the configured retention rules.
+func (c *fileConfig) retentionRules() ([]organizer.RetentionRule, error) {
+	var rules []organizer.RetentionRule
+	for i, r := range c.Retention {
+		if r.Category == "" {
+			return nil, fmt.Errorf("retention rule %d: category is required", i+1)
+		}
+		age, err := parseAge(r.OlderThan)
+		if err != nil || age == 0 {
+			return nil, fmt.Errorf("retention rule for '%s': invalid older_than '%s'", r.Category, r.OlderThan)
+		}
+		action := r.Action
+		if action == "" {
+			action = organizer.RetentionTrash
+		}
+		if !organizer.ValidRetentionAction(action) {
+			return nil, fmt.Errorf("retention rule for '%s': unknown action '%s' (use trash or delete)", r.Category, r.Action)
+		}
+		rules = append(rules, organizer.RetentionRule{Category: r.Category, OlderThan: age, Action: action})
+	}
+	return rules, nil
+}
+
+// normalizeMappings lowercases extension keys and makes sure each starts with a dot. Group
+// references ("@raw") are kept as they are.
+func normalizeMappings(mappings map[string]string) map[string]string {
+	normalizedMappings := make(map[string]string)
+	for ext, category := range mappings {
+		// Ensure extension starts with a dot
+		if strings.HasPrefix(ext, "@") {
+			normalizedMappings[ext] = category
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		normalizedMappings[strings.ToLower(ext)] = category
+	}
+	return normalizedMappings
+}
+
+// extGroups returns the default extension groups with the groups of the config over them, their
+// extensions normalized as in the mappings.
+func (c *fileConfig) extGroups() organizer.ExtGroups {
+	groups := organizer.DefaultExtGroups()
+	for name, exts := range c.ExtGroups {
+		normalized := make([]string, 0, len(exts))
+		for _, ext := range exts {
+			ext = strings.ToLower(ext)
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			normalized = append(normalized, ext)
+		}
+		groups[name] = normalized
+	}
+	return groups
+}
+
+// mergeMappings merges the mappings of the config over mappings, which start out as the defaults.
+// A default group the config redefines takes its category along: extensions dropped from it are
+// no longer mapped, added ones are. A mapping of "@name" maps every extension of the group name.
+func (c *fileConfig) mergeMappings(mappings map[string]string, groups organizer.ExtGroups) error {
+	defaults := organizer.DefaultExtGroups()
+	defaultMappings := organizer.DefaultCategoryMappings()
+	for name := range c.ExtGroups {
+		if _, ok := defaults[name]; !ok {
+			continue
+		}
+		category := defaultMappings[defaults[name][0]]
+		for _, ext := range defaults[name] {
+			delete(mappings, ext)
+		}
+		for _, ext := range groups[name] {
+			mappings[ext] = category
+		}
+	}
+	for key, category := range c.Mappings {
+		name, isGroup := strings.CutPrefix(key, "@")
+		if !isGroup {
+			mappings[key] = category
+			continue
+		}
+		exts, ok := groups[name]
+		if !ok {
+			return fmt.Errorf("mapping '%s' refers to unknown extension group '%s'", key, name)
+		}
+		for _, ext := range exts {
+			mappings[ext] = category
+		}
+	}
+	return nil
+}
+
+// splitList splits a comma separated flag value, dropping empty entries and surrounding spaces.
+func splitList(value string) []string {
+	var out []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// readFileList reads the --files-from list: one path per line, or NUL separated (as printed by
+// find -print0) if the list contains any NUL. Relative paths are resolved against the working
+// directory. An empty list yields an empty, non-nil slice so that nothing is organized.
+func readFileList(name string) ([]string, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading file list: %w", err)
+	}
+	sep := "\n"
+	if bytes.IndexByte(data, 0) >= 0 {
+		sep = "\x00"
+	}
+	files := []string{}
+	for _, path := range strings.Split(string(data), sep) {
+		if sep == "\n" {
+			path = strings.TrimSuffix(path, "\r")
+		}
+		if path == "" {
+			continue
+		}
+		if path, err = filepath.Abs(path); err != nil {
+			return nil, fmt.Errorf("reading file list: %w", err)
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/avizyt/org-cli/internal/organizer"
+	"github.com/fatih/color"
+)
+
+// daemonOptions configures the long-running mode.
+type daemonOptions struct {
+	Interval time.Duration // Re-run period for --watch; zero disables periodic runs
+	Listen   string        // Control API address, empty to disable
+	Schedule *scheduler    // Cron schedule for --schedule, nil to disable
+}
+
+// runDaemon keeps the organizer running, organizing the source every opts.Interval, on the cron
+// schedule and whenever a run is triggered through the control API, until interrupted. On SIGINT/SIGTERM a run in
+// progress stops dispatching new files and finishes the ones already being moved before exiting.
+func runDaemon(cfg organizer.Config, base organizer.Summary, opts daemonOptions, notifier *notifiers) {
+	blue := color.New(color.FgBlue).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	status := newRunStatus(cfg)
+	control := organizer.NewController()
+	cfg.Control = control
+	trigger := make(chan struct{}, 1) // Holds at most one pending run request
+	api := &apiHandler{status: status, control: control, trigger: trigger}
+	defer startStatusSocket(api, cfg.Printer)()
+
+	if opts.Listen != "" {
+		server, err := startAPI(opts.Listen, api)
+		if err != nil {
+			fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error starting control API on '%s': %v\n", opts.Listen, err)))
+			os.Exit(1)
+		}
+		defer server.Close()
+		i18n.Printf("%s Control API listening on %s\n", blue(glyph("🔌")), opts.Listen)
+	}
+
+	// Graceful shutdown: stop the controller so a run in progress winds down, then leave the loop
+	stopping := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		sig := <-signals
+		i18n.Printf("%s Received %s, finishing in-flight files...\n", blue(glyph("👋")), sig)
+		sdNotify("STOPPING=1")
+		control.Stop()
+		close(stopping)
+	}()
+
+	defer handlePauseSignals(control, cfg.Printer)()
+
+	go sdWatchdog(stopping)
+	sdNotify("READY=1")
+
+	var tick <-chan time.Time
+	if opts.Interval > 0 {
+		i18n.Printf("%s Watching '%s', organizing every %s.\n", blue(glyph("👀")), cfg.SourceDir, opts.Interval)
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+		trigger <- struct{}{} // Organize right away instead of waiting for the first tick
+	}
+	scheduled := make(chan time.Time, 1)
+	if opts.Schedule != nil {
+		i18n.Printf("%s Organizing '%s' on schedule '%s'.\n", blue(glyph("⏰")), cfg.SourceDir, opts.Schedule.Spec)
+		go opts.Schedule.run(scheduled, status.running, stopping)
+	}
+	if opts.Interval == 0 && opts.Schedule == nil {
+		i18n.Printf("%s Waiting for runs to be triggered through the control API.\n", blue(glyph("👀")))
+	}
+
+	for {
+		var due *time.Time // Set for scheduled runs, which get logged with their slot
+		select {
+		case <-stopping:
+			fmt.Println(blue(glyph(i18n.T("👋 Stopping daemon."))))
+			return
+		case <-tick:
+		case <-trigger:
+		case at := <-scheduled:
+			due = &at
+		}
+		if control.Stopped() {
+			continue // A signal raced with the trigger; let the stopping case end the loop
+		}
+
+		summary := base
+		summary.StartedAt = time.Now()
+		if due != nil {
+			i18n.Printf("%s [%s] Starting scheduled run (due %s).\n", blue(glyph("⏰")), summary.StartedAt.Format(time.DateTime), due.Format(time.DateTime))
+		}
+		summary = organize(cfg, summary, status)
+		if due != nil {
+			i18n.Printf("%s [%s] Scheduled run finished: %s, %d processed, %d errors in %s.\n", blue(glyph("⏰")),
+				summary.FinishedAt.Format(time.DateTime), summary.Status, summary.Processed, summary.Errors,
+				(time.Duration(summary.DurationMS) * time.Millisecond).String())
+		}
+		recordHistory(summary)
+		sendNotification(notifier, summary)
+	}
+}
+
+// progressSnapshot is a point-in-time view of the current (or last) run of an organizer process.
+type progressSnapshot struct {
+	PID       int            `json:"pid"`
+	Source    string         `json:"source"`
+	Dest      string         `json:"dest"`
+	Running   bool           `json:"running"`
+	Paused    bool           `json:"paused"`
+	StartedAt *time.Time     `json:"started_at,omitempty"`
+	Planned   int            `json:"planned"`
+	Processed int            `json:"processed"`
+	Bytes     int64          `json:"bytes"`
+	Skipped   int            `json:"skipped"`
+	Errors    int            `json:"errors"`
+	Workers   map[int]string `json:"workers,omitempty"` // Worker to the file it is working on
+	Copied    map[int]int64  `json:"copied,omitempty"`  // Worker to the bytes of its file copied so far, during long copies
+	Runs      int            `json:"completed_runs"`
+
+	Pool *organizer.PoolStats `json:"pool,omitempty"` // State of the worker pool as of its last report
+}
+
+// runStatus tracks progress across runs for the control API and the status socket. All methods
+// are safe for concurrent use and are no-ops on a nil receiver, so callers without either can
+// simply pass nil.
+type runStatus struct {
+	mu       sync.Mutex
+	progress progressSnapshot
+	stats    organizer.Stats // Of the current run, the counters of progress come from it
+	last     *organizer.Summary
+}
+
+// newRunStatus returns the status of an organizer process running cfg.
+func newRunStatus(cfg organizer.Config) *runStatus {
+	return &runStatus{progress: progressSnapshot{PID: os.Getpid(), Source: cfg.SourceDir, Dest: cfg.DestDir}}
+}
+
+// start resets the counters for a new run.
+func (s *runStatus) start(at time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.progress
+	s.progress = progressSnapshot{PID: p.PID, Source: p.Source, Dest: p.Dest, Running: true, StartedAt: &at, Workers: map[int]string{}, Copied: map[int]int64{}, Runs: p.Runs}
+	s.stats = organizer.Stats{}
+}
+
+// apply folds a worker progress update into the counters.
+func (s *runStatus) apply(update organizer.ProgressUpdate) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.Add(update)
+	s.progress.Planned = s.stats.ToProcess
+	s.progress.Processed, s.progress.Bytes = s.stats.Processed, s.stats.Bytes
+	s.progress.Skipped, s.progress.Errors = s.stats.Skipped, s.stats.Errors
+	if update.Pool != nil {
+		s.progress.Pool = update.Pool
+	}
+	switch {
+	case update.Started != "":
+		s.progress.Workers[update.Worker] = update.Started
+		delete(s.progress.Copied, update.Worker)
+	case update.Copied > 0:
+		s.progress.Copied[update.Worker] = update.Copied
+	case update.File != nil && update.Worker > 0:
+		delete(s.progress.Workers, update.Worker)
+		delete(s.progress.Copied, update.Worker)
+	}
+}
+
+// finish marks the run as done and keeps its summary.
+func (s *runStatus) finish(summary organizer.Summary) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progress.Running = false
+	s.progress.Workers, s.progress.Copied, s.progress.Pool = nil, nil, nil
+	s.progress.Runs++
+	s.last = &summary
+}
+
+// running reports whether a run is in progress.
+func (s *runStatus) running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.progress.Running
+}
+
+// snapshot returns the current progress.
+func (s *runStatus) snapshot() progressSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot := s.progress
+	snapshot.Workers = maps.Clone(s.progress.Workers)
+	snapshot.Copied = maps.Clone(s.progress.Copied)
+	return snapshot
+}
+
+// lastSummary returns the summary of the most recently completed run, or nil.
+func (s *runStatus) lastSummary() *organizer.Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
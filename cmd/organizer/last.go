@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/avizyt/org-cli/internal/history"
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/avizyt/org-cli/internal/organizer"
+	"github.com/fatih/color"
+)
+
+// maxFailedFiles is how many of the files that failed `organizer last` lists.
+const maxFailedFiles = 10
+
+// runLast implements `organizer last`, which prints the summary of the most recent run again
+// from the report every run leaves in the data directory, and returns the process exit code.
+func runLast(args []string) int {
+	red := color.New(color.FgRed).SprintFunc()
+	blue := color.New(color.FgBlue).SprintFunc()
+
+	fs := flag.NewFlagSet("last", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the report of the run as JSON, in the format of --report-json")
+	verbosity := addVerbosityFlags(fs)
+	addOutputFlags(fs)
+	fs.Parse(args)
+
+	data, err := history.LoadLast()
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Println(i18n.T("No run recorded yet."))
+		return 0
+	}
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+	if *asJSON {
+		os.Stdout.Write(data)
+		return 0
+	}
+	var report reportFile
+	if err := json.Unmarshal(data, &report); err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: the report of the last run is unreadable: %v\n", err)))
+		return 1
+	}
+	summary := report.Summary
+	summary.Files, summary.Space = report.Files, report.Space
+
+	run := summary.RunID
+	if run == "" {
+		run = i18n.T("(dry run, not journaled)")
+		if !summary.DryRun {
+			run = i18n.T("(not journaled)")
+		}
+	}
+	i18n.Printf("%s Last run %s: '%s' to '%s', %s\n", blue(glyph("🕘")), run, summary.SourceDir, summary.DestDir, summary.Status)
+	i18n.Printf("  Started %s, finished %s\n", summary.StartedAt.Local().Format("2006-01-02 15:04:05"), summary.FinishedAt.Local().Format("2006-01-02 15:04:05"))
+	if summary.Journal != "" {
+		i18n.Printf("  Journal: %s (undo with `organizer undo --run %s`)\n", summary.Journal, summary.RunID)
+	}
+	printSummary(summary, *verbosity)
+	printFailedFiles(summary.Files)
+	return 0
+}
+
+// printFailedFiles lists the files of a run that could not be processed, and why.
+func printFailedFiles(files []organizer.FileResult) {
+	red := color.New(color.FgRed).SprintFunc()
+
+	var failed []organizer.FileResult
+	for _, f := range files {
+		if f.Action == organizer.ActionFail {
+			failed = append(failed, f)
+		}
+	}
+	if len(failed) == 0 {
+		return
+	}
+	i18n.Printf("%s Failed files:\n", red(glyph("❌")))
+	for _, f := range failed[:min(len(failed), maxFailedFiles)] {
+		fmt.Printf("  %s: %v\n", f.Source, f.Err)
+	}
+	if len(failed) > maxFailedFiles {
+		i18n.Printf("  ... and %d more (all are listed in `organizer last --json`)\n", len(failed)-maxFailedFiles)
+	}
+}
@@ -2,107 +2,650 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strconv"
 	"strings"
 	"sync" // For waiting on the progress collector goroutine
+	"syscall"
 	"time"
 
+	"filippo.io/age"
+	"github.com/avizyt/org-cli/internal/history"
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/avizyt/org-cli/internal/journal"
+	"github.com/avizyt/org-cli/internal/notify"
 	"github.com/avizyt/org-cli/internal/organizer" // Replace with your module path
 	"github.com/fatih/color"
 	"github.com/schollz/progressbar/v3"
 )
 
 func main() {
+	// Subcommands are dispatched before the organize flags are parsed
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "report":
+			os.Exit(runReport(os.Args[2:]))
+		case "service":
+			os.Exit(runService(os.Args[2:]))
+		case "undo":
+			os.Exit(runUndo(os.Args[2:]))
+		case "redo":
+			os.Exit(runRedo(os.Args[2:]))
+		case "merge":
+			os.Exit(runMerge(os.Args[2:]))
+		case "bench":
+			os.Exit(runBench(os.Args[2:]))
+		case "restore":
+			os.Exit(runRestore(os.Args[2:]))
+		case "review":
+			os.Exit(runReview(os.Args[2:]))
+		case "prune":
+			os.Exit(runPrune(os.Args[2:]))
+		case "status":
+			os.Exit(runStatusCommand(os.Args[2:]))
+		case "verify-manifest":
+			os.Exit(runVerifyManifest(os.Args[2:]))
+		case "last":
+			os.Exit(runLast(os.Args[2:]))
+		}
+	}
 
-	startTime := time.Now()
 	// Define colors for initial messages
 	blue := color.New(color.FgBlue).SprintFunc()
 	red := color.New(color.FgRed).SprintFunc()
 	green := color.New(color.FgGreen).SprintFunc()
-	yellow := color.New(color.FgYellow).SprintFunc()
-	magenta := color.New(color.FgMagenta).SprintFunc()
-
-	fmt.Println(blue("✨ Go File Organizer CLI ✨"))
 
 	// 1. Define command-line flags
 	sourceDir := flag.String("source", "", "Source directory to organize files from (required)")
-	destDir := flag.String("dest", "", "Destination directory to move organized files to (required)")
+	destDir := flag.String("dest", "", "Destination directory to move organized files to, or a WebDAV, s3:// or sftp:// URL to upload them to (required)")
 	dryRun := flag.Bool("dry-run", false, "If true, only simulate actions without moving files")
 	recursive := flag.Bool("recursive", false, "If true, scan and organize files in subdirectories")
-	workers := flag.Int("workers", 5, "Number of concurrent file operations (default 5)")
+	filesFrom := flag.String("files-from", "", "Organize only the files listed in this file (one per line, or NUL separated) instead of walking --source; - reads the list from stdin")
+	workers := flag.String("workers", "auto", "Number of concurrent file operations, or auto to pick one for the CPUs and storage (fewer on spinning disks and network shares) and adjust it when latency spikes")
+	order := flag.String("order", "", "Order to process files in: name, size-asc, size-desc or mtime (oldest first); default is the scan order")
+	maxFiles := flag.Int("max-files", 0, "Process at most this many files per run, in --order (default oldest first); the rest is left for later runs")
+	maxBytes := flag.String("max-bytes", "", "Process at most this much data per run (e.g. 500MB, 2GB), in --order (default oldest first); the rest is left for later runs")
+	top := flag.Int("top", 0, "After the scan, list this many of the largest files and, per category, the directories holding the most bytes (also in --report-json)")
+	maxMemory := flag.String("max-memory", "", "Keep the memory of the run below this (e.g. 256MB) on small machines: planned moves beyond half of it are written to a temporary file, and garbage is collected harder as the limit nears")
+	bwLimit := flag.String("bwlimit", "", "Limit copies to this rate (e.g. 50MB/s), shared by all workers, to spare shared or slow disks")
+	fsync := flag.Bool("fsync", false, "Flush every organized file and its directories to disk, so a power loss right after the run cannot lose files (slower, especially on spinning disks)")
+	leaveSymlink := flag.Bool("leave-symlink", false, "Leave a symlink to the new location at the original path of every moved file, so playlists and recent-files lists keep working")
+	manifest := flag.String("manifest", "", "Record the checksum (see --hash) of every stored file in XXH64SUMS, SHA256SUMS or B3SUMS files: category (one per category folder) or global (one for the destination); check them with organizer verify-manifest")
+	hashAlg := flag.String("hash", organizer.HashXXH64, "Checksum algorithm of duplicate checks, manifests and the journal: xxhash64 (fastest), sha256 or blake3 (cryptographic)")
+	dedupe := flag.String("dedupe", "", "Find files whose content is anywhere in the destination already, by an index kept in "+organizer.IndexFile+" there: skip leaves them in place, link puts a hard link to the stored copy in their place")
+	hashWorkers := flag.Int("hash-workers", 0, "Number of files hashed at once, separately from --workers; 0 means one per CPU")
+	fileRetries := flag.Int("file-retries", organizer.DefaultFileRetries, "Try a file that failed because another program held it or its network share dropped out this many more times during the run, after a backoff of 5s doubled on every retry")
+	fileTimeout := flag.Duration("file-timeout", 0, "Give up on a file whose move or copy makes no progress for this long (e.g. 2m), report it as failed and go on with the next one; 0 waits as long as it takes")
 	configPath := flag.String("config", "", "Path to a JSON configuration file for custom category mappings")
-	quiet := flag.Bool("quiet", false, "Suppress detailed per-file output during processing (show only progress and summary)") // New flag
+	verbosity := addVerbosityFlags(flag.CommandLine)
+	skipTopDirs := flag.String("skip-top-dirs", "", "Comma separated first-level folder names of the source to exclude from a recursive run (e.g. \"Keep,In Progress\")")
+	onlyCategories := flag.String("only", "", "Comma separated categories to organize exclusively, as decided by the mappings and classifiers (e.g. \"Images,Videos\")")
+	skipCategories := flag.String("skip", "", "Comma separated categories to leave alone (e.g. \"Code\")")
+	minCategoryFiles := flag.Int("min-category-files", 0, "Don't create a folder for a category with fewer files than this in a run; see --small-categories")
+	events := flag.String("events", "", "Group photos and videos into event folders (Images/2024-06-15 Event/): files taken less than this apart (e.g. 6h, 1d) belong to one event")
+	media := flag.Bool("media", false, "Media mode: file videos named like releases into Videos/Shows/<show>/Season <n> (Show.S01E02...) and Videos/Movies/<title> (<year>) (Movie.2019.1080p...)")
+	similarImages := flag.String("similar-images", "", "Find photos that are resaves or slightly edited copies of another one: report flags them in the output and --report-json, move also puts them into Images/"+organizer.SimilarFolder+" for review")
+	corrupt := flag.String("corrupt", "", "Keep empty files and photos and videos that are cut off out of the category folders: move (put them into "+organizer.CorruptFolder+" for review) or skip (leave them in place)")
+	clipsUnder := flag.Duration("clips-under", 0, "Put videos shorter than this (e.g. 30s) into Clips in their category folder (Videos/Clips)")
+	eventCategories := flag.String("event-categories", "", "Comma separated categories --events groups (default: Images,Videos)")
+	smallCategories := flag.String("small-categories", organizer.SmallCategoryOthers, "What happens to the files of categories under --min-category-files: others (put them into Others) or leave (leave them where they are)")
+	includeMIME := flag.String("include-mime", "", "Comma separated MIME types to organize exclusively, sniffed from the content; * matches any subtype (e.g. \"video/*,image/*\")")
+	excludeMIME := flag.String("exclude-mime", "", "Comma separated MIME types to leave alone, sniffed from the content; * matches any subtype (e.g. \"video/*\")")
+	classifyContent := flag.Bool("classify-content", false, "File the files without a mapped extension by the type sniffed from their content (images, videos, audio, PDFs, text, archives) instead of into Others")
+	profileName := flag.String("profile", "", "Name of a profile from the --config file to apply")
+	iKnow := flag.Bool("i-know-what-im-doing", false, "Organize the source even if it is /, the home folder itself, a system folder or the destination")
+	hidden := flag.String("hidden", organizer.HiddenSkip, "Hidden files and folders (dotfiles; on Windows and macOS also those flagged hidden): skip, include them, or organize only them")
+	onlyMine := flag.Bool("only-mine", false, "Only organize files owned by the current user (Unix only)")
+	skipUnreadable := flag.Bool("skip-unreadable", false, "Count files and folders the scan may not read as skipped instead of as errors, so they don't make the run partial")
+	failOnUnreadable := flag.Bool("fail-on-unreadable", false, "Fail the run without moving anything when the scan may not read a file or folder")
+	cloudPlaceholders := flag.String("cloud-placeholders", "skip", "What to do with online-only OneDrive/Dropbox/iCloud files: skip, hydrate (download first) or move (move the placeholder)")
+	stripQuarantine := flag.Bool("strip-quarantine", false, "Remove the macOS quarantine flag from organized files, so downloaded apps and installers open without the Gatekeeper prompt (default: keep it)")
+	archiveOlderThan := flag.String("archive-older-than", "", "Archival mode: pack files older than this age (e.g. 180d, 1y) into per-month archives under Archives/")
+	archiveFormat := flag.String("archive-format", organizer.ArchiveFormatZip, "Format of the per-month archives: zip or tar.zst")
+	compress := flag.String("compress", "", "Store files compressed in the destination: gzip or zstd")
+	compressCategories := flag.String("compress-categories", "", "Comma separated categories to compress with --compress (default: all)")
+	encryptWith := flag.String("encrypt-with", "", "Store files encrypted in the destination: age:<recipient> (public key or recipients file)")
+	encryptCategories := flag.String("encrypt-categories", "", "Comma separated categories to encrypt with --encrypt-with (default: all)")
+	syncMode := flag.Bool("sync", false, "Sync mode: only copy files missing from the destination (same layout path and content are left alone); the source is not modified")
+	layout := flag.String("layout", organizer.DefaultLayout, "Folder below --dest to put files in, with the variables {category}, {ext}, {srcdir} (top-level source folder), {srcrel} (folder relative to the source), {tier} (age band from the config), {year}, {month}, {day}, {quarter}, {week}, {date} and {date:FORMAT} (strftime, e.g. %Y/%m), e.g. \"{category}/{year}-{quarter}\"")
+	mode := flag.String("mode", organizer.ModeMove, "How files get into the destination: move, or hardlink to build the categorized tree as hard links to the originals, which stay in place (same file system only)")
+	onConflict := flag.String("on-conflict", organizer.ConflictRename, "When a file with the same name exists in the destination: rename the new file with a timestamp, backup the existing one as <name>.bak-<n>, or trash the existing one; with backup and trash the new file keeps the name. keep-newest and keep-largest keep the newer or larger file under the name, see --conflict-loser; ask prompts for every collision")
+	conflictLoser := flag.String("conflict-loser", "", "With --on-conflict keep-newest or keep-largest, what happens to the other file: backup (as <name>.bak-<n>, default), trash or delete")
+	reviewCategories := flag.String("review", "", "Comma separated categories to stage in Review/ for approval with organizer review (e.g. Others for unknown types)")
+	classifierCmd := flag.String("classifier", "", "Command of a classifier plugin that decides category/destination per file (JSON lines on stdin/stdout)")
+	folderLanguage := flag.String("folder-language", "", "Name the category folders in this language (de, es, fr, it, nl or pt), or auto for the language of the environment")
+	lowercaseFolders := flag.Bool("lowercase-folders", false, "Lowercase the category folder names (images instead of Images)")
+	numberedFolders := flag.Bool("numbered-folders", false, "Prefix the category folder names with a number so they sort in a fixed order (01_Documents); the order can be set with folder_order in the config")
+	mirrorTo := flag.String("mirror", "", "Also copy every organized file to this backup directory or WebDAV, s3:// or sftp:// URL, in the same layout")
+	tui := flag.Bool("tui", false, "Review the planned moves in an interactive terminal UI, exclude categories and confirm before anything is moved")
+	porcelain := flag.Bool("porcelain", false, "Print exactly one tab-separated line per move for scripts (ACTION, SOURCE, DEST, CATEGORY) on stdout; all other output goes to stderr")
+	flag.BoolVar(&deterministic, "deterministic", false, "Make the output reproducible: a fixed clock (SOURCE_DATE_EPOCH, or 2000-01-01) for collision suffixes, journal and run IDs, numbered run IDs and one worker unless --workers is set")
+	analyzeOut := flag.String("analyze-out", "", "Write the number of files and bytes per extension found by the scan, including unmapped ones, as CSV to this path")
+	reportJSON := flag.String("report-json", "", "Write the run summary together with the outcome of every file as JSON to this path")
+	notifyWebhook := flag.String("notify-webhook", "", "URL to POST a JSON run summary to when the run finishes or fails")
+	notifyDiscord := flag.String("notify-discord", "", "Discord webhook URL to post a summary of every run that organized files or failed to")
+	notifyTelegram := flag.String("notify-telegram", "", "Telegram chat ID to send a summary of every run that organized files or failed to, from the bot whose token is in $"+notify.TelegramTokenEnv)
+	notifyTimeout := flag.Duration("notify-timeout", notify.DefaultTimeout, "Timeout for each delivery attempt of the webhook, Discord, Telegram and email notifications")
+	emailSummary := flag.Bool("email-summary", false, "Mail a digest of every run that organized files or failed (counts, errors, top categories, every file as CSV) with the SMTP settings in the email section of --config")
+	syslogTarget := flag.String("syslog", "", "Also log every file operation and run to syslog, as key=value pairs: local (the syslog daemon or journald of this machine), udp://host[:port] or tcp://host[:port]")
+	watch := flag.Bool("watch", false, "Keep running and organize the source again every --watch-interval")
+	watchInterval := flag.Duration("watch-interval", time.Minute, "How often to re-scan the source in --watch mode")
+	schedule := flag.String("schedule", "", "Cron expression (e.g. \"0 3 * * *\" or @daily) to organize on a schedule; implies daemon mode")
+	scheduleJitter := flag.Duration("schedule-jitter", 0, "Random delay of up to this duration added to each scheduled run")
+	listenAddr := flag.String("listen", "", "Serve the control API on a loopback address (e.g. 127.0.0.1:7733) or unix socket (unix:/path/to.sock); implies daemon mode")
+
+	addOutputFlags(flag.CommandLine)
+	pprofAddr := flag.String("pprof", "", "Serve the runtime profiles (net/http/pprof) on this loopback address during the run")
+	traceFile := flag.String("trace", "", "Write a runtime execution trace of the run to this file")
+	flag.Usage = usage
 
 	// 2. Parse the flags
 	flag.Parse()
+	stopProfiling, err := startProfiling(*pprofAddr, *traceFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, red(i18n.Sprintf("Error: %v", err)))
+		os.Exit(exitConfig)
+	}
+	defer stopProfiling()
+
+	// In porcelain mode stdout carries nothing but the result lines, everything else is moved to stderr
+	porcelainOut := os.Stdout
+	if *porcelain {
+		os.Stdout = os.Stderr
+	}
+
+	fmt.Println(blue(glyph(i18n.T("✨ Go File Organizer CLI ✨"))))
 
 	// 3. Basic validation for required arguments
 	if *sourceDir == "" {
-		fmt.Fprintln(os.Stderr, red("Error: --source directory is required."))
+		fmt.Fprintln(os.Stderr, red(i18n.T("Error: --source directory is required.")))
 		flag.Usage()
-		os.Exit(1)
+		os.Exit(exitConfig)
 	}
 	if *destDir == "" {
-		fmt.Fprintln(os.Stderr, red("Error: --dest directory is required."))
+		fmt.Fprintln(os.Stderr, red(i18n.T("Error: --dest directory is required.")))
 		flag.Usage()
-		os.Exit(1)
+		os.Exit(exitConfig)
+	}
+	if deterministic {
+		t, err := deterministicTime()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, red(i18n.Sprintf("Error: %v", err)))
+			os.Exit(exitConfig)
+		}
+		clock = organizer.FixedClock(t)
+	}
+	startTime := clock()
+
+	// The summary is filled in as the run progresses and delivered to notification targets at the end,
+	// including when the run bails out early.
+	summary := organizer.Summary{
+		SourceDir: *sourceDir,
+		DestDir:   *destDir,
+		DryRun:    *dryRun,
+		StartedAt: startTime,
+	}
+	summary.Host, _ = os.Hostname()
+	if u, err := url.Parse(*destDir); err == nil && organizer.IsRemoteDest(*destDir) {
+		summary.DestDir = u.Redacted() // Never report WebDAV or SFTP passwords to notification targets
+	}
+	notifier := &notifiers{}
+	if *notifyWebhook != "" {
+		notifier.webhook = notify.NewWebhook(*notifyWebhook)
+		notifier.webhook.Timeout = *notifyTimeout
+	}
+	if *notifyDiscord != "" {
+		notifier.discord = notify.NewDiscord(*notifyDiscord)
+		notifier.discord.Timeout = *notifyTimeout
+	}
+
+	// fatal reports a setup error that prevents the run, notifies and exits.
+	fatal := func(format string, args ...any) {
+		msg := fmt.Sprintf(format, args...)
+		fmt.Fprintln(os.Stderr, red(i18n.Sprintf(format, args...)))
+		summary.Status = organizer.StatusFailed
+		summary.Error = msg
+		summary.Finish(clock())
+		recordHistory(summary)
+		sendNotification(notifier, summary)
+		os.Exit(exitConfig)
+	}
+
+	if *notifyTelegram != "" {
+		token := os.Getenv(notify.TelegramTokenEnv)
+		if token == "" {
+			fatal("Error: --notify-telegram needs the bot token in $%s.", notify.TelegramTokenEnv)
+		}
+		notifier.telegram = notify.NewTelegram(token, *notifyTelegram)
+		notifier.telegram.Timeout = *notifyTimeout
+	}
+	if *syslogTarget != "" {
+		if opLog, err = newOpLogger(*syslogTarget); err != nil {
+			fatal("Error: %v", err)
+		}
 	}
 
 	// Resolve absolute paths for robustness
 	absSourceDir, err := filepath.Abs(*sourceDir)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, red("Error resolving absolute path for source directory '%s': %v\n"), *sourceDir, err)
-		os.Exit(1)
+		fatal("Error resolving absolute path for source directory '%s': %v", *sourceDir, err)
 	}
-	absDestDir, err := filepath.Abs(*destDir)
+	// The destination is either a local directory or a WebDAV (Nextcloud, ownCloud, ...), S3 or
+	// SFTP URL
+	var absDestDir string
+	var remote organizer.Remote
+	if organizer.IsRemoteDest(*destDir) {
+		if remote, err = organizer.NewRemote(*destDir); err != nil {
+			fatal("Error: --dest: %v", err)
+		}
+		absDestDir = remote.String() // Credentials stripped for display
+	} else if absDestDir, err = filepath.Abs(*destDir); err != nil {
+		fatal("Error resolving absolute path for destination directory '%s': %v", *destDir, err)
+	}
+	summary.SourceDir = absSourceDir
+	summary.DestDir = absDestDir
+
+	var files []string
+	if *filesFrom != "" {
+		if *watch || *listenAddr != "" || *schedule != "" {
+			fatal("Error: --files-from cannot be combined with --watch, --schedule or --listen.")
+		}
+		if *filesFrom == "-" && *tui {
+			fatal("Error: --files-from - cannot be combined with --tui, which reads the keyboard from stdin.")
+		}
+		if files, err = readFileList(*filesFrom); err != nil {
+			fatal("Error: %v", err)
+		}
+	}
+
+	placeholderPolicy, err := organizer.ParsePlaceholderPolicy(*cloudPlaceholders)
+	if err != nil {
+		fatal("Error: %v", err)
+	}
+
+	var maxBytesLimit int64
+	if *maxBytes != "" {
+		if maxBytesLimit, err = parseSize(*maxBytes); err != nil {
+			fatal("Error: --max-bytes: %v '%s' (use e.g. 500MB or 2GB)", err, *maxBytes)
+		}
+	}
+	workerCount := 0 // auto
+	if *workers == "auto" && deterministic {
+		workerCount = 1 // Concurrent workers finish files in a different order every run
+	} else if *workers != "auto" {
+		if workerCount, err = strconv.Atoi(*workers); err != nil || workerCount < 1 {
+			fatal("Error: --workers: must be a number of at least 1 or auto, not '%s'", *workers)
+		}
+	}
+	unreadable := organizer.UnreadableReport
+	switch {
+	case *skipUnreadable && *failOnUnreadable:
+		fatal("Error: --skip-unreadable and --fail-on-unreadable cannot be combined.")
+	case *skipUnreadable:
+		unreadable = organizer.UnreadableSkip
+	case *failOnUnreadable:
+		unreadable = organizer.UnreadableFail
+	}
+	var memoryLimit int64
+	if *maxMemory != "" {
+		if memoryLimit, err = parseSize(*maxMemory); err != nil || memoryLimit == 0 {
+			fatal("Error: --max-memory: invalid size '%s' (use e.g. 256MB or 1GB)", *maxMemory)
+		}
+		debug.SetMemoryLimit(memoryLimit)
+	}
+	var bandwidthLimit int64
+	if *bwLimit != "" {
+		if bandwidthLimit, err = parseRate(*bwLimit); err != nil || bandwidthLimit == 0 {
+			fatal("Error: --bwlimit: invalid rate '%s' (use e.g. 50MB/s)", *bwLimit)
+		}
+	}
+	eventGap, err := parseAge(*events)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, red("Error resolving absolute path for destination directory '%s': %v\n"), *destDir, err)
-		os.Exit(1)
+		fatal("Error: --events: %v", err)
+	}
+	archiveAge, err := parseAge(*archiveOlderThan)
+	if err != nil {
+		fatal("Error: --archive-older-than: %v", err)
+	}
+
+	var recipients []age.Recipient
+	if *encryptWith != "" {
+		if recipients, err = organizer.ParseEncryptWith(*encryptWith); err != nil {
+			fatal("Error: %v", err)
+		}
+	}
+
+	var mirror *organizer.Mirror
+	if *mirrorTo != "" {
+		if mirror, err = organizer.NewMirror(*mirrorTo); err != nil {
+			fatal("Error: %v", err)
+		}
+		summary.Mirror = mirror.String()
 	}
 
 	// Initialize category mappings with defaults
 	categoryMappings := organizer.DefaultCategoryMappings()
+	extGroups := organizer.DefaultExtGroups()
+
+	skipDirs := splitList(*skipTopDirs)
+	includeTypes, excludeTypes := splitList(*includeMIME), splitList(*excludeMIME)
+	var quotas []organizer.Quota
+	var tiers []organizer.Tier
+	naming := organizer.FolderNaming{Lowercase: *lowercaseFolders, Numbered: *numberedFolders}
+	switch lang, ok := organizer.FolderLanguage(*folderLanguage); {
+	case *folderLanguage == "auto":
+		if lang, ok = organizer.FolderLanguage(i18n.Detect()); ok {
+			naming.Language = lang
+		}
+	case *folderLanguage != "" && !ok:
+		fatal("Error: no folder names for language '%s' (available: %s)", *folderLanguage, strings.Join(organizer.FolderLanguages(), ", "))
+	default:
+		naming.Language = lang
+	}
+	var hooks organizer.Hooks
+	var vendors map[string]string
+	var classifiers []organizer.FileClassifier
 
 	// Load and merge custom mappings if a config path is provided
 	if *configPath != "" {
-		fmt.Printf("%s Loading custom category mappings from '%s'...\n", blue("⚙️"), *configPath)
-		customMappings, err := loadCustomMappings(*configPath)
+		i18n.Printf("%s Loading custom category mappings from '%s'...\n", blue(glyph("⚙️")), *configPath)
+		fileCfg, err := loadConfig(*configPath)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, red("Error loading custom mappings from '%s': %v\n"), *configPath, err)
-			os.Exit(1)
+			fatal("Error loading custom mappings from '%s': %v", *configPath, err)
 		}
 
 		// Merge custom mappings (custom overrides defaults)
-		for ext, category := range customMappings {
-			categoryMappings[ext] = category
+		extGroups = fileCfg.extGroups()
+		if err := fileCfg.mergeMappings(categoryMappings, extGroups); err != nil {
+			fatal("Error in config '%s': %v", *configPath, err)
+		}
+		fmt.Println(green(glyph(i18n.T("✔ Custom mappings loaded and merged."))))
+
+		if quotas, err = fileCfg.quotas(); err != nil {
+			fatal("Error in config '%s': %v", *configPath, err)
+		}
+		if tiers, err = fileCfg.tiers(); err != nil {
+			fatal("Error in config '%s': %v", *configPath, err)
+		}
+		hooks = organizer.Hooks(fileCfg.Hooks)
+		naming.Names, naming.Order = fileCfg.FolderNames, fileCfg.FolderOrder
+		vendors = fileCfg.Vendors
+		if *emailSummary {
+			if notifier.email, err = fileCfg.email(); err != nil {
+				fatal("Error in config '%s': %v", *configPath, err)
+			}
+			notifier.email.Timeout = *notifyTimeout
+		}
+		rules, err := fileCfg.rules()
+		if err != nil {
+			fatal("Error in config '%s': %v", *configPath, err)
+		}
+		if rules != nil {
+			classifiers = append(classifiers, rules)
+		}
+
+		if *profileName != "" {
+			profile, err := fileCfg.profile(*profileName)
+			if err != nil {
+				fatal("Error in config '%s': %v", *configPath, err)
+			}
+			skipDirs = append(skipDirs, profile.SkipTopDirs...)
+			includeTypes = append(includeTypes, profile.IncludeMIME...)
+			excludeTypes = append(excludeTypes, profile.ExcludeMIME...)
+			i18n.Printf("%s Using profile '%s'.\n", green(glyph("✔")), *profileName)
+		}
+	} else if *profileName != "" {
+		fatal("Error: --profile requires --config.")
+	} else if *emailSummary {
+		fatal("Error: --email-summary requires --config with the SMTP settings.")
+	}
+
+	if *classifierCmd != "" {
+		classifier, err := organizer.StartClassifier(*classifierCmd)
+		if err != nil {
+			fatal("Error: %v", err)
+		}
+		defer classifier.Close()
+		classifiers = append(classifiers, classifier)
+	}
+
+	printer := &terminalPrinter{}
+	only, skip := splitList(*onlyCategories), splitList(*skipCategories)
+	if len(classifiers) == 0 {
+		// Without classifiers the mappings are all the categories there are, anything else is a typo
+		for _, name := range append(slices.Clone(only), skip...) {
+			if !mappedCategory(categoryMappings, name) {
+				printer.Status(organizer.LevelWarn, "⚠️", "No extension is mapped to category '%s'.", name)
+			}
+		}
+	}
+	if *classifyContent {
+		// Ahead of the rules and plugins, so they see the category of the content
+		classifiers = append([]organizer.FileClassifier{organizer.ContentClassifier(organizer.DefaultContentCategories())}, classifiers...)
+	}
+	var asker organizer.ConflictAsker
+	if *onConflict == organizer.ConflictAsk {
+		if *tui || *watch || *listenAddr != "" || *schedule != "" {
+			fatal("Error: --on-conflict ask cannot be combined with --tui, --watch, --schedule or --listen.")
+		}
+		if asker, err = newTerminalAsker(printer); err != nil {
+			fatal("Error: %v", err)
 		}
-		fmt.Println(green("✔ Custom mappings loaded and merged."))
 	}
 
 	// Create the Config struct
 	cfg := organizer.Config{
-		SourceDir:        absSourceDir,
-		DestDir:          absDestDir,
-		DryRun:           *dryRun,
-		Recursive:        *recursive,
-		Workers:          *workers,
-		CategoryMappings: categoryMappings,
-		Quiet:            *quiet,
+		SourceDir:          absSourceDir,
+		DestDir:            absDestDir,
+		DryRun:             *dryRun,
+		AllowDangerous:     *iKnow,
+		Recursive:          *recursive,
+		Files:              files,
+		Workers:            workerCount,
+		Order:              *order,
+		MaxFiles:           *maxFiles,
+		MaxBytes:           maxBytesLimit,
+		TopN:               *top,
+		CountExtensions:    *analyzeOut != "",
+		BandwidthLimit:     bandwidthLimit,
+		MaxMemory:          memoryLimit / 2, // The other half is for the run itself
+		CategoryMappings:   categoryMappings,
+		ExtGroups:          extGroups,
+		Verbosity:          *verbosity,
+		OnlyMine:           *onlyMine,
+		Unreadable:         unreadable,
+		Hidden:             *hidden,
+		SkipTopDirs:        skipDirs,
+		IncludeMIME:        includeTypes,
+		ExcludeMIME:        excludeTypes,
+		OnlyCategories:     only,
+		SkipCategories:     skip,
+		MinCategoryFiles:   *minCategoryFiles,
+		SmallCategories:    *smallCategories,
+		EventGap:           eventGap,
+		EventCategories:    splitList(*eventCategories),
+		ClipsUnder:         *clipsUnder,
+		MediaNames:         *media,
+		SimilarImages:      *similarImages,
+		Corrupt:            *corrupt,
+		Vendors:            vendors,
+		Remote:             remote,
+		CloudPlaceholders:  placeholderPolicy,
+		StripQuarantine:    *stripQuarantine,
+		Fsync:              *fsync,
+		LeaveSymlink:       *leaveSymlink,
+		Manifest:           *manifest,
+		Hash:               *hashAlg,
+		HashWorkers:        *hashWorkers,
+		FileTimeout:        *fileTimeout,
+		FileRetries:        *fileRetries,
+		Dedupe:             *dedupe,
+		ArchiveOlderThan:   archiveAge,
+		ArchiveFormat:      *archiveFormat,
+		Compress:           *compress,
+		CompressCategories: splitList(*compressCategories),
+		EncryptTo:          recipients,
+		EncryptCategories:  splitList(*encryptCategories),
+		Mirror:             mirror,
+		Sync:               *syncMode,
+		Mode:               *mode,
+		OnConflict:         *onConflict,
+		ConflictLoser:      *conflictLoser,
+		Asker:              asker,
+		Layout:             *layout,
+		Naming:             naming,
+		Tiers:              tiers,
+		ReviewCategories:   splitList(*reviewCategories),
+		Quotas:             quotas,
+		Hooks:              hooks,
+		Classifiers:        classifiers,
+		Printer:            printer,
+		Clock:              clock,
+	}
+	if err := cfg.Validate(); err != nil {
+		fatal("Error: %v", err)
+	}
+	if *porcelain {
+		if *tui {
+			fatal("Error: --porcelain cannot be combined with --tui.")
+		}
+		if cfg.Verbosity == organizer.VerbosityNormal {
+			cfg.Verbosity = organizer.VerbosityQuiet // The per-file lines would repeat the porcelain ones
+		}
 	}
 
-	// Create a channel for progress updates from the organizer
-	progressChan := make(chan organizer.ProgressUpdate, cfg.Workers+10)
+	// 4. Keep running in daemon mode, or organize once
+	if *watch || *listenAddr != "" || *schedule != "" {
+		if *tui || *porcelain || deterministic {
+			fatal("Error: --tui, --porcelain and --deterministic cannot be combined with --watch, --schedule or --listen.")
+		}
+		opts := daemonOptions{Listen: *listenAddr}
+		if *watch {
+			opts.Interval = *watchInterval
+		}
+		if *schedule != "" {
+			if opts.Schedule, err = newScheduler(*schedule, *scheduleJitter); err != nil {
+				fatal("Error: %v", err)
+			}
+		}
+		runDaemon(cfg, summary, opts, notifier)
+		return
+	}
 
+	if *tui {
+		var ran bool
+		if summary, ran, err = runTUI(cfg, summary); err != nil {
+			fatal("Error: %v", err)
+		}
+		if !ran {
+			stopProfiling()
+			os.Exit(exitAborted) // Quit before confirming, nothing was touched
+		}
+		printSummary(summary, cfg.Verbosity)
+	} else {
+		// Ctrl-C stops dispatching and lets the files in flight finish; a second one exits right away
+		cfg.Control = organizer.NewController()
+		signals := make(chan os.Signal, 2)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			sig := <-signals
+			i18n.Printf("%s Received %s, finishing in-flight files (again to quit now)...\n", blue(glyph("👋")), sig)
+			cfg.Control.Stop()
+			<-signals
+			stopProfiling()
+			os.Exit(exitAborted)
+		}()
+
+		stopPause := handlePauseSignals(cfg.Control, cfg.Printer)
+		status := newRunStatus(cfg)
+		closeStatus := startStatusSocket(&apiHandler{status: status, control: cfg.Control}, cfg.Printer)
+		if *porcelain {
+			summary = execute(cfg, summary, status, porcelainLines(porcelainOut))
+		} else {
+			summary = organize(cfg, summary, status)
+		}
+		closeStatus()
+		stopPause()
+		signal.Stop(signals)
+	}
+	if *reportJSON != "" {
+		if err := writeReport(*reportJSON, summary); err != nil {
+			fmt.Fprintln(os.Stderr, red(i18n.Sprintf("Error: %v", err)))
+		}
+	}
+	if *analyzeOut != "" && summary.Extensions != nil {
+		if err := writeExtensionStats(*analyzeOut, summary.Extensions); err != nil {
+			fmt.Fprintln(os.Stderr, red(i18n.Sprintf("Error: %v", err)))
+		}
+	}
+	recordHistory(summary)
+	sendNotification(notifier, summary)
+	stopProfiling()
+	os.Exit(exitCode(summary))
+}
+
+// Exit codes of an organize run.
+const (
+	exitOK      = 0 // Everything was organized
+	exitPartial = 1 // The run completed but some files failed, or it failed as a whole
+	exitConfig  = 2 // Invalid flags or config; nothing was touched
+	exitAborted = 3 // Stopped by a signal or a before-run hook
+)
+
+// exitCode maps the outcome of a run to the process exit code.
+func exitCode(summary organizer.Summary) int {
+	switch summary.Status {
+	case organizer.StatusOK:
+		return exitOK
+	case organizer.StatusAborted:
+		return exitAborted
+	default:
+		return exitPartial
+	}
+}
+
+// porcelainLines returns a progress observer that writes the outcome of every file to w as one
+// line in the format of organizer.FileResult.Porcelain. Why a file failed goes to stderr.
+func porcelainLines(w io.Writer) func(organizer.ProgressUpdate) {
+	return func(update organizer.ProgressUpdate) {
+		if update.File == nil {
+			return
+		}
+		fmt.Fprintln(w, update.File.Porcelain())
+		if update.File.Action == organizer.ActionFail {
+			i18n.Fprintf(os.Stderr, "Error: %s: %v\n", update.File.Source, update.File.Err)
+		}
+	}
+}
+
+// organize performs a single run with a progress bar and prints the final summary.
+// base carries the run metadata (paths, start time, ...) and is returned completed with the counts.
+// If status is non-nil it is kept up to date while the run progresses.
+func organize(cfg organizer.Config, base organizer.Summary, status *runStatus) organizer.Summary {
 	// Initialize the progress bar
+	description, saucer := "[cyan]Processing files...[reset]", "[green]=[reset]"
+	if color.NoColor {
+		description, saucer = "Processing files...", "="
+	}
 	bar := progressbar.NewOptions(0, // Max is 0 initially, will be set after scanning
-		progressbar.OptionEnableColorCodes(true),
-		progressbar.OptionSetDescription("[cyan]Processing files...[reset]"),
+		progressbar.OptionEnableColorCodes(!color.NoColor),
+		progressbar.OptionSetDescription(description),
 		progressbar.OptionSetTheme(progressbar.Theme{
-			Saucer:        "[green]=[reset]",
+			Saucer:        saucer,
 			SaucerPadding: " ",
 			BarStart:      "[",
 			BarEnd:        "]",
@@ -111,86 +654,428 @@ func main() {
 		progressbar.OptionThrottle(100*time.Millisecond),
 		progressbar.OptionClearOnFinish(),
 	)
+	printer, _ := cfg.Printer.(*terminalPrinter)
+	if printer != nil {
+		printer.attach(bar)
+	}
 
-	// Variables to aggregate counts from workers
-	var totalProcessed int // Renamed from movedCount to be more general (dry-run counts as processed)
-	var totalErrors int
-	var wgProgress sync.WaitGroup // New WaitGroup for the progress collector goroutine
+	var stats organizer.Stats
+	summary := execute(cfg, base, status, func(update organizer.ProgressUpdate) {
+		if printer != nil {
+			// Not while a prompt of --on-conflict ask has the terminal
+			printer.mu.Lock()
+			defer printer.mu.Unlock()
+		}
+		stats.Add(update)
+		if update.Planned > 0 {
+			bar.ChangeMax(stats.ToProcess)
+		}
+		bar.Set(stats.Finished())
+	})
+	bar.Finish()
+	if printer != nil {
+		printer.attach(nil)
+	}
+	if summary.Status == organizer.StatusAborted && summary.Error != "" {
+		return summary // Cancelled by the before-run hook, nothing was done
+	}
+	// Final newline after progress bar
+	fmt.Println()
+	printSummary(summary, cfg.Verbosity)
+	return summary
+}
+
+// printSummary prints the outcome of a run; verbosity decides whether the slowest files are
+// listed.
+func printSummary(summary organizer.Summary, verbosity organizer.Verbosity) {
+	// Define colors for output
+	blue := color.New(color.FgBlue).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	magenta := color.New(color.FgMagenta).SprintFunc()
+
+	if summary.Error != "" {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error during file scanning: %v\n", summary.Error)))
+	}
+
+	fmt.Println(blue(glyph(i18n.T("🎉 Organizer finished."))))
+	i18n.Printf("%s --- Summary ---\n", blue(glyph("📄")))
+	i18n.Printf("%s Total files scanned: %s\n", blue(glyph("🔍")), green(fmt.Sprintf("%d", summary.Scanned)))
+	i18n.Printf("%s Files to process: %s\n", blue(glyph("📦")), green(fmt.Sprintf("%d", summary.ToProcess)))
+	i18n.Printf("%s Files skipped (already in dest, not a regular file, changed or access error): %s\n", yellow(glyph("⏩")), yellow(fmt.Sprintf("%d", summary.Skipped)))
+	if summary.DryRun {
+		i18n.Printf("%s Dry run completed. %s files would have been processed.\n", green(glyph("✅")), green(fmt.Sprintf("%d", summary.Processed)))
+	} else {
+		i18n.Printf("%s Successfully processed %s files.\n", green(glyph("✅")), green(fmt.Sprintf("%d", summary.Processed)))
+	}
+	if summary.Errors > 0 {
+		i18n.Printf("%s Encountered %s errors during processing.\n", red(glyph("❌")), red(fmt.Sprintf("%d", summary.Errors)))
+	} else {
+		i18n.Printf("%s No errors encountered during processing.\n", green(glyph("✔️")))
+	}
+	if summary.AccessDenied > 0 {
+		i18n.Printf("%s Access denied to %s files or folders:\n", yellow(glyph("🔒")), yellow(fmt.Sprintf("%d", summary.AccessDenied)))
+		denied := deniedPaths(summary.Files)
+		for _, path := range denied[:min(len(denied), maxDeniedPaths)] {
+			fmt.Printf("  %s\n", path)
+		}
+		if len(denied) > maxDeniedPaths {
+			i18n.Printf("  ... and %d more (all are listed in --report-json)\n", len(denied)-maxDeniedPaths)
+		}
+	}
+	if summary.Mirror != "" && !summary.DryRun {
+		if summary.MirrorErrors > 0 {
+			i18n.Printf("%s %s files could not be copied to the mirror '%s' (they were organized into the destination).\n", red(glyph("❌")), red(fmt.Sprintf("%d", summary.MirrorErrors)), summary.Mirror)
+		} else {
+			i18n.Printf("%s All files copied to the mirror '%s'.\n", green(glyph("🪞")), summary.Mirror)
+		}
+	}
+	if summary.Corrupt > 0 {
+		i18n.Printf("%s Found %s empty or damaged files:\n", yellow(glyph("🩹")), yellow(fmt.Sprintf("%d", summary.Corrupt)))
+		damaged := damagedFiles(summary.Files)
+		for _, f := range damaged[:min(len(damaged), maxDeniedPaths)] {
+			fmt.Printf("  %s (%s)\n", f.Source, f.Damage)
+		}
+		if len(damaged) > maxDeniedPaths {
+			i18n.Printf("  ... and %d more (all are listed in --report-json)\n", len(damaged)-maxDeniedPaths)
+		}
+	}
+	if summary.Rotated > 0 {
+		i18n.Printf("%s Rotated %s files out of categories over their quota.\n", yellow(glyph("♻️")), yellow(fmt.Sprintf("%d", summary.Rotated)))
+	}
+	duration := time.Duration(summary.DurationMS) * time.Millisecond
+	if t := summary.Timing; t != nil {
+		ms := func(n int64) string { return (time.Duration(n) * time.Millisecond).String() }
+		i18n.Printf("%s Total time taken: %s (scan %s, planning %s, processing %s)\n", magenta(glyph("⏱️")), magenta(duration.String()), ms(t.ScanMS), ms(t.PlanMS), ms(t.ProcessMS))
+	} else {
+		i18n.Printf("%s Total time taken: %s\n", magenta(glyph("⏱️")), magenta(duration.String())) // Print total time
+	}
+	if verbosity >= organizer.VerbosityVerbose && len(summary.Files) > 1 {
+		i18n.Printf("%s Slowest files:\n", magenta(glyph("🐢")))
+		for _, f := range organizer.SlowestFiles(summary.Files, slowestFiles) {
+			i18n.Printf("  %10s  %s (%s)\n", f.Duration.Round(time.Microsecond), f.Source, organizer.FormatBytes(f.Size))
+		}
+	}
+	if pool := summary.Pool; verbosity >= organizer.VerbosityVerbose && pool != nil {
+		i18n.Printf("%s Workers (%d retries):\n", magenta(glyph("👷")), pool.Retried)
+		for _, w := range pool.PerWorker {
+			i18n.Printf("  worker %d: %d files (%s), %d errors, busy %s\n", w.Worker, w.Files, organizer.FormatBytes(w.Bytes), w.Errors, (time.Duration(w.BusyMS) * time.Millisecond).String())
+		}
+	}
+}
+
+// mappedCategory reports whether name is a category of mappings or "Others", ignoring case.
+func mappedCategory(mappings map[string]string, name string) bool {
+	if strings.EqualFold(name, "Others") {
+		return true
+	}
+	for _, category := range mappings {
+		if strings.EqualFold(category, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxDeniedPaths is how many of the paths the scan was denied access to, and of the damaged
+// files, the summary lists.
+const maxDeniedPaths = 10
+
+// deniedPaths returns the paths of files the scan was denied access to.
+func deniedPaths(files []organizer.FileResult) []string {
+	var paths []string
+	for _, f := range files {
+		if f.Action == organizer.ActionDenied {
+			paths = append(paths, f.Source)
+		}
+	}
+	return paths
+}
+
+// damagedFiles returns the results of the empty and damaged files, see organizer.Config.Corrupt.
+func damagedFiles(files []organizer.FileResult) []organizer.FileResult {
+	var damaged []organizer.FileResult
+	for _, f := range files {
+		if f.Damage != "" {
+			damaged = append(damaged, f)
+		}
+	}
+	return damaged
+}
+
+// slowestFiles is how many of the slowest files the summary and the report list.
+const slowestFiles = 5
+
+// execute does a single run of cfg: it journals a real run, runs the run-level hooks around it and
+// returns base completed with the counts. Every progress update is also passed to observe, always
+// from the same goroutine; observe may be nil. Problems around the run are reported through
+// cfg.Printer, a scan error ends up in the summary's Error.
+func execute(cfg organizer.Config, base organizer.Summary, status *runStatus, observe func(organizer.ProgressUpdate)) organizer.Summary {
+	p := cfg.Printer
+	if p == nil {
+		p = organizer.PlainPrinter(os.Stdout)
+	}
+	summary := base
+	startTime := summary.StartedAt
+	status.start(time.Now()) // `organizer status` shows the elapsed time, also with --deterministic
+
+	recoverCrashedRuns(cfg, p)
+
+	// Journal every operation of a real run so it can be undone with `organizer undo`
+	summary.RunID = newRunID(startTime, 1)
+	if !cfg.DryRun {
+		var j *journal.Journal
+		var err error
+		summary.RunID, j, err = createJournal(startTime)
+		if err != nil {
+			p.Status(organizer.LevelWarn, "⚠️", "Could not create journal, this run cannot be undone: %v", err)
+		} else {
+			cfg.Journal = j
+		}
+	}
+
+	// A failing before-run hook (e.g. a NAS that isn't mounted) cancels the run
+	if cfg.Hooks.BeforeRun != "" && !cfg.DryRun {
+		if err := organizer.RunHook(cfg.Hooks.BeforeRun, organizer.RunEnv(summary)); err != nil {
+			p.Status(organizer.LevelError, "", "Error: %v. Run cancelled.", err)
+			summary.Status = organizer.StatusAborted
+			summary.Error = err.Error()
+			cfg.Journal.Close()
+			summary.Finish(clock())
+			opLog.runFinished(summary)
+			status.finish(summary)
+			return summary
+		}
+	}
+	opLog.runStarted(summary)
 
-	// Goroutine to update the progress bar and collect counts based on messages from progressChan
+	// The engine counts; the updates only feed the status socket and observe
+	progressChan := make(chan organizer.ProgressUpdate, cfg.Workers+10)
+	var wgProgress sync.WaitGroup
 	wgProgress.Add(1)
 	go func() {
 		defer wgProgress.Done()
 		for update := range progressChan {
-			totalProcessed += update.Moved
-			totalErrors += update.Errored
-			bar.Add(update.Moved)
+			status.apply(update)
+			opLog.file(summary.RunID, update.File)
+			if observe != nil {
+				observe(update)
+			}
 		}
-		bar.Finish() // Ensure bar finishes when channel is closed
 	}()
 
-	// 4. Call the organizer logic with the parsed config and progress channel
-	totalScanned, totalFilesToProcess, totalSkipped, scanErr := organizer.OrganizeFiles(cfg, progressChan)
-	if scanErr != nil {
-		fmt.Fprintf(os.Stderr, red("Error during file scanning: %v\n"), scanErr)
-		// Don't exit immediately, let summary print
+	var result organizer.Result
+	org, scanErr := organizer.New(organizer.WithConfig(cfg), organizer.WithProgress(progressChan))
+	if scanErr == nil {
+		result, scanErr = org.Run(context.Background())
+	}
+	close(progressChan)
+	wgProgress.Wait()
+	switch {
+	case errors.Is(scanErr, organizer.ErrAborted):
+		summary.Status = organizer.StatusAborted
+	case scanErr != nil:
+		summary.Error = scanErr.Error()
 	}
 
-	// Set the max value of the progress bar after scanning
-	bar.ChangeMax(totalFilesToProcess)
+	summary.SetStats(result.Stats)
+	summary.Timing = result.Timing
+	summary.Pool = result.Pool
+	summary.Files = result.Files
+	summary.Space = result.Space
+	summary.Extensions = result.Extensions
+	if cfg.Journal != nil {
+		cfg.Journal.Close()
+		summary.Journal = cfg.Journal.Path()
+	}
+	summary.Finish(clock())
+	opLog.runFinished(summary)
+	if cfg.Hooks.AfterRun != "" && !cfg.DryRun {
+		if err := organizer.RunHook(cfg.Hooks.AfterRun, organizer.RunEnv(summary)); err != nil {
+			p.Status(organizer.LevelWarn, "⚠️", "%v", err)
+		}
+	}
+	status.finish(summary)
+	return summary
+}
 
-	// Close the progress channel. This signals the progress collector goroutine to finish.
-	close(progressChan)
+// clock is the time of runs. --deterministic stops it and sets deterministic, which numbers the
+// runs started at the same time instead of telling them apart by a random suffix.
+var (
+	clock         = organizer.Clock(time.Now)
+	deterministic bool
+)
 
-	// Wait for the progress collector goroutine to finish
-	wgProgress.Wait()
+// deterministicTime returns the time of the fixed clock of --deterministic: SOURCE_DATE_EPOCH, the
+// convention of reproducible builds, or the start of 2000.
+func deterministicTime() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	secs, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH '%s', must be seconds since 1970", epoch)
+	}
+	return time.Unix(secs, 0).UTC(), nil
+}
 
-	// Final newline after progress bar
-	fmt.Println()
+// newRunID returns a unique, chronologically sortable ID for a run started at t. With
+// --deterministic it is the seq-th run at t instead.
+func newRunID(t time.Time, seq int) string {
+	if deterministic {
+		return fmt.Sprintf("%s-%04x", t.Format("20060102-150405"), seq)
+	}
+	return fmt.Sprintf("%s-%04x", t.Format("20060102-150405"), rand.N(0x10000))
+}
 
-	endTime := time.Now() // End timing the operation
-	duration := endTime.Sub(startTime)
+// createJournal opens the journal for a new run started at t in the data directory and returns
+// it with the ID of the run.
+func createJournal(t time.Time) (string, *journal.Journal, error) {
+	dataDir, err := history.DataDir()
+	if err != nil {
+		return "", nil, err
+	}
+	for seq := 1; ; seq++ {
+		runID := newRunID(t, seq)
+		j, err := journal.Create(journal.Dir(dataDir), runID)
+		if deterministic && errors.Is(err, os.ErrExist) {
+			continue // An earlier run at the same fixed time
+		}
+		return runID, j, err
+	}
+}
 
-	fmt.Println(blue("🎉 Organizer finished."))
-	fmt.Printf("%s --- Summary ---\n", blue("📄"))
-	fmt.Printf("%s Total files scanned: %s\n", blue("🔍"), green(fmt.Sprintf("%d", totalScanned)))
-	fmt.Printf("%s Files to process: %s\n", blue("📦"), green(fmt.Sprintf("%d", totalFilesToProcess)))
-	fmt.Printf("%s Files skipped (already in dest or access error): %s\n", yellow("⏩"), yellow(fmt.Sprintf("%d", totalSkipped)))
-	if *dryRun {
-		fmt.Printf("%s Dry run completed. %s files would have been processed.\n", green("✅"), green(fmt.Sprintf("%d", totalProcessed)))
-	} else {
-		fmt.Printf("%s Successfully processed %s files.\n", green("✅"), green(fmt.Sprintf("%d", totalProcessed)))
+// recoverCrashedRuns cleans up after the earlier runs whose journals show they crashed, before a
+// new run writes to the destination. A dry run only reports what it would clean up.
+func recoverCrashedRuns(cfg organizer.Config, p organizer.Printer) {
+	dataDir, err := history.DataDir()
+	if err != nil {
+		return // No journals to go by
 	}
-	if totalErrors > 0 {
-		fmt.Printf("%s Encountered %s errors during processing.\n", red("❌"), red(fmt.Sprintf("%d", totalErrors)))
-	} else {
-		fmt.Printf("%s No errors encountered during processing.\n", green("✔️"))
+	paths, running, err := journal.Crashed(journal.Dir(dataDir))
+	if err != nil {
+		p.Status(organizer.LevelWarn, "⚠️", "Could not look for crashed runs: %v", err)
+		return
 	}
-	fmt.Printf("%s Total time taken: %s\n", magenta("⏱️"), magenta(duration.Round(time.Millisecond).String())) // Print total time
+	for _, path := range paths {
+		entries, err := journal.Load(path)
+		if err != nil {
+			p.Status(organizer.LevelWarn, "⚠️", "Could not clean up after crashed run %s: %v", journal.RunID(path), err)
+			continue
+		}
+		p.Status(organizer.LevelWarn, "🩹", "Run %s did not finish, cleaning up after it...", journal.RunID(path))
+		result := organizer.CleanLeftovers(entries, running, cfg.DryRun, cfg.Verbosity, p)
+		if result.Damaged > 0 {
+			p.Status(organizer.LevelError, "❌", "%d files of run %s are empty and their originals are gone; restore them from a backup.", result.Damaged, journal.RunID(path))
+		}
+		if cfg.DryRun || result.Failed > 0 {
+			continue // Try again next time
+		}
+		if err := journal.Recovered(path); err != nil {
+			p.Status(organizer.LevelWarn, "⚠️", "%v", err)
+		}
+	}
+}
+
+// reportFile is the report of a run, as --report-json writes it and `organizer last` reads it.
+type reportFile struct {
+	organizer.Summary
+	Files   []organizer.FileResult `json:"files"`
+	Space   *organizer.SpaceReport `json:"space,omitempty"`
+	Slowest []organizer.FileResult `json:"slowest_files,omitempty"`
+	Denied  []string               `json:"access_denied_paths,omitempty"`
 }
 
-// loadCustomMappings reads a JSON file and unmarshals it into a map.
-func loadCustomMappings(filePath string) (map[string]string, error) {
-	data, err := os.ReadFile(filePath)
+// reportJSON encodes summary and the outcome of each of its files as the JSON of a reportFile.
+func reportJSON(summary organizer.Summary) ([]byte, error) {
+	report := reportFile{summary, summary.Files, summary.Space, organizer.SlowestFiles(summary.Files, slowestFiles), deniedPaths(summary.Files)}
+	data, err := json.MarshalIndent(report, "", "  ")
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file '%s': %w", filePath, err)
+		return nil, err
 	}
+	return append(data, '\n'), nil
+}
 
-	mappings := make(map[string]string)
-	err = json.Unmarshal(data, &mappings)
+// writeReport writes summary and the outcome of each of its files as JSON to path.
+func writeReport(path string, summary organizer.Summary) error {
+	data, err := reportJSON(summary)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse JSON config file '%s': %w", filePath, err)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write report '%s': %w", path, err)
+	}
+	return nil
+}
+
+// writeExtensionStats writes stats as CSV to path: extension, category (empty when no mapping
+// knows the extension), files and bytes.
+func writeExtensionStats(path string, stats []organizer.ExtensionStat) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"extension", "category", "files", "bytes"})
+	for _, s := range stats {
+		w.Write([]string{s.Ext, s.Category, strconv.Itoa(s.Files), strconv.FormatInt(s.Bytes, 10)})
+	}
+	w.Flush()
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write extension statistics '%s': %w", path, err)
 	}
+	return nil
+}
 
-	// Normalize keys to lowercase (extensions)
-	normalizedMappings := make(map[string]string)
-	for ext, category := range mappings {
-		// Ensure extension starts with a dot
-		if !strings.HasPrefix(ext, ".") {
-			ext = "." + ext
+// recordHistory appends the run summary to the history used by `organizer report`, and keeps its
+// full report for `organizer last`.
+func recordHistory(summary organizer.Summary) {
+	err := history.Append(summary)
+	if err == nil {
+		var report []byte
+		if report, err = reportJSON(summary); err == nil {
+			err = history.SaveLast(report)
 		}
-		normalizedMappings[strings.ToLower(ext)] = category
 	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, color.New(color.FgYellow).Sprint(i18n.Sprintf("%s Could not record run history: %v", glyph("⚠️"), err)))
+	}
+}
+
+// notifiers are the targets the summary of every run is delivered to; nil ones are not configured.
+type notifiers struct {
+	webhook  *notify.Webhook  // --notify-webhook
+	email    *notify.Email    // --email-summary
+	discord  *notify.Discord  // --notify-discord
+	telegram *notify.Telegram // --notify-telegram
+}
+
+// sendNotification delivers the run summary to the configured webhook, mailbox and chats, if
+// any. Runs that found nothing to organize are only sent to the webhook, so that --watch does not
+// fill the inbox and the chats. Delivery problems are reported but never change the outcome of
+// the run itself.
+func sendNotification(notifier *notifiers, summary organizer.Summary) {
+	if notifier.webhook != nil {
+		deliverSummary(notifier.webhook, summary, i18n.T("📣 Run summary sent to webhook."))
+	}
+	if summary.ToProcess == 0 && summary.Status == organizer.StatusOK {
+		return
+	}
+	if notifier.email != nil {
+		deliverSummary(notifier.email, summary, i18n.Sprintf("📧 Run summary emailed to %s.", strings.Join(notifier.email.To, ", ")))
+	}
+	if notifier.discord != nil {
+		deliverSummary(notifier.discord, summary, i18n.T("📣 Run summary posted to Discord."))
+	}
+	if notifier.telegram != nil {
+		deliverSummary(notifier.telegram, summary, i18n.T("📣 Run summary sent to Telegram."))
+	}
+}
 
-	return normalizedMappings, nil
+// deliverSummary sends summary with sender and prints done once it arrived, or a warning if not.
+func deliverSummary(sender interface{ Send(organizer.Summary) error }, summary organizer.Summary, done string) {
+	if err := sender.Send(summary); err != nil {
+		fmt.Fprintln(os.Stderr, color.New(color.FgYellow).Sprint(i18n.Sprintf("%s Could not deliver run summary: %v", glyph("⚠️"), err)))
+		return
+	}
+	fmt.Println(color.New(color.FgBlue).Sprint(glyph(done)))
 }
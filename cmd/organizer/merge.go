@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/avizyt/org-cli/internal/journal"
+	"github.com/avizyt/org-cli/internal/organizer"
+	"github.com/fatih/color"
+)
+
+// runMerge implements `organizer merge`, which moves the files of already organized trees into
+// one destination keeping their folders, and returns the process exit code. Files whose
+// destination holds the same content stay behind as duplicates; other collisions are resolved
+// with --on-conflict.
+func runMerge(args []string) int {
+	blue := color.New(color.FgBlue).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	destDir := fs.String("dest", "", "Directory to merge the trees into (required)")
+	onConflict := fs.String("on-conflict", organizer.ConflictRename, "When a file with the same name but different content is already there: rename, backup, trash, keep-newest, keep-largest or ask (as for organizing)")
+	conflictLoser := fs.String("conflict-loser", "", "With --on-conflict keep-newest or keep-largest, what happens to the other file: backup (default), trash or delete")
+	hashAlg := fs.String("hash", organizer.HashXXH64, "Checksum algorithm duplicates are found with: xxhash64, sha256 or blake3")
+	dryRun := fs.Bool("dry-run", false, "Only show what would be merged")
+	iKnow := fs.Bool("i-know-what-im-doing", false, "Merge trees even if one is /, the home folder itself or a system folder")
+	verbosity := addVerbosityFlags(fs)
+	addOutputFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), i18n.T("Usage: organizer merge <tree> <tree>... --dest <directory> [flags]"))
+		fs.PrintDefaults()
+	}
+	trees := parseInterspersed(fs, args)
+	if len(trees) < 2 || *destDir == "" {
+		fs.Usage()
+		return 2
+	}
+
+	absDest, err := filepath.Abs(*destDir)
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 2
+	}
+	for i, tree := range trees {
+		if trees[i], err = filepath.Abs(tree); err != nil {
+			fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+			return 2
+		}
+		if info, err := os.Stat(trees[i]); err != nil || !info.IsDir() {
+			fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: '%s' is not a directory\n", tree)))
+			return 2
+		}
+		if rel, err := filepath.Rel(absDest, trees[i]); err == nil && !strings.HasPrefix(rel, "..") {
+			fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: '%s' is inside the destination '%s'\n", tree, absDest)))
+			return 2
+		}
+	}
+
+	printer := &terminalPrinter{}
+	opts := []organizer.Option{
+		organizer.WithDest(absDest),
+		organizer.WithRecursive(true),
+		organizer.WithLayout("{srcrel}"), // The trees are organized already
+		organizer.WithSkipDuplicates(true),
+		organizer.WithHash(*hashAlg, 0),
+		organizer.WithHidden(organizer.HiddenInclude), // A merge takes the trees as they are
+		organizer.WithOnConflict(*onConflict),
+		organizer.WithConflictLoser(*conflictLoser),
+		organizer.WithDryRun(*dryRun),
+		organizer.WithDangerousSource(*iKnow),
+		organizer.WithVerbosity(*verbosity),
+		organizer.WithPrinter(printer),
+	}
+	if *onConflict == organizer.ConflictAsk {
+		asker, err := newTerminalAsker(printer)
+		if err != nil {
+			fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+			return 2
+		}
+		opts = append(opts, organizer.WithConflictAsker(asker))
+	}
+	var j *journal.Journal
+	if !*dryRun {
+		if _, j, err = createJournal(time.Now()); err != nil {
+			fmt.Fprintln(os.Stderr, yellow(i18n.Sprintf("%s Could not create journal, the merge cannot be undone: %v", glyph("⚠️"), err)))
+		}
+		opts = append(opts, organizer.WithJournal(j))
+	}
+
+	// Ctrl-C finishes the files in flight and stops before the next tree
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var merged, duplicates, skipped, failed int
+	var runErr error
+	for _, tree := range trees {
+		org, err := organizer.New(append(opts, organizer.WithSource(tree))...)
+		if err == nil {
+			err = org.Config().Validate()
+		}
+		if err != nil {
+			j.Close()
+			fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+			return 2
+		}
+		i18n.Printf("%s Merging '%s' into '%s'...\n", blue(glyph("🔀")), tree, absDest)
+		result, err := org.Run(ctx)
+		merged += result.Processed
+		failed += result.Errors
+		for _, f := range result.Files {
+			if errors.Is(f.Err, organizer.ErrDuplicate) {
+				duplicates++
+			}
+		}
+		skipped += result.Skipped
+		if err != nil {
+			runErr = err
+			break
+		}
+	}
+	j.Close()
+
+	if *dryRun {
+		i18n.Printf("%s Dry run completed. %s files would have been merged.\n", green(glyph("✅")), green(fmt.Sprintf("%d", merged)))
+	} else {
+		i18n.Printf("%s Merged %s files into '%s'.\n", green(glyph("✅")), green(fmt.Sprintf("%d", merged)), absDest)
+		if path := j.Path(); path != "" {
+			i18n.Printf("%s Journal: %s (undo the merge with 'organizer undo --run %s')\n", blue(glyph("📝")), path, journal.RunID(path))
+		}
+	}
+	if duplicates > 0 {
+		i18n.Printf("%s %s duplicates were left in the source trees.\n", yellow(glyph("⏩")), yellow(fmt.Sprintf("%d", duplicates)))
+	}
+	if skipped -= duplicates; skipped > 0 {
+		i18n.Printf("%s %s files were skipped.\n", yellow(glyph("⏩")), yellow(fmt.Sprintf("%d", skipped)))
+	}
+	switch {
+	case errors.Is(runErr, organizer.ErrAborted):
+		return exitAborted
+	case runErr != nil:
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", runErr)))
+		return exitPartial
+	case failed > 0:
+		i18n.Printf("%s %s files could not be merged.\n", red(glyph("❌")), red(fmt.Sprintf("%d", failed)))
+		return exitPartial
+	}
+	return exitOK
+}
+
+// parseInterspersed parses the flags of fs in args, which may also come after the positional
+// arguments, and returns the positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			return positional
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
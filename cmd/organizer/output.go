@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/avizyt/org-cli/internal/organizer"
+	"github.com/fatih/color"
+)
+
+// asciiOutput replaces the emoji in the output with plain ASCII labels. It is on by default when
+// the terminal is not known to render UTF-8, and can be forced with --ascii.
+var asciiOutput = !utf8Terminal()
+
+// asciiGlyphs maps the emoji and symbols used in the output to their ASCII labels. Emoji with a
+// variation selector come before their bare form so the selector is replaced along with them.
+var asciiGlyphs = strings.NewReplacer(
+	"✅", "[OK]",
+	"✔️", "[OK]",
+	"✔", "[OK]",
+	"❌", "[ERROR]",
+	"⚠️", "[WARN]",
+	"⚠", "[WARN]",
+	"ℹ️", "[INFO]",
+	"ℹ", "[INFO]",
+	"✨", "*",
+	"⚙️", "[CONFIG]",
+	"🚀", "[START]",
+	"🔍", "[SCAN]",
+	"📦", "[FILES]",
+	"⏩", "[SKIP]",
+	"⏪", "[UNDO]",
+	"🔁", "[REDO]",
+	"⏰", "[SCHEDULE]",
+	"⏱️", "[TIME]",
+	"🐢", "[SLOW]",
+	"👷", "[WORKERS]",
+	"☁️", "[CLOUD]",
+	"🗄️", "[ARCHIVE]",
+	"💾", "[MEMORY]",
+	"🌊", "[STREAM]",
+	"♻️", "[QUOTA]",
+	"🪞", "[MIRROR]",
+	"🧹", "[PRUNE]",
+	"🩹", "[RECOVER]",
+	"📝", "[JOURNAL]",
+	"📄", "[SUMMARY]",
+	"📣", "[NOTIFY]",
+	"📧", "[EMAIL]",
+	"🎉", "[DONE]",
+	"👀", "[WATCH]",
+	"👋", "[STOP]",
+	"🔌", "[API]",
+	"🐞", "[DEBUG]",
+	"⏸️", "[PAUSE]",
+	"▶️", "[RESUME]",
+	"🔄", "[RUN]",
+	"🌐", "[NETWORK]",
+	"📊", "[SPACE]",
+	"🔏", "[MANIFEST]",
+	"🔒", "[DENIED]",
+	"🔀", "[MERGE]",
+	"📸", "[EVENTS]",
+	"🎬", "[SUBTITLES]",
+	"🗂️", "[INDEX]",
+	"🖼️", "[SIMILAR]",
+	"🩹", "[CORRUPT]",
+	"🕘", "[LAST]",
+	"💽", "[DEVICE]",
+)
+
+// glyph returns s with its emoji replaced by ASCII labels in ASCII mode, and s unchanged otherwise.
+// It is applied to icons and to message templates, never to file names.
+func glyph(s string) string {
+	if !asciiOutput {
+		return s
+	}
+	return asciiGlyphs.Replace(s)
+}
+
+// utf8Terminal reports whether the terminal is expected to render emoji: the legacy Windows console
+// shows them as mojibake, and so does any terminal whose locale is not UTF-8.
+func utf8Terminal() bool {
+	if runtime.GOOS == "windows" {
+		// Windows Terminal and the VS Code terminal are UTF-8, the classic console host is not
+		return os.Getenv("WT_SESSION") != "" || os.Getenv("TERM_PROGRAM") == "vscode"
+	}
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := strings.ToUpper(os.Getenv(name)); v != "" {
+			return strings.Contains(v, "UTF-8") || strings.Contains(v, "UTF8")
+		}
+	}
+	// No locale at all, as under cron or systemd: the output ends up in a log, keep it as is
+	return true
+}
+
+// addOutputFlags registers --no-color, --ascii and --lang on fs. They take effect while fs is
+// parsed. Colours are also disabled when the NO_COLOR environment variable is set.
+func addOutputFlags(fs *flag.FlagSet) {
+	fs.BoolFunc("no-color", "Disable coloured output (also set by the NO_COLOR environment variable)", func(s string) error {
+		on, err := strconv.ParseBool(s)
+		if on {
+			color.NoColor = true
+		}
+		return err
+	})
+	fs.BoolFunc("ascii", "Replace emoji with plain ASCII labels (default when the terminal is not UTF-8)", func(s string) error {
+		on, err := strconv.ParseBool(s)
+		asciiOutput = on
+		return err
+	})
+	fs.Func("lang", "Language of the messages, e.g. en (default: from LC_ALL, LC_MESSAGES or LANG)", i18n.SetLanguage)
+}
+
+// addVerbosityFlags registers --quiet, -v, -vv and --verbosity on fs and returns the verbosity they
+// select once fs is parsed.
+func addVerbosityFlags(fs *flag.FlagSet) *organizer.Verbosity {
+	verbosity := new(organizer.Verbosity)
+	set := func(v organizer.Verbosity) func(string) error {
+		return func(s string) error {
+			on, err := strconv.ParseBool(s)
+			if on {
+				*verbosity = v
+			}
+			return err
+		}
+	}
+	fs.BoolFunc("quiet", "Only show the steps of the run and failures, no per-file output (same as --verbosity quiet)", set(organizer.VerbosityQuiet))
+	fs.BoolFunc("v", "Also show why files were skipped during the scan (same as --verbosity verbose)", set(organizer.VerbosityVerbose))
+	fs.BoolFunc("vv", "Also show debug output: how every file was classified and how long it took (same as --verbosity debug)", set(organizer.VerbosityDebug))
+	fs.Func("verbosity", "How much to print: quiet, normal, verbose or debug (default normal)", func(s string) error {
+		v, err := organizer.ParseVerbosity(s)
+		*verbosity = v
+		return err
+	})
+	return verbosity
+}
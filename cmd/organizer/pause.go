@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+
+	"github.com/avizyt/org-cli/internal/organizer"
+)
+
+// handlePauseSignals toggles control between paused and running on every pause signal (SIGUSR1
+// where available), so a long run can yield the disk for a while: files in flight are finished,
+// then nothing new is dispatched until the next signal. The returned function stops handling.
+func handlePauseSignals(control *organizer.Controller, p organizer.Printer) (stop func()) {
+	if len(pauseSignals) == 0 {
+		return func() {}
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, pauseSignals...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-signals:
+				if control.Paused() {
+					control.Resume()
+					p.Status(organizer.LevelInfo, "▶️", "Received %s, resuming.", sig)
+				} else {
+					control.Pause()
+					p.Status(organizer.LevelInfo, "⏸️", "Received %s, pausing after the files in flight (again to resume).", sig)
+				}
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// pauseSignals is empty: there is no spare signal to pause with on this platform, use the control
+// API of the daemon instead.
+var pauseSignals []os.Signal
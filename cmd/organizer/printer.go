@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/avizyt/org-cli/internal/organizer"
+	"github.com/fatih/color"
+	"github.com/schollz/progressbar/v3"
+)
+
+// levelColors maps the engine's message levels to terminal colours.
+var levelColors = map[organizer.Level]*color.Color{
+	organizer.LevelInfo:    color.New(color.FgBlue),
+	organizer.LevelSuccess: color.New(color.FgGreen),
+	organizer.LevelNotice:  color.New(color.FgCyan),
+	organizer.LevelWarn:    color.New(color.FgYellow),
+	organizer.LevelError:   color.New(color.FgRed),
+}
+
+// terminalPrinter is the CLI's organizer.Printer: translated messages behind coloured labels and
+// icons on stdout, the icons swapped for ASCII labels in ASCII mode. While a progress bar is
+// attached it is cleared before every line, so messages don't end up glued to the bar; the bar
+// redraws itself on its next update.
+type terminalPrinter struct {
+	mu  sync.Mutex
+	bar *progressbar.ProgressBar
+}
+
+// attach makes the printer clear bar before printing; nil detaches it.
+func (t *terminalPrinter) attach(bar *progressbar.ProgressBar) {
+	t.mu.Lock()
+	t.bar = bar
+	t.mu.Unlock()
+}
+
+func (t *terminalPrinter) Status(level organizer.Level, icon, format string, args ...any) {
+	t.line("", level, icon, format, args)
+}
+
+func (t *terminalPrinter) Detail(level organizer.Level, icon, format string, args ...any) {
+	t.line("  ", level, icon, format, args)
+}
+
+func (t *terminalPrinter) File(level organizer.Level, label, format string, args ...any) {
+	t.print("    " + levelColors[level].Sprint(i18n.T(label)) + ": " + i18n.Sprintf(format, args...))
+}
+
+// line prints a message behind its coloured icon, or the whole message coloured if there is none.
+func (t *terminalPrinter) line(indent string, level organizer.Level, icon, format string, args []any) {
+	msg := i18n.Sprintf(format, args...)
+	if icon == "" {
+		t.print(indent + levelColors[level].Sprint(msg))
+		return
+	}
+	t.print(indent + levelColors[level].Sprint(glyph(icon)) + " " + msg)
+}
+
+func (t *terminalPrinter) print(s string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.bar != nil {
+		t.bar.Clear()
+	}
+	fmt.Println(s)
+}
+
+// exclusive runs fn, which may print and read from the terminal itself, while other messages wait.
+func (t *terminalPrinter) exclusive(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.bar != nil {
+		t.bar.Clear()
+	}
+	fn()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime/trace"
+	"sync"
+)
+
+// hiddenFlags are left out of the usage. They are for diagnosing the organizer itself, not for
+// organizing.
+var hiddenFlags = map[string]bool{"pprof": true, "trace": true}
+
+// usage prints the usage of the organize command without the hidden flags.
+func usage() {
+	visible := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flag.VisitAll(func(f *flag.Flag) {
+		if !hiddenFlags[f.Name] {
+			visible.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+	visible.SetOutput(flag.CommandLine.Output())
+	visible.PrintDefaults()
+}
+
+// startProfiling serves the runtime profiles of net/http/pprof on pprofAddr and writes an
+// execution trace to traceFile, for whichever is set. It returns a function that stops both and
+// can be called more than once.
+func startProfiling(pprofAddr, traceFile string) (stop func(), err error) {
+	var stops []func()
+	stop = sync.OnceFunc(func() {
+		for _, s := range stops {
+			s()
+		}
+	})
+	if pprofAddr != "" {
+		if err := requireLoopback(pprofAddr, "the runtime profiles"); err != nil {
+			return stop, err
+		}
+		ln, err := net.Listen("tcp", pprofAddr)
+		if err != nil {
+			return stop, err
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		srv := &http.Server{Handler: mux}
+		go srv.Serve(ln)
+		stops = append(stops, func() { srv.Close() })
+	}
+	if traceFile != "" {
+		f, err := os.Create(traceFile)
+		if err != nil {
+			stop()
+			return stop, err
+		}
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			stop()
+			return stop, err
+		}
+		stops = append(stops, func() {
+			trace.Stop()
+			f.Close()
+		})
+	}
+	return stop, nil
+}
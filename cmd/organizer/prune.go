@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/avizyt/org-cli/internal/journal"
+	"github.com/avizyt/org-cli/internal/organizer"
+	"github.com/fatih/color"
+)
+
+// runPrune implements `organizer prune`, which applies the retention rules of the config file to
+// an organized destination, and returns the process exit code.
+func runPrune(args []string) int {
+	blue := color.New(color.FgBlue).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	destDir := fs.String("dest", "", "Organized destination directory to prune (required)")
+	configPath := fs.String("config", "", "JSON configuration file with the retention rules (required)")
+	dryRun := fs.Bool("dry-run", false, "Only show which files would be trashed or deleted")
+	verbosity := addVerbosityFlags(fs)
+	addOutputFlags(fs)
+	fs.Parse(args)
+
+	if *destDir == "" || *configPath == "" {
+		fmt.Fprintln(os.Stderr, red(i18n.T("Usage: organizer prune --dest <dir> --config <file> [--dry-run]")))
+		return 2
+	}
+	absDestDir, err := filepath.Abs(*destDir)
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+	fileCfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+	rules, err := fileCfg.retentionRules()
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error in config '%s': %v\n", *configPath, err)))
+		return 1
+	}
+	if len(rules) == 0 {
+		fmt.Println(yellow(glyph(i18n.T("⚠️ No retention rules configured, nothing to prune."))))
+		return 0
+	}
+
+	startTime := time.Now()
+	var j *journal.Journal
+	if !*dryRun {
+		if _, j, err = createJournal(startTime); err != nil {
+			fmt.Fprintln(os.Stderr, yellow(i18n.Sprintf("%s Could not create journal, trashed files cannot be restored with undo: %v", glyph("⚠️"), err)))
+		}
+	}
+
+	i18n.Printf("%s Pruning '%s' with %d retention rules...\n", blue(glyph("🧹")), absDestDir, len(rules))
+	result := organizer.Prune(absDestDir, rules, *dryRun, *verbosity, j, startTime, &terminalPrinter{})
+	j.Close()
+
+	if *dryRun {
+		i18n.Printf("%s Dry run completed. %s files (%s) would have been pruned.\n", green(glyph("✅")), green(fmt.Sprintf("%d", result.Pruned)), organizer.FormatBytes(result.Bytes))
+	} else {
+		i18n.Printf("%s Pruned %s files (%s).\n", green(glyph("✅")), green(fmt.Sprintf("%d", result.Pruned)), organizer.FormatBytes(result.Bytes))
+		if path := j.Path(); path != "" {
+			i18n.Printf("%s Journal: %s (trashed files can be restored with 'organizer undo --run %s')\n", blue(glyph("📝")), path, journal.RunID(path))
+		}
+	}
+	if result.Failed > 0 {
+		i18n.Printf("%s %s files could not be pruned.\n", red(glyph("❌")), red(fmt.Sprintf("%d", result.Failed)))
+		return 1
+	}
+	return 0
+}
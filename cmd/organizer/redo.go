@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"filippo.io/age"
+	"github.com/avizyt/org-cli/internal/history"
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/avizyt/org-cli/internal/journal"
+	"github.com/avizyt/org-cli/internal/organizer"
+	"github.com/fatih/color"
+)
+
+// runRedo implements `organizer redo`, which does again what undo or restore reverted of a run,
+// without scanning the source, and returns the process exit code. The redo is journaled as a run
+// of its own, so it can be undone again.
+func runRedo(args []string) int {
+	blue := color.New(color.FgBlue).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	fs := flag.NewFlagSet("redo", flag.ExitOnError)
+	runID := fs.String("run", "", "ID of the run to redo (default: the most recent run that was undone)")
+	dryRun := fs.Bool("dry-run", false, "Only show what would be redone")
+	verbosity := addVerbosityFlags(fs)
+	encryptWith := fs.String("encrypt-with", "", "age recipients to encrypt files to again that were encrypted on move (as for organizing)")
+	addOutputFlags(fs)
+	fs.Parse(args)
+
+	dataDir, err := history.DataDir()
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+	path, err := journal.Find(journal.RedoDir(dataDir), *runID)
+	if errors.Is(err, journal.ErrNoRuns) {
+		fmt.Println(yellow(glyph(i18n.T("⚠️ Nothing to redo."))))
+		return 0
+	}
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+	entries, err := journal.Load(path)
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+
+	var recipients []age.Recipient
+	if *encryptWith != "" {
+		if recipients, err = organizer.ParseEncryptWith(*encryptWith); err != nil {
+			fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+			return 1
+		}
+	}
+
+	var j *journal.Journal
+	if !*dryRun {
+		if _, j, err = createJournal(time.Now()); err != nil {
+			fmt.Fprintln(os.Stderr, yellow(i18n.Sprintf("%s Could not create journal, the redo cannot be undone: %v", glyph("⚠️"), err)))
+		}
+	}
+
+	org, err := organizer.New(organizer.WithEncryption(recipients), organizer.WithDryRun(*dryRun), organizer.WithVerbosity(*verbosity), organizer.WithJournal(j), organizer.WithPrinter(&terminalPrinter{}))
+	if err != nil {
+		j.Close()
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+
+	// Ctrl-C finishes the file being redone and stops; the redo journal is kept for another try
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	i18n.Printf("%s Redoing run %s (%d operations)...\n", blue(glyph("🔁")), journal.RunID(path), len(entries))
+	redone, failed, err := org.Redo(ctx, entries)
+	j.Close()
+	if p := j.Path(); p != "" {
+		i18n.Printf("%s Journal: %s (undo the redo with 'organizer undo --run %s')\n", blue(glyph("📝")), p, journal.RunID(p))
+	}
+	if err != nil {
+		i18n.Printf("%s Stopped after redoing %s operations; run redo again to continue.\n", yellow(glyph("⚠️")), yellow(fmt.Sprintf("%d", redone)))
+		return 3
+	}
+
+	if *dryRun {
+		i18n.Printf("%s Dry run completed. %s operations would have been redone.\n", green(glyph("✅")), green(fmt.Sprintf("%d", redone)))
+		return 0
+	}
+	i18n.Printf("%s Redid %s operations.\n", green(glyph("✅")), green(fmt.Sprintf("%d", redone)))
+	if failed > 0 {
+		i18n.Printf("%s %s operations could not be redone; the redo journal is kept at %s.\n", red(glyph("❌")), red(fmt.Sprintf("%d", failed)), path)
+		return 1
+	}
+	if err := os.Remove(path); err != nil {
+		fmt.Fprintln(os.Stderr, yellow(i18n.Sprintf("%s Could not remove the redo journal: %v", glyph("⚠️"), err)))
+	}
+	return 0
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/history"
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/avizyt/org-cli/internal/organizer"
+	"github.com/fatih/color"
+)
+
+// runReport implements `organizer report <kind>` and returns the process exit code.
+func runReport(args []string) int {
+	red := color.New(color.FgRed).SprintFunc()
+
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintln(os.Stderr, red(i18n.T("Usage: organizer report trends [--weeks N] [--format table|json]")))
+		return 2
+	}
+
+	switch args[0] {
+	case "trends":
+		return runTrendsReport(args[1:])
+	default:
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: unknown report '%s' (available: trends)\n", args[0])))
+		return 2
+	}
+}
+
+// runTrendsReport prints week-over-week statistics of the recorded runs.
+func runTrendsReport(args []string) int {
+	red := color.New(color.FgRed).SprintFunc()
+
+	fs := flag.NewFlagSet("report trends", flag.ExitOnError)
+	weeks := fs.Int("weeks", 8, "Number of weeks to include, ending with the current week")
+	format := fs.String("format", "table", "Output format: table or json")
+	addOutputFlags(fs)
+	fs.Parse(args)
+
+	if *format != "table" && *format != "json" {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: unknown format '%s' (use table or json)\n", *format)))
+		return 2
+	}
+
+	summaries, err := history.Load()
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error loading run history: %v\n", err)))
+		return 1
+	}
+	trends := history.WeeklyTrends(summaries, *weeks, time.Now())
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(trends); err != nil {
+			fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error encoding report: %v\n", err)))
+			return 1
+		}
+		return 0
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, i18n.T("WEEK\tRUNS\tFILES\tCHANGE\tBYTES\tERRORS\tERROR RATE\tTOP CATEGORIES"))
+	for _, w := range trends {
+		change := "-"
+		if w.FilesChange != nil {
+			change = fmt.Sprintf("%+.0f%%", *w.FilesChange*100)
+		}
+		top := make([]string, 0, len(w.TopCategories))
+		for _, c := range w.TopCategories {
+			top = append(top, fmt.Sprintf("%s (%d)", c.Category, c.Files))
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%d\t%.1f%%\t%s\n",
+			w.WeekStart.Format("2006-01-02"), w.Runs, w.Files, change, organizer.FormatBytes(w.Bytes),
+			w.Errors, w.ErrorRate*100, strings.Join(top, ", "))
+	}
+	tw.Flush()
+	return 0
+}
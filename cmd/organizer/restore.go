@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"filippo.io/age"
+	"github.com/avizyt/org-cli/internal/history"
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/avizyt/org-cli/internal/journal"
+	"github.com/avizyt/org-cli/internal/organizer"
+	"github.com/fatih/color"
+)
+
+// runRestore implements `organizer restore`, which puts the files of a run matching --only back
+// where they came from using its journal, and returns the process exit code. Unlike undo, the rest
+// of the run stays in place and the run can still be undone later.
+func runRestore(args []string) int {
+	blue := color.New(color.FgBlue).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	runID := fs.String("run", "", "ID of the run to restore files from (required, see `organizer report`)")
+	only := fs.String("only", "", "Comma separated globs of the files to restore, relative to the destination of the run; ** matches any number of folders (required, e.g. \"Documents/**\")")
+	dryRun := fs.Bool("dry-run", false, "Only show what would be restored")
+	verbosity := addVerbosityFlags(fs)
+	identityPath := fs.String("identity", os.Getenv(organizer.IdentityEnv), "age identity file to decrypt files that were encrypted on move (env "+organizer.IdentityEnv+")")
+	addOutputFlags(fs)
+	fs.Parse(args)
+
+	patterns := splitList(*only)
+	if *runID == "" || len(patterns) == 0 {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: --run and --only are required.\n")))
+		fs.Usage()
+		return 2
+	}
+
+	dataDir, err := history.DataDir()
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+	path, err := journal.Find(journal.Dir(dataDir), *runID)
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+	entries, err := journal.Load(path)
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+
+	// Relative patterns are matched below the destination the run was recorded with
+	summaries, err := history.Load()
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+	var destDir string
+	for _, s := range summaries {
+		if s.RunID == *runID {
+			destDir = s.DestDir
+		}
+	}
+	if destDir == "" {
+		fmt.Fprintln(os.Stderr, yellow(i18n.Sprintf("%s Run %s is not in the history; only absolute patterns can match.", glyph("⚠️"), *runID)))
+	}
+	selected, err := organizer.SelectEntries(entries, destDir, patterns)
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+	if len(selected) == 0 {
+		i18n.Printf("%s No files of run %s match %s.\n", yellow(glyph("⚠️")), *runID, *only)
+		return 0
+	}
+
+	var identities []age.Identity
+	if *identityPath != "" {
+		if identities, err = organizer.LoadIdentities(*identityPath); err != nil {
+			fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+			return 1
+		}
+	}
+
+	// What is reverted is kept for `organizer redo`
+	var redo *journal.Journal
+	if !*dryRun {
+		if redo, err = journal.Open(journal.RedoDir(dataDir), *runID); err != nil {
+			fmt.Fprintln(os.Stderr, yellow(i18n.Sprintf("%s Could not open the redo journal, this cannot be redone: %v", glyph("⚠️"), err)))
+		}
+		defer redo.Close()
+	}
+
+	org, err := organizer.New(organizer.WithIdentities(identities), organizer.WithDryRun(*dryRun), organizer.WithVerbosity(*verbosity), organizer.WithJournal(redo), organizer.WithPrinter(&terminalPrinter{}))
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+
+	// Ctrl-C finishes the file being restored and stops; files already back are skipped next time
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	i18n.Printf("%s Restoring %d of %d operations of run %s...\n", blue(glyph("⏪")), len(selected), len(entries), *runID)
+	restored, failed, err := org.Undo(ctx, selected)
+	if err != nil {
+		i18n.Printf("%s Stopped after restoring %s files; run restore again to continue.\n", yellow(glyph("⚠️")), yellow(fmt.Sprintf("%d", restored)))
+		return 3
+	}
+
+	if *dryRun {
+		i18n.Printf("%s Dry run completed. %s files would have been restored.\n", green(glyph("✅")), green(fmt.Sprintf("%d", restored)))
+		return 0
+	}
+	i18n.Printf("%s Restored %s files.\n", green(glyph("✅")), green(fmt.Sprintf("%d", restored)))
+	if failed > 0 {
+		i18n.Printf("%s %s files could not be restored.\n", red(glyph("❌")), red(fmt.Sprintf("%d", failed)))
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/avizyt/org-cli/internal/organizer"
+	"github.com/fatih/color"
+)
+
+// runReview implements `organizer review [list|approve|reject]`, which works through the files
+// staged in the destination's Review folder, and returns the process exit code.
+func runReview(args []string) int {
+	red := color.New(color.FgRed).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	action := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+	if action != "list" && action != "approve" && action != "reject" {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: unknown review action '%s' (use list, approve or reject)\n", action)))
+		return 2
+	}
+
+	fs := flag.NewFlagSet("review "+action, flag.ExitOnError)
+	destDir := fs.String("dest", "", "Destination directory the files were organized into (required)")
+	all := fs.Bool("all", false, "Approve or reject every staged file")
+	addOutputFlags(fs)
+	fs.Parse(args)
+
+	if *destDir == "" {
+		fmt.Fprintln(os.Stderr, red(i18n.T("Usage: organizer review [list|approve|reject] --dest <dir> [--all | <name>...]")))
+		return 2
+	}
+	absDestDir, err := filepath.Abs(*destDir)
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+	items, err := organizer.LoadReview(absDestDir)
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+
+	if action == "list" {
+		if len(items) == 0 {
+			fmt.Println(green(glyph(i18n.T("✔ Nothing to review."))))
+			return 0
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, i18n.T("NAME\tCATEGORY\tSIZE\tSTAGED\tORIGINAL PATH"))
+		for _, item := range items {
+			name := item.Name
+			if _, err := os.Stat(filepath.Join(absDestDir, organizer.ReviewDir, item.Name)); err != nil {
+				name += " (missing)"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", name, item.Category, organizer.FormatBytes(item.Size), item.StagedAt.Format("2006-01-02 15:04"), item.Source)
+		}
+		tw.Flush()
+		return 0
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range fs.Args() {
+		selected[name] = true
+	}
+	if !*all && len(selected) == 0 {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: name the files to %s, or use --all\n", action)))
+		return 2
+	}
+
+	var remaining []organizer.ReviewItem
+	failed := 0
+	for _, item := range items {
+		if !*all && !selected[item.Name] {
+			remaining = append(remaining, item)
+			continue
+		}
+		delete(selected, item.Name)
+
+		if _, err := os.Stat(filepath.Join(absDestDir, organizer.ReviewDir, item.Name)); errors.Is(err, os.ErrNotExist) {
+			i18n.Printf("    %s: '%s' is no longer in %s; dropping it from the manifest\n", yellow(i18n.T("MISSING")), item.Name, organizer.ReviewDir)
+			continue
+		}
+		if action == "approve" {
+			final, err := organizer.ApproveReview(absDestDir, item)
+			if err != nil {
+				i18n.Printf("    %s: %v\n", red(i18n.T("ERROR")), err)
+				remaining = append(remaining, item)
+				failed++
+				continue
+			}
+			i18n.Printf("    %s: Filed '%s' into '%s'\n", green(i18n.T("APPROVED")), item.Name, final)
+		} else {
+			if err := organizer.RejectReview(absDestDir, item); err != nil {
+				i18n.Printf("    %s: %v\n", red(i18n.T("ERROR")), err)
+				remaining = append(remaining, item)
+				failed++
+				continue
+			}
+			i18n.Printf("    %s: Restored '%s' to '%s'\n", green(i18n.T("REJECTED")), item.Name, item.Source)
+		}
+	}
+	for name := range selected {
+		i18n.Printf("    %s: '%s' is not staged for review\n", yellow(i18n.T("UNKNOWN")), name)
+		failed++
+	}
+
+	if err := organizer.SaveReview(absDestDir, remaining); err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
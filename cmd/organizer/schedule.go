@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/fatih/color"
+	"github.com/robfig/cron/v3"
+)
+
+// scheduler fires runs according to a cron expression. Each firing is delayed by a random
+// amount up to Jitter so that many machines sharing the same schedule don't hit a NAS at once.
+type scheduler struct {
+	Spec     string
+	Schedule cron.Schedule
+	Jitter   time.Duration
+}
+
+// newScheduler parses a standard 5-field cron expression (or a descriptor such as @daily).
+func newScheduler(spec string, jitter time.Duration) (*scheduler, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule '%s': %w", spec, err)
+	}
+	if jitter < 0 {
+		return nil, fmt.Errorf("schedule jitter must not be negative")
+	}
+	return &scheduler{Spec: spec, Schedule: schedule, Jitter: jitter}, nil
+}
+
+// next returns the next firing time after now, jitter included.
+func (s *scheduler) next(now time.Time) time.Time {
+	next := s.Schedule.Next(now)
+	if s.Jitter > 0 {
+		next = next.Add(rand.N(s.Jitter))
+	}
+	return next
+}
+
+// run sends the scheduled time on fire whenever the schedule is due, until stop is closed.
+// If the previous run is still going (busy returns true) or another run is already queued,
+// the firing is skipped and logged instead of piling up overlapping runs.
+func (s *scheduler) run(fire chan<- time.Time, busy func() bool, stop <-chan struct{}) {
+	blue := color.New(color.FgBlue).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	for {
+		due := s.next(time.Now())
+		i18n.Printf("%s [%s] Next scheduled run at %s\n", blue(glyph("⏰")), time.Now().Format(time.DateTime), due.Format(time.DateTime))
+		timer := time.NewTimer(time.Until(due))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if busy() {
+			i18n.Printf("%s [%s] Skipping scheduled run: previous run is still in progress.\n", yellow(glyph("⏰")), time.Now().Format(time.DateTime))
+			continue
+		}
+		select {
+		case fire <- due:
+		default:
+			i18n.Printf("%s [%s] Skipping scheduled run: another run is already queued.\n", yellow(glyph("⏰")), time.Now().Format(time.DateTime))
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/fatih/color"
+)
+
+const (
+	systemdUnitName = "organizer.service"
+	launchdLabel    = "com.github.avizyt.org-cli"
+)
+
+// runService implements `organizer service <action>` and returns the process exit code.
+func runService(args []string) int {
+	red := color.New(color.FgRed).SprintFunc()
+
+	if len(args) == 0 || args[0] != "install" {
+		fmt.Fprintln(os.Stderr, red(i18n.T("Usage: organizer service install [--source DIR] [--dest DIR] [--interval DURATION] [--print] [--force]")))
+		return 2
+	}
+	return runServiceInstall(args[1:])
+}
+
+// runServiceInstall writes a per-user systemd unit (Linux) or launchd agent (macOS) that runs the
+// organizer in watch mode.
+func runServiceInstall(args []string) int {
+	blue := color.New(color.FgBlue).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error determining home directory: %v\n", err)))
+		return 1
+	}
+
+	fs := flag.NewFlagSet("service install", flag.ExitOnError)
+	source := fs.String("source", filepath.Join(home, "Downloads"), "Directory to watch")
+	dest := fs.String("dest", filepath.Join(home, "Downloads", "Organized"), "Directory to organize files into")
+	interval := fs.Duration("interval", 5*time.Minute, "How often the service organizes the source")
+	configPath := fs.String("config", "", "Optional configuration file passed to the service")
+	printOnly := fs.Bool("print", false, "Print the service definition instead of installing it")
+	force := fs.Bool("force", false, "Overwrite an existing service definition")
+	addOutputFlags(fs)
+	fs.Parse(args)
+
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error locating the organizer executable: %v\n", err)))
+		return 1
+	}
+	if exe, err = filepath.Abs(exe); err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error locating the organizer executable: %v\n", err)))
+		return 1
+	}
+	absSource, _ := filepath.Abs(*source)
+	absDest, _ := filepath.Abs(*dest)
+
+	command := []string{exe, "--source", absSource, "--dest", absDest, "--watch", "--watch-interval", interval.String(), "--quiet"}
+	if *configPath != "" {
+		absConfig, _ := filepath.Abs(*configPath)
+		command = append(command, "--config", absConfig)
+	}
+
+	var path, content, activate string
+	switch runtime.GOOS {
+	case "linux":
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error determining config directory: %v\n", err)))
+			return 1
+		}
+		path = filepath.Join(configDir, "systemd", "user", systemdUnitName)
+		content = systemdUnit(absSource, command)
+		activate = "systemctl --user daemon-reload && systemctl --user enable --now " + systemdUnitName
+	case "darwin":
+		path = filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist")
+		content = launchdPlist(command, filepath.Join(home, "Library", "Logs", "org-cli.log"))
+		activate = "launchctl load -w " + path
+	default:
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: service installation is not supported on %s.\n", runtime.GOOS)))
+		return 1
+	}
+
+	if *printOnly {
+		fmt.Print(content)
+		return 0
+	}
+
+	if _, err := os.Stat(path); err == nil && !*force {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: '%s' already exists (use --force to overwrite).\n", path)))
+		return 1
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error checking '%s': %v\n", path, err)))
+		return 1
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error creating '%s': %v\n", filepath.Dir(path), err)))
+		return 1
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error writing '%s': %v\n", path, err)))
+		return 1
+	}
+
+	i18n.Printf("%s Service definition written to %s\n", green(glyph("✔")), path)
+	i18n.Printf("%s Activate it with:\n    %s\n", blue(glyph("ℹ️")), activate)
+	return 0
+}
+
+// systemdUnit renders a user unit running command with readiness notification and a watchdog.
+func systemdUnit(source string, command []string) string {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = systemdQuote(arg)
+	}
+	return fmt.Sprintf(`[Unit]
+Description=Go File Organizer (watching %s)
+
+[Service]
+Type=notify
+ExecStart=%s
+Restart=on-failure
+WatchdogSec=2min
+TimeoutStopSec=2min
+
+[Install]
+WantedBy=default.target
+`, source, strings.Join(quoted, " "))
+}
+
+// systemdQuote quotes an ExecStart argument when it contains characters systemd would split on
+// or interpret.
+func systemdQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\$%;") {
+		return arg
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `$$`, `%`, `%%`)
+	return `"` + r.Replace(arg) + `"`
+}
+
+// launchdPlist renders a launchd agent that keeps command running and logs to logPath.
+func launchdPlist(command []string, logPath string) string {
+	var args strings.Builder
+	for _, arg := range command {
+		fmt.Fprintf(&args, "        <string>%s</string>\n", html.EscapeString(arg))
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+    <key>Label</key>
+    <string>%s</string>
+    <key>ProgramArguments</key>
+    <array>
+%s    </array>
+    <key>RunAtLoad</key>
+    <true/>
+    <key>KeepAlive</key>
+    <true/>
+    <key>StandardOutPath</key>
+    <string>%s</string>
+    <key>StandardErrorPath</key>
+    <string>%s</string>
+</dict>
+</plist>
+`, launchdLabel, args.String(), html.EscapeString(logPath), html.EscapeString(logPath))
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/history"
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/avizyt/org-cli/internal/organizer"
+	"github.com/fatih/color"
+)
+
+// statusDir returns the directory every running organizer puts its status socket in.
+func statusDir() (string, error) {
+	dir, err := history.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "status"), nil
+}
+
+// startStatusSocket serves h on <statusDir>/<pid>.sock for `organizer status`. A socket that
+// cannot be created is reported through p; the run goes on without it. The returned function
+// closes the socket.
+func startStatusSocket(h *apiHandler, p organizer.Printer) (stop func()) {
+	dir, err := statusDir()
+	if err == nil {
+		err = os.MkdirAll(dir, 0700)
+	}
+	var server *apiServer
+	if err == nil {
+		server, err = startAPI("unix:"+filepath.Join(dir, strconv.Itoa(os.Getpid())+".sock"), h)
+	}
+	if err != nil {
+		p.Status(organizer.LevelWarn, "⚠️", "Could not create the status socket, `organizer status` won't see this run: %v", err)
+		return func() {}
+	}
+	return func() { server.Close() }
+}
+
+// statusClient returns an HTTP client that talks to the API on the unix socket at path.
+func statusClient(path string) *http.Client {
+	return &http.Client{
+		Timeout: 2 * time.Second,
+		Transport: &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		}},
+	}
+}
+
+// queryStatus fetches the progress of the organizer listening on the unix socket at path.
+func queryStatus(path string) (progressSnapshot, error) {
+	var snapshot progressSnapshot
+	resp, err := statusClient(path).Get("http://organizer/progress")
+	if err != nil {
+		return snapshot, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return snapshot, fmt.Errorf("unexpected response %s", resp.Status)
+	}
+	return snapshot, json.NewDecoder(resp.Body).Decode(&snapshot)
+}
+
+// runStatusCommand implements `organizer status`, which shows the progress of the organizers
+// running as the current user, and returns the process exit code.
+func runStatusCommand(args []string) int {
+	red := color.New(color.FgRed).SprintFunc()
+
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	socket := fs.String("socket", "", "Query only the organizer listening on this unix socket, e.g. the --listen unix:/path of a daemon")
+	asJSON := fs.Bool("json", false, "Print the progress as JSON")
+	addOutputFlags(fs)
+	fs.Parse(args)
+
+	var sockets []string
+	if *socket != "" {
+		sockets = []string{strings.TrimPrefix(*socket, "unix:")}
+	} else {
+		dir, err := statusDir()
+		if err != nil {
+			fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+			return 1
+		}
+		if sockets, err = filepath.Glob(filepath.Join(dir, "*.sock")); err != nil {
+			fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+			return 1
+		}
+	}
+
+	snapshots := []progressSnapshot{}
+	for _, path := range sockets {
+		snapshot, err := queryStatus(path)
+		if err != nil {
+			if *socket != "" {
+				fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error querying '%s': %v\n", path, err)))
+				return 1
+			}
+			// The organizer was killed before it could remove its socket
+			if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, os.ErrNotExist) {
+				os.Remove(path)
+			}
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	slices.SortFunc(snapshots, func(a, b progressSnapshot) int { return a.PID - b.PID })
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(snapshots); err != nil {
+			fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+			return 1
+		}
+		return 0
+	}
+	if len(snapshots) == 0 {
+		fmt.Println(i18n.T("No organizer is running."))
+		return 0
+	}
+	for i, s := range snapshots {
+		if i > 0 {
+			fmt.Println()
+		}
+		printStatus(s)
+	}
+	return 0
+}
+
+// printStatus prints the progress of one organizer.
+func printStatus(s progressSnapshot) {
+	blue := color.New(color.FgBlue).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	faint := color.New(color.Faint).SprintFunc()
+
+	i18n.Printf("%s PID %d: '%s' to '%s'\n", blue(glyph("🔄")), s.PID, s.Source, s.Dest)
+	if !s.Running {
+		i18n.Printf("  Idle, %d completed runs.\n", s.Runs)
+		return
+	}
+	state := i18n.T("Running")
+	if s.Paused {
+		state = yellow(i18n.T("Paused"))
+	}
+	elapsed := ""
+	if s.StartedAt != nil {
+		elapsed = time.Since(*s.StartedAt).Round(time.Second).String()
+	}
+	i18n.Printf("  %s for %s: %d/%d files (%s), %d skipped, %d errors\n", state, elapsed, s.Processed, s.Planned, organizer.FormatBytes(s.Bytes), s.Skipped, s.Errors)
+	if s.Pool != nil {
+		i18n.Printf("  %d/%d workers busy, %d files queued, %d waiting for a retry\n", s.Pool.Busy, s.Pool.Workers, s.Pool.Queued, s.Pool.Retrying)
+	}
+	for _, w := range slices.Sorted(maps.Keys(s.Workers)) {
+		line := i18n.Sprintf("  worker %d: %s", w, s.Workers[w])
+		if n := s.Copied[w]; n > 0 {
+			line += " " + i18n.Sprintf("(%s copied)", organizer.FormatBytes(n))
+		}
+		fmt.Println(faint(line))
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/avizyt/org-cli/internal/organizer"
+	"github.com/fatih/color"
+)
+
+// syslogTag is the program name the operation log is sent under (SYSLOG_IDENTIFIER in journald).
+const syslogTag = "org-cli"
+
+// syslogWriter is the part of a log/syslog Writer the operation log uses.
+type syslogWriter interface {
+	Info(msg string) error
+	Notice(msg string) error
+	Warning(msg string) error
+	Err(msg string) error
+	Close() error
+}
+
+// opLog ships the operations of every run to syslog with --syslog; nil without it.
+var opLog *opLogger
+
+// opLogger writes one line per file and per run to syslog, as logfmt key=value pairs, so that log
+// pipelines can pick the fields apart. Messages that cannot be delivered are counted and reported
+// once per run; they never fail it.
+type opLogger struct {
+	w      syslogWriter
+	mu     sync.Mutex
+	failed int
+	err    error
+}
+
+// newOpLogger connects to the syslog target of --syslog: "local" for the syslog daemon or
+// journald of this machine, or udp://host[:port] or tcp://host[:port] for a remote one, port 514
+// by default.
+func newOpLogger(target string) (*opLogger, error) {
+	network, addr := "", ""
+	if target != "local" {
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "udp" && u.Scheme != "tcp") || u.Host == "" {
+			return nil, fmt.Errorf("invalid syslog target '%s' (use local, udp://host[:port] or tcp://host[:port])", target)
+		}
+		network, addr = u.Scheme, u.Host
+		if u.Port() == "" {
+			addr = net.JoinHostPort(u.Hostname(), "514")
+		}
+	}
+	w, err := dialSyslog(network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog '%s': %w", target, err)
+	}
+	return &opLogger{w: w}, nil
+}
+
+// send writes msg with the severity of write, counting it if it cannot be delivered.
+func (l *opLogger) send(write func(string) error, msg string) {
+	if err := write(msg); err != nil {
+		l.mu.Lock()
+		l.failed++
+		l.err = err
+		l.mu.Unlock()
+	}
+}
+
+// runStarted logs the start of the run of summary.
+func (l *opLogger) runStarted(summary organizer.Summary) {
+	if l == nil {
+		return
+	}
+	l.send(l.w.Notice, logfmt("event", "run_started", "run", summary.RunID, "source", summary.SourceDir, "dest", summary.DestDir, "dry_run", strconv.FormatBool(summary.DryRun)))
+}
+
+// file logs the outcome of a file of the run runID: failures as errors, files the scan was denied
+// access to as warnings, everything else as informational.
+func (l *opLogger) file(runID string, f *organizer.FileResult) {
+	if l == nil || f == nil {
+		return
+	}
+	fields := []string{"event", "file", "run", runID, "action", string(f.Action), "source", f.Source}
+	if f.Dest != "" {
+		fields = append(fields, "dest", f.Dest)
+	}
+	if f.Category != "" {
+		fields = append(fields, "category", f.Category)
+	}
+	fields = append(fields, "size", strconv.FormatInt(f.Size, 10), "duration_ms", strconv.FormatInt(f.Duration.Milliseconds(), 10))
+	if f.Err != nil {
+		fields = append(fields, "error", f.Err.Error())
+	}
+	write := l.w.Info
+	switch f.Action {
+	case organizer.ActionFail:
+		write = l.w.Err
+	case organizer.ActionDenied:
+		write = l.w.Warning
+	}
+	l.send(write, logfmt(fields...))
+}
+
+// runFinished logs the outcome of the run of summary, and reports on stderr how many messages of
+// the run could not be delivered.
+func (l *opLogger) runFinished(summary organizer.Summary) {
+	if l == nil {
+		return
+	}
+	fields := []string{"event", "run_finished", "run", summary.RunID, "status", summary.Status,
+		"processed", strconv.Itoa(summary.Processed), "skipped", strconv.Itoa(summary.Skipped), "errors", strconv.Itoa(summary.Errors),
+		"bytes", strconv.FormatInt(summary.Bytes, 10), "duration_ms", strconv.FormatInt(summary.DurationMS, 10)}
+	if summary.Journal != "" {
+		fields = append(fields, "journal", summary.Journal)
+	}
+	if summary.Error != "" {
+		fields = append(fields, "error", summary.Error)
+	}
+	write := l.w.Notice
+	if summary.Status != organizer.StatusOK {
+		write = l.w.Warning
+	}
+	l.send(write, logfmt(fields...))
+
+	l.mu.Lock()
+	failed, err := l.failed, l.err
+	l.failed, l.err = 0, nil
+	l.mu.Unlock()
+	if failed > 0 {
+		fmt.Fprintln(os.Stderr, color.New(color.FgYellow).Sprint(i18n.Sprintf("%s Could not send %d log messages to syslog: %v", glyph("⚠️"), failed, err)))
+	}
+}
+
+// logfmt joins the key and value pairs of fields as key=value, quoting values with spaces,
+// quotes or equal signs, and leaving out empty values.
+func logfmt(fields ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(fields); i += 2 {
+		key, value := fields[i], fields[i+1]
+		if value == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(key)
+		b.WriteByte('=')
+		if strings.ContainsAny(value, " \"=\t\n") {
+			value = strconv.Quote(value)
+		}
+		b.WriteString(value)
+	}
+	return b.String()
+}
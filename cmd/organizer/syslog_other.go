@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "errors"
+
+// dialSyslog fails: the standard library has no syslog client for this platform.
+func dialSyslog(network, addr string) (syslogWriter, error) {
+	return nil, errors.New("syslog is not available on this platform")
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package main
+
+import "log/syslog"
+
+// dialSyslog connects to the syslog daemon at addr over network, or to the local one if network
+// is empty.
+func dialSyslog(network, addr string) (syslogWriter, error) {
+	return syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_DAEMON, syslogTag)
+}
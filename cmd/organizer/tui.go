@@ -0,0 +1,626 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/avizyt/org-cli/internal/organizer"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fatih/color"
+	"github.com/muesli/termenv"
+)
+
+// runTUI organizes cfg interactively: it plans the run, lets the user go through the planned moves
+// and exclude categories, and once confirmed runs it while showing what every worker is doing.
+// ran is false if the user quit before confirming; nothing was touched then.
+func runTUI(cfg organizer.Config, base organizer.Summary) (summary organizer.Summary, ran bool, err error) {
+	if color.NoColor {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+	link := &tuiLink{}
+	cfg.Printer = tuiPrinter{link}
+	cfg.Control = organizer.NewController()
+	model := &tuiModel{cfg: cfg, base: base, send: link.send, status: i18n.T("Scanning..."), width: tuiDefaultWidth, height: tuiDefaultHeight}
+	link.program = tea.NewProgram(model, tea.WithAltScreen())
+	if _, err := link.program.Run(); err != nil {
+		return base, false, err
+	}
+	if model.phase < tuiRunning {
+		return base, false, nil
+	}
+	if model.phase == tuiRunning {
+		// Quit while the run was being stopped: the files in flight are not accounted for
+		model.summary = base
+		model.summary.Status = organizer.StatusAborted
+		model.summary.Finish(clock())
+	}
+	return model.summary, true, nil
+}
+
+// tuiLink lets the printer and the run send messages to the program, which only exists once the
+// model has been created.
+type tuiLink struct {
+	program *tea.Program
+}
+
+func (l *tuiLink) send(msg tea.Msg) {
+	l.program.Send(msg)
+}
+
+// tuiPrinter routes the engine's status lines into the TUI's header. Per-file lines are dropped:
+// the TUI shows the planned moves and the failures from the results instead.
+type tuiPrinter struct {
+	link *tuiLink
+}
+
+func (t tuiPrinter) Status(level organizer.Level, icon, format string, args ...any) {
+	t.link.send(tuiStatusMsg{level: level, text: i18n.Sprintf(format, args...)})
+}
+
+func (t tuiPrinter) Detail(level organizer.Level, icon, format string, args ...any) {}
+
+func (t tuiPrinter) File(level organizer.Level, label, format string, args ...any) {}
+
+// Messages of the TUI.
+type (
+	tuiStatusMsg struct {
+		level organizer.Level
+		text  string
+	}
+	tuiPlanMsg struct {
+		result organizer.Result
+		err    error
+	}
+	tuiProgressMsg organizer.ProgressUpdate
+	tuiDoneMsg     organizer.Summary
+)
+
+type tuiPhase int
+
+const (
+	tuiPlanning tuiPhase = iota // Scanning the source
+	tuiReview                   // Showing the plan, waiting for confirmation
+	tuiRunning                  // Organizing
+	tuiDone                     // Finished, showing the outcome
+)
+
+// tuiView is one of the two ways the plan can be browsed.
+type tuiView int
+
+const (
+	viewCategories tuiView = iota // Planned moves grouped by category
+	viewTree                      // Directories of the source with the number of planned files
+)
+
+// tuiCategory is the part of the plan that goes into one category.
+type tuiCategory struct {
+	name     string
+	files    []organizer.FileResult
+	bytes    int64
+	excluded bool
+	expanded bool
+}
+
+// tuiRow is a line of the categories view: a category, or one of its files when expanded.
+type tuiRow struct {
+	category int
+	file     int // -1 for the category itself
+}
+
+// maxErrorLines is how many failures the error pane shows; older ones scroll out.
+const maxErrorLines = 5
+
+// The size the TUI lays itself out for until the terminal reports its own. Bubble Tea sends the
+// size when it can tell; when it cannot (output piped, some CI terminals) the screen is drawn for
+// this one instead of waiting.
+const (
+	tuiDefaultWidth  = 80
+	tuiDefaultHeight = 24
+)
+
+type tuiModel struct {
+	cfg  organizer.Config
+	base organizer.Summary
+	send func(tea.Msg)
+
+	phase  tuiPhase
+	status string
+	level  organizer.Level
+	width  int // Of the terminal, tuiDefaultWidth until it is known
+	height int // Of the terminal, tuiDefaultHeight until it is known
+
+	categories []*tuiCategory
+	tree       []string
+	view       tuiView
+	cursor     [2]int // Per view
+	offset     [2]int // First visible line, per view
+	errors     []string
+	errorCount int
+
+	workers  map[int]organizer.FileEvent // Worker to the latest event of the file it is working on
+	copied   map[int]int64               // Worker to the bytes of its file copied so far, during long copies
+	poolSize int                         // Number of workers the run starts
+	stats    organizer.Stats             // Of the run, from its progress updates
+	stopping bool
+	summary  organizer.Summary
+}
+
+var (
+	tuiTitle    = lipgloss.NewStyle().Bold(true)
+	tuiCursor   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6"))
+	tuiExcluded = lipgloss.NewStyle().Faint(true).Strikethrough(true)
+	tuiFaint    = lipgloss.NewStyle().Faint(true)
+	tuiError    = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	tuiWarn     = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	tuiSuccess  = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+)
+
+func (m *tuiModel) Init() tea.Cmd {
+	return m.plan
+}
+
+// plan runs the dry run the review is based on.
+func (m *tuiModel) plan() tea.Msg {
+	org, err := organizer.New(organizer.WithConfig(m.cfg))
+	if err != nil {
+		return tuiPlanMsg{err: err}
+	}
+	result, err := org.DryRun(context.Background())
+	return tuiPlanMsg{result: result, err: err}
+}
+
+// run organizes the source without the excluded categories.
+func (m *tuiModel) run() tea.Msg {
+	cfg := m.cfg
+	var excluded categoryFilter
+	for _, c := range m.categories {
+		if c.excluded {
+			if excluded == nil {
+				excluded = categoryFilter{}
+			}
+			excluded[c.name] = true
+		}
+	}
+	if excluded != nil {
+		cfg.Classifiers = append(slices.Clone(cfg.Classifiers), excluded)
+	}
+	base := m.base
+	base.StartedAt = clock()
+	return tuiDoneMsg(execute(cfg, base, nil, func(update organizer.ProgressUpdate) {
+		m.send(tuiProgressMsg(update))
+	}))
+}
+
+// categoryFilter is a classifier that skips the files of the categories excluded in the TUI. It
+// goes last, so it sees the category the other classifiers decided on.
+type categoryFilter map[string]bool
+
+func (f categoryFilter) Classify(req organizer.ClassifyRequest) (organizer.ClassifyResponse, error) {
+	return organizer.ClassifyResponse{Skip: f[req.Category]}, nil
+}
+
+// excludable reports whether files of category can be left out of the run. Staged and archived
+// files are grouped under the folder they go to, not a category the classifiers see.
+func excludable(category string) bool {
+	return category != organizer.ReviewDir && category != organizer.ArchivalCategory
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		if msg.Width > 0 && msg.Height > 0 { // Some terminals report 0x0; the default is better than that
+			m.width, m.height = msg.Width, msg.Height
+		}
+	case tuiStatusMsg:
+		m.status, m.level = msg.text, msg.level
+	case tuiPlanMsg:
+		m.setPlan(msg.result)
+		if msg.err != nil {
+			m.status, m.level = i18n.Sprintf("Error: %v", msg.err), organizer.LevelError
+		} else {
+			m.status, m.level = i18n.Sprintf("%d files planned in %d categories. Exclude categories, then press y to run.", m.included(), len(m.categories)), organizer.LevelInfo
+		}
+		m.phase = tuiReview
+	case tuiProgressMsg:
+		m.progress(organizer.ProgressUpdate(msg))
+	case tuiDoneMsg:
+		m.summary = organizer.Summary(msg)
+		m.phase = tuiDone
+		m.workers, m.copied = nil, nil
+		m.status, m.level = i18n.Sprintf("Finished: %d processed, %d skipped, %d errors.", m.summary.Processed, m.summary.Skipped, m.summary.Errors), organizer.LevelSuccess
+		if m.summary.Errors > 0 || m.summary.Status == organizer.StatusAborted {
+			m.level = organizer.LevelWarn
+		}
+	case tea.KeyMsg:
+		return m, m.key(msg)
+	}
+	return m, nil
+}
+
+// key handles a key press and returns the command it starts, if any.
+func (m *tuiModel) key(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c", "q", "esc":
+		if m.phase == tuiRunning && !m.stopping {
+			// Like Ctrl-C without the TUI: finish the files in flight, a second press quits
+			m.stopping = true
+			m.cfg.Control.Stop()
+			m.status, m.level = i18n.T("Stopping after the files in flight (again to quit now)..."), organizer.LevelWarn
+			return nil
+		}
+		return tea.Quit
+	}
+	if m.phase != tuiReview {
+		return nil
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "pgup":
+		m.move(-m.listHeight())
+	case "pgdown":
+		m.move(m.listHeight())
+	case "tab":
+		m.view = 1 - m.view
+	case "enter":
+		if m.view == viewCategories {
+			if row, ok := m.selectedRow(); ok {
+				c := m.categories[row.category]
+				c.expanded = !c.expanded
+				m.cursor[viewCategories] = slices.Index(m.rows(), tuiRow{category: row.category, file: -1})
+			}
+		}
+	case " ", "x":
+		if m.view == viewCategories {
+			if row, ok := m.selectedRow(); ok {
+				c := m.categories[row.category]
+				if excludable(c.name) {
+					c.excluded = !c.excluded
+				} else {
+					m.status, m.level = i18n.Sprintf("Files staged in %s or archived cannot be excluded here.", organizer.ReviewDir), organizer.LevelWarn
+				}
+			}
+		}
+	case "y":
+		if m.included() == 0 {
+			m.status, m.level = i18n.T("Nothing left to organize."), organizer.LevelWarn
+			return nil
+		}
+		m.phase = tuiRunning
+		m.workers, m.copied = make(map[int]organizer.FileEvent), make(map[int]int64)
+		if m.poolSize = m.cfg.Workers; m.poolSize <= 0 {
+			m.poolSize, _ = organizer.AutoWorkers(m.cfg.SourceDir, m.cfg.DestDir)
+		}
+		m.status, m.level = i18n.T("Organizing..."), organizer.LevelInfo
+		return m.run
+	}
+	return nil
+}
+
+// setPlan groups the planned files by category and builds the directory tree.
+func (m *tuiModel) setPlan(result organizer.Result) {
+	byName := make(map[string]*tuiCategory)
+	dirs := make(map[string]int)
+	for _, f := range result.Files {
+		if f.Action == organizer.ActionFail {
+			m.addError(f)
+			continue
+		}
+		if f.Action == organizer.ActionSkip {
+			continue
+		}
+		c := byName[f.Category]
+		if c == nil {
+			c = &tuiCategory{name: f.Category}
+			byName[f.Category] = c
+			m.categories = append(m.categories, c)
+		}
+		c.files = append(c.files, f)
+		c.bytes += f.Size
+
+		// Count the file in its directory and every directory above it
+		rel, err := filepath.Rel(m.cfg.SourceDir, filepath.Dir(f.Source))
+		if err != nil || strings.HasPrefix(rel, "..") {
+			rel = "."
+		}
+		for {
+			dirs[rel]++
+			if rel == "." {
+				break
+			}
+			rel = filepath.Dir(rel)
+		}
+	}
+	sort.Slice(m.categories, func(i, j int) bool { return m.categories[i].name < m.categories[j].name })
+	for _, c := range m.categories {
+		sort.Slice(c.files, func(i, j int) bool { return c.files[i].Source < c.files[j].Source })
+	}
+
+	paths := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		paths = append(paths, dir)
+	}
+	// Parents before their children, "." (the source itself) first
+	sort.Slice(paths, func(i, j int) bool {
+		if paths[i] == "." || paths[j] == "." {
+			return paths[i] == "."
+		}
+		sep := string(filepath.Separator)
+		return slices.Compare(strings.Split(paths[i], sep), strings.Split(paths[j], sep)) < 0
+	})
+	for _, dir := range paths {
+		name, depth := m.cfg.SourceDir, 0
+		if dir != "." {
+			name, depth = filepath.Base(dir)+string(filepath.Separator), strings.Count(dir, string(filepath.Separator))+1
+		}
+		m.tree = append(m.tree, fmt.Sprintf("%s%s  %s", strings.Repeat("  ", depth), name, tuiFaint.Render(i18n.Sprintf("%d files", dirs[dir]))))
+	}
+}
+
+// progress applies an update of the run.
+func (m *tuiModel) progress(update organizer.ProgressUpdate) {
+	if update.Started != "" {
+		delete(m.copied, update.Worker)
+	}
+	if e := update.Event; e != nil && update.Worker > 0 && e.Phase != organizer.PhaseDone && e.Phase != organizer.PhaseFailed {
+		m.workers[update.Worker] = *e
+		return
+	}
+	if update.Copied > 0 {
+		m.copied[update.Worker] = update.Copied
+		return
+	}
+	m.stats.Add(update)
+	if update.File != nil {
+		if update.Worker > 0 {
+			delete(m.workers, update.Worker)
+			delete(m.copied, update.Worker)
+		}
+		if update.File.Action == organizer.ActionFail {
+			m.addError(*update.File)
+		}
+	}
+}
+
+func (m *tuiModel) addError(f organizer.FileResult) {
+	m.errorCount++
+	m.errors = append(m.errors, fmt.Sprintf("%s: %v", f.Source, f.Err))
+	if len(m.errors) > maxErrorLines {
+		m.errors = m.errors[1:]
+	}
+}
+
+// included returns the number of planned files that are not excluded.
+func (m *tuiModel) included() int {
+	n := 0
+	for _, c := range m.categories {
+		if !c.excluded {
+			n += len(c.files)
+		}
+	}
+	return n
+}
+
+// rows returns the lines of the categories view.
+func (m *tuiModel) rows() []tuiRow {
+	var rows []tuiRow
+	for i, c := range m.categories {
+		rows = append(rows, tuiRow{category: i, file: -1})
+		if c.expanded {
+			for j := range c.files {
+				rows = append(rows, tuiRow{category: i, file: j})
+			}
+		}
+	}
+	return rows
+}
+
+func (m *tuiModel) selectedRow() (tuiRow, bool) {
+	rows := m.rows()
+	if len(rows) == 0 {
+		return tuiRow{}, false
+	}
+	return rows[m.cursor[viewCategories]], true
+}
+
+// move moves the cursor of the current view by delta lines.
+func (m *tuiModel) move(delta int) {
+	n := len(m.tree)
+	if m.view == viewCategories {
+		n = len(m.rows())
+	}
+	m.cursor[m.view] = max(0, min(n-1, m.cursor[m.view]+delta))
+}
+
+// listHeight is the number of lines available for the categories or the tree.
+func (m *tuiModel) listHeight() int {
+	used := 6 // Title, status, blank, footer and spacing
+	if len(m.errors) > 0 {
+		used += len(m.errors) + 2
+	}
+	return max(3, m.height-used)
+}
+
+func (m *tuiModel) View() string {
+	var b strings.Builder
+	b.WriteString(tuiTitle.Render(i18n.Sprintf("Organizer: %s -> %s", m.cfg.SourceDir, m.cfg.DestDir)))
+	if m.cfg.DryRun {
+		b.WriteString(tuiWarn.Render(i18n.T(" (dry run)")))
+	}
+	b.WriteString("\n")
+	b.WriteString(tuiLevelStyle(m.level).Render(m.status))
+	b.WriteString("\n\n")
+
+	switch m.phase {
+	case tuiReview:
+		if m.view == viewCategories {
+			m.viewCategories(&b)
+		} else {
+			m.viewTree(&b)
+		}
+	case tuiRunning, tuiDone:
+		m.viewProgress(&b)
+	}
+
+	if len(m.errors) > 0 {
+		b.WriteString("\n")
+		b.WriteString(tuiError.Render(i18n.Sprintf("Errors (%d)", m.errorCount)))
+		b.WriteString("\n")
+		for _, e := range m.errors {
+			b.WriteString(tuiError.Render("  " + m.truncate(e, 2)))
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("\n")
+	var help string
+	switch m.phase {
+	case tuiPlanning:
+		help = i18n.T("q quit")
+	case tuiReview:
+		help = i18n.T("up/down move · space exclude category · enter show files · tab categories/tree · y run · q quit")
+	case tuiRunning:
+		help = i18n.T("q stop")
+	case tuiDone:
+		help = i18n.T("q quit")
+	}
+	b.WriteString(tuiFaint.Render(help))
+	return b.String()
+}
+
+func tuiLevelStyle(level organizer.Level) lipgloss.Style {
+	switch level {
+	case organizer.LevelError:
+		return tuiError
+	case organizer.LevelWarn:
+		return tuiWarn
+	case organizer.LevelSuccess:
+		return tuiSuccess
+	}
+	return lipgloss.NewStyle()
+}
+
+// visible returns the range of lines of a list of n lines to show, keeping the cursor of the
+// current view in sight.
+func (m *tuiModel) visible(n int) (from, to int) {
+	height, v := m.listHeight(), m.view
+	if m.cursor[v] < m.offset[v] {
+		m.offset[v] = m.cursor[v]
+	}
+	if m.cursor[v] >= m.offset[v]+height {
+		m.offset[v] = m.cursor[v] - height + 1
+	}
+	return m.offset[v], min(n, m.offset[v]+height)
+}
+
+func (m *tuiModel) viewCategories(b *strings.Builder) {
+	rows := m.rows()
+	if len(rows) == 0 {
+		b.WriteString(i18n.T("Nothing to organize."))
+		b.WriteString("\n")
+		return
+	}
+	from, to := m.visible(len(rows))
+	for i := from; i < to; i++ {
+		row, c := rows[i], m.categories[rows[i].category]
+		var line string
+		if row.file < 0 {
+			mark := "[x]"
+			if c.excluded {
+				mark = "[ ]"
+			}
+			line = fmt.Sprintf("%s %-16s %s", mark, c.name, i18n.Sprintf("%d files, %s", len(c.files), organizer.FormatBytes(c.bytes)))
+		} else {
+			f := c.files[row.file]
+			line = m.truncate(fmt.Sprintf("      %s -> %s", f.Source, f.Dest), 0)
+		}
+		switch {
+		case i == m.cursor[viewCategories]:
+			line = tuiCursor.Render("> " + line)
+		case c.excluded:
+			line = "  " + tuiExcluded.Render(line)
+		default:
+			line = "  " + line
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	b.WriteString(tuiFaint.Render(i18n.Sprintf("%d of %d files selected", m.included(), m.included()+m.excludedFiles())))
+	b.WriteString("\n")
+}
+
+func (m *tuiModel) excludedFiles() int {
+	n := 0
+	for _, c := range m.categories {
+		if c.excluded {
+			n += len(c.files)
+		}
+	}
+	return n
+}
+
+func (m *tuiModel) viewTree(b *strings.Builder) {
+	from, to := m.visible(len(m.tree))
+	for i := from; i < to; i++ {
+		if i == m.cursor[viewTree] {
+			b.WriteString(tuiCursor.Render("> ") + m.tree[i])
+		} else {
+			b.WriteString("  " + m.tree[i])
+		}
+		b.WriteString("\n")
+	}
+}
+
+func (m *tuiModel) viewProgress(b *strings.Builder) {
+	total := max(m.stats.ToProcess, 1)
+	width := max(10, min(50, m.width-20))
+	finished := m.stats.Finished()
+	filled := min(width, finished*width/total)
+	fmt.Fprintf(b, "[%s%s] %d/%d\n\n", strings.Repeat("=", filled), strings.Repeat(" ", width-filled), finished, m.stats.ToProcess)
+
+	if m.phase == tuiRunning {
+		for w := 1; w <= m.poolSize; w++ {
+			e, ok := m.workers[w]
+			file := tuiFaint.Render(i18n.T("idle"))
+			switch {
+			case !ok:
+			case m.copied[w] > 0 && e.Phase == organizer.PhaseStarted:
+				file = e.Source + tuiFaint.Render(" "+i18n.Sprintf("(%s, %s of %s copied)", e.Action, organizer.FormatBytes(m.copied[w]), organizer.FormatBytes(e.Bytes)))
+			default:
+				file = e.Source + tuiFaint.Render(" "+i18n.Sprintf("(%s, %s)", e.Action, e.Phase))
+			}
+			b.WriteString(m.truncate(i18n.Sprintf("worker %d: %s", w, file), 0))
+			b.WriteString("\n")
+		}
+		return
+	}
+
+	s := m.summary
+	b.WriteString(i18n.Sprintf("Processed: %d (%s)", s.Processed, organizer.FormatBytes(s.Bytes)))
+	b.WriteString("\n")
+	b.WriteString(i18n.Sprintf("Skipped:   %d", s.Skipped))
+	b.WriteString("\n")
+	b.WriteString(i18n.Sprintf("Errors:    %d", s.Errors))
+	b.WriteString("\n")
+	b.WriteString(i18n.Sprintf("Took:      %s", time.Duration(s.DurationMS)*time.Millisecond))
+	b.WriteString("\n")
+}
+
+// truncate shortens s to the width of the terminal minus indent, keeping the end of long paths.
+func (m *tuiModel) truncate(s string, indent int) string {
+	limit, runes := m.width-indent-2, []rune(s)
+	if m.width == 0 || len(runes) <= limit || limit < 10 {
+		return s
+	}
+	return "..." + string(runes[len(runes)-limit+3:])
+}
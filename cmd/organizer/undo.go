@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"filippo.io/age"
+	"github.com/avizyt/org-cli/internal/history"
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/avizyt/org-cli/internal/journal"
+	"github.com/avizyt/org-cli/internal/organizer"
+	"github.com/fatih/color"
+)
+
+// runUndo implements `organizer undo`, which reverts the most recent (or a given) run using its
+// journal, and returns the process exit code.
+func runUndo(args []string) int {
+	blue := color.New(color.FgBlue).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	fs := flag.NewFlagSet("undo", flag.ExitOnError)
+	runID := fs.String("run", "", "ID of the run to undo (default: the most recent run that was not undone)")
+	dryRun := fs.Bool("dry-run", false, "Only show what would be restored")
+	verbosity := addVerbosityFlags(fs)
+	identityPath := fs.String("identity", os.Getenv(organizer.IdentityEnv), "age identity file to decrypt files that were encrypted on move (env "+organizer.IdentityEnv+")")
+	addOutputFlags(fs)
+	fs.Parse(args)
+
+	dataDir, err := history.DataDir()
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+	path, err := journal.Find(journal.Dir(dataDir), *runID)
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+	entries, err := journal.Load(path)
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+
+	var identities []age.Identity
+	if *identityPath != "" {
+		if identities, err = organizer.LoadIdentities(*identityPath); err != nil {
+			fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+			return 1
+		}
+	}
+
+	// What is reverted is kept for `organizer redo`
+	var redo *journal.Journal
+	if !*dryRun {
+		if redo, err = journal.Open(journal.RedoDir(dataDir), journal.RunID(path)); err != nil {
+			fmt.Fprintln(os.Stderr, yellow(i18n.Sprintf("%s Could not open the redo journal, this cannot be redone: %v", glyph("⚠️"), err)))
+		}
+		defer redo.Close()
+	}
+
+	org, err := organizer.New(organizer.WithIdentities(identities), organizer.WithDryRun(*dryRun), organizer.WithVerbosity(*verbosity), organizer.WithJournal(redo), organizer.WithPrinter(&terminalPrinter{}))
+	if err != nil {
+		fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+		return 1
+	}
+
+	// Ctrl-C finishes the file being restored and stops; the journal is kept for another try
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	i18n.Printf("%s Undoing run %s (%d operations)...\n", blue(glyph("⏪")), journal.RunID(path), len(entries))
+	undone, failed, err := org.Undo(ctx, entries)
+	if err != nil {
+		i18n.Printf("%s Stopped after restoring %s files; run undo again to continue.\n", yellow(glyph("⚠️")), yellow(fmt.Sprintf("%d", undone)))
+		return 3
+	}
+
+	if *dryRun {
+		i18n.Printf("%s Dry run completed. %s files would have been restored.\n", green(glyph("✅")), green(fmt.Sprintf("%d", undone)))
+		return 0
+	}
+	i18n.Printf("%s Restored %s files.\n", green(glyph("✅")), green(fmt.Sprintf("%d", undone)))
+	if failed > 0 {
+		i18n.Printf("%s %s files could not be restored; the journal is kept at %s.\n", red(glyph("❌")), red(fmt.Sprintf("%d", failed)), path)
+		return 1
+	}
+	if err := journal.MarkUndone(path); err != nil {
+		fmt.Fprintln(os.Stderr, yellow(i18n.Sprintf("%s Could not mark the run as undone: %v", glyph("⚠️"), err)))
+	}
+	if undone > 0 {
+		i18n.Printf("%s Run 'organizer redo --run %s' to do it again.\n", blue(glyph("🔁")), journal.RunID(path))
+	}
+	return 0
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseAge parses an age threshold. On top of Go durations ("36h") it accepts whole days, weeks
+// and years ("90d", "2w", "1y"), which is how people think about file ages.
+func parseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	units := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour, 'y': 365 * 24 * time.Hour}
+	if unit, ok := units[s[len(s)-1]]; ok {
+		n, err := strconv.ParseFloat(s[:len(s)-1], 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age '%s'", s)
+		}
+		return time.Duration(n * float64(unit)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age '%s' (use e.g. 90d, 2w, 1y or 36h)", s)
+	}
+	return d, nil
+}
+
+// parseSize parses a size like "500GB", "1.5 TiB" or "750M". Units are binary (1 GB = 1024 MB),
+// matching how sizes are reported elsewhere; a bare number is bytes.
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+	multiplier := int64(1)
+	if s != "" {
+		if i := strings.IndexByte("KMGTP", s[len(s)-1]); i >= 0 {
+			multiplier = int64(1) << (10 * (i + 1))
+			s = s[:len(s)-1]
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size")
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// parseRate parses a transfer rate like "50MB/s" or "512K"; the "/s" is optional.
+func parseRate(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if lower := strings.ToLower(s); strings.HasSuffix(lower, "/s") {
+		s = s[:len(s)-2]
+	}
+	n, err := parseSize(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate")
+	}
+	return n, nil
+}
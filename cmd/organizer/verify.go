@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/avizyt/org-cli/internal/i18n"
+	"github.com/avizyt/org-cli/internal/organizer"
+	"github.com/fatih/color"
+)
+
+// runVerifyManifest implements `organizer verify-manifest`, which checks the files listed in
+// checksum manifests, and returns the process exit code: 1 if any file is missing or changed.
+func runVerifyManifest(args []string) int {
+	red := color.New(color.FgRed).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	fs := flag.NewFlagSet("verify-manifest", flag.ExitOnError)
+	quiet := fs.Bool("quiet", false, "Only print files that are missing or changed")
+	hashWorkers := fs.Int("hash-workers", 0, "Number of files hashed at once; 0 means one per CPU")
+	addOutputFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), i18n.T("Usage: organizer verify-manifest [--quiet] <destination or manifest>..."))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var manifests []string
+	for _, arg := range fs.Args() {
+		found, err := findManifests(arg)
+		if err != nil {
+			fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+			return 1
+		}
+		if len(found) == 0 {
+			fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: no checksum manifest found in '%s'\n", arg)))
+			return 1
+		}
+		manifests = append(manifests, found...)
+	}
+
+	ok, bad := 0, 0
+	for _, manifest := range manifests {
+		results, err := organizer.VerifyManifest(manifest, *hashWorkers)
+		if err != nil {
+			fmt.Fprint(os.Stderr, red(i18n.Sprintf("Error: %v\n", err)))
+			bad++
+			continue
+		}
+		for _, r := range results {
+			switch {
+			case r.Err == nil:
+				ok++
+				if !*quiet {
+					fmt.Printf("%s: %s\n", r.Path, green(i18n.T("OK")))
+				}
+			case errors.Is(r.Err, organizer.ErrChecksumMismatch):
+				bad++
+				fmt.Printf("%s: %s\n", r.Path, red(i18n.T("FAILED")))
+			case errors.Is(r.Err, os.ErrNotExist):
+				bad++
+				fmt.Printf("%s: %s\n", r.Path, yellow(i18n.T("MISSING")))
+			default:
+				bad++
+				fmt.Printf("%s: %s (%v)\n", r.Path, red(i18n.T("ERROR")), r.Err)
+			}
+		}
+	}
+	if bad > 0 {
+		i18n.Printf("%s %d files verified, %s missing, changed or unreadable.\n", red(glyph("❌")), ok, red(fmt.Sprintf("%d", bad)))
+		return 1
+	}
+	i18n.Printf("%s %d files verified.\n", green(glyph("✅")), ok)
+	return 0
+}
+
+// findManifests returns path if it is a file, or the manifests anywhere below it if it is a
+// directory.
+func findManifests(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var manifests []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && organizer.IsManifest(d.Name()) {
+			manifests = append(manifests, p)
+		}
+		return nil
+	})
+	return manifests, err
+}
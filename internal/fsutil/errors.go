@@ -0,0 +1,22 @@
+package fsutil
+
+// IsBusy reports whether err means that another program holds the file open in a way that keeps
+// it from being moved, deleted or read right now: a sharing or lock violation on Windows (an open
+// document, an antivirus scan, a backup agent), EBUSY or ETXTBSY elsewhere. Such files are worth
+// retrying later rather than treating as failed.
+func IsBusy(err error) bool {
+	return err != nil && isBusy(err)
+}
+
+// IsTransient reports whether err is one of the errors network file systems return while the
+// connection hiccups or the server fails over: ESTALE, EIO and timeouts on NFS and SMB mounts, a
+// dropped or unreachable share on Windows. The operation usually succeeds when tried again.
+func IsTransient(err error) bool {
+	return err != nil && isTransient(err)
+}
+
+// IsCrossDevice reports whether err is a rename failing because old and new path are on different
+// file systems, or on different exports or shares of a network file system. Such moves need a copy.
+func IsCrossDevice(err error) bool {
+	return err != nil && isCrossDevice(err)
+}
@@ -0,0 +1,31 @@
+package fsutil
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+func isBusy(err error) bool {
+	return errors.Is(err, windows.ERROR_SHARING_VIOLATION) || errors.Is(err, windows.ERROR_LOCK_VIOLATION)
+}
+
+func isTransient(err error) bool {
+	for _, errno := range []windows.Errno{
+		windows.ERROR_NETNAME_DELETED,
+		windows.ERROR_UNEXP_NET_ERR,
+		windows.ERROR_NETWORK_BUSY,
+		windows.ERROR_SEM_TIMEOUT,
+		windows.ERROR_BAD_NETPATH,
+		windows.ERROR_NETWORK_UNREACHABLE,
+	} {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCrossDevice(err error) bool {
+	return errors.Is(err, windows.ERROR_NOT_SAME_DEVICE)
+}
@@ -0,0 +1,137 @@
+package fsutil
+
+import (
+	"io/fs"
+	"os"
+	"time"
+)
+
+// Faulty returns fsys with the operations fault picks failing, for testing how code working
+// against an FS copes with errors. fault is called with the name of the operation and each path
+// it touches, the old one first; an error it returns is returned instead of doing the operation,
+// wrapped in an *fs.PathError or *os.LinkError like the os package does. The operations are
+// "stat", "lstat", "open", "create" (OpenFile with O_CREATE), "mkdir", "rename", "link",
+// "symlink", "readlink", "remove", "chtimes" and "walk", which hands the error to the walk
+// function for that entry as if it could not be read.
+func Faulty(fsys FS, fault func(op, path string) error) FS {
+	return faultyFS{FS: fsys, fault: fault}
+}
+
+type faultyFS struct {
+	FS
+	fault func(op, path string) error
+}
+
+// check returns the error fault picks for op on path, if any.
+func (f faultyFS) check(op, path string) error {
+	if err := f.fault(op, path); err != nil {
+		return &fs.PathError{Op: op, Path: path, Err: err}
+	}
+	return nil
+}
+
+// check2 is check for the operations on two paths.
+func (f faultyFS) check2(op, oldpath, newpath string) error {
+	for _, path := range []string{oldpath, newpath} {
+		if err := f.fault(op, path); err != nil {
+			return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: err}
+		}
+	}
+	return nil
+}
+
+func (f faultyFS) Stat(name string) (fs.FileInfo, error) {
+	if err := f.check("stat", name); err != nil {
+		return nil, err
+	}
+	return f.FS.Stat(name)
+}
+
+func (f faultyFS) Lstat(name string) (fs.FileInfo, error) {
+	if err := f.check("lstat", name); err != nil {
+		return nil, err
+	}
+	return f.FS.Lstat(name)
+}
+
+func (f faultyFS) Open(name string) (File, error) {
+	if err := f.check("open", name); err != nil {
+		return nil, err
+	}
+	return f.FS.Open(name)
+}
+
+func (f faultyFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	op := "open"
+	if flag&os.O_CREATE != 0 {
+		op = "create"
+	}
+	if err := f.check(op, name); err != nil {
+		return nil, err
+	}
+	return f.FS.OpenFile(name, flag, perm)
+}
+
+func (f faultyFS) MkdirAll(path string, perm fs.FileMode) error {
+	if err := f.check("mkdir", path); err != nil {
+		return err
+	}
+	return f.FS.MkdirAll(path, perm)
+}
+
+func (f faultyFS) Rename(oldpath, newpath string) error {
+	if err := f.check2("rename", oldpath, newpath); err != nil {
+		return err
+	}
+	return f.FS.Rename(oldpath, newpath)
+}
+
+func (f faultyFS) Link(oldname, newname string) error {
+	if err := f.check2("link", oldname, newname); err != nil {
+		return err
+	}
+	return f.FS.Link(oldname, newname)
+}
+
+func (f faultyFS) Symlink(oldname, newname string) error {
+	if err := f.check("symlink", newname); err != nil {
+		return err
+	}
+	return f.FS.Symlink(oldname, newname)
+}
+
+func (f faultyFS) Readlink(name string) (string, error) {
+	if err := f.check("readlink", name); err != nil {
+		return "", err
+	}
+	return f.FS.Readlink(name)
+}
+
+func (f faultyFS) Remove(name string) error {
+	if err := f.check("remove", name); err != nil {
+		return err
+	}
+	return f.FS.Remove(name)
+}
+
+func (f faultyFS) Chtimes(name string, atime, mtime time.Time) error {
+	if err := f.check("chtimes", name); err != nil {
+		return err
+	}
+	return f.FS.Chtimes(name, atime, mtime)
+}
+
+func (f faultyFS) WalkDir(root string, fn fs.WalkDirFunc) error {
+	return f.FS.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fn(path, d, err)
+		}
+		if err := f.check("walk", path); err != nil {
+			if err := fn(path, d, err); err != nil || d == nil || !d.IsDir() {
+				return err
+			}
+			return fs.SkipDir
+		}
+		return fn(path, d, nil)
+	})
+}
@@ -0,0 +1,86 @@
+// Package fsutil holds small file system helpers shared by the organizer's packages.
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+)
+
+// MoveFile renames src to dst on fsys, falling back to copy and delete when they are on different
+// file systems. The copy keeps the modification time and, on macOS, the extended attributes. A
+// file at dst is never replaced: MoveFile fails with an error matching os.ErrExist instead, see
+// RenameNoReplace.
+func MoveFile(fsys FS, src, dst string) error {
+	if err := RenameNoReplace(fsys, src, dst); err == nil || errors.Is(err, os.ErrExist) {
+		return err
+	}
+
+	in, err := fsys.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("'%s' is not a regular file", src)
+	}
+	out, err := fsys.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fsys.Remove(dst)
+		return err
+	}
+	fsys.Chtimes(dst, info.ModTime(), info.ModTime())
+	CopyXattrs(src, dst)
+	return fsys.Remove(src)
+}
+
+// RenameNoReplace renames oldpath to newpath on fsys like Rename, but fails with an error
+// matching os.ErrExist instead of replacing a file at newpath. It links the file under the new
+// name, which fails atomically if the name is taken, and then removes the old name. File systems
+// without hard links (FAT, some network shares) get a check before a plain rename instead, which
+// leaves a short window for a file appearing at newpath to be replaced.
+func RenameNoReplace(fsys FS, oldpath, newpath string) error {
+	err := fsys.Link(oldpath, newpath)
+	if err == nil {
+		fsys.Remove(oldpath)
+		return nil
+	}
+	if errors.Is(err, os.ErrExist) {
+		return err
+	}
+	if _, err := fsys.Lstat(newpath); err == nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrExist}
+	}
+	return fsys.Rename(oldpath, newpath)
+}
+
+// SyncPath flushes the file or directory at path on fsys to disk. Syncing a directory makes the
+// entries created in or removed from it durable. Windows cannot flush directories; they are
+// skipped there.
+func SyncPath(fsys FS, path string) error {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.IsDir() && runtime.GOOS == "windows" {
+		return nil
+	}
+	return f.Sync()
+}
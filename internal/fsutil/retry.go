@@ -0,0 +1,110 @@
+package fsutil
+
+import (
+	"errors"
+	"io/fs"
+	"time"
+)
+
+// busyDelays are the pauses between the attempts of RetryBusy. Together they outlast the usual
+// on-access scan of a freshly written file, without holding up a worker for long.
+var busyDelays = []time.Duration{250 * time.Millisecond, 500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second}
+
+// networkDelays are the pauses between the attempts of RetryNetwork, long enough to ride out a
+// reconnect or a NAS waking its disks.
+var networkDelays = []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 15 * time.Second}
+
+// RetryBusy returns fsys with operations that fail because the file is busy (see IsBusy) retried
+// a few times over several seconds. The error of the last attempt is returned if the file stays
+// busy.
+func RetryBusy(fsys FS) FS {
+	return retryFS{FS: fsys, retryable: IsBusy, delays: busyDelays}
+}
+
+// RetryNetwork is RetryBusy for network file systems: transient errors (see IsTransient) are
+// retried as well, for half a minute.
+func RetryNetwork(fsys FS) FS {
+	return retryFS{FS: fsys, retryable: func(err error) bool { return IsBusy(err) || IsTransient(err) }, delays: networkDelays}
+}
+
+type retryFS struct {
+	FS
+	retryable func(error) bool
+	delays    []time.Duration
+}
+
+// retry calls op until it succeeds, fails for a reason not worth retrying or the delays run out.
+func retry[T any](r retryFS, op func() (T, error)) (T, error) {
+	v, err := op()
+	for _, d := range r.delays {
+		if !r.retryable(err) {
+			break
+		}
+		time.Sleep(d)
+		v, err = op()
+	}
+	return v, err
+}
+
+// done retries op like retry. An attempt failing with ErrNotExist after a retryable error counts
+// as success if gone reports so: the earlier attempt went through and only its reply was lost.
+func (r retryFS) done(op func() error, gone func() bool) error {
+	err := op()
+	for _, d := range r.delays {
+		if !r.retryable(err) {
+			break
+		}
+		time.Sleep(d)
+		if err = op(); errors.Is(err, fs.ErrNotExist) && gone() {
+			return nil
+		}
+	}
+	return err
+}
+
+func (r retryFS) Stat(name string) (fs.FileInfo, error) {
+	return retry(r, func() (fs.FileInfo, error) { return r.FS.Stat(name) })
+}
+
+func (r retryFS) Lstat(name string) (fs.FileInfo, error) {
+	return retry(r, func() (fs.FileInfo, error) { return r.FS.Lstat(name) })
+}
+
+func (r retryFS) Open(name string) (File, error) {
+	return retry(r, func() (File, error) { return r.FS.Open(name) })
+}
+
+func (r retryFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	return retry(r, func() (File, error) { return r.FS.OpenFile(name, flag, perm) })
+}
+
+func (r retryFS) MkdirAll(path string, perm fs.FileMode) error {
+	_, err := retry(r, func() (struct{}, error) { return struct{}{}, r.FS.MkdirAll(path, perm) })
+	return err
+}
+
+func (r retryFS) Rename(oldpath, newpath string) error {
+	return r.done(func() error { return r.FS.Rename(oldpath, newpath) }, func() bool {
+		_, err := r.FS.Lstat(newpath)
+		return err == nil
+	})
+}
+
+func (r retryFS) Link(oldname, newname string) error {
+	_, err := retry(r, func() (struct{}, error) { return struct{}{}, r.FS.Link(oldname, newname) })
+	return err
+}
+
+func (r retryFS) Symlink(oldname, newname string) error {
+	_, err := retry(r, func() (struct{}, error) { return struct{}{}, r.FS.Symlink(oldname, newname) })
+	return err
+}
+
+func (r retryFS) Remove(name string) error {
+	return r.done(func() error { return r.FS.Remove(name) }, func() bool { return true })
+}
+
+func (r retryFS) Chtimes(name string, atime, mtime time.Time) error {
+	_, err := retry(r, func() (struct{}, error) { return struct{}{}, r.FS.Chtimes(name, atime, mtime) })
+	return err
+}
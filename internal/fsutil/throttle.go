@@ -0,0 +1,108 @@
+package fsutil
+
+import (
+	"io"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// throttleChunk is the most a single read takes from a Limiter at once. Reads are metered in
+// chunks this size so concurrent readers take turns instead of one large read holding the rate.
+const throttleChunk = 64 << 10
+
+// Limiter is a token bucket limiting the combined throughput of everything reading through it.
+// Waiting readers are served in the order they asked, chunk by chunk, so each of them gets an
+// even share of the rate.
+type Limiter struct {
+	mu   sync.Mutex
+	rate float64   // Bytes per second
+	next time.Time // When the bytes handed out so far have been paid for
+}
+
+// NewLimiter returns a Limiter allowing bytesPerSecond bytes per second.
+func NewLimiter(bytesPerSecond int64) *Limiter {
+	return &Limiter{rate: float64(bytesPerSecond)}
+}
+
+// Wait blocks until n more bytes fit into the rate.
+func (l *Limiter) Wait(n int) {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now // Idle time is not saved up for a later burst
+	}
+	l.next = l.next.Add(time.Duration(float64(n) / l.rate * float64(time.Second)))
+	wait := l.next.Sub(now)
+	l.mu.Unlock()
+	time.Sleep(wait)
+}
+
+// Reader returns r with its reads limited by l.
+func (l *Limiter) Reader(r io.Reader) io.Reader {
+	return &throttledReader{r: r, l: l}
+}
+
+type throttledReader struct {
+	r io.Reader
+	l *Limiter
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > throttleChunk {
+		p = p[:throttleChunk]
+	}
+	n, err := t.r.Read(p)
+	t.l.Wait(n)
+	return n, err
+}
+
+// Throttle returns fsys with reads from its files limited by l. Only reads are metered: a copy
+// reads every byte it writes, and the source is usually the disk that needs protecting.
+func Throttle(fsys FS, l *Limiter) FS {
+	return throttledFS{FS: fsys, l: l}
+}
+
+type throttledFS struct {
+	FS
+	l *Limiter
+}
+
+func (t throttledFS) Open(name string) (File, error) {
+	f, err := t.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return throttledFile{File: f, r: t.l.Reader(f), l: t.l}, nil
+}
+
+func (t throttledFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	f, err := t.FS.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return throttledFile{File: f, r: t.l.Reader(f), l: t.l}, nil
+}
+
+// ThrottledReader returns r limited like the reads of fsys, for data that reaches the organizer
+// some other way, such as the entries of a zip archive. Unthrottled file systems return r as is.
+func ThrottledReader(fsys FS, r io.Reader) io.Reader {
+	if t, ok := fsys.(throttledFS); ok {
+		return t.l.Reader(r)
+	}
+	return r
+}
+
+type throttledFile struct {
+	File
+	r io.Reader
+	l *Limiter
+}
+
+func (t throttledFile) Read(p []byte) (int, error) { return t.r.Read(p) }
+
+func (t throttledFile) ReadAt(p []byte, off int64) (int, error) {
+	n, err := t.File.ReadAt(p, off)
+	t.l.Wait(n)
+	return n, err
+}
@@ -0,0 +1,48 @@
+package fsutil
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// CopyXattrs gives dst the extended attributes of src: the quarantine flag, the Finder comment,
+// tags, where a download came from and so on. A rename keeps them; copies need this to behave
+// the same. It is a no-op outside macOS.
+func CopyXattrs(src, dst string) error {
+	size, err := unix.Listxattr(src, nil)
+	if err != nil || size == 0 {
+		return err
+	}
+	list := make([]byte, size)
+	if size, err = unix.Listxattr(src, list); err != nil {
+		return err
+	}
+	var errs []error
+	for _, name := range strings.Split(strings.TrimRight(string(list[:size]), "\x00"), "\x00") {
+		n, err := unix.Getxattr(src, name, nil)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		value := make([]byte, n)
+		if n, err = unix.Getxattr(src, name, value); err == nil {
+			err = unix.Setxattr(dst, name, value[:n], 0)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RemoveQuarantine drops the com.apple.quarantine attribute macOS puts on downloaded files, so
+// Gatekeeper no longer asks before an app or installer is first opened. A file without it is left
+// alone. It is a no-op outside macOS.
+func RemoveQuarantine(path string) error {
+	if err := unix.Removexattr(path, "com.apple.quarantine"); err != nil && !errors.Is(err, unix.ENOATTR) {
+		return err
+	}
+	return nil
+}
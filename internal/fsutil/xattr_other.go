@@ -0,0 +1,9 @@
+//go:build !darwin
+
+package fsutil
+
+// CopyXattrs is only implemented on macOS.
+func CopyXattrs(src, dst string) error { return nil }
+
+// RemoveQuarantine is only implemented on macOS.
+func RemoveQuarantine(path string) error { return nil }
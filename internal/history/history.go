@@ -0,0 +1,153 @@
+// Package history persists run summaries so past runs can be inspected and aggregated later.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/avizyt/org-cli/internal/organizer"
+)
+
+// DataDirEnv overrides the directory history and other state files are kept in.
+const DataDirEnv = "ORG_CLI_DATA_DIR"
+
+const historyFile = "history.jsonl"
+
+// lastRunFile holds the full report of the most recent run, files included, for `organizer last`.
+const lastRunFile = "last-run.json"
+
+// DataDir returns the per-user directory for organizer state, following platform conventions:
+// $XDG_DATA_HOME (or ~/.local/share) on Linux, ~/Library/Application Support on macOS and
+// %LocalAppData% on Windows.
+func DataDir() (string, error) {
+	if dir := os.Getenv(DataDirEnv); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine data directory: %w", err)
+	}
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return filepath.Join(dir, "org-cli"), nil
+		}
+		return filepath.Join(home, "AppData", "Local", "org-cli"), nil
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", "org-cli"), nil
+	default:
+		if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+			return filepath.Join(dir, "org-cli"), nil
+		}
+		return filepath.Join(home, ".local", "share", "org-cli"), nil
+	}
+}
+
+// Append adds a summary to the history file, creating the data directory if needed.
+func Append(summary organizer.Summary) error {
+	dir, err := DataDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create data directory '%s': %w", dir, err)
+	}
+
+	line, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to encode summary: %w", err)
+	}
+
+	path := filepath.Join(dir, historyFile)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file '%s': %w", path, err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history file '%s': %w", path, err)
+	}
+	return nil
+}
+
+// Load returns all recorded summaries, oldest first. A missing history file is not an error.
+// Lines that cannot be decoded (e.g. a truncated last line after a crash) are skipped.
+func Load() ([]organizer.Summary, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, historyFile)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file '%s': %w", path, err)
+	}
+	defer f.Close()
+
+	var summaries []organizer.Summary
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var s organizer.Summary
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			continue
+		}
+		summaries = append(summaries, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file '%s': %w", path, err)
+	}
+	return summaries, nil
+}
+
+// SaveLast replaces the report of the most recent run with report, a JSON document. It is written
+// next to the history and moved into place, so a crash leaves the previous one intact.
+func SaveLast(report []byte) error {
+	dir, err := DataDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create data directory '%s': %w", dir, err)
+	}
+	path := filepath.Join(dir, lastRunFile)
+	tmp, err := os.CreateTemp(dir, lastRunFile+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write '%s': %w", path, err)
+	}
+	_, err = tmp.Write(report)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write '%s': %w", path, err)
+	}
+	return nil
+}
+
+// LoadLast returns the report of the most recent run saved with SaveLast. If no run was recorded
+// yet the error wraps os.ErrNotExist.
+func LoadLast() ([]byte, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, lastRunFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+	return data, nil
+}
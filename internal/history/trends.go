@@ -0,0 +1,107 @@
+package history
+
+import (
+	"sort"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/organizer"
+)
+
+// CategoryCount is the number of files organized into a category.
+type CategoryCount struct {
+	Category string `json:"category"`
+	Files    int    `json:"files"`
+}
+
+// WeekStats aggregates the runs that started within one week (Monday to Sunday, local time).
+type WeekStats struct {
+	WeekStart     time.Time       `json:"week_start"`
+	Runs          int             `json:"runs"`
+	FailedRuns    int             `json:"failed_runs"`
+	Files         int             `json:"files"`
+	Bytes         int64           `json:"bytes"`
+	Errors        int             `json:"errors"`
+	ErrorRate     float64         `json:"error_rate"`               // Errors relative to all attempted files
+	FilesChange   *float64        `json:"files_change,omitempty"`   // Relative change in Files against the previous week
+	TopCategories []CategoryCount `json:"top_categories,omitempty"` // Most used categories, largest first
+}
+
+// topCategoriesPerWeek limits how many categories are reported for each week.
+const topCategoriesPerWeek = 3
+
+// WeeklyTrends groups the non dry-run summaries into the last `weeks` calendar weeks ending with
+// the week containing now. Weeks without runs are included so gaps stay visible.
+func WeeklyTrends(summaries []organizer.Summary, weeks int, now time.Time) []WeekStats {
+	if weeks <= 0 {
+		return nil
+	}
+	current := startOfWeek(now)
+	first := current.AddDate(0, 0, -7*(weeks-1))
+
+	stats := make([]WeekStats, weeks)
+	categories := make([]map[string]int, weeks)
+	for i := range stats {
+		stats[i].WeekStart = first.AddDate(0, 0, 7*i)
+		categories[i] = make(map[string]int)
+	}
+
+	for _, s := range summaries {
+		if s.DryRun {
+			continue
+		}
+		start := startOfWeek(s.StartedAt.In(now.Location()))
+		if start.Before(first) || start.After(current) {
+			continue
+		}
+		i := int(start.Sub(first).Hours()/24+0.5) / 7
+		w := &stats[i]
+		w.Runs++
+		if s.Status == organizer.StatusFailed {
+			w.FailedRuns++
+		}
+		w.Files += s.Processed
+		w.Bytes += s.Bytes
+		w.Errors += s.Errors
+		for category, n := range s.Categories {
+			categories[i][category] += n
+		}
+	}
+
+	for i := range stats {
+		w := &stats[i]
+		if attempted := w.Files + w.Errors; attempted > 0 {
+			w.ErrorRate = float64(w.Errors) / float64(attempted)
+		}
+		if i > 0 && stats[i-1].Files > 0 {
+			change := float64(w.Files-stats[i-1].Files) / float64(stats[i-1].Files)
+			w.FilesChange = &change
+		}
+		w.TopCategories = topCategories(categories[i], topCategoriesPerWeek)
+	}
+	return stats
+}
+
+// startOfWeek returns midnight of the Monday of t's week, in t's location.
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7 // Monday = 0
+	y, m, d := t.Date()
+	return time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+}
+
+// topCategories returns the n categories with the most files, ties broken by name.
+func topCategories(counts map[string]int, n int) []CategoryCount {
+	list := make([]CategoryCount, 0, len(counts))
+	for category, files := range counts {
+		list = append(list, CategoryCount{Category: category, Files: files})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Files != list[j].Files {
+			return list[i].Files > list[j].Files
+		}
+		return list[i].Category < list[j].Category
+	})
+	if len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
@@ -0,0 +1,149 @@
+// Package i18n translates the messages the CLI prints. Messages are identified by their English
+// format string, so untranslated messages (and languages without a catalog) fall back to English
+// and code keeps reading like plain fmt calls:
+//
+//	i18n.Printf("%s Restored %s files.\n", icon, count)
+//
+// A translation is a catalog mapping English format strings to translated ones, added with
+// Register from an init function in a file named after the language (de.go, pt_br.go, ...).
+// Translations must keep the verbs of the English string in the same order. Error messages of the
+// engine are not translated; they end up in logs and reports as well.
+package i18n
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Default is the language of the messages in the source code.
+const Default = "en"
+
+var (
+	mu       sync.RWMutex
+	catalogs = map[string]map[string]string{Default: {}}
+	selected string // Matched catalog; empty until the first message or SetLanguage
+)
+
+// Register adds the translations of lang, a language tag such as "de" or "pt-BR". Registering a
+// language again adds to its catalog.
+func Register(lang string, messages map[string]string) {
+	mu.Lock()
+	defer mu.Unlock()
+	lang = normalize(lang)
+	if catalogs[lang] == nil {
+		catalogs[lang] = map[string]string{}
+	}
+	for msg, translation := range messages {
+		catalogs[lang][msg] = translation
+	}
+}
+
+// Languages returns the languages with a catalog, sorted.
+func Languages() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// SetLanguage selects the language messages are printed in. An empty lang selects the language of
+// the environment (see Detect), falling back to English. An explicitly requested language without
+// a catalog is an error.
+func SetLanguage(lang string) error {
+	if lang == "" {
+		lang = Detect()
+	} else if _, ok := lookupCatalog(lang); !ok {
+		return fmt.Errorf("unsupported language '%s' (available: %s)", lang, strings.Join(Languages(), ", "))
+	}
+	matched := match(lang)
+	mu.Lock()
+	selected = matched
+	mu.Unlock()
+	return nil
+}
+
+// Detect returns the language of the environment from LC_ALL, LC_MESSAGES or LANG, the first that
+// is set, or Default.
+func Detect() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return Default
+}
+
+// normalize turns a locale such as "pt_BR.UTF-8@euro" into a lower-case tag like "pt-br".
+func normalize(lang string) string {
+	if i := strings.IndexAny(lang, ".@"); i >= 0 {
+		lang = lang[:i]
+	}
+	return strings.ToLower(strings.ReplaceAll(lang, "_", "-"))
+}
+
+// lookupCatalog returns the catalog matching lang or its base language ("pt" for "pt-BR").
+func lookupCatalog(lang string) (string, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	lang = normalize(lang)
+	if _, ok := catalogs[lang]; ok {
+		return lang, true
+	}
+	base, _, _ := strings.Cut(lang, "-")
+	if _, ok := catalogs[base]; ok {
+		return base, true
+	}
+	return "", false
+}
+
+// match returns the catalog to use for lang; "C", "POSIX" and unknown languages get Default.
+func match(lang string) string {
+	if matched, ok := lookupCatalog(lang); ok {
+		return matched
+	}
+	return Default
+}
+
+// T returns the translation of msg in the selected language, or msg itself.
+func T(msg string) string {
+	mu.RLock()
+	lang := selected
+	mu.RUnlock()
+	if lang == "" {
+		// Catalogs register themselves in init functions, so the environment is only matched
+		// against them once the first message is printed
+		lang = match(Detect())
+		mu.Lock()
+		selected = lang
+		mu.Unlock()
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	if translation, ok := catalogs[lang][msg]; ok {
+		return translation
+	}
+	return msg
+}
+
+// Sprintf formats the translation of format.
+func Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// Printf prints the translation of format to stdout.
+func Printf(format string, args ...any) {
+	fmt.Print(Sprintf(format, args...))
+}
+
+// Fprintf prints the translation of format to w.
+func Fprintf(w io.Writer, format string, args ...any) {
+	fmt.Fprint(w, Sprintf(format, args...))
+}
@@ -0,0 +1,269 @@
+// Package journal records the file operations of a run so they can be undone later.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Operations recorded in a journal.
+const (
+	OpMove     = "move"     // Source was renamed to Dest
+	OpCopy     = "copy"     // Source was copied to Dest and left in place
+	OpLink     = "link"     // Dest was created as a hard link to Source, which was left in place
+	OpCompress = "compress" // Source was compressed into Dest (Codec) and removed
+	OpEncrypt  = "encrypt"  // Source was encrypted with age into Dest, compressed first if Codec is set, and removed
+	OpTrash    = "trash"    // Source was moved to the trash at Dest
+	OpDelete   = "delete"   // Source was deleted permanently; cannot be undone
+)
+
+// Entry is a single completed file operation.
+type Entry struct {
+	Op     string      `json:"op"`
+	Source string      `json:"source"`
+	Dest   string      `json:"dest"`
+	Codec  string      `json:"codec,omitempty"` // Compression codec for OpCompress and OpEncrypt
+	Link   bool        `json:"link,omitempty"`  // For OpMove: a symlink to Dest was left at Source
+	Size   int64       `json:"size,omitempty"`  // Size of the original file
+	Mode   os.FileMode `json:"mode,omitempty"`  // Permissions of the original file for OpCompress and OpEncrypt, restored on undo
+	Hash   string      `json:"hash,omitempty"`  // Checksum of the content as "algorithm:hex", if the run computed one
+	Time   time.Time   `json:"time"`
+}
+
+// Journal is an append-only JSON lines file of entries for one run. Record is safe for
+// concurrent use by the workers, and all methods are no-ops on a nil Journal so callers don't
+// need to check whether journaling is enabled.
+type Journal struct {
+	mu      sync.Mutex
+	f       *os.File
+	path    string
+	marker  string // Running marker of a journal made by Create, removed by Close
+	entries int
+	now     func() time.Time // Time of the entries; nil means time.Now
+}
+
+// undoneSuffix marks journals whose run has been undone.
+const undoneSuffix = ".undone.jsonl"
+
+// runningSuffix names the marker next to the journal of a run that is still going, holding the
+// ID of its process. A marker whose process is gone was left by a run that crashed.
+const runningSuffix = ".running"
+
+// Dir returns the directory journals are kept in below the organizer's data directory.
+func Dir(dataDir string) string {
+	return filepath.Join(dataDir, "journals")
+}
+
+// RedoDir returns the directory below the organizer's data directory where undo keeps the
+// operations it reverted, one journal per run, for redo.
+func RedoDir(dataDir string) string {
+	return filepath.Join(Dir(dataDir), "redo")
+}
+
+// Create starts a new journal for runID in dir.
+func Create(dir, runID string) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory '%s': %w", dir, err)
+	}
+	path := filepath.Join(dir, runID+".jsonl")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create journal '%s': %w", path, err)
+	}
+	marker := filepath.Join(dir, runID+runningSuffix)
+	if err := os.WriteFile(marker, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to create journal marker '%s': %w", marker, err)
+	}
+	return &Journal{f: f, path: path, marker: marker}, nil
+}
+
+// Open opens the journal for runID in dir to add entries to it, creating it if needed.
+func Open(dir, runID string) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory '%s': %w", dir, err)
+	}
+	path := filepath.Join(dir, runID+".jsonl")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal '%s': %w", path, err)
+	}
+	return &Journal{f: f, path: path}, nil
+}
+
+// Path returns the journal file location, or "" for a nil journal or one that was discarded
+// by Close because nothing was recorded.
+func (j *Journal) Path() string {
+	if j == nil {
+		return ""
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.path
+}
+
+// SetClock makes Record take the time of entries without one from now instead of time.Now.
+func (j *Journal) SetClock(now func() time.Time) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	j.now = now
+	j.mu.Unlock()
+}
+
+// Record appends e to the journal. Each entry is written through immediately, so a crash
+// mid-run still leaves an accurate record of what has been done.
+func (j *Journal) Record(e Entry) error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+		if j.now != nil {
+			e.Time = j.now()
+		}
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := j.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	j.entries++
+	return nil
+}
+
+// Close closes the journal file and marks the run as finished. A journal without entries is
+// removed, so runs that didn't touch anything don't show up as undoable; one reopened with Open
+// is kept if it had entries before.
+func (j *Journal) Close() error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	err := j.f.Close()
+	if info, statErr := os.Stat(j.path); j.entries == 0 && statErr == nil && info.Size() == 0 {
+		os.Remove(j.path)
+		j.path = ""
+	}
+	if j.marker != "" {
+		os.Remove(j.marker)
+		j.marker = ""
+	}
+	return err
+}
+
+// Crashed returns the journals in dir of runs that never finished because their process is gone,
+// and whether any other run is still going.
+func Crashed(dir string) (paths []string, running bool, err error) {
+	markers, err := filepath.Glob(filepath.Join(dir, "*"+runningSuffix))
+	if err != nil {
+		return nil, false, err
+	}
+	sort.Strings(markers)
+	for _, marker := range markers {
+		data, err := os.ReadFile(marker)
+		if err != nil {
+			continue // Removed by a run that just finished
+		}
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && processRunning(pid) {
+			running = true
+			continue
+		}
+		paths = append(paths, strings.TrimSuffix(marker, runningSuffix)+".jsonl")
+	}
+	return paths, running, nil
+}
+
+// Recovered marks the crashed run whose journal is at path as dealt with. Its journal is removed
+// if the run crashed before recording anything.
+func Recovered(path string) error {
+	if info, err := os.Stat(path); err == nil && info.Size() == 0 {
+		os.Remove(path)
+	}
+	err := os.Remove(strings.TrimSuffix(path, ".jsonl") + runningSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Load reads all entries of the journal at path, in the order they were recorded.
+func Load(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal '%s': %w", path, err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue // Truncated last line after a crash
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal '%s': %w", path, err)
+	}
+	return entries, nil
+}
+
+// ErrNoRuns is returned by Find when dir holds no journal to pick.
+var ErrNoRuns = errors.New("no runs to undo")
+
+// Find returns the journal path for runID, or for the most recent run that has not been undone
+// yet when runID is empty. Run IDs start with a timestamp, so they sort chronologically.
+func Find(dir, runID string) (string, error) {
+	if runID != "" {
+		path := filepath.Join(dir, runID+".jsonl")
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("no journal for run '%s': %w", runID, err)
+		}
+		return path, nil
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return "", err
+	}
+	var candidates []string
+	for _, m := range matches {
+		if !strings.HasSuffix(m, undoneSuffix) {
+			candidates = append(candidates, m)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", ErrNoRuns
+	}
+	sort.Strings(candidates)
+	return candidates[len(candidates)-1], nil
+}
+
+// MarkUndone renames the journal at path so it is no longer picked up as the latest run.
+func MarkUndone(path string) error {
+	return os.Rename(path, strings.TrimSuffix(path, ".jsonl")+undoneSuffix)
+}
+
+// RunID returns the run ID a journal path belongs to.
+func RunID(path string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), undoneSuffix), ".jsonl")
+}
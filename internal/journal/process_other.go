@@ -0,0 +1,16 @@
+//go:build !unix
+
+package journal
+
+import "os"
+
+// processRunning reports whether a process with the given ID exists. Finding a process fails on
+// Windows when there is none; elsewhere it is taken to be running.
+func processRunning(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/organizer"
+)
+
+// maxChatErrors is how many failed files a chat message lists.
+const maxChatErrors = 5
+
+// headline is the first line of the chat message of summary: the outcome at a glance.
+func headline(s organizer.Summary) string {
+	mark := "✅"
+	switch s.Status {
+	case organizer.StatusPartial:
+		mark = "⚠️"
+	case organizer.StatusFailed, organizer.StatusAborted:
+		mark = "❌"
+	}
+	verb := "organized"
+	if s.DryRun {
+		verb = "would be organized"
+	}
+	line := fmt.Sprintf("%s org-cli %s: %d files %s from %s", mark, s.Status, s.Processed, verb, s.SourceDir)
+	if s.Host != "" {
+		line += " on " + s.Host
+	}
+	return line
+}
+
+// chatBody is the rest of the chat message of summary: the counts, the top categories and the
+// first files that failed.
+func chatBody(s organizer.Summary) string {
+	var b strings.Builder
+	if s.Error != "" {
+		fmt.Fprintf(&b, "Error: %s\n", s.Error)
+	}
+	fmt.Fprintf(&b, "Processed %d (%s), skipped %d, errors %d in %s\n", s.Processed, organizer.FormatBytes(s.Bytes),
+		s.Skipped, s.Errors, time.Duration(s.DurationMS)*time.Millisecond)
+	if len(s.Categories) > 0 {
+		categories := slices.SortedFunc(maps.Keys(s.Categories), func(a, b string) int {
+			return cmp.Or(cmp.Compare(s.Categories[b], s.Categories[a]), strings.Compare(a, b))
+		})
+		top := make([]string, 0, topCategories)
+		for _, c := range categories[:min(len(categories), topCategories)] {
+			top = append(top, fmt.Sprintf("%s %d", c, s.Categories[c]))
+		}
+		fmt.Fprintf(&b, "Top categories: %s\n", strings.Join(top, ", "))
+	}
+
+	var failed []organizer.FileResult
+	for _, f := range s.Files {
+		if f.Action == organizer.ActionFail || f.Action == organizer.ActionDenied {
+			failed = append(failed, f)
+		}
+	}
+	if len(failed) > 0 {
+		fmt.Fprintf(&b, "Failed:\n")
+		for _, f := range failed[:min(len(failed), maxChatErrors)] {
+			fmt.Fprintf(&b, "• %s: %v\n", f.Source, f.Err)
+		}
+		if len(failed) > maxChatErrors {
+			fmt.Fprintf(&b, "… and %d more\n", len(failed)-maxChatErrors)
+		}
+	}
+	if s.RunID != "" {
+		fmt.Fprintf(&b, "Run %s, undo with: organizer undo --run %s\n", s.RunID, s.RunID)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// truncate shortens s to at most n characters, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/organizer"
+)
+
+// Colors of the Discord embed per run status.
+const (
+	discordGreen  = 0x2ecc71
+	discordOrange = 0xe67e22
+	discordRed    = 0xe74c3c
+)
+
+// Discord posts the run summary as a message to a Discord channel through one of its webhooks
+// (Server Settings > Integrations > Webhooks), colored by the outcome of the run.
+type Discord struct {
+	URL     string        // Webhook URL, https://discord.com/api/webhooks/<id>/<token>
+	Timeout time.Duration // Per-attempt timeout
+	Retries int           // Additional attempts after the first one fails
+	Backoff time.Duration // Delay before the first retry, doubled on every further retry
+}
+
+// NewDiscord returns a Discord for the webhook url with the default timeout and retry policy.
+func NewDiscord(url string) *Discord {
+	return &Discord{
+		URL:     url,
+		Timeout: DefaultTimeout,
+		Retries: DefaultRetries,
+		Backoff: time.Second,
+	}
+}
+
+// discordMessage is the part of the Discord webhook payload Discord uses.
+type discordMessage struct {
+	Username string         `json:"username"`
+	Embeds   []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Color       int    `json:"color"`
+	Timestamp   string `json:"timestamp,omitempty"`
+}
+
+// Send posts the summary, retrying on network errors, 429 and 5xx responses. The webhook URL is
+// left out of errors since it holds the token of the webhook.
+func (d *Discord) Send(summary organizer.Summary) error {
+	color := discordGreen
+	switch summary.Status {
+	case organizer.StatusPartial:
+		color = discordOrange
+	case organizer.StatusFailed, organizer.StatusAborted:
+		color = discordRed
+	}
+	embed := discordEmbed{
+		Title:       truncate(headline(summary), 256),
+		Description: truncate(chatBody(summary), 4096),
+		Color:       color,
+	}
+	if !summary.FinishedAt.IsZero() {
+		embed.Timestamp = summary.FinishedAt.Format(time.RFC3339)
+	}
+	body, err := json.Marshal(discordMessage{Username: "org-cli", Embeds: []discordEmbed{embed}})
+	if err != nil {
+		return fmt.Errorf("failed to encode summary: %w", err)
+	}
+
+	client := &http.Client{Timeout: d.Timeout}
+	err = retry(d.Retries, d.Backoff, func() (bool, error) { return postJSON(client, d.URL, body) })
+	if err != nil {
+		return fmt.Errorf("discord webhook failed: %w", redactURL(err))
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package notify
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/organizer"
+)
+
+// Defaults of an Email.
+const (
+	DefaultSMTPPort = 587 // Submission with STARTTLS; 465 is TLS from the start
+	maxEmailErrors  = 20  // Failed files listed in the body, all of them are in the attachment
+	topCategories   = 5   // Categories listed in the body
+)
+
+// Email sends the run summary as a plain text digest over SMTP: the counts, the top categories
+// and the files that failed, with the outcome of every file attached as CSV.
+type Email struct {
+	Host     string   // SMTP server
+	Port     int      // DefaultSMTPPort if 0
+	Username string   // Empty to send without authentication
+	Password string   // Used with Username; see password_env in the config
+	From     string   // Sender, "Organizer <organizer@example.com>" or a plain address
+	To       []string // Recipients
+
+	Timeout time.Duration // Per-attempt timeout
+	Retries int           // Additional attempts after the first one fails
+	Backoff time.Duration // Delay before the first retry, doubled on every further retry
+}
+
+// NewEmail returns an Email through host from from to to, with the default port, timeout and
+// retry policy.
+func NewEmail(host, from string, to []string) *Email {
+	return &Email{
+		Host:    host,
+		From:    from,
+		To:      to,
+		Timeout: DefaultTimeout,
+		Retries: DefaultRetries,
+		Backoff: time.Second,
+	}
+}
+
+// Send mails the digest of summary, retrying unless the server rejects it for good (a 5xx reply,
+// like failed authentication or an unknown recipient).
+func (e *Email) Send(summary organizer.Summary) error {
+	msg, err := e.message(summary)
+	if err != nil {
+		return fmt.Errorf("failed to compose summary email: %w", err)
+	}
+	err = retry(e.Retries, e.Backoff, func() (bool, error) {
+		err := e.deliver(msg)
+		var reply *textproto.Error
+		return !errors.As(err, &reply) || reply.Code < 500, err
+	})
+	if err != nil {
+		return fmt.Errorf("email to %s failed: %w", strings.Join(e.To, ", "), err)
+	}
+	return nil
+}
+
+// deliver makes one attempt at handing msg to the SMTP server, upgrading the connection to TLS
+// when the server offers it.
+func (e *Email) deliver(msg []byte) error {
+	from, err := mail.ParseAddress(e.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender '%s': %w", e.From, err)
+	}
+	port := cmp.Or(e.Port, DefaultSMTPPort)
+	addr := net.JoinHostPort(e.Host, strconv.Itoa(port))
+	dialer := &net.Dialer{Timeout: e.Timeout}
+	tlsConfig := &tls.Config{ServerName: e.Host}
+	var conn net.Conn
+	if port == 465 {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if e.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(e.Timeout))
+	}
+	c, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok && port != 465 {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if e.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.Username, e.Password, e.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range e.To {
+		rcpt, err := mail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("invalid recipient '%s': %w", to, err)
+		}
+		if err := c.Rcpt(rcpt.Address); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message composes the email of summary: the digest as text, the files as a CSV attachment.
+func (e *Email) message(summary organizer.Summary) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	subject := fmt.Sprintf("org-cli: %s, %d files organized", summary.Status, summary.Processed)
+	if summary.DryRun {
+		subject = fmt.Sprintf("org-cli: %s, %d files would be organized", summary.Status, summary.Processed)
+	}
+	if summary.Host != "" {
+		subject += " on " + summary.Host
+	}
+	date := cmp.Or(summary.FinishedAt, time.Now())
+	fmt.Fprintf(&buf, "From: %s\r\n", e.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+
+	text, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	qp := quotedprintable.NewWriter(text)
+	writeDigest(qp, summary)
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+
+	name := "org-cli-" + cmp.Or(summary.RunID, "run") + ".csv"
+	attachment, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType("text/csv", map[string]string{"charset": "utf-8", "name": name})},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": name})},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var files bytes.Buffer
+	writeFilesCSV(&files, summary.Files)
+	encoded := base64.StdEncoding.EncodeToString(files.Bytes())
+	for len(encoded) > 76 {
+		io.WriteString(attachment, encoded[:76]+"\r\n")
+		encoded = encoded[76:]
+	}
+	io.WriteString(attachment, encoded+"\r\n")
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeDigest writes the text of the summary email.
+func writeDigest(w io.Writer, s organizer.Summary) {
+	fmt.Fprintf(w, "Run %s: %s\n\n", s.RunID, s.Status)
+	fmt.Fprintf(w, "Source:       %s\n", s.SourceDir)
+	fmt.Fprintf(w, "Destination:  %s\n", s.DestDir)
+	fmt.Fprintf(w, "Started:      %s (took %s)\n", s.StartedAt.Format(time.DateTime), time.Duration(s.DurationMS)*time.Millisecond)
+	if s.Journal != "" {
+		fmt.Fprintf(w, "Journal:      %s\n", s.Journal)
+	}
+	if s.DryRun {
+		fmt.Fprintf(w, "Dry run, nothing was moved.\n")
+	}
+	if s.Error != "" {
+		fmt.Fprintf(w, "\nError: %s\n", s.Error)
+	}
+	fmt.Fprintf(w, "\nScanned:      %d\n", s.Scanned)
+	fmt.Fprintf(w, "Processed:    %d (%s)\n", s.Processed, organizer.FormatBytes(s.Bytes))
+	fmt.Fprintf(w, "Skipped:      %d\n", s.Skipped)
+	fmt.Fprintf(w, "Errors:       %d\n", s.Errors)
+
+	if len(s.Categories) > 0 {
+		categories := slices.SortedFunc(maps.Keys(s.Categories), func(a, b string) int {
+			return cmp.Or(cmp.Compare(s.Categories[b], s.Categories[a]), strings.Compare(a, b))
+		})
+		fmt.Fprintf(w, "\nTop categories:\n")
+		for _, c := range categories[:min(len(categories), topCategories)] {
+			fmt.Fprintf(w, "  %-20s %d\n", c, s.Categories[c])
+		}
+	}
+
+	var failed []organizer.FileResult
+	for _, f := range s.Files {
+		if f.Action == organizer.ActionFail || f.Action == organizer.ActionDenied {
+			failed = append(failed, f)
+		}
+	}
+	if len(failed) > 0 {
+		fmt.Fprintf(w, "\nFailed:\n")
+		for _, f := range failed[:min(len(failed), maxEmailErrors)] {
+			fmt.Fprintf(w, "  %s: %v\n", f.Source, f.Err)
+		}
+		if len(failed) > maxEmailErrors {
+			fmt.Fprintf(w, "  ... and %d more, see the attachment\n", len(failed)-maxEmailErrors)
+		}
+	}
+}
+
+// writeFilesCSV writes the outcome of every file as CSV: source, destination, category, action,
+// size and error.
+func writeFilesCSV(w io.Writer, files []organizer.FileResult) {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"source", "dest", "category", "action", "size", "error"})
+	for _, f := range files {
+		reason := ""
+		if f.Err != nil {
+			reason = f.Err.Error()
+		}
+		cw.Write([]string{f.Source, f.Dest, f.Category, string(f.Action), strconv.FormatInt(f.Size, 10), reason})
+	}
+	cw.Flush()
+}
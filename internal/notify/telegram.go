@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/organizer"
+)
+
+// Telegram settings.
+const (
+	TelegramTokenEnv   = "ORG_CLI_TELEGRAM_TOKEN" // Environment variable holding the bot token
+	DefaultTelegramAPI = "https://api.telegram.org"
+)
+
+// Telegram sends the run summary as a message from a Telegram bot (created with @BotFather) to
+// a chat, group or channel the bot is a member of.
+type Telegram struct {
+	Token  string // Bot token, like 123456:ABC-DEF...
+	ChatID string // Numeric chat ID, or @channelname for public channels
+	API    string // Bot API server, DefaultTelegramAPI unless self-hosted
+
+	Timeout time.Duration // Per-attempt timeout
+	Retries int           // Additional attempts after the first one fails
+	Backoff time.Duration // Delay before the first retry, doubled on every further retry
+}
+
+// NewTelegram returns a Telegram for the bot token and chat with the default API server, timeout
+// and retry policy.
+func NewTelegram(token, chatID string) *Telegram {
+	return &Telegram{
+		Token:   token,
+		ChatID:  chatID,
+		API:     DefaultTelegramAPI,
+		Timeout: DefaultTimeout,
+		Retries: DefaultRetries,
+		Backoff: time.Second,
+	}
+}
+
+// Send sends the summary, retrying on network errors, 429 and 5xx responses. The bot token is left
+// out of errors.
+func (t *Telegram) Send(summary organizer.Summary) error {
+	text := truncate(headline(summary)+"\n\n"+chatBody(summary), 4096)
+	body, err := json.Marshal(map[string]any{
+		"chat_id":                  t.ChatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode summary: %w", err)
+	}
+
+	endpoint := strings.TrimSuffix(t.API, "/") + "/bot" + t.Token + "/sendMessage"
+	client := &http.Client{Timeout: t.Timeout}
+	err = retry(t.Retries, t.Backoff, func() (bool, error) { return postJSON(client, endpoint, body) })
+	if err != nil {
+		return fmt.Errorf("telegram message to %s failed: %w", t.ChatID, redactURL(err))
+	}
+	return nil
+}
+
+// redactURL strips the request URL from err, for endpoints whose URL holds a secret.
+func redactURL(err error) error {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return uerr.Err
+	}
+	return err
+}
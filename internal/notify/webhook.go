@@ -0,0 +1,103 @@
+// Package notify delivers run summaries to external services once the organizer finishes.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/organizer"
+)
+
+// Defaults used when a Webhook is created without explicit settings.
+const (
+	DefaultTimeout = 10 * time.Second
+	DefaultRetries = 3
+)
+
+// Webhook POSTs the JSON encoded run summary to a URL (ntfy, Home Assistant, Slack-compatible
+// endpoints, or anything else that accepts a JSON body).
+type Webhook struct {
+	URL     string        // Endpoint to POST to
+	Timeout time.Duration // Per-attempt timeout
+	Retries int           // Additional attempts after the first one fails
+	Backoff time.Duration // Delay before the first retry, doubled on every further retry
+}
+
+// NewWebhook returns a Webhook for url with the default timeout and retry policy.
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		URL:     url,
+		Timeout: DefaultTimeout,
+		Retries: DefaultRetries,
+		Backoff: time.Second,
+	}
+}
+
+// Send delivers the summary, retrying on network errors, 429 and 5xx responses.
+// Client errors (4xx) are not retried since repeating the same request will not help.
+func (w *Webhook) Send(summary organizer.Summary) error {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to encode summary: %w", err)
+	}
+
+	client := &http.Client{Timeout: w.Timeout}
+	err = retry(w.Retries, w.Backoff, func() (bool, error) { return postJSON(client, w.URL, body) })
+	if err != nil {
+		return fmt.Errorf("webhook '%s' failed: %w", w.URL, err)
+	}
+	return nil
+}
+
+// retry calls attempt until it succeeds, it reports that a failure is not worth retrying, or
+// retries additional attempts failed, waiting backoff before the first retry and twice as long
+// before every further one. It returns the error of the last attempt.
+func retry(retries int, backoff time.Duration, attempt func() (retry bool, err error)) error {
+	var lastErr error
+	for i := 0; i <= retries; i++ {
+		if i > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		again, err := attempt()
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !again {
+			break
+		}
+	}
+	return lastErr
+}
+
+// postJSON performs a single delivery attempt of the JSON body to url and reports whether a
+// failure is worth retrying.
+func postJSON(client *http.Client, url string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "org-cli")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // Drain so the connection can be reused
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("server responded with %s", resp.Status)
+	default:
+		return false, fmt.Errorf("server responded with %s", resp.Status)
+	}
+}
@@ -0,0 +1,93 @@
+package organizer
+
+import (
+	"sync"
+	"time"
+)
+
+// adaptiveWorkers limits how many workers process a file at once when the worker count is picked
+// automatically. It follows the time files take per byte: when it climbs well above the best seen
+// so far, the storage is struggling (another job, a disk spinning up, a congested share) and a
+// worker is parked; once it recovers, a worker is let back in, up to the initial count.
+type adaptiveWorkers struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	p       logger
+	max     int
+	limit   int     // Workers allowed to process a file at once
+	active  int     // Workers processing a file right now
+	cost    float64 // Moving average of seconds per byte
+	best    float64 // Lowest average seen after the warm-up
+	samples int
+	settle  int // Samples to wait after a change before judging it
+}
+
+const (
+	adaptiveWarmup   = 8        // Samples before the average is trusted
+	adaptiveMinBytes = 64 << 10 // Small files are counted as this big: their cost is mostly overhead
+	adaptiveSpike    = 3.0      // Average over best that parks a worker
+	adaptiveRecover  = 1.5      // Average over best below which a worker is let back in
+	adaptiveSmooth   = 0.2      // Weight of a new sample in the moving average
+
+	// Below this per file (of adaptiveMinBytes), latency is noise from the page cache and scheduler
+	adaptiveFloor = 10 * time.Millisecond
+)
+
+func newAdaptiveWorkers(n int, p logger) *adaptiveWorkers {
+	a := &adaptiveWorkers{p: p, max: n, limit: n}
+	a.cond = sync.NewCond(&a.mu)
+	return a
+}
+
+// acquire blocks until the worker may process a file. A nil *adaptiveWorkers never blocks.
+func (a *adaptiveWorkers) acquire() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	for a.active >= a.limit {
+		a.cond.Wait()
+	}
+	a.active++
+	a.mu.Unlock()
+}
+
+// release records that processing size bytes took d and adjusts the limit.
+func (a *adaptiveWorkers) release(size int64, d time.Duration) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	defer a.cond.Broadcast()
+	a.active--
+
+	cost := d.Seconds() / float64(max(size, adaptiveMinBytes))
+	if a.samples == 0 {
+		a.cost = cost
+	} else {
+		a.cost = (1-adaptiveSmooth)*a.cost + adaptiveSmooth*cost
+	}
+	a.samples++
+	if a.samples < adaptiveWarmup {
+		return
+	}
+	if a.best == 0 || a.cost < a.best {
+		a.best = a.cost
+	}
+	if a.settle > 0 {
+		a.settle--
+		return
+	}
+	switch {
+	case a.cost > adaptiveSpike*a.best && a.cost*adaptiveMinBytes > adaptiveFloor.Seconds() && a.limit > 1:
+		a.limit--
+		a.p.Debug("Latency is up %.1fx, down to %d workers", a.cost/a.best, a.limit)
+	case a.cost < adaptiveRecover*a.best && a.limit < a.max:
+		a.limit++
+		a.p.Debug("Latency recovered, up to %d workers", a.limit)
+	default:
+		return
+	}
+	a.settle = a.max
+}
@@ -0,0 +1,357 @@
+package organizer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Formats supported for period archives.
+const (
+	ArchiveFormatZip    = "zip"
+	ArchiveFormatTarZst = "tar.zst"
+)
+
+// ArchivalCategory is the destination folder period archives are written to, and the category
+// archived files are reported under.
+const ArchivalCategory = "Archives"
+
+// archiveIndexFile lists every file packed into a period archive, for later retrieval.
+const archiveIndexFile = "index.tsv"
+
+// ValidArchiveFormat reports whether format is supported for period archives.
+func ValidArchiveFormat(format string) bool {
+	return format == ArchiveFormatZip || format == ArchiveFormatTarZst
+}
+
+// archivePeriod returns the month bucket ("2022-05") a file belongs to, based on its modification time.
+func archivePeriod(fm FileMove) string {
+	return fm.Info.ModTime().Format("2006-01")
+}
+
+// archiveOldFiles packs each period's files into DestDir/Archives/<period>.<format> on the workers
+// of pool, one archive per worker at a time. Existing archives for a period are extended rather
+// than replaced. The source files are only removed once the new archive has been written and synced.
+func archiveOldFiles(cfg Config, pool *workerPool, periods map[string][]FileMove, progressChan chan<- ProgressUpdate) {
+	keys := make([]string, 0, len(periods))
+	for period := range periods {
+		keys = append(keys, period)
+	}
+	sort.Strings(keys)
+
+	var indexMu sync.Mutex // Serializes appends to the shared index file
+	for _, period := range keys {
+		files := periods[period]
+		job := poolJob{
+			name: filepath.Join(cfg.DestDir, ArchivalCategory, period+"."+cfg.ArchiveFormat),
+			run: func(worker int, updates chan<- ProgressUpdate) error {
+				return archivePeriodFiles(cfg, period, files, &indexMu, updates)
+			},
+		}
+		for _, fm := range files {
+			job.size += fm.Info.Size()
+		}
+		if !pool.dispatchJob(job) {
+			break
+		}
+	}
+	pool.drain()
+
+	if !cfg.DryRun {
+		index := filepath.Join(cfg.DestDir, ArchivalCategory, archiveIndexFile)
+		if _, err := cfg.fsys().Stat(index); err == nil {
+			cfg.mirrorFile(index, true, progressChan)
+		}
+	}
+}
+
+// archivePeriodFiles writes one period archive and reports progress for each of its files. It
+// returns the error that kept the archive from being written, with all of its files left in place.
+func archivePeriodFiles(cfg Config, period string, files []FileMove, indexMu *sync.Mutex, progressChan chan<- ProgressUpdate) error {
+	p := cfg.printer()
+
+	archiveDir := filepath.Join(cfg.DestDir, ArchivalCategory)
+	archivePath := filepath.Join(archiveDir, period+"."+cfg.ArchiveFormat)
+
+	if cfg.DryRun {
+		for _, fm := range files {
+			p.File(LevelNotice, "DRY RUN", "Would archive '%s' into '%s'", fm.SourcePath, archivePath)
+			progressChan <- fm.movedUpdate(ActionArchive, archivePath)
+		}
+		return nil
+	}
+
+	failAll := func(err error) error {
+		p.File(LevelError, "ERROR", "%v", err)
+		for _, fm := range files {
+			progressChan <- fm.failedUpdate(err)
+		}
+		return err
+	}
+
+	if err := cfg.fsys().MkdirAll(archiveDir, 0755); err != nil {
+		return failAll(fmt.Errorf("failed to create archive directory '%s': %w", archiveDir, err))
+	}
+
+	// Files that changed since the scan are left alone instead of being packed
+	var accepted []FileMove
+	for _, fm := range files {
+		if err := verifyUnchanged(cfg.fsys(), fm); err != nil {
+			p.File(LevelWarn, "CHANGED", "%v. Skipping.", err)
+			progressChan <- fm.skippedUpdate(err)
+			continue
+		}
+		accepted = append(accepted, fm)
+	}
+	if len(accepted) == 0 {
+		return nil
+	}
+	files = accepted
+
+	names, err := writePeriodArchive(cfg, archivePath, files)
+	if err != nil {
+		return failAll(fmt.Errorf("failed to write archive '%s': %w", archivePath, err))
+	}
+
+	indexMu.Lock()
+	indexErr := appendArchiveIndex(cfg.fsys(), filepath.Join(archiveDir, archiveIndexFile), filepath.Base(archivePath), files, names)
+	indexMu.Unlock()
+	if indexErr != nil {
+		p.File(LevelWarn, "WARNING", "%v", indexErr)
+	}
+	cfg.persist(archivePath) // Before the originals are gone
+	cfg.mirrorFile(archivePath, true, progressChan)
+
+	for i, fm := range files {
+		if err := cfg.fsys().Remove(fm.SourcePath); err != nil {
+			err = fmt.Errorf("archived '%s' but failed to remove it: %w", fm.SourcePath, err)
+			fm.reportFailure(p, err, progressChan)
+			continue
+		}
+		p.File(LevelSuccess, "ARCHIVED", "Archived '%s' as '%s' in '%s'", fm.SourcePath, names[i], archivePath)
+		progressChan <- fm.movedUpdate(ActionArchive, archivePath+":"+names[i])
+	}
+	return nil
+}
+
+// writePeriodArchive writes files (plus the entries of an existing archive at archivePath) into a
+// temporary file and atomically replaces archivePath with it. It returns the entry name used for
+// each file, which is its path relative to the source with a timestamp added on name clashes.
+func writePeriodArchive(cfg Config, archivePath string, files []FileMove) ([]string, error) {
+	fsys := cfg.fsys()
+	tmp, err := stage(fsys, filepath.Dir(archivePath), 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer tmp.abort() // No-op once renamed into place
+
+	var names []string
+	if cfg.ArchiveFormat == ArchiveFormatZip {
+		names, err = writeZipArchive(fsys, tmp, archivePath, cfg.SourceDir, files, cfg.now())
+	} else {
+		names, err = writeTarZstArchive(fsys, tmp, archivePath, cfg.SourceDir, files, cfg.now())
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := fsys.Rename(tmp.path, archivePath); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// writeZipArchive copies the existing archive's entries (without recompressing) and adds files.
+func writeZipArchive(fsys fsutil.FS, out io.Writer, existing, sourceDir string, files []FileMove, now time.Time) ([]string, error) {
+	zw := zip.NewWriter(out)
+	taken := make(map[string]bool)
+
+	if r, closeZip, err := openZip(fsys, existing); err == nil {
+		for _, f := range r.File {
+			if err := zw.Copy(f); err != nil {
+				closeZip()
+				return nil, fmt.Errorf("failed to copy existing entry '%s': %w", f.Name, err)
+			}
+			taken[f.Name] = true
+		}
+		closeZip()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read existing archive: %w", err)
+	}
+
+	names := make([]string, len(files))
+	for i, fm := range files {
+		names[i] = archiveEntryName(sourceDir, fm.SourcePath, taken, now)
+		hdr, err := zip.FileInfoHeader(fm.Info)
+		if err != nil {
+			return nil, err
+		}
+		hdr.Name = names[i]
+		hdr.Method = zip.Deflate
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return nil, err
+		}
+		if err := copyFileInto(fsys, w, fm.SourcePath); err != nil {
+			return nil, err
+		}
+	}
+	return names, zw.Close()
+}
+
+// openZip opens the zip archive at path on fsys.
+func openZip(fsys fsutil.FS, path string) (*zip.Reader, func() error, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	r, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return r, f.Close, nil
+}
+
+// writeTarZstArchive streams the existing archive's entries and files into a new tar.zst.
+func writeTarZstArchive(fsys fsutil.FS, out io.Writer, existing, sourceDir string, files []FileMove, now time.Time) ([]string, error) {
+	zw, err := zstd.NewWriter(out)
+	if err != nil {
+		return nil, err
+	}
+	tw := tar.NewWriter(zw)
+	taken := make(map[string]bool)
+
+	if f, err := fsys.Open(existing); err == nil {
+		err := copyTarZstEntries(tw, f, taken)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy existing entries: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read existing archive: %w", err)
+	}
+
+	names := make([]string, len(files))
+	for i, fm := range files {
+		names[i] = archiveEntryName(sourceDir, fm.SourcePath, taken, now)
+		hdr, err := tar.FileInfoHeader(fm.Info, "")
+		if err != nil {
+			return nil, err
+		}
+		hdr.Name = names[i]
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if err := copyFileInto(fsys, tw, fm.SourcePath); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return names, zw.Close()
+}
+
+// copyTarZstEntries appends all entries of the tar.zst stream r to tw, recording their names.
+func copyTarZstEntries(tw *tar.Writer, r io.Reader, taken map[string]bool) error {
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+		taken[hdr.Name] = true
+	}
+}
+
+// archiveEntryName returns the slash separated path of src relative to sourceDir, made unique
+// among taken with the usual suffix of the time now, and marks it as taken.
+func archiveEntryName(sourceDir, src string, taken map[string]bool, now time.Time) string {
+	rel, err := filepath.Rel(sourceDir, src)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(src)
+	}
+	base := filepath.ToSlash(rel)
+	name := base
+	if taken[name] {
+		ext := filepath.Ext(base)
+		stem := strings.TrimSuffix(base, ext)
+		timestamp := now.Format("20060102_150405")
+		name = fmt.Sprintf("%s_%s%s", stem, timestamp, ext)
+		for i := 2; taken[name]; i++ {
+			name = fmt.Sprintf("%s_%s_%d%s", stem, timestamp, i, ext)
+		}
+	}
+	taken[name] = true
+	return name
+}
+
+// copyFileInto copies the content of the file at path to w.
+func copyFileInto(fsys fsutil.FS, w io.Writer, path string) error {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// appendArchiveIndex records the archived files in the tab separated index at indexPath on fsys,
+// next to the archives: archive, entry name, original path, size and modification time.
+func appendArchiveIndex(fsys fsutil.FS, indexPath, archiveName string, files []FileMove, names []string) error {
+	_, statErr := fsys.Stat(indexPath)
+	f, err := fsys.OpenFile(indexPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open archive index '%s': %w", indexPath, err)
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	if errors.Is(statErr, os.ErrNotExist) {
+		b.WriteString("archive\tentry\toriginal_path\tsize\tmodified\n")
+	}
+	for i, fm := range files {
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%d\t%s\n", archiveName, names[i], fm.SourcePath, fm.Info.Size(), fm.Info.ModTime().Format(time.RFC3339))
+	}
+	if _, err := io.WriteString(f, b.String()); err != nil {
+		return fmt.Errorf("failed to write archive index '%s': %w", indexPath, err)
+	}
+	return nil
+}
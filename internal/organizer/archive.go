@@ -0,0 +1,368 @@
+package organizer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// archiveEntry is a regular file inside a source archive.
+type archiveEntry struct {
+	Name     string    // Slash separated path inside the archive
+	Size     int64     // Uncompressed size
+	Modified time.Time // Modification time recorded in the archive
+	Category string    // Category the entry was classified into
+	open     func() (io.ReadCloser, error)
+}
+
+// archiveKind returns "zip", "tar" or "tar.gz" based on the file name, or "" for anything else.
+func archiveKind(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar"
+	default:
+		return ""
+	}
+}
+
+// IsArchiveSource reports whether source is a zip or tar archive whose contents should be
+// organized, rather than a directory.
+func IsArchiveSource(source string) bool {
+	info, err := os.Stat(source)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	return archiveKind(source) != ""
+}
+
+// organizeArchive extracts the entries of the archive at cfg.SourceDir directly into their
+// destination categories on the worker pool. Tar archives can only be read front to back, so
+// their entries are handed to the workers as they stream by, all of it counting as processing time.
+func organizeArchive(cfg Config, adaptive *adaptiveWorkers, timer *phaseTimer, progressChan chan<- ProgressUpdate) (totalScanned int, totalToProcess int, totalSkipped int, err error) {
+	p := cfg.printer()
+
+	if cfg.Remote != nil {
+		return 0, 0, 0, fmt.Errorf("archive sources cannot be organized into a remote destination")
+	}
+
+	p.Status(LevelInfo, "📦", "Reading archive '%s'...", cfg.SourceDir)
+	var extensions extensionCounter
+	if cfg.CountExtensions {
+		extensions = make(extensionCounter)
+	}
+
+	// include classifies an entry and reports whether it should be extracted
+	include := func(e *archiveEntry) bool {
+		totalScanned++
+		e.Name = strings.TrimPrefix(path.Clean("/"+e.Name), "/") // "./sub/a.txt" -> "sub/a.txt"
+		dir := path.Dir(e.Name)
+		if !cfg.Recursive && dir != "." {
+			totalSkipped++
+			return false
+		}
+		if first, _, _ := strings.Cut(e.Name, "/"); dir != "." && matchesAnyName(first, cfg.SkipTopDirs) {
+			totalSkipped++
+			return false
+		}
+		// Entries carry no hidden flags, their names have to do; as in a walk, skipping a hidden
+		// folder skips what is in it
+		if cfg.skipsHidden() && strings.Contains("/"+e.Name, "/.") || cfg.Hidden == HiddenOnly && !strings.HasPrefix(path.Base(e.Name), ".") {
+			totalSkipped++
+			return false
+		}
+		ext := strings.ToLower(path.Ext(e.Name))
+		// Entries cannot be sniffed before they are extracted, for MIME filters the extension has to do
+		if mimeType := extMIME(ext); len(cfg.IncludeMIME) > 0 && !matchesMIME(mimeType, cfg.IncludeMIME) || matchesMIME(mimeType, cfg.ExcludeMIME) {
+			totalSkipped++
+			return false
+		}
+		mappedExt, category, ok := cfg.mappedExt(path.Base(e.Name))
+		if !ok {
+			category = "Others"
+		}
+		extensions.add(cfg, mappedExt, e.Size)
+		if !cfg.categorySelected(category) {
+			totalSkipped++
+			return false
+		}
+		e.Category = category
+		return true
+	}
+
+	if archiveKind(cfg.SourceDir) == "zip" {
+		r, err := zip.OpenReader(cfg.SourceDir)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("failed to open zip archive '%s': %w", cfg.SourceDir, err)
+		}
+		defer r.Close()
+
+		var entries []archiveEntry
+		for _, f := range r.File {
+			if !f.Mode().IsRegular() {
+				continue // Directories and symlinks
+			}
+			e := archiveEntry{Name: f.Name, Size: int64(f.UncompressedSize64), Modified: f.Modified, open: func() (io.ReadCloser, error) {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				return struct {
+					io.Reader
+					io.Closer
+				}{fsutil.ThrottledReader(cfg.fsys(), rc), rc}, nil
+			}}
+			if include(&e) {
+				entries = append(entries, e)
+			}
+		}
+		totalToProcess = len(entries)
+		timer.scanDone()
+		if extensions != nil {
+			progressChan <- ProgressUpdate{Extensions: extensions.stats()}
+		}
+		p.Status(LevelInfo, "✅", "Found %d files to extract.", totalToProcess)
+		progressChan <- ProgressUpdate{Planned: totalToProcess}
+		timer.planDone()
+
+		pool := newWorkerPool(cfg, adaptive, progressChan)
+		for _, e := range entries {
+			if !pool.dispatchJob(e.job(cfg)) {
+				break
+			}
+		}
+		pool.wait()
+		if cfg.Control.Stopped() {
+			return totalScanned, totalToProcess, totalSkipped, ErrAborted
+		}
+		return totalScanned, totalToProcess, totalSkipped, nil
+	}
+
+	f, err := cfg.fsys().Open(cfg.SourceDir)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to open archive '%s': %w", cfg.SourceDir, err)
+	}
+	defer f.Close()
+	var stream io.Reader = f
+	if archiveKind(cfg.SourceDir) == "tar.gz" {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("failed to open gzip stream of '%s': %w", cfg.SourceDir, err)
+		}
+		defer gz.Close()
+		stream = gz
+	}
+
+	tr := tar.NewReader(stream)
+	timer.scanDone()
+	timer.planDone()
+	// The entries can only be read one after another, so each is spooled to a staged file in the
+	// destination for a worker to extract from while the next is read. The bounded queue of the
+	// pool keeps the spooled entries to a few per worker.
+	fsys := cfg.fsys()
+	if !cfg.DryRun {
+		if _, err := cfg.dirs.ensure(fsys, cfg.DestDir, false); err != nil {
+			return 0, 0, 0, fmt.Errorf("failed to create destination directory '%s': %w", cfg.DestDir, err)
+		}
+	}
+	pool := newWorkerPool(cfg, adaptive, progressChan)
+	var readErr error
+	for cfg.Control.wait() {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			readErr = fmt.Errorf("failed to read archive '%s': %w", cfg.SourceDir, err)
+			break
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue // Directories, links and special files
+		}
+		e := archiveEntry{Name: hdr.Name, Size: hdr.Size, Modified: hdr.ModTime}
+		if !include(&e) {
+			continue
+		}
+		totalToProcess++
+		progressChan <- ProgressUpdate{Planned: totalToProcess}
+		var spool *stagedFile
+		if !cfg.DryRun {
+			if spool, err = spoolEntry(fsys, cfg.DestDir, tr, e.Size); err != nil {
+				e.fail(cfg, fmt.Errorf("failed to extract '%s': %w", e.source(cfg), err), cfg.now(), progressChan)
+				continue
+			}
+			e.open = func() (io.ReadCloser, error) { return fsys.Open(spool.path) }
+		}
+		job := e.job(cfg)
+		if spool != nil {
+			job.done = spool.abort
+		}
+		if !pool.dispatchJob(job) {
+			break
+		}
+	}
+	if extensions != nil {
+		progressChan <- ProgressUpdate{Extensions: extensions.stats()} // A tar archive is only known once it was read to the end
+	}
+	p.Status(LevelInfo, "✅", "Found %d files to extract.", totalToProcess)
+	pool.wait()
+	if readErr != nil {
+		return totalScanned, totalToProcess, totalSkipped, readErr
+	}
+	if cfg.Control.Stopped() {
+		return totalScanned, totalToProcess, totalSkipped, ErrAborted
+	}
+	return totalScanned, totalToProcess, totalSkipped, nil
+}
+
+// spoolEntry copies the size bytes of an archive entry from r to a staged file in dir, closed and
+// verified, for the entry to be extracted from later.
+func spoolEntry(fsys fsutil.FS, dir string, r io.Reader, size int64) (*stagedFile, error) {
+	spool, err := stage(fsys, dir, 0600)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(spool, r)
+	if closeErr := spool.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = spool.verify(size)
+	}
+	if err != nil {
+		spool.abort()
+		return nil, err
+	}
+	return spool, nil
+}
+
+// source names e in results and messages: the archive and the entry's path inside it.
+func (e archiveEntry) source(cfg Config) string {
+	return cfg.SourceDir + ":" + e.Name
+}
+
+// result builds the result of e being done with after it was started.
+func (e archiveEntry) result(cfg Config, action Action, dest string, err error, started time.Time) *FileResult {
+	return &FileResult{Source: e.source(cfg), Dest: dest, Category: e.Category, Action: action, Err: err, Size: e.Size, Duration: cfg.now().Sub(started)}
+}
+
+// fail reports that e, started at started, failed with err, and returns err.
+func (e archiveEntry) fail(cfg Config, err error, started time.Time, progressChan chan<- ProgressUpdate) error {
+	cfg.printer().File(LevelError, "ERROR", "%v", err)
+	progressChan <- ProgressUpdate{Errored: 1, File: e.result(cfg, ActionFail, "", err, started)}.finished()
+	return err
+}
+
+// job is the job extracting e.
+func (e archiveEntry) job(cfg Config) poolJob {
+	source := e.source(cfg)
+	return poolJob{
+		name: source,
+		size: e.Size,
+		event: func(phase Phase) *FileEvent {
+			return &FileEvent{Phase: phase, Source: source, Action: ActionExtract, Bytes: e.Size}
+		},
+		run: func(worker int, updates chan<- ProgressUpdate) error {
+			return extractEntry(cfg, e, updates)
+		},
+	}
+}
+
+// extractEntry writes an archive entry into its folder of the layout, resolving name collisions
+// the same way moveFile does.
+func extractEntry(cfg Config, e archiveEntry, progressChan chan<- ProgressUpdate) error {
+	p := cfg.printer()
+
+	// Only the base name is used, which also rules out "../" path traversal from crafted archives
+	fileName := path.Base(e.Name)
+	srcRel := path.Dir(e.Name)
+	if srcRel == "." {
+		srcRel = ""
+	}
+	destPath := filepath.Join(cfg.DestDir, cfg.layoutFolder(layoutFields{Category: e.Category, Name: fileName, SrcRel: srcRel, ModTime: e.Modified}), fileName)
+	source := e.source(cfg)
+	started := cfg.now()
+
+	if cfg.DryRun {
+		p.File(LevelNotice, "DRY RUN", "Would extract '%s' to '%s'", source, destPath)
+		progressChan <- ProgressUpdate{Moved: 1, Bytes: e.Size, Category: e.Category, File: e.result(cfg, ActionExtract, destPath, nil, started)}.finished()
+		return nil
+	}
+
+	fail := func(err error) error {
+		return e.fail(cfg, err, started, progressChan)
+	}
+
+	fsys := cfg.fsys()
+	if _, err := cfg.dirs.ensure(fsys, filepath.Dir(destPath), false); err != nil {
+		return fail(fmt.Errorf("failed to create destination directory '%s': %w", filepath.Dir(destPath), err))
+	}
+
+	out, err := stage(fsys, filepath.Dir(destPath), 0644)
+	if err != nil {
+		return fail(err)
+	}
+	in, err := e.open()
+	if err == nil {
+		_, err = io.Copy(out, in)
+		in.Close()
+	}
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = out.verify(e.Size)
+	}
+	if err != nil {
+		out.abort() // Don't leave a truncated file behind
+		return fail(fmt.Errorf("failed to extract '%s': %w", source, err))
+	}
+	if !e.Modified.IsZero() {
+		fsys.Chtimes(out.path, e.Modified, e.Modified)
+	}
+	finalDestPath, err := out.commitUnique(destPath, "", cfg.now())
+	if err != nil {
+		return fail(fmt.Errorf("failed to extract '%s': %w", source, err))
+	}
+	if finalDestPath != destPath {
+		p.File(LevelWarn, "COLLISION", "Renaming '%s' to '%s'", fileName, filepath.Base(finalDestPath))
+	}
+	cfg.persist(finalDestPath)
+
+	p.File(LevelSuccess, "EXTRACTED", "Extracted '%s' to '%s'", source, finalDestPath)
+	cfg.fileStored(source, finalDestPath, e.Category, progressChan)
+	progressChan <- ProgressUpdate{Moved: 1, Bytes: e.Size, Category: e.Category, File: e.result(cfg, ActionExtract, finalDestPath, nil, started)}.finished()
+	return nil
+}
+
+// createUnique creates destPath exclusively. If it already exists, the time now is appended to the
+// name (report.pdf -> report_20250704_220740.pdf), and a counter if that is taken too, as for
+// moved files.
+func createUnique(fsys fsutil.FS, destPath string, now time.Time) (fsutil.File, string, error) {
+	var f fsutil.File
+	unique, err := placeUnique(destPath, destPath, "", now, func(target string) (err error) {
+		f, err = fsys.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		return err
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create '%s': %w", unique, err)
+	}
+	return f, unique, nil
+}
@@ -0,0 +1,125 @@
+package organizer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// writeZip creates a zip archive at path holding files, by name.
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err == nil {
+			_, err = w.Write([]byte(content))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTarEntriesGoToWorkers(t *testing.T) {
+	archive, dest := filepath.Join(t.TempDir(), "in.tar.gz"), t.TempDir()
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	names := []string{"a.txt", "b.txt", "sub/c.txt", "photo.jpg"}
+	for _, name := range names {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(name))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, c := range []interface{ Close() error }{tw, gz, f} {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := testConfig(t, archive, dest)
+	cfg.Recursive = true
+	progress := make(chan ProgressUpdate, 100)
+	result, err := OrganizeFiles(cfg, progress)
+	close(progress)
+	if err != nil || result.Processed != len(names) || result.ToProcess != len(names) {
+		t.Fatalf("OrganizeFiles = %d of %d processed, %v; want %d", result.Processed, result.ToProcess, err, len(names))
+	}
+	planned := 0
+	for update := range progress {
+		planned = max(planned, update.Planned)
+	}
+	if planned != len(names) {
+		t.Errorf("planned %d files, want %d", planned, len(names))
+	}
+	workerFiles := 0
+	for _, w := range result.Pool.PerWorker {
+		workerFiles += w.Files
+	}
+	if workerFiles != len(names) {
+		t.Errorf("workers extracted %d files, want %d", workerFiles, len(names))
+	}
+	if content, err := os.ReadFile(filepath.Join(dest, "Documents", "c.txt")); err != nil || string(content) != "sub/c.txt" {
+		t.Errorf("c.txt = %q, %v", content, err)
+	}
+	entries, err := os.ReadDir(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), stagedPrefix) {
+			t.Errorf("spooled entry %s left in the destination", entry.Name())
+		}
+	}
+}
+
+func TestArchiveEntriesRetried(t *testing.T) {
+	archive, dest := filepath.Join(t.TempDir(), "in.zip"), t.TempDir()
+	writeZip(t, archive, map[string]string{"notes.txt": "notes"})
+	cfg := testConfig(t, archive, dest)
+	cfg.FileRetries = 1
+
+	// The first copy into the destination fails as if its share dropped out
+	var failed atomic.Bool
+	cfg.FS = fsutil.Faulty(fsutil.OS, func(op, path string) error {
+		if op == "create" && strings.HasPrefix(path, filepath.Join(dest, "Documents")) && !failed.Swap(true) {
+			return syscall.EIO
+		}
+		return nil
+	})
+	result, err := OrganizeFiles(cfg, nil)
+	if err != nil || result.Processed != 1 || result.Errors != 0 {
+		t.Fatalf("OrganizeFiles = %d processed, %d errors, %v; want 1 processed", result.Processed, result.Errors, err)
+	}
+	if result.ToProcess != 1 || result.Pool == nil || result.Pool.Retried != 1 {
+		t.Errorf("to process %d, pool %+v; want 1 file retried once", result.ToProcess, result.Pool)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "Documents", "notes.txt")); err != nil {
+		t.Errorf("entry not extracted: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package organizer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// captureTime returns when the photo or video at path on fsys was taken, read from the EXIF data
+// of JPEG and TIFF-based raw files or the movie header of MP4 and QuickTime files. For other
+// files, and files without the date, it returns modTime.
+func captureTime(fsys fsutil.FS, path string, modTime time.Time) time.Time {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return modTime
+	}
+	defer f.Close()
+	var head [12]byte
+	if _, err := io.ReadFull(f, head[:]); err != nil {
+		return modTime
+	}
+	var t time.Time
+	switch {
+	case head[0] == 0xFF && head[1] == 0xD8:
+		t = jpegCaptureTime(f)
+	case string(head[:4]) == "II*\x00" || string(head[:4]) == "MM\x00*":
+		t = exifCaptureTime(io.NewSectionReader(f, 0, 1<<62))
+	case isMovie(head[:]):
+		t = movieCaptureTime(f)
+	}
+	if t.IsZero() {
+		return modTime
+	}
+	return t
+}
+
+// jpegCaptureTime looks for the EXIF segment among the segments of a JPEG file before the image
+// data.
+func jpegCaptureTime(f io.ReadSeeker) time.Time {
+	if _, err := f.Seek(2, io.SeekStart); err != nil {
+		return time.Time{}
+	}
+	var marker [4]byte
+	for {
+		if _, err := io.ReadFull(f, marker[:]); err != nil || marker[0] != 0xFF {
+			return time.Time{}
+		}
+		size := int64(binary.BigEndian.Uint16(marker[2:])) - 2
+		if marker[1] == 0xDA || size < 0 { // Start of the image data: no EXIF before it
+			return time.Time{}
+		}
+		if marker[1] != 0xE1 || size < 8 {
+			if _, err := f.Seek(size, io.SeekCurrent); err != nil {
+				return time.Time{}
+			}
+			continue
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(f, data); err != nil {
+			return time.Time{}
+		}
+		if bytes.HasPrefix(data, []byte("Exif\x00\x00")) {
+			return exifCaptureTime(bytes.NewReader(data[6:]))
+		}
+	}
+}
+
+// EXIF tags of the capture time.
+const (
+	tagDateTime         = 0x0132 // When the file was last changed, in IFD0
+	tagExifIFD          = 0x8769 // Offset of the EXIF IFD, in IFD0
+	tagDateTimeOriginal = 0x9003 // When the picture was taken, in the EXIF IFD
+)
+
+// exifCaptureTime reads the capture time from TIFF structured EXIF data: DateTimeOriginal, or
+// DateTime of the image if there is none. EXIF times have no time zone; they are taken as local.
+func exifCaptureTime(r io.ReaderAt) time.Time {
+	var header [8]byte
+	if _, err := r.ReadAt(header[:], 0); err != nil {
+		return time.Time{}
+	}
+	var order binary.ByteOrder
+	switch string(header[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return time.Time{}
+	}
+	ifd0 := readIFD(r, order, int64(order.Uint32(header[4:])))
+	if ptr, ok := ifd0[tagExifIFD]; ok {
+		exif := readIFD(r, order, int64(order.Uint32(ptr[8:])))
+		if t := exifTime(r, order, exif[tagDateTimeOriginal]); !t.IsZero() {
+			return t
+		}
+	}
+	return exifTime(r, order, ifd0[tagDateTime])
+}
+
+// readIFD returns the 12 byte entries of the IFD at offset by tag.
+func readIFD(r io.ReaderAt, order binary.ByteOrder, offset int64) map[uint16][]byte {
+	var count [2]byte
+	if offset <= 0 {
+		return nil
+	}
+	if _, err := r.ReadAt(count[:], offset); err != nil {
+		return nil
+	}
+	n := int(order.Uint16(count[:]))
+	data := make([]byte, 12*n)
+	if _, err := r.ReadAt(data, offset+2); err != nil {
+		return nil
+	}
+	entries := make(map[uint16][]byte, n)
+	for i := 0; i < n; i++ {
+		entry := data[12*i : 12*i+12]
+		entries[order.Uint16(entry)] = entry
+	}
+	return entries
+}
+
+// exifTime parses the "2006:01:02 15:04:05" ASCII value of a date entry.
+func exifTime(r io.ReaderAt, order binary.ByteOrder, entry []byte) time.Time {
+	if entry == nil || order.Uint16(entry[2:]) != 2 || order.Uint32(entry[4:]) < 19 {
+		return time.Time{}
+	}
+	value := make([]byte, 19)
+	if _, err := r.ReadAt(value, int64(order.Uint32(entry[8:]))); err != nil {
+		return time.Time{}
+	}
+	t, err := time.ParseInLocation("2006:01:02 15:04:05", strings.TrimSpace(string(value)), time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// movieEpoch is when the times of MP4 and QuickTime files count from.
+var movieEpoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// movieCaptureTime reads the creation time from the movie header (moov/mvhd) of an MP4 or
+// QuickTime file. Cameras that don't know the time write 0, which counts as no time.
+func movieCaptureTime(f fsutil.File) time.Time {
+	mvhd := findBox(f, "moov", "mvhd")
+	if len(mvhd) < 12 {
+		return time.Time{}
+	}
+	var seconds uint64
+	if mvhd[0] == 1 {
+		seconds = binary.BigEndian.Uint64(mvhd[4:])
+	} else {
+		seconds = uint64(binary.BigEndian.Uint32(mvhd[4:]))
+	}
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return movieEpoch.Add(time.Duration(seconds) * time.Second).Local()
+}
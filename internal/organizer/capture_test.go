@@ -0,0 +1,29 @@
+package organizer
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+func TestCaptureTime(t *testing.T) {
+	taken := time.Date(2021, 6, 12, 9, 30, 0, 0, time.UTC)
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[4:], uint32(taken.Sub(movieEpoch)/time.Second))
+	movie := append(box("ftyp", []byte("isom\x00\x00\x02\x00")), box("moov", box("mvhd", mvhd))...)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "clip.mov")
+	writeFile(t, path, string(movie))
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := captureTime(fsutil.OS, path, modTime); !got.Equal(taken) {
+		t.Errorf("captureTime = %v, want %v from the movie header", got, taken)
+	}
+	if got := captureTime(failing(dir, syscall.EACCES, "open"), path, modTime); !got.Equal(modTime) {
+		t.Errorf("captureTime = %v, want %v when the file system cannot open it", got, modTime)
+	}
+}
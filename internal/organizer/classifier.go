@@ -0,0 +1,179 @@
+package organizer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// classifierTimeout bounds how long the plugin may take to answer for a single file.
+const classifierTimeout = 10 * time.Second
+
+// ClassifyRequest is what the organizer sends to a classifier plugin for every candidate file,
+// as one JSON object per line on the plugin's stdin.
+type ClassifyRequest struct {
+	Path     string    `json:"path"`
+	Name     string    `json:"name"`
+	Ext      string    `json:"ext"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mtime"`
+	MIME     string    `json:"mime"`             // Sniffed from the file's first bytes
+	Category string    `json:"category"`         // What the built-in extension mappings would pick
+	Groups   []string  `json:"groups,omitempty"` // Extension groups the file belongs to, the most specific first
+}
+
+// ClassifyResponse is the plugin's answer, one JSON object per line on its stdout. Empty fields
+// keep the built-in decision.
+type ClassifyResponse struct {
+	Category string `json:"category,omitempty"`
+	Dest     string `json:"dest,omitempty"` // Folder relative to the destination root, e.g. "Finance/2024"; defaults to the category
+	Skip     bool   `json:"skip,omitempty"` // Leave the file where it is
+}
+
+// FileClassifier overrides the built-in category and destination folder of candidate files.
+// Classifiers are consulted in order; each one sees the category chosen so far in the request.
+// ExtensionClassifier, ContentClassifier, StarlarkRules and ExternalClassifier implement it.
+type FileClassifier interface {
+	Classify(req ClassifyRequest) (ClassifyResponse, error)
+}
+
+// ExternalClassifier is a long-running plugin process that decides where files go. It is started
+// once and fed one request per file, so plugins can keep models or lookup tables loaded.
+type ExternalClassifier struct {
+	command string
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	lines   chan string
+
+	mu     sync.Mutex
+	broken error // Set once the plugin died or timed out; later requests fail fast
+}
+
+// StartClassifier launches command through the platform shell as a classifier plugin.
+func StartClassifier(command string) (*ExternalClassifier, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start classifier '%s': %w", command, err)
+	}
+
+	c := &ExternalClassifier{command: command, cmd: cmd, stdin: stdin, lines: make(chan string)}
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			c.lines <- scanner.Text()
+		}
+		close(c.lines)
+	}()
+	return c, nil
+}
+
+// Classify asks the plugin about one file.
+func (c *ExternalClassifier) Classify(req ClassifyRequest) (ClassifyResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.broken != nil {
+		return ClassifyResponse{}, c.broken
+	}
+
+	line, err := json.Marshal(req)
+	if err != nil {
+		return ClassifyResponse{}, err
+	}
+	if _, err := c.stdin.Write(append(line, '\n')); err != nil {
+		c.broken = fmt.Errorf("classifier '%s' is not accepting input: %w", c.command, err)
+		return ClassifyResponse{}, c.broken
+	}
+
+	select {
+	case answer, ok := <-c.lines:
+		if !ok {
+			c.broken = fmt.Errorf("classifier '%s' exited", c.command)
+			return ClassifyResponse{}, c.broken
+		}
+		var resp ClassifyResponse
+		if err := json.Unmarshal([]byte(answer), &resp); err != nil {
+			return ClassifyResponse{}, fmt.Errorf("classifier '%s' sent an invalid answer %q: %w", c.command, answer, err)
+		}
+		if resp.Dest, err = cleanDest(resp.Dest); err != nil {
+			return ClassifyResponse{}, fmt.Errorf("classifier '%s': %w", c.command, err)
+		}
+		return resp, nil
+	case <-time.After(classifierTimeout):
+		// The answer may still arrive later and would then be out of step with the requests
+		c.broken = fmt.Errorf("classifier '%s' did not answer within %s", c.command, classifierTimeout)
+		return ClassifyResponse{}, c.broken
+	}
+}
+
+// Close ends the plugin by closing its input and waits for it to exit.
+func (c *ExternalClassifier) Close() error {
+	if c == nil {
+		return nil
+	}
+	c.stdin.Close()
+	go func() {
+		for range c.lines { // Drain so the reader goroutine can finish
+		}
+	}()
+	if c.broken != nil {
+		c.cmd.Process.Kill()
+	}
+	err := c.cmd.Wait()
+	var exitErr *exec.ExitError
+	if c.broken != nil && errors.As(err, &exitErr) {
+		return nil // Killed above, already reported
+	}
+	return err
+}
+
+// cleanDest normalizes a destination folder returned by a classifier and makes sure it stays
+// inside the destination root.
+func cleanDest(dest string) (string, error) {
+	if dest == "" {
+		return "", nil
+	}
+	clean := path.Clean(strings.ReplaceAll(dest, "\\", "/"))
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("dest '%s' is outside of the destination", dest)
+	}
+	return clean, nil
+}
+
+// sniffMIME returns the MIME type guessed from the first bytes of the file at path on fsys.
+func sniffMIME(fsys fsutil.FS, path string) string {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, _ := io.ReadFull(f, buf)
+	return http.DetectContentType(buf[:n])
+}
@@ -0,0 +1,74 @@
+package organizer
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// The built-in classifiers below implement FileClassifier like plugins and rules do, so a chain
+// of classifiers (Config.Classifiers, WithClassifier) can mix them freely: extension mappings
+// layered on each other, content sniffing for files the extensions leave in Others, Starlark rules
+// (StarlarkRules) and plugins (ExternalClassifier). Each sees the category chosen before it.
+
+// ExtensionClassifier files a file by its extension, the longest mapped one first so ".tar.gz"
+// wins over ".gz", like CategoryMappings does for every file before the chain is consulted. Keys
+// are lowercase extensions with the dot. Files with no mapped extension keep their category.
+type ExtensionClassifier map[string]string
+
+// Classify implements FileClassifier.
+func (c ExtensionClassifier) Classify(req ClassifyRequest) (ClassifyResponse, error) {
+	_, category, _ := c.lookup(req.Name)
+	return ClassifyResponse{Category: category}, nil
+}
+
+// lookup returns the longest extension of name that is mapped and its category; without one, the
+// last extension of name and ok false.
+func (c ExtensionClassifier) lookup(name string) (ext, category string, ok bool) {
+	lower := strings.ToLower(name)
+	for i := 0; i < len(lower); i++ {
+		if lower[i] != '.' {
+			continue
+		}
+		if category, ok := c[lower[i:]]; ok {
+			return lower[i:], category, true
+		}
+	}
+	return filepath.Ext(lower), "", false
+}
+
+// ContentClassifier files the files left in Others by the MIME type sniffed from their content,
+// for downloads without an extension or with a made-up one. Keys are MIME types, "*" matching any
+// subtype; an exact type wins over a wildcard.
+type ContentClassifier map[string]string
+
+// DefaultContentCategories returns the MIME types ContentClassifier maps to the default categories.
+func DefaultContentCategories() map[string]string {
+	return map[string]string{
+		"image/*":                      "Images",
+		"video/*":                      "Videos",
+		"audio/*":                      "Audio",
+		"application/ogg":              "Audio",
+		"application/pdf":              "Documents",
+		"application/postscript":       "Documents",
+		"text/*":                       "Documents",
+		"application/zip":              "Archives",
+		"application/x-gzip":           "Archives",
+		"application/x-rar-compressed": "Archives",
+	}
+}
+
+// Classify implements FileClassifier.
+func (c ContentClassifier) Classify(req ClassifyRequest) (ClassifyResponse, error) {
+	if req.Category != "Others" || req.MIME == "" {
+		return ClassifyResponse{}, nil
+	}
+	mediaType, _, _ := strings.Cut(req.MIME, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if category, ok := c[mediaType]; ok {
+		return ClassifyResponse{Category: category}, nil
+	}
+	if major, _, ok := strings.Cut(mediaType, "/"); ok {
+		return ClassifyResponse{Category: c[major+"/*"]}, nil
+	}
+	return ClassifyResponse{}, nil
+}
@@ -0,0 +1,27 @@
+package organizer
+
+import "time"
+
+// Clock returns the current time. A run takes every time it records or puts into a file name
+// from its Config's Clock: collision suffixes, the archival cutoff, journal and review timestamps
+// and the durations in results. A FixedClock makes runs reproducible, e.g. for golden-file tests
+// or for diffing the output of dry runs.
+type Clock func() time.Time
+
+// FixedClock returns a Clock that is stopped at t.
+func FixedClock(t time.Time) Clock {
+	return func() time.Time { return t }
+}
+
+// now returns the time of c, or the wall clock for a nil Clock.
+func (c Clock) now() time.Time {
+	if c == nil {
+		return time.Now()
+	}
+	return c()
+}
+
+// now returns the current time by cfg's Clock.
+func (cfg Config) now() time.Time {
+	return cfg.Clock.now()
+}
@@ -0,0 +1,222 @@
+package organizer
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/avizyt/org-cli/internal/fsutil"
+	"github.com/avizyt/org-cli/internal/journal"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codecs supported by --compress.
+const (
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
+)
+
+// alreadyCompressed lists extensions that don't benefit from another round of compression.
+var alreadyCompressed = map[string]bool{
+	".gz": true, ".zst": true, ".zip": true, ".7z": true, ".rar": true, ".xz": true, ".bz2": true, ".tgz": true,
+}
+
+// ValidCompression reports whether codec is supported for compression on move.
+func ValidCompression(codec string) bool {
+	return codec == CompressGzip || codec == CompressZstd
+}
+
+// compressionSuffix returns the extension appended to files compressed with codec.
+func compressionSuffix(codec string) string {
+	if codec == CompressZstd {
+		return ".zst"
+	}
+	return ".gz"
+}
+
+// newCompressor wraps w so that everything written to it is compressed with codec.
+func newCompressor(codec string, w io.Writer) (io.WriteCloser, error) {
+	switch codec {
+	case CompressGzip:
+		return gzip.NewWriter(w), nil
+	case CompressZstd:
+		return zstd.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("unknown compression codec '%s'", codec)
+	}
+}
+
+// newDecompressor wraps r so that reading from it yields the data compressed with codec.
+func newDecompressor(codec string, r io.Reader) (io.ReadCloser, error) {
+	switch codec {
+	case CompressGzip:
+		return gzip.NewReader(r)
+	case CompressZstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unknown compression codec '%s'", codec)
+	}
+}
+
+// shouldCompress reports whether fm is stored compressed in the destination.
+func (cfg Config) shouldCompress(fm FileMove) bool {
+	if cfg.Compress == "" || alreadyCompressed[strings.ToLower(filepath.Ext(fm.SourcePath))] {
+		return false
+	}
+	return len(cfg.CompressCategories) == 0 || matchesAnyName(fm.Category, cfg.CompressCategories)
+}
+
+// transformFile stores fm compressed and/or encrypted in its category directory, keeping the
+// original name plus the suffixes of the applied steps (report.pdf -> report.pdf.zst.age), and
+// removes the source afterwards. The operation is journaled so that undo can restore the file.
+func transformFile(fm FileMove, cfg Config, progressChan chan<- ProgressUpdate) error {
+	p := cfg.printer()
+
+	codec, encrypt := "", cfg.shouldEncrypt(fm)
+	if cfg.shouldCompress(fm) {
+		codec = cfg.Compress
+	}
+	suffix, verb, label, op, action := "", "compress", "COMPRESSED", journal.OpCompress, ActionCompress
+	if codec != "" {
+		suffix = compressionSuffix(codec)
+	}
+	if encrypt {
+		suffix += encryptionSuffix
+		verb, label, op, action = "encrypt", "ENCRYPTED", journal.OpEncrypt, ActionEncrypt
+	}
+
+	if fm.DryRun {
+		p.File(LevelNotice, "DRY RUN", "Would %s '%s' to '%s'", verb, fm.SourcePath, fm.DestPath+suffix)
+		progressChan <- fm.movedUpdate(action, fm.DestPath+suffix)
+		return nil
+	}
+
+	fsys := cfg.fsys()
+	fail := func(err error) error {
+		fm.reportFailure(p, err, progressChan)
+		return err
+	}
+
+	if err := verifyUnchanged(fsys, fm); err != nil {
+		p.File(LevelWarn, "CHANGED", "%v. Skipping.", err)
+		progressChan <- fm.skippedUpdate(err)
+		return err
+	}
+	destDir := filepath.Dir(fm.DestPath)
+	if _, err := cfg.dirs.ensure(fsys, destDir, false); err != nil {
+		return fail(fmt.Errorf("failed to create destination directory '%s': %w", destDir, err))
+	}
+
+	if existing, err := fsys.Stat(fm.DestPath + suffix); err == nil && cfg.replaces() {
+		if cfg.OnConflict == ConflictAsk {
+			if cfg, err = cfg.decideConflict(fm, fm.DestPath+suffix, existing); err != nil {
+				return fail(err)
+			}
+		}
+		switch {
+		case cfg.OnConflict == ConflictSkip:
+			return cfg.skipConflict(fm, fm.DestPath+suffix, progressChan)
+		case cfg.OnConflict == ConflictRename:
+			// commitUnique adds the timestamp
+		case cfg.sourceWins(fm, existing):
+			if err := cfg.setAside(fm.DestPath+suffix, false); err != nil {
+				return fail(err)
+			}
+		case cfg.loser() == ConflictBackup:
+			// The kept file has the name, the new one is stored as its backup
+			suffix = strings.TrimPrefix(backupName(fsys, fm.DestPath+suffix), fm.DestPath)
+		default:
+			return cfg.discardSource(fm, fm.DestPath+suffix, progressChan)
+		}
+	}
+
+	out, err := stage(fsys, destDir, fm.Info.Mode().Perm())
+	if err != nil {
+		return fail(err)
+	}
+	out.watch = fm.watch
+
+	var recipients []age.Recipient
+	if encrypt {
+		recipients = cfg.EncryptTo
+	}
+	err = transformInto(fsys, out, fm.SourcePath, codec, recipients)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		out.abort()
+		return fail(fmt.Errorf("failed to %s '%s': %w", verb, fm.SourcePath, err))
+	}
+	progressChan <- fm.eventUpdate(PhaseCopied, action)
+	fsys.Chtimes(out.path, fm.Info.ModTime(), fm.Info.ModTime())
+	cfg.copied(fm.SourcePath, out.path)
+	finalDestPath, err := out.commitUnique(fm.DestPath, suffix, cfg.now())
+	if err != nil {
+		return fail(fmt.Errorf("failed to %s '%s': %w", verb, fm.SourcePath, err))
+	}
+	if finalDestPath != fm.DestPath+suffix {
+		p.File(LevelWarn, "COLLISION", "Renaming '%s' to '%s'", filepath.Base(fm.DestPath)+suffix, filepath.Base(finalDestPath))
+	}
+	cfg.persist(finalDestPath)
+
+	if err := fsys.Remove(fm.SourcePath); err != nil {
+		fsys.Remove(finalDestPath) // Keep the original as the only copy
+		return fail(fmt.Errorf("failed to remove '%s' after storing it as '%s': %w", fm.SourcePath, finalDestPath, err))
+	}
+	cfg.Journal.Record(journal.Entry{Op: op, Source: fm.SourcePath, Dest: finalDestPath, Codec: codec, Size: fm.Info.Size(), Mode: fm.Info.Mode().Perm()})
+
+	p.File(LevelSuccess, label, "Stored '%s' as '%s'", fm.SourcePath, finalDestPath)
+	cfg.fileStored(fm.SourcePath, finalDestPath, fm.Category, progressChan)
+	progressChan <- fm.movedUpdate(action, finalDestPath)
+	return nil
+}
+
+// transformInto writes the content of the file at src to w, compressed with codec unless it is
+// empty and then encrypted to recipients unless there are none.
+func transformInto(fsys fsutil.FS, w io.Writer, src, codec string, recipients []age.Recipient) error {
+	var closers []io.Closer
+	closeAll := func() error {
+		var first error
+		for i := len(closers) - 1; i >= 0; i-- {
+			if err := closers[i].Close(); err != nil && first == nil {
+				first = err
+			}
+		}
+		return first
+	}
+
+	if len(recipients) > 0 {
+		ew, err := age.Encrypt(w, recipients...)
+		if err != nil {
+			return err
+		}
+		closers = append(closers, ew)
+		w = ew
+	}
+	if codec != "" {
+		cw, err := newCompressor(codec, w)
+		if err != nil {
+			closeAll()
+			return err
+		}
+		closers = append(closers, cw)
+		w = cw
+	}
+
+	if err := copyFileInto(fsys, w, src); err != nil {
+		closeAll()
+		return err
+	}
+	return closeAll()
+}
@@ -0,0 +1,299 @@
+package organizer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+	"github.com/avizyt/org-cli/internal/journal"
+	"github.com/avizyt/org-cli/internal/trash"
+)
+
+// What happens when the destination of a file is taken.
+const (
+	ConflictRename      = "rename"       // The new file gets a timestamp suffix (default)
+	ConflictBackup      = "backup"       // The existing file is renamed to <name>.bak-<n> and the new one takes its name
+	ConflictTrash       = "trash"        // The existing file is moved to the trash and the new one takes its name
+	ConflictKeepNewest  = "keep-newest"  // The file modified last keeps the name; the other one is handled per Config.ConflictLoser
+	ConflictKeepLargest = "keep-largest" // The larger file keeps the name; the other one is handled per Config.ConflictLoser
+	ConflictAsk         = "ask"          // Config.Asker decides every collision, see ConflictAsker
+	ConflictDelete      = "delete"       // Only as Config.ConflictLoser: the losing file is deleted permanently
+	ConflictSkip        = "skip"         // Only as the answer of a ConflictAsker: the new file is left where it is
+)
+
+// ValidConflict reports whether strategy is one of the supported conflict strategies.
+func ValidConflict(strategy string) bool {
+	switch strategy {
+	case "", ConflictRename, ConflictBackup, ConflictTrash, ConflictKeepNewest, ConflictKeepLargest, ConflictAsk:
+		return true
+	}
+	return false
+}
+
+// ValidConflictLoser reports whether policy is one of the supported policies for the file that
+// loses a keep-newest or keep-largest conflict: ConflictBackup, ConflictTrash or ConflictDelete.
+func ValidConflictLoser(policy string) bool {
+	switch policy {
+	case "", ConflictBackup, ConflictTrash, ConflictDelete:
+		return true
+	}
+	return false
+}
+
+// Conflict is a collision a ConflictAsker is asked about.
+type Conflict struct {
+	Source   string      // The new file
+	Dest     string      // Where it is to go, taken by the existing file
+	Info     fs.FileInfo // Of the new file
+	Existing fs.FileInfo // Of the file at Dest
+
+	fsys   fsutil.FS
+	hashes *hasher
+}
+
+// SameContent reports whether the two files have the same content.
+func (c Conflict) SameContent() (bool, error) {
+	return sameContent(c.fsys, c.hashes, c.Source, c.Info, c.Dest, c.Existing)
+}
+
+// ConflictAsker decides collisions under ConflictAsk. Ask returns ConflictRename, ConflictBackup,
+// ConflictTrash or ConflictSkip; an error fails the file. The workers ask one at a time, so Ask
+// may prompt on a terminal: workers running into a collision meanwhile wait for their turn.
+type ConflictAsker interface {
+	Ask(c Conflict) (string, error)
+}
+
+// askMu lets one worker at a time ask about a collision.
+var askMu sync.Mutex
+
+// decideConflict asks Asker what to do about fm, whose destination dest is taken by the file
+// described by existing, and returns cfg set up to do it. A dry run doesn't ask and plans to
+// rename, as without ConflictAsk.
+func (cfg Config) decideConflict(fm FileMove, dest string, existing fs.FileInfo) (Config, error) {
+	if fm.DryRun {
+		cfg.printer().File(LevelNotice, "DRY RUN", "Would ask what to do as '%s' exists", dest)
+		cfg.OnConflict = ConflictRename
+		return cfg, nil
+	}
+	askMu.Lock()
+	answer, err := cfg.Asker.Ask(Conflict{Source: fm.SourcePath, Dest: dest, Info: fm.Info, Existing: existing, fsys: cfg.fsys(), hashes: cfg.hashes})
+	askMu.Unlock()
+	if err != nil {
+		return cfg, fmt.Errorf("no decision on '%s' taken by '%s': %w", fm.SourcePath, dest, err)
+	}
+	switch answer {
+	case ConflictRename, ConflictBackup, ConflictTrash, ConflictSkip:
+		cfg.OnConflict = answer
+		return cfg, nil
+	}
+	return cfg, fmt.Errorf("unknown answer '%s' on '%s' taken by '%s'", answer, fm.SourcePath, dest)
+}
+
+// skipConflict leaves fm in place because its destination dest is taken.
+func (cfg Config) skipConflict(fm FileMove, dest string, progressChan chan<- ProgressUpdate) error {
+	cfg.printer().File(LevelWarn, "CONFLICT", "'%s' exists. Skipping '%s'.", dest, fm.SourcePath)
+	err := &ConflictError{Path: dest, Reason: fmt.Sprintf("exists, '%s' was skipped", fm.SourcePath)}
+	progressChan <- fm.skippedUpdate(err)
+	return err
+}
+
+// ErrDuplicate is the reason of files left in place with Config.SkipDuplicates because their
+// destination already holds the same content.
+var ErrDuplicate = errors.New("duplicate")
+
+// duplicateOf reports whether fm has the same content as the file at dest described by existing,
+// for SkipDuplicates.
+func (cfg Config) duplicateOf(fm FileMove, dest string, existing fs.FileInfo) (bool, error) {
+	if !cfg.SkipDuplicates {
+		return false, nil
+	}
+	same, err := sameContent(cfg.fsys(), cfg.hashes, fm.SourcePath, fm.Info, dest, existing)
+	if err != nil {
+		return false, fmt.Errorf("failed to compare '%s' with '%s': %w", fm.SourcePath, dest, err)
+	}
+	return same, nil
+}
+
+// skipDuplicate leaves fm in place because dest already holds the same content.
+func (cfg Config) skipDuplicate(fm FileMove, dest string, progressChan chan<- ProgressUpdate) error {
+	cfg.printer().File(LevelNotice, "DUPLICATE", "'%s' is already in '%s'. Skipping.", fm.SourcePath, dest)
+	progressChan <- fm.skippedUpdate(fmt.Errorf("%w of '%s'", ErrDuplicate, dest))
+	return nil
+}
+
+// replaces reports whether the new file can take the name of an existing one, which is set aside.
+func (cfg Config) replaces() bool {
+	return cfg.OnConflict != "" && cfg.OnConflict != ConflictRename
+}
+
+// sourceWins reports whether fm takes the name of the existing file described by existing. With
+// keep-newest and keep-largest the existing file wins ties.
+func (cfg Config) sourceWins(fm FileMove, existing fs.FileInfo) bool {
+	switch cfg.OnConflict {
+	case ConflictKeepNewest:
+		return fm.Info.ModTime().After(existing.ModTime())
+	case ConflictKeepLargest:
+		return fm.Info.Size() > existing.Size()
+	}
+	return true
+}
+
+// loser returns what happens to the file that doesn't get the name: ConflictBackup, ConflictTrash
+// or ConflictDelete.
+func (cfg Config) loser() string {
+	switch {
+	case cfg.OnConflict == ConflictBackup || cfg.OnConflict == ConflictTrash:
+		return cfg.OnConflict
+	case cfg.ConflictLoser == "":
+		return ConflictBackup
+	}
+	return cfg.ConflictLoser
+}
+
+// maxCollisionNames is how many names placeUnique tries for a file before it gives up.
+const maxCollisionNames = 1000
+
+// collisionName returns the n-th name, from 0, a file colliding at dest+suffix is stored under:
+// the time now before the extension of dest, and from the second name on a counter after it
+// (report_20250704_220740.pdf, report_20250704_220740_2.pdf, ...). The time alone is not unique:
+// files of the same name collide within a second, and with --deterministic in every run.
+func collisionName(dest, suffix string, now time.Time, n int) string {
+	ext := filepath.Ext(dest)
+	name := strings.TrimSuffix(dest, ext) + "_" + now.Format("20060102_150405")
+	if n > 0 {
+		name += fmt.Sprintf("_%d", n+1)
+	}
+	return name + ext + suffix
+}
+
+// freeCollisionName returns the first collision name of dest that is not taken on fsys.
+func freeCollisionName(fsys fsutil.FS, dest string, now time.Time) string {
+	for n := 0; ; n++ {
+		name := collisionName(dest, "", now, n)
+		if _, err := fsys.Lstat(name); errors.Is(err, os.ErrNotExist) || n+1 >= maxCollisionNames {
+			return name
+		}
+	}
+}
+
+// placeUnique calls place with target and, for as long as place fails because the name it was
+// given is taken (os.ErrExist), with the collision names of dest+suffix one after the other. place
+// must never replace an existing file. It returns the name place succeeded with.
+func placeUnique(target, dest, suffix string, now time.Time, place func(target string) error) (string, error) {
+	err := place(target)
+	for n := 0; errors.Is(err, os.ErrExist) && n < maxCollisionNames; n++ {
+		if name := collisionName(dest, suffix, now, n); name != target {
+			target = name
+			err = place(target)
+		}
+	}
+	return target, err
+}
+
+// backupName returns <dest>.bak-<n> with the lowest n that is free.
+func backupName(fsys fsutil.FS, dest string) string {
+	for n := 1; ; n++ {
+		backup := fmt.Sprintf("%s.bak-%d", dest, n)
+		if _, err := fsys.Lstat(backup); errors.Is(err, os.ErrNotExist) {
+			return backup
+		}
+	}
+}
+
+// setAside makes room at dest for the winning version of a file by renaming the file there to
+// <name>.bak-<n>, with the lowest free n, by moving it to the trash or by deleting it, see loser.
+// It is journaled before the new file, so undo puts the new file back first and then the old one
+// under its name (except a deleted one).
+func (cfg Config) setAside(dest string, dryRun bool) error {
+	p := cfg.printer()
+	switch cfg.loser() {
+	case ConflictDelete:
+		if dryRun {
+			p.File(LevelNotice, "DRY RUN", "Would delete the existing '%s'", dest)
+			return nil
+		}
+		if err := cfg.fsys().Remove(dest); err != nil {
+			return fmt.Errorf("failed to delete '%s': %w", dest, err)
+		}
+		cfg.Journal.Record(journal.Entry{Op: journal.OpDelete, Source: dest})
+		p.File(LevelWarn, "DELETED", "Deleted the existing '%s'", filepath.Base(dest))
+		return nil
+	case ConflictTrash:
+		if dryRun {
+			p.File(LevelNotice, "DRY RUN", "Would move '%s' to the trash", dest)
+			return nil
+		}
+		trashed, err := trash.Move(dest)
+		if err != nil {
+			return fmt.Errorf("failed to move '%s' to the trash: %w", dest, err)
+		}
+		cfg.Journal.Record(journal.Entry{Op: journal.OpTrash, Source: dest, Dest: trashed})
+		p.File(LevelWarn, "TRASHED", "Moved the existing '%s' to the trash", filepath.Base(dest))
+		return nil
+	}
+
+	fsys := cfg.fsys()
+	for {
+		backup := backupName(fsys, dest)
+		if dryRun {
+			p.File(LevelNotice, "DRY RUN", "Would back up '%s' as '%s'", dest, filepath.Base(backup))
+			return nil
+		}
+		err := fsutil.RenameNoReplace(fsys, dest, backup)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to back up '%s': %w", dest, err)
+		}
+		cfg.persist(backup)
+		cfg.Journal.Record(journal.Entry{Op: journal.OpMove, Source: dest, Dest: backup})
+		p.File(LevelWarn, "BACKUP", "Backed up the existing '%s' as '%s'", filepath.Base(dest), filepath.Base(backup))
+		return nil
+	}
+}
+
+// discardSource trashes or deletes fm, which lost a conflict against the file at dest.
+func (cfg Config) discardSource(fm FileMove, dest string, progressChan chan<- ProgressUpdate) error {
+	fsys, p := cfg.fsys(), cfg.printer()
+	verb, done := "move to the trash", "moved to the trash"
+	if cfg.loser() == ConflictDelete {
+		verb, done = "delete", "deleted"
+	}
+	if fm.DryRun {
+		p.File(LevelNotice, "DRY RUN", "Would keep '%s' and %s '%s'", dest, verb, fm.SourcePath)
+		progressChan <- fm.discardedUpdate("")
+		return nil
+	}
+	if err := verifyUnchanged(fsys, fm); err != nil {
+		p.File(LevelWarn, "CHANGED", "%v. Skipping.", err)
+		progressChan <- fm.skippedUpdate(err)
+		return err
+	}
+	trashed := ""
+	if cfg.loser() == ConflictDelete {
+		if err := fsys.Remove(fm.SourcePath); err != nil {
+			err = fmt.Errorf("failed to delete '%s': %w", fm.SourcePath, err)
+			fm.reportFailure(p, err, progressChan)
+			return err
+		}
+		cfg.Journal.Record(journal.Entry{Op: journal.OpDelete, Source: fm.SourcePath, Size: fm.Info.Size()})
+	} else {
+		var err error
+		if trashed, err = trash.Move(fm.SourcePath); err != nil {
+			err = fmt.Errorf("failed to move '%s' to the trash: %w", fm.SourcePath, err)
+			fm.reportFailure(p, err, progressChan)
+			return err
+		}
+		cfg.Journal.Record(journal.Entry{Op: journal.OpTrash, Source: fm.SourcePath, Dest: trashed, Size: fm.Info.Size()})
+	}
+	p.File(LevelWarn, "DISCARDED", "Kept '%s', %s '%s'", dest, done, fm.SourcePath)
+	progressChan <- fm.discardedUpdate(trashed)
+	return nil
+}
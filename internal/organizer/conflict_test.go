@@ -0,0 +1,79 @@
+package organizer
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testConfig returns a quiet Config organizing source into dest.
+func testConfig(t *testing.T, source, dest string) Config {
+	t.Helper()
+	t.Setenv("ORG_CLI_DATA_DIR", t.TempDir())
+	return Config{
+		SourceDir:        source,
+		DestDir:          dest,
+		Workers:          2,
+		CategoryMappings: DefaultCategoryMappings(),
+		ArchiveFormat:    ArchiveFormatZip,
+		Printer:          PlainPrinter(io.Discard),
+	}
+}
+
+// writeFile creates the file at path, and its directory, with content.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollisionsKeepEveryFile(t *testing.T) {
+	source, dest := t.TempDir(), t.TempDir()
+	cfg := testConfig(t, source, dest)
+	cfg.Clock = func() time.Time { return time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	// With a fixed clock every collision gets the same timestamp
+	for _, content := range []string{"run 1", "run 2", "run 3"} {
+		writeFile(t, filepath.Join(source, "a.txt"), content)
+		if _, err := OrganizeFiles(cfg, nil); err != nil {
+			t.Fatalf("run with %q: %v", content, err)
+		}
+	}
+	want := map[string]string{
+		"a.txt":                   "run 1",
+		"a_20000101_000000.txt":   "run 2",
+		"a_20000101_000000_2.txt": "run 3",
+	}
+	for name, content := range want {
+		data, err := os.ReadFile(filepath.Join(dest, "Documents", name))
+		if err != nil || string(data) != content {
+			t.Errorf("%s = %q, %v; want %q", name, data, err, content)
+		}
+	}
+}
+
+func TestCollisionsWithinRun(t *testing.T) {
+	source, dest := t.TempDir(), t.TempDir()
+	cfg := testConfig(t, source, dest)
+	cfg.Recursive, cfg.Workers = true, 3
+	for _, dir := range []string{"x", "y", "z"} {
+		writeFile(t, filepath.Join(source, dir, "a.txt"), dir)
+	}
+	result, err := OrganizeFiles(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(filepath.Join(dest, "Documents"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Processed != 3 || len(entries) != 3 {
+		t.Errorf("processed %d files into %d names, want 3 into 3", result.Processed, len(entries))
+	}
+}
@@ -0,0 +1,90 @@
+package organizer
+
+import "sync"
+
+// Controller lets another goroutine (a signal handler, the control API, ...) pause, resume or
+// stop a running OrganizeFiles call. Pausing and stopping prevent new files from being
+// dispatched to the workers; files already being moved are finished.
+type Controller struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	paused  bool
+	stopped bool
+	stopCh  chan struct{} // Closed by Stop
+}
+
+// NewController returns a Controller in the running state.
+func NewController() *Controller {
+	c := &Controller{stopCh: make(chan struct{})}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Pause stops dispatching new files until Resume is called.
+func (c *Controller) Pause() {
+	c.mu.Lock()
+	c.paused = true
+	c.mu.Unlock()
+}
+
+// Resume continues dispatching after Pause.
+func (c *Controller) Resume() {
+	c.mu.Lock()
+	c.paused = false
+	c.mu.Unlock()
+	c.cond.Broadcast()
+}
+
+// Stop ends dispatching for good, e.g. on shutdown. Files that were not dispatched yet are left
+// in place. A stopped controller cannot be resumed.
+func (c *Controller) Stop() {
+	c.mu.Lock()
+	if !c.stopped {
+		close(c.stopCh)
+	}
+	c.stopped = true
+	c.mu.Unlock()
+	c.cond.Broadcast()
+}
+
+// Stopped reports whether Stop has been called.
+func (c *Controller) Stopped() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stopped
+}
+
+// Paused reports whether dispatching is currently paused.
+func (c *Controller) Paused() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// wait blocks while the controller is paused and reports whether dispatching may continue.
+// A nil controller never blocks.
+func (c *Controller) wait() bool {
+	if c == nil {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.paused && !c.stopped {
+		c.cond.Wait()
+	}
+	return !c.stopped
+}
+
+// done returns a channel that is closed once Stop is called. That of a nil controller never is.
+func (c *Controller) done() <-chan struct{} {
+	if c == nil {
+		return nil
+	}
+	return c.stopCh
+}
@@ -0,0 +1,102 @@
+package organizer
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// copyProgressEvery is how often a copy reports the bytes copied so far.
+const copyProgressEvery = time.Second
+
+// copyAttempts is how often copyMove starts a copy over after a transient network error.
+const copyAttempts = 3
+
+// copyMove moves the source of fm to dest by copying and deleting, for a rename that cannot work:
+// across file systems, or across the exports or shares of a network file system. The copy reports
+// its progress while it runs, is written under a staged name and moved into place once it is synced
+// and checked against the size of the source, and is started over when the network drops out in
+// the middle. The source is removed last. action is what the move is, for the events of the copy.
+func (cfg Config) copyMove(fm FileMove, dest string, action Action, progressChan chan<- ProgressUpdate) error {
+	fsys := cfg.fsys()
+	var out *stagedFile
+	var err error
+	for attempt := 1; attempt <= copyAttempts; attempt++ {
+		if out, err = cfg.copyChunked(fm, filepath.Dir(dest), action, progressChan); err == nil || !fsutil.IsTransient(err) {
+			break
+		}
+		cfg.printer().File(LevelWarn, "RETRY", "Copying '%s' failed (%v), starting over.", fm.SourcePath, err)
+		time.Sleep(time.Duration(attempt) * 2 * time.Second)
+	}
+	if err != nil {
+		return err
+	}
+	fsys.Chtimes(out.path, fm.Info.ModTime(), fm.Info.ModTime())
+	cfg.copied(fm.SourcePath, out.path)
+	if err := out.commit(dest); err != nil {
+		return err
+	}
+	cfg.persist(dest)
+	if err := fsys.Remove(fm.SourcePath); err != nil {
+		fsys.Remove(dest) // Keep the original as the only copy
+		return fmt.Errorf("failed to remove '%s' after copying it: %w", fm.SourcePath, err)
+	}
+	return nil
+}
+
+// copyChunked makes one attempt at copying the source of fm to a staged file in dir, and returns
+// it complete and closed. Nothing is left behind if it fails.
+func (cfg Config) copyChunked(fm FileMove, dir string, action Action, progressChan chan<- ProgressUpdate) (*stagedFile, error) {
+	fsys := cfg.fsys()
+	out, err := stage(fsys, dir, fm.Info.Mode().Perm())
+	if err != nil {
+		return nil, err
+	}
+	out.watch = fm.watch
+	w, hashed := cfg.teeHash(out, fm.SourcePath, fm.Info)
+	w = &progressWriter{w: w, report: func(n int64) {
+		progressChan <- ProgressUpdate{Worker: fm.worker, Copied: n}
+	}}
+	err = copyFileInto(fsys, w, fm.SourcePath)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		progressChan <- fm.eventUpdate(PhaseCopied, action)
+		err = out.verify(fm.Info.Size())
+	}
+	if err != nil {
+		out.abort()
+		return nil, err
+	}
+	hashed()
+	progressChan <- fm.eventUpdate(PhaseVerified, action)
+	return out, nil
+}
+
+// progressWriter passes writes on to w and reports the bytes written so far every
+// copyProgressEvery.
+type progressWriter struct {
+	w       io.Writer
+	report  func(written int64)
+	written int64
+	last    time.Time
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	if now := time.Now(); now.Sub(p.last) >= copyProgressEvery {
+		if !p.last.IsZero() {
+			p.report(p.written)
+		}
+		p.last = now
+	}
+	return n, err
+}
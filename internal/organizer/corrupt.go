@@ -0,0 +1,110 @@
+package organizer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// What happens to empty and damaged files, for Config.Corrupt.
+const (
+	CorruptMove = "move" // Into CorruptFolder below the destination, to look at
+	CorruptSkip = "skip" // Left in place
+)
+
+// ValidCorrupt reports whether policy is one of the supported policies for damaged files.
+func ValidCorrupt(policy string) bool {
+	return policy == CorruptMove || policy == CorruptSkip
+}
+
+// CorruptFolder is the folder below the destination that CorruptMove puts empty and damaged
+// files into, instead of their category folder.
+const CorruptFolder = "Corrupt"
+
+// ErrCorrupt is the reason of files left in place with CorruptSkip.
+var ErrCorrupt = errors.New("corrupt")
+
+// checkedMedia are the extensions whose files damage checks, with what they should be.
+var checkedMedia = map[string]string{
+	".jpg": "JPEG", ".jpeg": "JPEG", ".png": "PNG", ".gif": "GIF",
+	".mp4": "video", ".m4v": "video", ".mov": "video", ".3gp": "video", ".m4a": "audio",
+	".mkv": "video", ".webm": "video",
+}
+
+// damageWindow is how much of the start and of the end of a file damage reads.
+const damageWindow = 64 << 10
+
+// damage returns what is wrong with the file at path on fsys described by info, "empty" or
+// "truncated JPEG", or "" if nothing is. Besides empty files it finds photos and videos that are
+// cut off or aren't what their extension says, going by the structure at their start and their
+// end only: a file that passes may still not decode.
+func damage(fsys fsutil.FS, path string, info fs.FileInfo) string {
+	size := info.Size()
+	if size == 0 {
+		return "empty"
+	}
+	kind, ok := checkedMedia[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return ""
+	}
+	f, err := fsys.Open(path)
+	if err != nil {
+		return "" // Whatever keeps it from being read shows when it is moved
+	}
+	defer f.Close()
+	head := make([]byte, min(size, damageWindow))
+	if _, err := io.ReadFull(f, head); err != nil {
+		return ""
+	}
+	tail := head
+	if size > damageWindow {
+		tail = make([]byte, damageWindow)
+		if _, err := f.ReadAt(tail, size-damageWindow); err != nil {
+			return ""
+		}
+	}
+
+	switch {
+	case bytes.HasPrefix(head, []byte{0xFF, 0xD8, 0xFF}):
+		// Motion photos carry their video after the end of the image, where it cannot be told
+		// from garbage; the end of the image is in the last bytes of others
+		if !bytes.Contains(tail, []byte{0xFF, 0xD9}) && !bytes.Contains(head, []byte("MotionPhoto")) && !bytes.Contains(head, []byte("MicroVideo")) {
+			return "truncated JPEG"
+		}
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		if !bytes.Contains(tail, []byte("IEND")) {
+			return "truncated PNG"
+		}
+	case bytes.HasPrefix(head, []byte("GIF87a")) || bytes.HasPrefix(head, []byte("GIF89a")):
+		if !bytes.HasSuffix(bytes.TrimRight(tail, "\x00"), []byte{0x3B}) {
+			return "truncated GIF"
+		}
+	case len(head) >= 12 && isMovie(head):
+		if !movieComplete(f, size) {
+			return "truncated " + kind
+		}
+	case bytes.HasPrefix(head, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		// Matroska and WebM, which do not tell where they end
+	case bytes.HasPrefix(head, []byte("RIFF")), bytes.HasPrefix(head, []byte("II*\x00")), bytes.HasPrefix(head, []byte("MM\x00*")):
+		// WebP and TIFF based raw files with the wrong extension are still intact
+	default:
+		return "not a valid " + kind
+	}
+	return ""
+}
+
+// movieComplete reports whether the top-level boxes of the MP4 or QuickTime file r of size bytes
+// fill it exactly and include the movie box, without which the media data cannot be played.
+func movieComplete(r io.ReaderAt, size int64) bool {
+	end, moov := int64(0), false
+	eachBox(r, 0, size, func(typ string, off, boxSize int64) bool {
+		end, moov = off+boxSize, moov || typ == "moov"
+		return true
+	})
+	return moov && end == size
+}
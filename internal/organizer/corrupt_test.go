@@ -0,0 +1,39 @@
+package organizer
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+func TestDamage(t *testing.T) {
+	dir := t.TempDir()
+	cut := filepath.Join(dir, "cut.jpg")
+	writeFile(t, cut, "\xFF\xD8\xFF\xE0 no end of image")
+	empty := filepath.Join(dir, "empty.txt")
+	writeFile(t, empty, "")
+	whole := filepath.Join(dir, "whole.jpg")
+	writeFile(t, whole, "\xFF\xD8\xFF\xE0 image\xFF\xD9")
+
+	for _, tc := range []struct {
+		fsys fsutil.FS
+		path string
+		want string
+	}{
+		{fsutil.OS, cut, "truncated JPEG"},
+		{fsutil.OS, empty, "empty"},
+		{fsutil.OS, whole, ""},
+		{failing(dir, syscall.EACCES, "open"), cut, ""}, // Read through the given file system
+	} {
+		info, err := os.Stat(tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := damage(tc.fsys, tc.path, info); got != tc.want {
+			t.Errorf("damage(%s) = %q, want %q", filepath.Base(tc.path), got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package organizer
+
+import "io/fs"
+
+// deviceOf always returns false where devices cannot be told apart; files are then renamed and
+// copied only when the rename fails.
+func deviceOf(info fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+// inodeOf always returns false where files have no inode to tell them apart by.
+func inodeOf(info fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
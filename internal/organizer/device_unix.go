@@ -0,0 +1,27 @@
+//go:build unix
+
+package organizer
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// deviceOf returns the device info is on, and false if it cannot be told.
+func deviceOf(info fs.FileInfo) (uint64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Dev), true
+}
+
+// inodeOf returns the inode of info, and false if it cannot be told. With deviceOf it tells a
+// file apart from one that replaced it under the same name.
+func inodeOf(info fs.FileInfo) (uint64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Ino), true
+}
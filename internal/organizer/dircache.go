@@ -0,0 +1,72 @@
+package organizer
+
+import (
+	"os"
+	"sync"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// dirCache remembers the destination directories of a run that are known to exist, so that of
+// thousands of files going to the same folder only the first one has it looked up, and created,
+// and workers reaching a new folder at the same time create it once. It lives for one run: the
+// run never removes a destination directory before its files are all placed.
+type dirCache struct {
+	mu   sync.Mutex
+	dirs map[string]*dirEntry
+}
+
+// dirEntry is the lookup of a directory, done once.
+type dirEntry struct {
+	done    chan struct{} // Closed once created and err are set
+	created bool          // The directory was missing; created unless the run is a dry run
+	err     error
+}
+
+func newDirCache() *dirCache {
+	return &dirCache{dirs: make(map[string]*dirEntry)}
+}
+
+// ensure makes sure dir exists, creating it and its parents if needed, and reports whether it was
+// missing. With dryRun nothing is created and dir is taken to exist from then on. Only the first
+// call for a directory touches fsys and reports it missing; calls for it while that one runs wait
+// for its outcome. Failures are not remembered, the next call tries again. A nil cache looks dir
+// up every time.
+func (c *dirCache) ensure(fsys fsutil.FS, dir string, dryRun bool) (bool, error) {
+	if c == nil {
+		return makeDir(fsys, dir, dryRun)
+	}
+	c.mu.Lock()
+	entry, ok := c.dirs[dir]
+	if ok {
+		c.mu.Unlock()
+		<-entry.done
+		return false, entry.err
+	}
+	entry = &dirEntry{done: make(chan struct{})}
+	c.dirs[dir] = entry
+	c.mu.Unlock()
+
+	entry.created, entry.err = makeDir(fsys, dir, dryRun)
+	if entry.err != nil {
+		c.mu.Lock()
+		delete(c.dirs, dir)
+		c.mu.Unlock()
+	}
+	close(entry.done)
+	return entry.created, entry.err
+}
+
+// makeDir creates dir and its parents if it does not exist, unless dryRun, and reports whether it
+// was missing.
+func makeDir(fsys fsutil.FS, dir string, dryRun bool) (bool, error) {
+	_, err := fsys.Stat(dir)
+	if err == nil {
+		return false, nil
+	}
+	missing := os.IsNotExist(err)
+	if dryRun {
+		return missing, nil
+	}
+	return missing, fsys.MkdirAll(dir, 0755)
+}
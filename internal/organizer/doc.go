@@ -0,0 +1,12 @@
+// Package organizer is the engine behind the organizer CLI: it scans a source, classifies files
+// into categories and moves, copies, compresses, encrypts, uploads or archives them. It is the
+// only implementation; the CLI, the daemon and the subcommands all go through it, so new features
+// are implemented here once.
+//
+// New code should use New with functional options and the Organizer methods; OrganizeFiles and
+// Undo remain for callers that build a Config themselves.
+package organizer
+
+// APIVersion is the version of the Organizer API. It is increased on incompatible changes to
+// New, its options or the Organizer methods.
+const APIVersion = 3
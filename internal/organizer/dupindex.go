@@ -0,0 +1,301 @@
+package organizer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+	"github.com/avizyt/org-cli/internal/journal"
+)
+
+// IndexFile is the name of the duplicate index Config.Dedupe keeps in the root of the destination.
+const IndexFile = ".organizer-index"
+
+// What happens to files whose content is somewhere in the destination already, for Config.Dedupe.
+const (
+	DedupeSkip = "skip" // Left in place, as duplicates
+	DedupeLink = "link" // A hard link to the stored copy takes their place in the destination, and they are removed
+)
+
+// ValidDedupe reports whether policy is one of the supported duplicate policies.
+func ValidDedupe(policy string) bool {
+	return policy == DedupeSkip || policy == DedupeLink
+}
+
+// indexHeader starts the index file, followed by the checksum algorithm.
+const indexHeader = "# org-cli duplicate index: "
+
+// dupIndex knows the files below the destination by size, and the checksums of those that were
+// needed so far. Checksums are computed only for files of the size of an incoming file, and kept
+// in IndexFile for the next run as long as the size and modification time of the file hold.
+type dupIndex struct {
+	mu     sync.Mutex
+	fsys   fsutil.FS
+	path   string
+	alg    string
+	files  map[string]*indexEntry // Slash separated path relative to the destination -> entry
+	bySize map[int64][]string
+	dirty  bool
+}
+
+type indexEntry struct {
+	size    int64
+	modTime time.Time
+	sum     []byte // nil until needed
+}
+
+// loadIndex reads the index of cfg.DestDir and brings it up to date with the files there. It
+// reports how many files it found and how many checksums it could keep.
+func loadIndex(cfg Config) (x *dupIndex, files, kept int, err error) {
+	x = &dupIndex{
+		fsys:   cfg.fsys(),
+		path:   filepath.Join(cfg.DestDir, IndexFile),
+		alg:    cfg.hashes.algorithm(),
+		files:  make(map[string]*indexEntry),
+		bySize: make(map[int64][]string),
+	}
+	saved, err := readIndex(x.fsys, x.path, x.alg)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		cfg.printer().Status(LevelWarn, "⚠️", "Could not read %s, rebuilding it: %v", x.path, err)
+	}
+	err = x.fsys.WalkDir(cfg.DestDir, func(path string, d fs.DirEntry, err error) error {
+		switch {
+		case errors.Is(err, fs.ErrNotExist) && path == cfg.DestDir:
+			return fs.SkipAll // Nothing stored yet
+		case err != nil:
+			return err
+		case strings.HasPrefix(d.Name(), ".") && path != cfg.DestDir:
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		case d.IsDir() && d.Name() == ReviewDir && filepath.Dir(path) == cfg.DestDir:
+			return fs.SkipDir // Staged files are not stored yet
+		case !d.Type().IsRegular():
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // Gone since it was listed
+		}
+		rel, err := filepath.Rel(cfg.DestDir, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		e := &indexEntry{size: info.Size(), modTime: info.ModTime()}
+		if old, ok := saved[rel]; ok && old.size == e.size && old.modTime.Equal(e.modTime) {
+			e.sum = old.sum
+			kept++
+		}
+		x.files[rel] = e
+		x.bySize[e.size] = append(x.bySize[e.size], rel)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to index '%s': %w", cfg.DestDir, err)
+	}
+	x.dirty = kept != len(saved)
+	return x, len(x.files), kept, nil
+}
+
+// readIndex reads the checksums of the index file at path on fsys, if it is of the algorithm alg.
+func readIndex(fsys fsutil.FS, path, alg string) (map[string]*indexEntry, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() || scanner.Text() != indexHeader+alg {
+		return nil, scanner.Err() // Another algorithm: the checksums are of no use
+	}
+	entries := make(map[string]*indexEntry)
+	for line := 2; scanner.Scan(); line++ {
+		fields := strings.SplitN(scanner.Text(), "\t", 4)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("%s:%d: malformed entry", path, line)
+		}
+		sum, err := hex.DecodeString(fields[0])
+		size, sizeErr := strconv.ParseInt(fields[1], 10, 64)
+		nanos, timeErr := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil || sizeErr != nil || timeErr != nil {
+			return nil, fmt.Errorf("%s:%d: malformed entry", path, line)
+		}
+		entries[fields[3]] = &indexEntry{size: size, modTime: time.Unix(0, nanos), sum: sum}
+	}
+	return entries, scanner.Err()
+}
+
+// find returns the path of a file in the destination with the content of fm, or "" if there is
+// none. Empty files are never duplicates.
+func (x *dupIndex) find(cfg Config, fm FileMove) (string, error) {
+	size := fm.Info.Size()
+	if x == nil || size == 0 {
+		return "", nil
+	}
+	x.mu.Lock()
+	candidates := slices.Clone(x.bySize[size])
+	x.mu.Unlock()
+	if len(candidates) == 0 {
+		return "", nil
+	}
+	fsys := cfg.fsys()
+	sum, err := cfg.hashes.sum(fsys, fm.SourcePath, fm.Info, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to checksum '%s': %w", fm.SourcePath, err)
+	}
+	for _, rel := range candidates {
+		path := filepath.Join(cfg.DestDir, filepath.FromSlash(rel))
+		info, err := fsys.Stat(path)
+		if err != nil || fsys.SameFile(info, fm.Info) {
+			continue // Gone, or the file itself when the source is in the destination
+		}
+		x.mu.Lock()
+		var candidate []byte
+		if e := x.files[rel]; e != nil && e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+			candidate = e.sum
+		}
+		x.mu.Unlock()
+		if candidate == nil {
+			if candidate, err = cfg.hashes.sum(fsys, path, info, ""); err != nil {
+				continue
+			}
+			x.mu.Lock()
+			if e := x.files[rel]; e != nil {
+				e.size, e.modTime, e.sum = info.Size(), info.ModTime(), candidate
+				x.dirty = true
+			}
+			x.mu.Unlock()
+		}
+		if bytes.Equal(candidate, sum) {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// add indexes the file just stored at dest from source, so later files of the run find it too.
+// Files staged for review are left out until they are filed.
+func (x *dupIndex) add(cfg Config, source, dest, category string) {
+	if x == nil || category == ReviewDir {
+		return
+	}
+	info, err := cfg.fsys().Stat(dest)
+	if err != nil {
+		return
+	}
+	rel, err := filepath.Rel(cfg.DestDir, dest)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return
+	}
+	rel = filepath.ToSlash(rel)
+	e := &indexEntry{size: info.Size(), modTime: info.ModTime()}
+	if known := cfg.hashes.known(source, info); known != "" {
+		e.sum, _ = hex.DecodeString(known[len(x.alg)+1:])
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if _, ok := x.files[rel]; !ok {
+		x.bySize[e.size] = append(x.bySize[e.size], rel)
+	}
+	x.files[rel] = e
+	x.dirty = true
+}
+
+// save writes the checksums of the index to its file, if they changed.
+func (x *dupIndex) save() error {
+	if x == nil || !x.dirty {
+		return nil
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var b strings.Builder
+	b.WriteString(indexHeader + x.alg + "\n")
+	for _, rel := range slices.Sorted(maps.Keys(x.files)) {
+		if e := x.files[rel]; e.sum != nil {
+			fmt.Fprintf(&b, "%x\t%d\t%d\t%s\n", e.sum, e.size, e.modTime.UnixNano(), rel)
+		}
+	}
+	tmp, err := stage(x.fsys, filepath.Dir(x.path), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", x.path, err)
+	}
+	_, err = io.WriteString(tmp, b.String())
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = x.fsys.Rename(tmp.path, x.path)
+	}
+	if err != nil {
+		tmp.abort()
+		return fmt.Errorf("failed to write %s: %w", x.path, err)
+	}
+	x.dirty = false
+	return nil
+}
+
+// storeDuplicate handles fm, whose content is at dup in the destination already, by cfg.Dedupe:
+// it is left in place, or a hard link to dup takes its place in the destination. In move mode
+// the source is removed then; undo puts the link back in its place.
+func (cfg Config) storeDuplicate(fm FileMove, dup string, progressChan chan<- ProgressUpdate) error {
+	fsys, p := cfg.fsys(), cfg.printer()
+	if cfg.Dedupe == DedupeSkip {
+		return cfg.skipDuplicate(fm, dup, progressChan)
+	}
+	if fm.DryRun {
+		p.File(LevelNotice, "DRY RUN", "Would link '%s' at '%s', a duplicate of '%s'", fm.SourcePath, fm.DestPath, dup)
+		progressChan <- fm.movedUpdate(ActionLink, fm.DestPath)
+		return nil
+	}
+	fail := func(err error) error {
+		fm.reportFailure(p, err, progressChan)
+		return err
+	}
+	if err := verifyUnchanged(fsys, fm); err != nil {
+		p.File(LevelWarn, "CHANGED", "%v. Skipping.", err)
+		progressChan <- fm.skippedUpdate(err)
+		return err
+	}
+	destDir := filepath.Dir(fm.DestPath)
+	if _, err := cfg.dirs.ensure(fsys, destDir, false); err != nil {
+		return fail(fmt.Errorf("failed to create destination directory '%s': %w", destDir, err))
+	}
+	finalDestPath, err := placeUnique(fm.DestPath, fm.DestPath, "", cfg.now(), func(target string) error {
+		return fsys.Link(dup, target)
+	})
+	if finalDestPath != fm.DestPath {
+		p.File(LevelWarn, "COLLISION", "Renaming '%s' to '%s'", filepath.Base(fm.DestPath), filepath.Base(finalDestPath))
+	}
+	if err != nil {
+		return fail(fmt.Errorf("failed to link '%s' at '%s': %w", dup, finalDestPath, err))
+	}
+	cfg.persist(finalDestPath)
+	entry := journal.Entry{Op: journal.OpLink, Source: fm.SourcePath, Dest: finalDestPath, Size: fm.Info.Size(), Hash: cfg.hashes.known(fm.SourcePath, fm.Info)}
+	if !cfg.Sync && cfg.Mode != ModeHardlink {
+		if err := fsys.Remove(fm.SourcePath); err != nil {
+			fsys.Remove(finalDestPath)
+			return fail(fmt.Errorf("failed to remove '%s', a duplicate of '%s': %w", fm.SourcePath, dup, err))
+		}
+		cfg.persist(filepath.Dir(fm.SourcePath))
+		entry.Op = journal.OpMove // Undo moves the link back, which has the content of the source
+	}
+	cfg.Journal.Record(entry)
+	p.File(LevelSuccess, "LINKED", "Linked '%s' at '%s', a duplicate of '%s'", fm.SourcePath, finalDestPath, dup)
+	cfg.fileStored(fm.SourcePath, finalDestPath, fm.Category, progressChan)
+	progressChan <- fm.movedUpdate(ActionLink, finalDestPath)
+	return nil
+}
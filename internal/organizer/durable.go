@@ -0,0 +1,34 @@
+package organizer
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// persist flushes a file just placed at dst to disk when Fsync is set: dst itself, then every
+// directory from its own up to the destination root, any of which may have been created for it.
+// Finally the directories in removed are flushed, where a moved file was taken from. A failed
+// flush is reported as a warning; the file is in place, it is only not known to be on disk yet.
+func (cfg Config) persist(dst string, removed ...string) {
+	if !cfg.Fsync {
+		return
+	}
+	fsys := cfg.fsys()
+	paths := []string{dst}
+	root := filepath.Clean(cfg.DestDir)
+	for dir := filepath.Dir(dst); ; dir = filepath.Dir(dir) {
+		paths = append(paths, dir)
+		if dir == root || !strings.HasPrefix(dir, root+string(filepath.Separator)) {
+			break
+		}
+	}
+	paths = append(paths, removed...)
+	for _, path := range paths {
+		if err := fsutil.SyncPath(fsys, path); err != nil {
+			cfg.printer().File(LevelWarn, "WARNING", "Could not flush '%s' to disk: %v", path, err)
+			return
+		}
+	}
+}
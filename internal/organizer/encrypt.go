@@ -0,0 +1,72 @@
+package organizer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// IdentityEnv names the environment variable holding the path of the age identity file used to
+// decrypt files again on undo.
+const IdentityEnv = "ORG_CLI_AGE_IDENTITY"
+
+// encryptionSuffix is appended to the names of encrypted files.
+const encryptionSuffix = ".age"
+
+// ParseEncryptWith parses the value of --encrypt-with. The only supported scheme is
+// "age:<recipient>", where the recipient is an age public key (age1...), several of them
+// separated by commas, or the path of a recipients file with one key per line.
+func ParseEncryptWith(spec string) ([]age.Recipient, error) {
+	scheme, value, ok := strings.Cut(spec, ":")
+	if !ok || scheme != "age" || value == "" {
+		return nil, configError("--encrypt-with", fmt.Errorf("invalid encryption '%s' (use age:<recipient>)", spec))
+	}
+
+	if strings.HasPrefix(value, "age1") {
+		var recipients []age.Recipient
+		for _, key := range strings.Split(value, ",") {
+			r, err := age.ParseX25519Recipient(strings.TrimSpace(key))
+			if err != nil {
+				return nil, configError("--encrypt-with", fmt.Errorf("invalid age recipient '%s': %w", key, err))
+			}
+			recipients = append(recipients, r)
+		}
+		return recipients, nil
+	}
+
+	f, err := os.Open(value)
+	if err != nil {
+		return nil, configError("--encrypt-with", fmt.Errorf("failed to open recipients file '%s': %w", value, err))
+	}
+	defer f.Close()
+	recipients, err := age.ParseRecipients(f)
+	if err != nil {
+		return nil, configError("--encrypt-with", fmt.Errorf("failed to parse recipients file '%s': %w", value, err))
+	}
+	return recipients, nil
+}
+
+// LoadIdentities reads the age identities (private keys) from the file at path, as written by
+// age-keygen.
+func LoadIdentities(path string) ([]age.Identity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open identity file '%s': %w", path, err)
+	}
+	defer f.Close()
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse identity file '%s': %w", path, err)
+	}
+	return identities, nil
+}
+
+// shouldEncrypt reports whether fm is stored encrypted in the destination.
+func (cfg Config) shouldEncrypt(fm FileMove) bool {
+	if len(cfg.EncryptTo) == 0 {
+		return false
+	}
+	return len(cfg.EncryptCategories) == 0 || matchesAnyName(fm.Category, cfg.EncryptCategories)
+}
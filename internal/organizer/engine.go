@@ -0,0 +1,796 @@
+package organizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"filippo.io/age"
+	"github.com/avizyt/org-cli/internal/fsutil"
+	"github.com/avizyt/org-cli/internal/journal"
+)
+
+// Organizer is the file organizing engine. It is built with New and functional options:
+//
+//	org, err := organizer.New(
+//		organizer.WithSource("/home/me/Downloads"),
+//		organizer.WithDest("/home/me/Sorted"),
+//		organizer.WithWorkers(8),
+//	)
+//	moves, err := org.Plan(ctx)   // What the scan finds to organize, as it finds it; read to the end or cancel ctx
+//	plan, err := org.DryRun(ctx)  // What would happen
+//	result, err := org.Run(ctx)   // Do it
+//
+// New capabilities get a new option instead of another positional parameter.
+type Organizer struct {
+	cfg        Config
+	progress   chan<- ProgressUpdate
+	identities []age.Identity
+}
+
+// Option configures an Organizer.
+type Option func(*Organizer) error
+
+// New returns an Organizer configured by opts. Without options for them it uses 5 workers, the
+// default category mappings, zip archives and DefaultFileRetries. Plan and Run validate the configuration and report
+// invalid settings as *ConfigError.
+func New(opts ...Option) (*Organizer, error) {
+	o := &Organizer{cfg: Config{
+		Workers:          5,
+		CategoryMappings: DefaultCategoryMappings(),
+		ArchiveFormat:    ArchiveFormatZip,
+		FileRetries:      DefaultFileRetries,
+	}}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// Config returns a copy of the configuration the Organizer runs with.
+func (o *Organizer) Config() Config {
+	return o.cfg
+}
+
+// Plan scans the source without touching any file and sends every move the scan plans to the
+// returned channel as soon as the file is classified, so that a large source can be shown and
+// filtered while the scan goes on. The channel is closed once the scan is done or ctx is
+// cancelled. If the scan fails partway (an unreadable folder with Config.Unreadable set to fail,
+// a walk error, a cancelled ctx), the last move sent carries nothing but the error in Err, so a
+// plan cut short can be told from a complete one. The scan waits for the moves to be read: a
+// caller that stops reading before the channel is closed must cancel ctx, or the scan blocks for
+// good. The destinations are those before collision renaming, and the limits applied after the
+// scan (MaxFiles, MaxBytes, quotas, ...) are not; skipped files and scan errors are reported
+// through WithProgress like in Run. DryRun reports the full outcome of every file instead, once
+// the whole source has been scanned. Archives as source cannot be planned this way.
+func (o *Organizer) Plan(ctx context.Context) (<-chan PlannedMove, error) {
+	cfg := o.cfg
+	cfg.DryRun = true
+	cfg.Journal = nil
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if IsArchiveSource(cfg.SourceDir) {
+		return nil, configError("--source", errors.New("the plan of an archive cannot be streamed, use DryRun"))
+	}
+	if _, err := cfg.fsys().Stat(cfg.SourceDir); err != nil {
+		return nil, &ScanError{Path: cfg.SourceDir, Err: err}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Join(ErrAborted, err)
+	}
+	moves := make(chan PlannedMove, 64)
+	cfg.planned = func(fm FileMove) error {
+		select {
+		case moves <- fm.planned():
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	go func() {
+		defer close(moves)
+		if _, err := o.run(ctx, cfg); err != nil {
+			failed := PlannedMove{Err: err}
+			select {
+			case moves <- failed: // Room left, or read right away
+			default:
+				select {
+				case moves <- failed:
+				case <-ctx.Done(): // Nobody reads any more
+				}
+			}
+		}
+	}()
+	return moves, nil
+}
+
+// DryRun scans the source and reports what Run would do, without touching any file.
+func (o *Organizer) DryRun(ctx context.Context) (Result, error) {
+	cfg := o.cfg
+	cfg.DryRun = true
+	cfg.Journal = nil
+	return o.run(ctx, cfg)
+}
+
+// Run organizes the source. Cancelling ctx stops dispatching new files; the files being moved
+// are finished and Run returns ErrAborted.
+func (o *Organizer) Run(ctx context.Context) (Result, error) {
+	return o.run(ctx, o.cfg)
+}
+
+func (o *Organizer) run(ctx context.Context, cfg Config) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, errors.Join(ErrAborted, err)
+	}
+	if cfg.Control == nil {
+		cfg.Control = NewController()
+	}
+	stop := context.AfterFunc(ctx, cfg.Control.Stop)
+	defer stop()
+	return OrganizeFiles(cfg, o.progress)
+}
+
+// Undo reverts the journaled operations of a run (see journal.Load), most recent first, and records
+// each reverted operation in the journal given with WithJournal so that Redo can replay it.
+// Cancelling ctx stops before the next operation. It returns how many operations were reverted and
+// how many failed.
+func (o *Organizer) Undo(ctx context.Context, entries []journal.Entry) (undone int, failed int, err error) {
+	return undo(ctx, o.cfg.fsys(), entries, o.identities, o.cfg.DryRun, o.cfg.Journal, o.cfg.printer())
+}
+
+// Redo replays the operations Undo reverted, as recorded in its journal, in the order they were
+// first done, without scanning the source again. The replayed operations are recorded in the
+// journal given with WithJournal, so the redo can be undone in turn. Files moved with encryption
+// are encrypted to the recipients given with WithEncryption. Cancelling ctx stops before the next
+// operation. It returns how many operations were replayed and how many failed.
+func (o *Organizer) Redo(ctx context.Context, entries []journal.Entry) (redone int, failed int, err error) {
+	return redo(ctx, o.cfg.fsys(), entries, o.cfg.EncryptTo, o.cfg.DryRun, o.cfg.Journal, o.cfg.printer())
+}
+
+// WithConfig replaces the whole configuration, for callers that build a Config themselves.
+func WithConfig(cfg Config) Option {
+	return func(o *Organizer) error {
+		o.cfg = cfg
+		return nil
+	}
+}
+
+// WithSource sets the directory (or archive) to organize.
+func WithSource(dir string) Option {
+	return func(o *Organizer) error {
+		o.cfg.SourceDir = dir
+		return nil
+	}
+}
+
+// WithDest sets the local directory the categories are created in. A WebDAV, S3 or SFTP URL
+// (see IsRemoteDest) makes the run upload to that remote destination instead, as WithRemote.
+func WithDest(dir string) Option {
+	return func(o *Organizer) error {
+		if IsRemoteDest(dir) {
+			remote, err := NewRemote(dir)
+			if err != nil {
+				return configError("--dest", err)
+			}
+			return WithRemote(remote)(o)
+		}
+		o.cfg.DestDir = dir
+		return nil
+	}
+}
+
+// WithRemote uploads to a WebDAV, S3 or SFTP destination created with NewRemote instead of a
+// local one.
+func WithRemote(remote Remote) Option {
+	return func(o *Organizer) error {
+		o.cfg.Remote = remote
+		o.cfg.DestDir = remote.String()
+		return nil
+	}
+}
+
+// WithWebDAV uploads to a WebDAV server instead of a local destination.
+func WithWebDAV(client *WebDAVClient) Option {
+	return WithRemote(client)
+}
+
+// WithDryRun only reports what would happen. Run then behaves like Plan.
+func WithDryRun(dryRun bool) Option {
+	return func(o *Organizer) error {
+		o.cfg.DryRun = dryRun
+		return nil
+	}
+}
+
+// WithRecursive also organizes files in subdirectories of the source.
+func WithRecursive(recursive bool) Option {
+	return func(o *Organizer) error {
+		o.cfg.Recursive = recursive
+		return nil
+	}
+}
+
+// WithFiles organizes only the given files instead of walking the source. Files outside the
+// source are skipped.
+func WithFiles(paths ...string) Option {
+	return func(o *Organizer) error {
+		o.cfg.Files = paths
+		return nil
+	}
+}
+
+// WithWorkers sets the number of concurrent file operations. 0 picks a number for the CPUs and
+// the storage (see AutoWorkers) and lowers it while latency spikes during the run.
+func WithWorkers(n int) Option {
+	return func(o *Organizer) error {
+		if n < 0 {
+			return configError("--workers", errors.New("must not be negative"))
+		}
+		o.cfg.Workers = n
+		return nil
+	}
+}
+
+// WithOrder dispatches the files to the workers in order, one of OrderName, OrderSizeAsc,
+// OrderSizeDesc or OrderMtime.
+func WithOrder(order string) Option {
+	return func(o *Organizer) error {
+		if !ValidOrder(order) {
+			return configError("--order", fmt.Errorf("unknown order '%s' (use name, size-asc, size-desc or mtime)", order))
+		}
+		o.cfg.Order = order
+		return nil
+	}
+}
+
+// WithLimits processes at most maxFiles files and maxBytes bytes per run, in the order set with
+// WithOrder or oldest first; 0 means no limit. The remaining files are left for later runs.
+func WithLimits(maxFiles int, maxBytes int64) Option {
+	return func(o *Organizer) error {
+		o.cfg.MaxFiles = maxFiles
+		o.cfg.MaxBytes = maxBytes
+		return nil
+	}
+}
+
+// WithSpaceReport lists the n largest files and, per category, the n directories holding the most
+// bytes after the scan, and returns them in Result.Space.
+func WithSpaceReport(n int) Option {
+	return func(o *Organizer) error {
+		if n < 0 {
+			return configError("--top", errors.New("must not be negative"))
+		}
+		o.cfg.TopN = n
+		return nil
+	}
+}
+
+// WithMaxMemory keeps the moves planned by the scan within about maxBytes of memory by writing
+// the excess to a temporary file; 0 means no limit. It cannot be combined with WithOrder,
+// WithLimits, WithSpaceReport and WithArchival, which need all planned moves at once.
+func WithMaxMemory(maxBytes int64) Option {
+	return func(o *Organizer) error {
+		if maxBytes < 0 {
+			return configError("--max-memory", errors.New("must not be negative"))
+		}
+		o.cfg.MaxMemory = maxBytes
+		return nil
+	}
+}
+
+// WithBandwidthLimit limits copies to bytesPerSecond, shared evenly by the workers; 0 means no
+// limit.
+func WithBandwidthLimit(bytesPerSecond int64) Option {
+	return func(o *Organizer) error {
+		if bytesPerSecond < 0 {
+			return configError("--bwlimit", errors.New("must not be negative"))
+		}
+		o.cfg.BandwidthLimit = bytesPerSecond
+		return nil
+	}
+}
+
+// WithVerbosity sets how much is printed, see Verbosity.
+func WithVerbosity(v Verbosity) Option {
+	return func(o *Organizer) error {
+		o.cfg.Verbosity = v
+		return nil
+	}
+}
+
+// WithMappings merges extension to category mappings over the defaults.
+func WithMappings(mappings map[string]string) Option {
+	return func(o *Organizer) error {
+		if o.cfg.CategoryMappings == nil {
+			o.cfg.CategoryMappings = DefaultCategoryMappings()
+		}
+		for ext, category := range mappings {
+			o.cfg.CategoryMappings[ext] = category
+		}
+		return nil
+	}
+}
+
+// WithExtGroups sets the named extension groups for {group} in the layout and the groups of a
+// ClassifyRequest.
+func WithExtGroups(groups ExtGroups) Option {
+	return func(o *Organizer) error {
+		o.cfg.ExtGroups = groups
+		return nil
+	}
+}
+
+// WithClassifier adds a classifier consulted after the ones added before it.
+func WithClassifier(c FileClassifier) Option {
+	return func(o *Organizer) error {
+		o.cfg.Classifiers = append(o.cfg.Classifiers, c)
+		return nil
+	}
+}
+
+// WithClassifiers replaces the classifiers added so far with cs, consulted in order. A chain whose
+// first classifier sets the category of every file replaces the extension mappings.
+func WithClassifiers(cs ...FileClassifier) Option {
+	return func(o *Organizer) error {
+		o.cfg.Classifiers = cs
+		return nil
+	}
+}
+
+// WithArchival packs files older than olderThan into per-month archives of the given format.
+func WithArchival(olderThan time.Duration, format string) Option {
+	return func(o *Organizer) error {
+		o.cfg.ArchiveOlderThan = olderThan
+		o.cfg.ArchiveFormat = format
+		return nil
+	}
+}
+
+// WithCompression stores files of the given categories (all if none) compressed with codec.
+func WithCompression(codec string, categories ...string) Option {
+	return func(o *Organizer) error {
+		o.cfg.Compress = codec
+		o.cfg.CompressCategories = categories
+		return nil
+	}
+}
+
+// WithEncryption stores files of the given categories (all if none) encrypted to recipients.
+func WithEncryption(recipients []age.Recipient, categories ...string) Option {
+	return func(o *Organizer) error {
+		o.cfg.EncryptTo = recipients
+		o.cfg.EncryptCategories = categories
+		return nil
+	}
+}
+
+// WithIdentities sets the age identities Undo uses to decrypt encrypted files.
+func WithIdentities(identities []age.Identity) Option {
+	return func(o *Organizer) error {
+		o.identities = identities
+		return nil
+	}
+}
+
+// WithMirror also copies every organized file to m.
+func WithMirror(m *Mirror) Option {
+	return func(o *Organizer) error {
+		o.cfg.Mirror = m
+		return nil
+	}
+}
+
+// WithSync only copies files missing from the destination and leaves the source untouched.
+func WithSync(sync bool) Option {
+	return func(o *Organizer) error {
+		o.cfg.Sync = sync
+		return nil
+	}
+}
+
+// WithMode sets how files get into the destination, ModeMove or ModeHardlink.
+func WithMode(mode string) Option {
+	return func(o *Organizer) error {
+		if !ValidMode(mode) {
+			return configError("--mode", fmt.Errorf("unknown mode '%s' (use move or hardlink)", mode))
+		}
+		o.cfg.Mode = mode
+		return nil
+	}
+}
+
+// WithOnConflict sets what happens when the destination of a file is taken: ConflictRename,
+// ConflictBackup, ConflictTrash, ConflictKeepNewest, ConflictKeepLargest or ConflictAsk, which
+// needs WithConflictAsker.
+func WithOnConflict(strategy string) Option {
+	return func(o *Organizer) error {
+		if !ValidConflict(strategy) {
+			return configError("--on-conflict", fmt.Errorf("unknown strategy '%s' (use rename, backup, trash, keep-newest, keep-largest or ask)", strategy))
+		}
+		o.cfg.OnConflict = strategy
+		return nil
+	}
+}
+
+// WithConflictAsker sets who decides collisions under ConflictAsk.
+func WithConflictAsker(a ConflictAsker) Option {
+	return func(o *Organizer) error {
+		o.cfg.Asker = a
+		return nil
+	}
+}
+
+// WithDangerousSource lets the source be one CheckSource refuses, such as / or the home folder.
+func WithDangerousSource(allow bool) Option {
+	return func(o *Organizer) error {
+		o.cfg.AllowDangerous = allow
+		return nil
+	}
+}
+
+// WithSkipDuplicates leaves files in place whose destination already holds the same content
+// instead of resolving the collision.
+func WithSkipDuplicates(skip bool) Option {
+	return func(o *Organizer) error {
+		o.cfg.SkipDuplicates = skip
+		return nil
+	}
+}
+
+// WithConflictLoser sets what happens to the file that loses a keep-newest or keep-largest
+// conflict: ConflictBackup, ConflictTrash or ConflictDelete.
+func WithConflictLoser(policy string) Option {
+	return func(o *Organizer) error {
+		if !ValidConflictLoser(policy) {
+			return configError("--conflict-loser", fmt.Errorf("unknown policy '%s' (use backup, trash or delete)", policy))
+		}
+		o.cfg.ConflictLoser = policy
+		return nil
+	}
+}
+
+// WithLayout sets the template of the folder files are put in below the destination, e.g.
+// "{category}/{srcdir}" (see DefaultLayout).
+func WithLayout(layout string) Option {
+	return func(o *Organizer) error {
+		if err := ValidateLayout(layout); err != nil {
+			return configError("--layout", err)
+		}
+		o.cfg.Layout = layout
+		return nil
+	}
+}
+
+// WithFolderNaming sets how categories are turned into folder names, see FolderNaming.
+func WithFolderNaming(n FolderNaming) Option {
+	return func(o *Organizer) error {
+		if err := n.validate(); err != nil {
+			return configError("--folder-language", err)
+		}
+		o.cfg.Naming = n
+		return nil
+	}
+}
+
+// WithTiers routes files into age bands, e.g. Hot, Warm and Cold, with {tier} in the layout or
+// in front of it (see Tier).
+func WithTiers(tiers ...Tier) Option {
+	return func(o *Organizer) error {
+		if err := validateTiers(tiers); err != nil {
+			return configError("tiers", err)
+		}
+		o.cfg.Tiers = tiers
+		return nil
+	}
+}
+
+// WithReview stages files of the given categories in ReviewDir for approval.
+func WithReview(categories ...string) Option {
+	return func(o *Organizer) error {
+		o.cfg.ReviewCategories = categories
+		return nil
+	}
+}
+
+// WithQuotas enforces size limits per category after each run.
+func WithQuotas(quotas ...Quota) Option {
+	return func(o *Organizer) error {
+		o.cfg.Quotas = quotas
+		return nil
+	}
+}
+
+// WithHooks runs the per-file hooks of h. The run-level hooks are left to the caller.
+func WithHooks(h Hooks) Option {
+	return func(o *Organizer) error {
+		o.cfg.Hooks = h
+		return nil
+	}
+}
+
+// WithJournal records completed operations in j so the run can be undone.
+func WithJournal(j *journal.Journal) Option {
+	return func(o *Organizer) error {
+		o.cfg.Journal = j
+		return nil
+	}
+}
+
+// WithController lets another goroutine pause, resume or stop runs.
+func WithController(c *Controller) Option {
+	return func(o *Organizer) error {
+		o.cfg.Control = c
+		return nil
+	}
+}
+
+// WithProgress streams progress updates, including every file's outcome, to ch. The channel is
+// not closed by the Organizer.
+func WithProgress(ch chan<- ProgressUpdate) Option {
+	return func(o *Organizer) error {
+		o.progress = ch
+		return nil
+	}
+}
+
+// WithPrinter sends the console messages to p instead of plain lines on stdout.
+func WithPrinter(p Printer) Option {
+	return func(o *Organizer) error {
+		o.cfg.Printer = p
+		return nil
+	}
+}
+
+// WithUnreadable sets what the scan does with entries it is denied access to: UnreadableReport
+// (the default), UnreadableSkip or UnreadableFail.
+func WithUnreadable(policy string) Option {
+	return func(o *Organizer) error {
+		if !ValidUnreadable(policy) {
+			return configError("unreadable", fmt.Errorf("unknown policy '%s' (use skip or fail)", policy))
+		}
+		o.cfg.Unreadable = policy
+		return nil
+	}
+}
+
+// WithHidden sets what the scan does with hidden files and folders: HiddenSkip (the default),
+// HiddenInclude or HiddenOnly.
+func WithHidden(policy string) Option {
+	return func(o *Organizer) error {
+		if !ValidHidden(policy) {
+			return configError("--hidden", fmt.Errorf("unknown policy '%s' (use skip, include or only)", policy))
+		}
+		o.cfg.Hidden = policy
+		return nil
+	}
+}
+
+// WithMIMEFilter organizes only files whose MIME type, sniffed from their content, matches one of
+// include (all files if it is empty) and none of exclude. Patterns are MIME types that may use
+// wildcards, like "video/*".
+func WithMIMEFilter(include, exclude []string) Option {
+	return func(o *Organizer) error {
+		if err := checkMIMEPatterns(include); err != nil {
+			return configError("--include-mime", err)
+		}
+		if err := checkMIMEPatterns(exclude); err != nil {
+			return configError("--exclude-mime", err)
+		}
+		o.cfg.IncludeMIME, o.cfg.ExcludeMIME = include, exclude
+		return nil
+	}
+}
+
+// WithCategories organizes only the files classified into one of only (all files if it is empty)
+// and none of skip. Category names are matched case-insensitively.
+func WithCategories(only, skip []string) Option {
+	return func(o *Organizer) error {
+		o.cfg.OnlyCategories, o.cfg.SkipCategories = only, skip
+		return nil
+	}
+}
+
+// WithMinCategoryFiles keeps categories with fewer than n files in a run from getting a folder
+// of their own, unless they have one already: policy SmallCategoryOthers (the default) puts
+// their files into Others, SmallCategoryLeave leaves them where they are.
+func WithMinCategoryFiles(n int, policy string) Option {
+	return func(o *Organizer) error {
+		if n < 0 {
+			return configError("--min-category-files", errors.New("must not be negative"))
+		}
+		if !ValidSmallCategory(policy) {
+			return configError("--small-categories", fmt.Errorf("unknown policy '%s' (use others or leave)", policy))
+		}
+		o.cfg.MinCategoryFiles, o.cfg.SmallCategories = n, policy
+		return nil
+	}
+}
+
+// WithEvents groups the photos and videos of a run into events: files taken less than gap apart,
+// by their EXIF or movie header dates, go into a shared "2006-01-02 Event" folder below the one
+// the layout chose. categories replaces DefaultEventCategories if not empty.
+func WithEvents(gap time.Duration, categories []string) Option {
+	return func(o *Organizer) error {
+		if gap < 0 {
+			return configError("--events", errors.New("must not be negative"))
+		}
+		o.cfg.EventGap, o.cfg.EventCategories = gap, categories
+		return nil
+	}
+}
+
+// WithClipsUnder puts videos shorter than d, by their MP4, QuickTime or Matroska container, into
+// ClipsFolder in their category folder instead of the folder the layout chooses.
+func WithClipsUnder(d time.Duration) Option {
+	return func(o *Organizer) error {
+		if d < 0 {
+			return configError("--clips-under", errors.New("must not be negative"))
+		}
+		o.cfg.ClipsUnder = d
+		return nil
+	}
+}
+
+// WithMediaNames puts videos named like releases of episodes and movies into Shows/<show>/Season
+// <n> and Movies/<title> (<year>) in their category folder instead of the folder the layout
+// chooses.
+func WithMediaNames(enabled bool) Option {
+	return func(o *Organizer) error {
+		o.cfg.MediaNames = enabled
+		return nil
+	}
+}
+
+// WithVendors files documents whose names contain one of the vendor names of vendors, ignoring
+// case, into the folder it maps to in their category folder, e.g. "Chase": "Banking/Chase".
+func WithVendors(vendors map[string]string) Option {
+	return func(o *Organizer) error {
+		if err := validateVendors(vendors); err != nil {
+			return configError("vendors", err)
+		}
+		o.cfg.Vendors = vendors
+		return nil
+	}
+}
+
+// WithSimilarImages finds photos of the run that are resaves or slightly edited copies of another
+// one by their perceptual hash. SimilarReport flags them with the photo they resemble in the
+// results; SimilarMove also puts them into SimilarFolder in the category folder for review.
+func WithSimilarImages(mode string) Option {
+	return func(o *Organizer) error {
+		if !ValidSimilar(mode) {
+			return configError("--similar-images", fmt.Errorf("unknown mode '%s' (use report or move)", mode))
+		}
+		o.cfg.SimilarImages = mode
+		return nil
+	}
+}
+
+// WithCorrupt keeps empty files and photos and videos that are cut off or aren't what their
+// extension says out of the category folders: CorruptMove puts them into CorruptFolder,
+// CorruptSkip leaves them in place. Either way they are reported with their FileResult.Damage.
+func WithCorrupt(policy string) Option {
+	return func(o *Organizer) error {
+		if !ValidCorrupt(policy) {
+			return configError("--corrupt", fmt.Errorf("unknown policy '%s' (use move or skip)", policy))
+		}
+		o.cfg.Corrupt = policy
+		return nil
+	}
+}
+
+// WithStripQuarantine drops the macOS quarantine flag of organized files, so Gatekeeper no longer
+// asks before downloaded apps and installers are first opened. By default it is kept.
+func WithStripQuarantine(strip bool) Option {
+	return func(o *Organizer) error {
+		o.cfg.StripQuarantine = strip
+		return nil
+	}
+}
+
+// WithLeaveSymlink leaves a symlink to the new location at the original path of every moved
+// file, so playlists, shortcuts and recent-files lists keep working. Undo removes the links.
+func WithLeaveSymlink(leave bool) Option {
+	return func(o *Organizer) error {
+		o.cfg.LeaveSymlink = leave
+		return nil
+	}
+}
+
+// WithManifest records the checksum of every stored file in manifests named after the algorithm
+// (see WithHash and ManifestName), one per category folder (ManifestCategory) or one for the
+// whole destination (ManifestGlobal).
+func WithManifest(mode string) Option {
+	return func(o *Organizer) error {
+		if !ValidManifest(mode) {
+			return configError("--manifest", fmt.Errorf("unknown manifest '%s' (use category or global)", mode))
+		}
+		o.cfg.Manifest = mode
+		return nil
+	}
+}
+
+// WithHash sets the checksum algorithm of duplicate checks, manifests and the journal (HashXXH64,
+// the default, HashSHA256 or HashBLAKE3) and how many files are hashed at once, separately from
+// the workers that move them; 0 means one per CPU.
+func WithHash(alg string, workers int) Option {
+	return func(o *Organizer) error {
+		if !ValidHash(alg) {
+			return configError("--hash", fmt.Errorf("unknown algorithm '%s' (use xxhash64, sha256 or blake3)", alg))
+		}
+		if workers < 0 {
+			return configError("--hash-workers", errors.New("must not be negative"))
+		}
+		o.cfg.Hash, o.cfg.HashWorkers = alg, workers
+		return nil
+	}
+}
+
+// WithFileTimeout makes workers give up on a file that makes no progress for d, a move or copy
+// stuck on a dying disk or a hung network share, and go on with the next one. The file is
+// reported as failed with ErrFileTimeout; 0 waits as long as it takes.
+func WithFileTimeout(d time.Duration) Option {
+	return func(o *Organizer) error {
+		if d < 0 {
+			return configError("--file-timeout", errors.New("must not be negative"))
+		}
+		o.cfg.FileTimeout = d
+		return nil
+	}
+}
+
+// WithFileRetries makes workers try a file that failed because another program held it or its
+// network share dropped out up to n more times, after a backoff of 5s doubled on every retry,
+// before reporting it; 0 reports it right away.
+func WithFileRetries(n int) Option {
+	return func(o *Organizer) error {
+		if n < 0 {
+			return configError("--file-retries", errors.New("must not be negative"))
+		}
+		o.cfg.FileRetries = n
+		return nil
+	}
+}
+
+// WithDedupe finds files whose content is anywhere in the destination already, by an index of
+// the destination kept in IndexFile, and leaves them in place (DedupeSkip) or puts a hard link to
+// the stored copy in their place (DedupeLink) instead of storing them again.
+func WithDedupe(policy string) Option {
+	return func(o *Organizer) error {
+		if !ValidDedupe(policy) {
+			return configError("--dedupe", fmt.Errorf("unknown policy '%s' (use skip or link)", policy))
+		}
+		o.cfg.Dedupe = policy
+		return nil
+	}
+}
+
+// WithFsync flushes every placed file and the directories it was added to or removed from to
+// disk, so a power loss right after the run cannot lose files. It costs a few disk flushes per file.
+func WithFsync(fsync bool) Option {
+	return func(o *Organizer) error {
+		o.cfg.Fsync = fsync
+		return nil
+	}
+}
+
+// WithClock takes the times a run records or puts into file names from c, e.g. a FixedClock
+// for reproducible plans and reports.
+func WithClock(c Clock) Option {
+	return func(o *Organizer) error {
+		o.cfg.Clock = c
+		return nil
+	}
+}
+
+// WithFS organizes files on fsys instead of the operating system's file system.
+func WithFS(fsys fsutil.FS) Option {
+	return func(o *Organizer) error {
+		o.cfg.FS = fsys
+		return nil
+	}
+}
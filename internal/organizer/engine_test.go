@@ -0,0 +1,121 @@
+package organizer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// planAll reads the whole plan of an Organizer for source with opts.
+func planAll(t *testing.T, source string, opts ...Option) []PlannedMove {
+	t.Helper()
+	t.Setenv("ORG_CLI_DATA_DIR", t.TempDir())
+	opts = append([]Option{WithSource(source), WithDest(t.TempDir()), WithRecursive(true), WithPrinter(PlainPrinter(io.Discard))}, opts...)
+	org, err := New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moves, err := org.Plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var all []PlannedMove
+	for m := range moves {
+		all = append(all, m)
+	}
+	return all
+}
+
+func TestPlanComplete(t *testing.T) {
+	source := t.TempDir()
+	writeFile(t, filepath.Join(source, "a.txt"), "a")
+	writeFile(t, filepath.Join(source, "sub", "b.jpg"), "b")
+	moves := planAll(t, source)
+	if len(moves) != 2 {
+		t.Fatalf("planned %d moves, want 2: %+v", len(moves), moves)
+	}
+	for _, m := range moves {
+		if m.Err != nil {
+			t.Errorf("complete plan carries error %v", m.Err)
+		}
+	}
+}
+
+func TestPlanReportsFailedScan(t *testing.T) {
+	source := t.TempDir()
+	writeFile(t, filepath.Join(source, "a.txt"), "a")
+	writeFile(t, filepath.Join(source, "locked", "b.txt"), "b")
+	moves := planAll(t, source, WithFS(failing(string(filepath.Separator)+"locked", fs.ErrPermission, "walk")), WithUnreadable(UnreadableFail))
+	if len(moves) == 0 {
+		t.Fatal("no moves, want the error as the last one")
+	}
+	last := moves[len(moves)-1]
+	if !errors.Is(last.Err, fs.ErrPermission) || last.Source != "" {
+		t.Errorf("last move = %+v, want nothing but a permission error", last)
+	}
+	for _, m := range moves[:len(moves)-1] {
+		if m.Err != nil {
+			t.Errorf("move %+v carries an error before the last one", m)
+		}
+	}
+}
+
+func TestPlanCancelled(t *testing.T) {
+	source := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		writeFile(t, filepath.Join(source, name), name)
+	}
+	t.Setenv("ORG_CLI_DATA_DIR", t.TempDir())
+	org, err := New(WithSource(source), WithDest(t.TempDir()), WithPrinter(PlainPrinter(io.Discard)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	moves, err := org.Plan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-moves
+	cancel() // Stop reading; the channel must still be closed
+	for range moves {
+	}
+}
+
+func TestRemoteDests(t *testing.T) {
+	t.Setenv(S3AccessKeyEnv, "AKID")
+	t.Setenv(S3SecretKeyEnv, "secret")
+	_, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKnownHosts(t, "host:22", hostKey.PublicKey())
+
+	for dest, want := range map[string]string{"s3://bucket/sorted": "*organizer.S3Client", "SFTP://host/sorted": "*organizer.SFTPClient"} {
+		org, err := New(WithSource(t.TempDir()), WithDest(dest))
+		if err != nil || fmt.Sprintf("%T", org.cfg.Remote) != want {
+			t.Errorf("New with dest %s = %v, want a %s", dest, err, want)
+			continue
+		}
+		if org.cfg.DestDir != org.cfg.Remote.String() {
+			t.Errorf("dest dir %s, want the remote %s", org.cfg.DestDir, org.cfg.Remote)
+		}
+		if m, err := NewMirror(dest); err != nil || fmt.Sprintf("%T", m.Remote) != want {
+			t.Errorf("NewMirror(%s) = %v, want a %s", dest, err, want)
+		}
+	}
+	var cerr *ConfigError
+	if _, err := New(WithSource(t.TempDir()), WithDest("sftp://unknown/sorted")); !errors.As(err, &cerr) || cerr.Setting != "--dest" {
+		t.Errorf("New with a host of unknown key = %v, want a --dest config error", err)
+	}
+}
@@ -0,0 +1,83 @@
+package organizer
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrAborted is returned by OrganizeFiles when the run was stopped through its Controller
+// before all files were dispatched.
+var ErrAborted = errors.New("run aborted")
+
+// ConfigError reports an invalid setting. Nothing has been touched when it is returned.
+type ConfigError struct {
+	Setting string // Flag or config key, e.g. "--encrypt-with"
+	Err     error
+}
+
+func (e *ConfigError) Error() string {
+	if e.Setting == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Setting, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+// configError wraps err as a ConfigError for setting.
+func configError(setting string, err error) error {
+	return &ConfigError{Setting: setting, Err: err}
+}
+
+// ScanError reports a path of the source that could not be read while scanning.
+type ScanError struct {
+	Path string
+	Err  error
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("error scanning '%s': %v", e.Path, e.Err)
+}
+
+func (e *ScanError) Unwrap() error { return e.Err }
+
+// What the scan does with entries it is denied access to, see Config.Unreadable.
+const (
+	UnreadableReport = ""     // Count them as errors, so the run ends as partial
+	UnreadableSkip   = "skip" // Count them as skipped: what cannot be read is expected to stay where it is
+	UnreadableFail   = "fail" // Fail the run after the scan, before anything is moved
+)
+
+// ValidUnreadable reports whether policy is one of the Unreadable policies.
+func ValidUnreadable(policy string) bool {
+	switch policy {
+	case UnreadableReport, UnreadableSkip, UnreadableFail:
+		return true
+	}
+	return false
+}
+
+// MoveError reports a file that could not be placed at its destination. The source is left
+// in place unless the error says otherwise.
+type MoveError struct {
+	Source string
+	Dest   string
+	Err    error
+}
+
+func (e *MoveError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *MoveError) Unwrap() error { return e.Err }
+
+// ConflictError reports that a path is occupied by a different file and the operation refused
+// to overwrite it (a sync conflict, or an undo whose original location was taken again).
+type ConflictError struct {
+	Path   string // The occupied path
+	Reason string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("'%s' %s", e.Path, e.Reason)
+}
@@ -0,0 +1,66 @@
+package organizer
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// DefaultEventCategories are the categories EventGap groups into events unless EventCategories
+// says otherwise.
+var DefaultEventCategories = []string{"Images", "Videos"}
+
+// eventCategories returns the categories cfg groups into events.
+func (cfg Config) eventCategories() []string {
+	if len(cfg.EventCategories) > 0 {
+		return cfg.EventCategories
+	}
+	return DefaultEventCategories
+}
+
+// groupEvents applies EventGap to the planned moves: the photos and videos among them are sorted
+// by when they were taken, and every run of them with less than EventGap between one and the next
+// is an event, moved into a "2006-01-02 Event" folder, dated by its first file, below the folder
+// the layout chose. Events starting on the same day are numbered from the second on.
+func groupEvents(cfg Config, files []FileMove, p logger) {
+	type shot struct {
+		i     int
+		taken time.Time
+	}
+	var shots []shot
+	for i, fm := range files {
+		if fm.Review != "" || fm.archive || !matchesAnyName(fm.Category, cfg.eventCategories()) {
+			continue
+		}
+		shots = append(shots, shot{i, captureTime(cfg.fsys(), fm.SourcePath, fm.Info.ModTime())})
+	}
+	if len(shots) == 0 {
+		return
+	}
+	slices.SortStableFunc(shots, func(a, b shot) int { return a.taken.Compare(b.taken) })
+
+	perDay := make(map[string]int)
+	events := 0
+	name := ""
+	for j, s := range shots {
+		if j == 0 || s.taken.Sub(shots[j-1].taken) >= cfg.EventGap {
+			day := s.taken.Format("2006-01-02")
+			perDay[day]++
+			name = day + " Event"
+			if perDay[day] > 1 {
+				name = fmt.Sprintf("%s %d", name, perDay[day])
+			}
+			events++
+		}
+		fm := &files[s.i]
+		if cfg.Remote != nil { // Relative to the remote location, slash separated
+			fm.DestPath = path.Join(path.Dir(fm.DestPath), name, path.Base(fm.DestPath))
+		} else {
+			fm.DestPath = filepath.Join(filepath.Dir(fm.DestPath), name, filepath.Base(fm.DestPath))
+		}
+		p.Debug("%s: taken %s, event '%s'", filepath.Base(fm.SourcePath), s.taken.Format(time.DateTime), name)
+	}
+	p.Status(LevelInfo, "📸", "Grouped %d photos and videos into %d events.", len(shots), events)
+}
@@ -0,0 +1,44 @@
+package organizer
+
+import "sort"
+
+// ExtensionStat counts the files of one extension that a scan found, to help build mappings from
+// real data.
+type ExtensionStat struct {
+	Ext      string // Lower case with the dot; "" for files without an extension
+	Category string // Category the mappings put it in; "" for extensions they don't know
+	Files    int
+	Bytes    int64
+}
+
+// extensionCounter collects ExtensionStats during a scan. The scan calls add from one goroutine.
+type extensionCounter map[string]*ExtensionStat
+
+// add counts a file with extension ext of size bytes.
+func (c extensionCounter) add(cfg Config, ext string, size int64) {
+	if c == nil {
+		return
+	}
+	s := c[ext]
+	if s == nil {
+		s = &ExtensionStat{Ext: ext, Category: cfg.CategoryMappings[ext]}
+		c[ext] = s
+	}
+	s.Files++
+	s.Bytes += size
+}
+
+// stats returns the counted extensions, the most files first; ties by extension.
+func (c extensionCounter) stats() []ExtensionStat {
+	stats := make([]ExtensionStat, 0, len(c))
+	for _, s := range c {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Files != stats[j].Files {
+			return stats[i].Files > stats[j].Files
+		}
+		return stats[i].Ext < stats[j].Ext
+	})
+	return stats
+}
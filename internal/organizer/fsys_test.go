@@ -0,0 +1,79 @@
+package organizer
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// failing returns the OS file system, with the operations ops (see fsutil.Faulty) failing with err
+// on every path containing part.
+func failing(part string, err error, ops ...string) fsutil.FS {
+	return fsutil.Faulty(fsutil.OS, func(op, path string) error {
+		if slices.Contains(ops, op) && strings.Contains(path, part) {
+			return err
+		}
+		return nil
+	})
+}
+
+func TestCrossDeviceMove(t *testing.T) {
+	source, dest := t.TempDir(), t.TempDir()
+	cfg := testConfig(t, source, dest)
+	cfg.FS = failing(source, syscall.EXDEV, "link", "rename")
+	writeFile(t, filepath.Join(source, "a.txt"), "copied")
+	modTime := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(source, "a.txt"), modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := OrganizeFiles(cfg, nil)
+	if err != nil || result.Processed != 1 {
+		t.Fatalf("OrganizeFiles = %d processed, %v; want the file copied over", result.Processed, err)
+	}
+	if _, err := os.Lstat(filepath.Join(source, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("source still there: %v", err)
+	}
+	stored := filepath.Join(dest, "Documents", "a.txt")
+	data, err := os.ReadFile(stored)
+	if err != nil || string(data) != "copied" {
+		t.Fatalf("stored file = %q, %v", data, err)
+	}
+	if info, err := os.Stat(stored); err != nil || !info.ModTime().Equal(modTime) {
+		t.Errorf("stored file modified %v, %v; want %v", info.ModTime(), err, modTime)
+	}
+}
+
+func TestCrossDeviceMoveKeepsUnremovableSource(t *testing.T) {
+	source, dest := t.TempDir(), t.TempDir()
+	cfg := testConfig(t, source, dest)
+	cfg.FS = fsutil.Faulty(fsutil.OS, func(op, path string) error {
+		switch {
+		case !strings.HasPrefix(path, source):
+			return nil
+		case op == "link" || op == "rename":
+			return syscall.EXDEV
+		case op == "remove":
+			return syscall.EACCES
+		}
+		return nil
+	})
+	writeFile(t, filepath.Join(source, "a.txt"), "kept")
+
+	result, err := OrganizeFiles(cfg, nil)
+	if err != nil || result.Errors != 1 || result.Processed != 0 {
+		t.Fatalf("OrganizeFiles = %d processed, %d errors, %v; want the move failed", result.Processed, result.Errors, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(source, "a.txt")); err != nil || string(data) != "kept" {
+		t.Errorf("source = %q, %v; want it untouched", data, err)
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "Documents", "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("copy left in the destination: %v", err)
+	}
+}
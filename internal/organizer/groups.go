@@ -0,0 +1,54 @@
+package organizer
+
+import (
+	"slices"
+	"strings"
+)
+
+// ExtGroups are named lists of extensions, lower case with the dot as in the mappings, that the
+// layout and classifiers can refer to instead of the extensions themselves.
+type ExtGroups map[string][]string
+
+// DefaultExtGroups returns the extension groups the default mappings are made of, one per
+// category, named after it in lower case ("images": .jpg, .png, ...).
+func DefaultExtGroups() ExtGroups {
+	groups := make(ExtGroups)
+	for ext, category := range DefaultCategoryMappings() {
+		name := strings.ToLower(category)
+		groups[name] = append(groups[name], ext)
+	}
+	for _, exts := range groups {
+		slices.Sort(exts)
+	}
+	return groups
+}
+
+// extGroups returns the names of the groups of cfg.ExtGroups that the longest extension of name
+// any group has belongs to, the group with the fewest extensions first, ties by name.
+func (cfg Config) extGroups(name string) []string {
+	if len(cfg.ExtGroups) == 0 {
+		return nil
+	}
+	lower := strings.ToLower(name)
+	for i := 0; i < len(lower); i++ {
+		if lower[i] != '.' {
+			continue
+		}
+		var groups []string
+		for group, exts := range cfg.ExtGroups {
+			if slices.Contains(exts, lower[i:]) {
+				groups = append(groups, group)
+			}
+		}
+		if len(groups) > 0 {
+			slices.SortFunc(groups, func(a, b string) int {
+				if n, m := len(cfg.ExtGroups[a]), len(cfg.ExtGroups[b]); n != m {
+					return n - m
+				}
+				return strings.Compare(a, b)
+			})
+			return groups
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package organizer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrDangerousSource is wrapped by the errors of CheckSource.
+var ErrDangerousSource = errors.New("organizing it would scatter what is there (use --i-know-what-im-doing if this is really meant)")
+
+// CheckSource refuses sources that are almost certainly given by mistake: the root of a file
+// system, the home folder itself, a folder of the system or the destination. Organizing any of
+// them would tear apart the layout programs and the user rely on. Folders below the home folder,
+// and below the roots, are fine. The error returned wraps ErrDangerousSource.
+func CheckSource(source, dest string) error {
+	source, dest = resolvePath(source), resolvePath(dest)
+	why := ""
+	switch home, _ := os.UserHomeDir(); {
+	case filepath.Dir(source) == source:
+		why = "the root of the file system"
+	case home != "" && samePath(source, resolvePath(home)):
+		why = "your home folder"
+	case samePath(source, dest) || sameDir(source, dest):
+		why = "the destination"
+	default:
+		for _, dir := range systemDirs() {
+			if samePath(source, dir.path) || dir.tree && isBelow(source, dir.path) {
+				why = "a system folder"
+				break
+			}
+		}
+	}
+	if why == "" {
+		return nil
+	}
+	return fmt.Errorf("'%s' is %s; %w", source, why, ErrDangerousSource)
+}
+
+// systemDir is a folder of the operating system; with tree, everything below it is one too.
+type systemDir struct {
+	path string
+	tree bool
+}
+
+// systemDirs returns the folders of the operating system CheckSource refuses. Folders like /var
+// and C:\Users hold data that may be organized, only not all at once.
+func systemDirs() []systemDir {
+	if runtime.GOOS == "windows" {
+		dirs := []systemDir{{`C:\Users`, false}, {`C:\Windows`, true}, {`C:\Program Files`, true}, {`C:\Program Files (x86)`, true}, {`C:\ProgramData`, true}}
+		for _, env := range []string{"SystemRoot", "ProgramFiles", "ProgramFiles(x86)", "ProgramData"} {
+			if dir := os.Getenv(env); dir != "" {
+				dirs = append(dirs, systemDir{dir, true})
+			}
+		}
+		return dirs
+	}
+	dirs := []systemDir{
+		{"/bin", true}, {"/boot", true}, {"/dev", true}, {"/etc", true}, {"/lib", true}, {"/lib32", true},
+		{"/lib64", true}, {"/proc", true}, {"/sbin", true}, {"/sys", true}, {"/usr", true},
+		{"/home", false}, {"/opt", false}, {"/root", false}, {"/run", false}, {"/srv", false}, {"/var", false},
+	}
+	if runtime.GOOS == "darwin" {
+		dirs = append(dirs, systemDir{"/System", true}, systemDir{"/Library", true}, systemDir{"/Applications", true},
+			systemDir{"/private/etc", true}, systemDir{"/private/var", false}, systemDir{"/Users", false})
+	}
+	return dirs
+}
+
+// resolvePath returns the absolute path of path with symlinks resolved as far as it exists, so
+// that a link to the home folder is refused like the home folder.
+func resolvePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real
+	}
+	return path
+}
+
+// samePath reports whether a and b, both clean, are the same path; on Windows and macOS, whose
+// file systems usually ignore case, regardless of case.
+func samePath(a, b string) bool {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// sameDir reports whether a and b are the same existing directory, which they can be under
+// different paths through a bind mount.
+func sameDir(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	return err == nil && os.SameFile(infoA, infoB)
+}
+
+// isBelow reports whether path lies inside dir, both clean.
+func isBelow(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		rel, err = filepath.Rel(strings.ToLower(dir), strings.ToLower(path))
+	}
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,234 @@
+package organizer
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+	"github.com/cespare/xxhash/v2"
+	"github.com/zeebo/blake3"
+)
+
+// Checksum algorithms for Config.Hash. All three use the vector or hash instructions of the CPU
+// where it has them.
+const (
+	HashXXH64  = "xxhash64" // Fast, finds duplicates and bit rot but not tampering; the default
+	HashSHA256 = "sha256"   // Cryptographic, for manifests checked with sha256sum
+	HashBLAKE3 = "blake3"   // Cryptographic and several times faster than SHA-256
+)
+
+// ValidHash reports whether alg is one of the supported checksum algorithms; empty is the default.
+func ValidHash(alg string) bool {
+	switch alg {
+	case "", HashXXH64, HashSHA256, HashBLAKE3:
+		return true
+	}
+	return false
+}
+
+// newHash returns a new hash of alg, HashXXH64 if alg is empty.
+func newHash(alg string) hash.Hash {
+	switch alg {
+	case HashSHA256:
+		return sha256.New()
+	case HashBLAKE3:
+		return blake3.New()
+	}
+	return xxhash.New()
+}
+
+// hashBlock is the size of the blocks large files are read in for hashing.
+const hashBlock = 1 << 20
+
+var hashBuffers = sync.Pool{New: func() any { return new([hashBlock]byte) }}
+
+// hashFile returns the alg checksum of the file at path, of size bytes. For files of more than a
+// block the next block is read while the last one is hashed, so fast disks are not left waiting
+// for the hash.
+func hashFile(fsys fsutil.FS, path string, size int64, alg string) ([]byte, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := newHash(alg)
+	if size <= hashBlock {
+		_, err = io.Copy(h, f)
+	} else {
+		err = pipeHash(h, f)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// pipeHash writes r to h, reading in a goroutine of its own with two buffers in turn.
+func pipeHash(h hash.Hash, r io.Reader) error {
+	free := make(chan *[hashBlock]byte, 2)
+	full := make(chan []byte, 1)
+	for range 2 {
+		buf := hashBuffers.Get().(*[hashBlock]byte)
+		defer hashBuffers.Put(buf)
+		free <- buf
+	}
+	errc := make(chan error, 1)
+	go func() {
+		defer close(full)
+		for buf := range free {
+			n, err := io.ReadFull(r, buf[:])
+			if n > 0 {
+				full <- buf[:n]
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				err = nil
+			}
+			if err != nil || n < hashBlock {
+				errc <- err
+				return
+			}
+		}
+	}()
+	for block := range full {
+		h.Write(block)
+		free <- (*[hashBlock]byte)(block[:hashBlock])
+	}
+	return <-errc
+}
+
+// hasher computes the checksums of a run with Config.Hash, at most Config.HashWorkers files at a
+// time however many workers move files, and remembers them, so that duplicate checks, the
+// manifest and the journal read a file once. A nil hasher hashes with the default algorithm and
+// remembers nothing.
+type hasher struct {
+	alg   string
+	slots chan struct{} // One per file being hashed
+	mu    sync.Mutex
+	sums  map[hashKey][]byte
+}
+
+// hashKey tells contents apart by path, size and modification time, as rsync does.
+type hashKey struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// newHasher returns a hasher for alg that hashes up to workers files at a time, one per CPU if
+// workers is 0.
+func newHasher(alg string, workers int) *hasher {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return &hasher{alg: cmp.Or(alg, HashXXH64), slots: make(chan struct{}, workers), sums: make(map[hashKey][]byte)}
+}
+
+// algorithm returns the checksum algorithm of h.
+func (h *hasher) algorithm() string {
+	if h == nil {
+		return HashXXH64
+	}
+	return h.alg
+}
+
+// sum returns the checksum of the file at path, described by info or stat'ed if info is nil. If
+// the file was at from before, with the same size and modification time, a checksum computed
+// there is reused.
+func (h *hasher) sum(fsys fsutil.FS, path string, info fs.FileInfo, from string) ([]byte, error) {
+	if info == nil {
+		var err error
+		if info, err = fsys.Stat(path); err != nil {
+			return nil, err
+		}
+	}
+	key := hashKey{path, info.Size(), info.ModTime()}
+	if h == nil {
+		return hashFile(fsys, path, info.Size(), h.algorithm())
+	}
+	h.mu.Lock()
+	sum, ok := h.sums[key]
+	if !ok && from != "" {
+		sum, ok = h.sums[hashKey{from, key.size, key.modTime}]
+	}
+	h.mu.Unlock()
+	if ok {
+		return sum, nil
+	}
+	h.slots <- struct{}{}
+	sum, err := hashFile(fsys, path, info.Size(), h.alg)
+	<-h.slots
+	if err != nil {
+		return nil, err
+	}
+	h.mu.Lock()
+	h.sums[key] = sum
+	h.mu.Unlock()
+	return sum, nil
+}
+
+// remember records sum as the checksum of the file at path described by info.
+func (h *hasher) remember(path string, info fs.FileInfo, sum []byte) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	h.sums[hashKey{path, info.Size(), info.ModTime()}] = sum
+	h.mu.Unlock()
+}
+
+// teeHash returns w writing through a hash as well if the run records checksums in a manifest,
+// so that a copy of the file at source described by info yields its checksum without reading it
+// again. done remembers the checksum once the copy is complete.
+func (cfg Config) teeHash(w io.Writer, source string, info fs.FileInfo) (_ io.Writer, done func()) {
+	if cfg.sums == nil || cfg.hashes == nil {
+		return w, func() {}
+	}
+	h := newHash(cfg.hashes.alg)
+	return io.MultiWriter(w, h), func() { cfg.hashes.remember(source, info, h.Sum(nil)) }
+}
+
+// known returns the checksum of the file at path described by info as "algorithm:hex" if it was
+// computed during the run, or "".
+func (h *hasher) known(path string, info fs.FileInfo) string {
+	if h == nil {
+		return ""
+	}
+	h.mu.Lock()
+	sum, ok := h.sums[hashKey{path, info.Size(), info.ModTime()}]
+	h.mu.Unlock()
+	if !ok {
+		return ""
+	}
+	return h.alg + ":" + hex.EncodeToString(sum)
+}
+
+// matchesHash reports whether the file at path on fsys has the checksum sum, as returned by known.
+func matchesHash(fsys fsutil.FS, path, sum string) (bool, error) {
+	alg, digest, _ := strings.Cut(sum, ":")
+	if !ValidHash(alg) || alg == "" {
+		return false, fmt.Errorf("unknown checksum algorithm '%s'", alg)
+	}
+	want, err := hex.DecodeString(digest)
+	if err != nil {
+		return false, fmt.Errorf("invalid checksum '%s': %w", sum, err)
+	}
+	info, err := fsys.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	got, err := hashFile(fsys, path, info.Size(), alg)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(got, want), nil
+}
@@ -0,0 +1,38 @@
+package organizer
+
+import (
+	"io/fs"
+	"strings"
+)
+
+// What the scan does with hidden files and folders, see Config.Hidden.
+const (
+	HiddenSkip    = "skip"    // Leave them where they are (default)
+	HiddenInclude = "include" // Organize them like any other file
+	HiddenOnly    = "only"    // Organize nothing but hidden files, e.g. to clean up stray dotfiles
+)
+
+// ValidHidden reports whether policy is one of the Hidden policies.
+func ValidHidden(policy string) bool {
+	switch policy {
+	case HiddenSkip, HiddenInclude, HiddenOnly:
+		return true
+	}
+	return false
+}
+
+// skipsHidden reports whether the scan leaves hidden files and folders alone.
+func (cfg Config) skipsHidden() bool {
+	return cfg.Hidden == "" || cfg.Hidden == HiddenSkip
+}
+
+// isHidden reports whether the file or folder with info is hidden: its name starts with a dot
+// or, on Windows and macOS, it is flagged hidden (on Windows system files too). iCloud stubs are
+// hidden by name only; they stand for a file that is not, and are left to CloudPlaceholders.
+func isHidden(info fs.FileInfo) bool {
+	name := info.Name()
+	if isICloudStub(name) {
+		return false
+	}
+	return strings.HasPrefix(name, ".") || hasHiddenFlag(info)
+}
@@ -0,0 +1,20 @@
+//go:build darwin
+
+package organizer
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// ufHidden is the st_flags bit chflags hidden sets to hide a file in the Finder.
+const ufHidden = 0x8000
+
+// hasHiddenFlag reports whether the file is hidden from the Finder.
+func hasHiddenFlag(info fs.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false
+	}
+	return st.Flags&ufHidden != 0
+}
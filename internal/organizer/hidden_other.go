@@ -0,0 +1,10 @@
+//go:build !windows && !darwin
+
+package organizer
+
+import "io/fs"
+
+// hasHiddenFlag always returns false: on this platform only the leading dot hides a file.
+func hasHiddenFlag(info fs.FileInfo) bool {
+	return false
+}
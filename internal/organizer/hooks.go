@@ -0,0 +1,95 @@
+package organizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Hooks are shell commands run around a job and around every file. They get their context
+// through ORG_* environment variables. A failing BeforeRun hook aborts the run and a failing
+// BeforeFile hook leaves that file in place (e.g. when a virus scanner flags it); failures of the
+// After hooks are only reported.
+type Hooks struct {
+	BeforeRun  string
+	AfterRun   string
+	BeforeFile string
+	AfterFile  string
+}
+
+// RunHook runs command through the platform shell with env added to the organizer's
+// environment. The hook's output goes to the organizer's stdout and stderr.
+func RunHook(command string, env []string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("hook '%s' failed: %w", command, err)
+	}
+	return nil
+}
+
+// RunEnv returns the environment for the run-level hooks. Before the run only the directories
+// are meaningful; after it the counters and status of s are filled in too.
+func RunEnv(s Summary) []string {
+	categories := make([]string, 0, len(s.Categories))
+	for category := range s.Categories {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	summaryJSON, _ := json.Marshal(s)
+
+	return []string{
+		"ORG_RUN_ID=" + s.RunID,
+		"ORG_SOURCE_DIR=" + s.SourceDir,
+		"ORG_DEST_DIR=" + s.DestDir,
+		"ORG_DRY_RUN=" + strconv.FormatBool(s.DryRun),
+		"ORG_STATUS=" + s.Status,
+		"ORG_PROCESSED=" + strconv.Itoa(s.Processed),
+		"ORG_ERRORS=" + strconv.Itoa(s.Errors),
+		"ORG_CATEGORIES=" + strings.Join(categories, ","), // Categories that received files
+		"ORG_SUMMARY=" + string(summaryJSON),
+	}
+}
+
+// fileEnv returns the environment for the per-file hooks.
+func fileEnv(source, dest, category string) []string {
+	return []string{"ORG_SOURCE=" + source, "ORG_DEST=" + dest, "ORG_CATEGORY=" + category}
+}
+
+// beforeFileHook runs the BeforeFile hook for fm and reports whether the file may be organized.
+func (cfg Config) beforeFileHook(fm FileMove, progressChan chan<- ProgressUpdate) bool {
+	if cfg.Hooks.BeforeFile == "" || fm.DryRun {
+		return true
+	}
+	if err := RunHook(cfg.Hooks.BeforeFile, fileEnv(fm.SourcePath, fm.DestPath, fm.Category)); err != nil {
+		cfg.printer().File(LevelWarn, "HOOK", "%v. Leaving '%s' in place.", err, fm.SourcePath)
+		progressChan <- fm.skippedUpdate(err)
+		return false
+	}
+	return true
+}
+
+// fileStored runs the follow-up steps for a file that now lives at dest: the copy to the mirror
+// and the AfterFile hook.
+func (cfg Config) fileStored(source, dest, category string, progressChan chan<- ProgressUpdate) {
+	cfg.mirrorFile(dest, false, progressChan)
+	cfg.sums.add(cfg, source, dest, category)
+	cfg.index.add(cfg, source, dest, category)
+	if cfg.Hooks.AfterFile != "" {
+		if err := RunHook(cfg.Hooks.AfterFile, fileEnv(source, dest, category)); err != nil {
+			cfg.printer().File(LevelWarn, "HOOK", "%v", err)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package organizer
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultLayout puts every file straight into the folder of its category.
+const DefaultLayout = "{category}"
+
+// layoutFields are what the layout variables of a file are taken from.
+type layoutFields struct {
+	Category string
+	Name     string // File name, for the extension
+	SrcRel   string // Slash separated folder of the file relative to the source root, "" at the root
+	ModTime  time.Time
+	Tier     string // Age band of the file, see Tier
+	Group    string // Most specific extension group of the file, see Config.ExtGroups
+	Video    string // Resolution class of a video, see videoInfo.resolution
+}
+
+// layoutVars are the variables a layout can use.
+var layoutVars = map[string]func(f layoutFields) string{
+	"category": func(f layoutFields) string { return f.Category },
+	"ext": func(f layoutFields) string {
+		return strings.TrimPrefix(strings.ToLower(path.Ext(f.Name)), ".")
+	},
+	"srcdir": func(f layoutFields) string {
+		first, _, _ := strings.Cut(f.SrcRel, "/")
+		return first
+	},
+	"srcrel": func(f layoutFields) string { return f.SrcRel },
+	"tier":   func(f layoutFields) string { return f.Tier },
+	"group":  func(f layoutFields) string { return f.Group },
+	"year":   func(f layoutFields) string { return f.ModTime.Format("2006") },
+	"month":  func(f layoutFields) string { return f.ModTime.Format("01") },
+	"day":    func(f layoutFields) string { return f.ModTime.Format("02") },
+	"date":   func(f layoutFields) string { return f.ModTime.Format("2006-01-02") },
+	"quarter": func(f layoutFields) string {
+		return fmt.Sprintf("Q%d", (f.ModTime.Month()+2)/3)
+	},
+	"week": func(f layoutFields) string {
+		year, week := f.ModTime.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	},
+	"resolution": func(f layoutFields) string {
+		return f.Video // Empty for files that are no videos, which drops the segment
+	},
+	"nameyear": func(f layoutFields) string {
+		return nameDate(f.Name, f.ModTime).Format("2006")
+	},
+	"namemonth": func(f layoutFields) string {
+		return nameDate(f.Name, f.ModTime).Format("01")
+	},
+	"nameday": func(f layoutFields) string {
+		return nameDate(f.Name, f.ModTime).Format("02")
+	},
+}
+
+// ValidateLayout checks that layout only uses known variables. An empty layout is DefaultLayout.
+func ValidateLayout(layout string) error {
+	_, err := expandLayout(layout, layoutFields{})
+	return err
+}
+
+// expandLayout fills the variables of layout in for f and returns the folder below the
+// destination root, slash separated. Empty segments, such as {srcdir} of a file at the source
+// root, are dropped, and the result never climbs above the root.
+func expandLayout(layout string, f layoutFields) (string, error) {
+	if layout == "" {
+		layout = DefaultLayout
+	}
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(layout, '{')
+		if start < 0 {
+			b.WriteString(layout)
+			break
+		}
+		end := strings.IndexByte(layout[start:], '}')
+		if end < 0 {
+			return "", errors.New("unclosed '{' in layout")
+		}
+		b.WriteString(layout[:start])
+		name, format, custom := strings.Cut(layout[start+1:start+end], ":")
+		switch value, ok := layoutVars[name]; {
+		case custom && name == "date":
+			s, err := strftime(format, f.ModTime)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(s)
+		case custom:
+			return "", fmt.Errorf("variable {%s} in layout takes no format", name)
+		case ok:
+			b.WriteString(value(f))
+		default:
+			return "", fmt.Errorf("unknown variable {%s} in layout (use category, ext, group, resolution, srcdir, srcrel, tier, year, month, day, quarter, week, date, date:FORMAT, nameyear, namemonth or nameday)", name)
+		}
+		layout = layout[start+end+1:]
+	}
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(b.String())), "/"), nil
+}
+
+// layoutFolder returns the folder below DestDir, in the OS's form, that the layout of cfg puts
+// the file described by f in.
+func (cfg Config) layoutFolder(f layoutFields) string {
+	f.Tier = cfg.tier(f.ModTime)
+	f.Category = cfg.categoryFolder(f.Category)
+	if groups := cfg.extGroups(f.Name); len(groups) > 0 {
+		f.Group = groups[0]
+	}
+	// Validate has rejected layouts that don't expand
+	folder, _ := expandLayout(cfg.layout(), f)
+	return filepath.FromSlash(folder)
+}
+
+// strftime formats t with the strftime-style directives of format: %Y, %y, %m, %d, %j, %H, %M,
+// %S, %b, %B, %a, %A, %G and %V (ISO week year and week), %u (ISO weekday), %q (quarter) and %%.
+func strftime(format string, t time.Time) (string, error) {
+	var b strings.Builder
+	isoYear, isoWeek := t.ISOWeek()
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+		if i++; i == len(format) {
+			return "", errors.New("date format in layout ends with '%'")
+		}
+		switch format[i] {
+		case 'Y':
+			b.WriteString(t.Format("2006"))
+		case 'y':
+			b.WriteString(t.Format("06"))
+		case 'm':
+			b.WriteString(t.Format("01"))
+		case 'd':
+			b.WriteString(t.Format("02"))
+		case 'j':
+			fmt.Fprintf(&b, "%03d", t.YearDay())
+		case 'H':
+			b.WriteString(t.Format("15"))
+		case 'M':
+			b.WriteString(t.Format("04"))
+		case 'S':
+			b.WriteString(t.Format("05"))
+		case 'b':
+			b.WriteString(t.Format("Jan"))
+		case 'B':
+			b.WriteString(t.Format("January"))
+		case 'a':
+			b.WriteString(t.Format("Mon"))
+		case 'A':
+			b.WriteString(t.Format("Monday"))
+		case 'G':
+			fmt.Fprintf(&b, "%d", isoYear)
+		case 'V':
+			fmt.Fprintf(&b, "%02d", isoWeek)
+		case 'u':
+			fmt.Fprintf(&b, "%d", (int(t.Weekday())+6)%7+1)
+		case 'q':
+			fmt.Fprintf(&b, "%d", (t.Month()+2)/3)
+		case '%':
+			b.WriteByte('%')
+		default:
+			return "", fmt.Errorf("unknown directive %%%c in the date format of the layout", format[i])
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,89 @@
+package organizer
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/journal"
+)
+
+// LeftoverResult counts what cleaning up after a crashed run found (or would do in a dry run).
+type LeftoverResult struct {
+	Removed int // Temporary files and empty copies removed
+	Damaged int // Empty destinations whose original is gone, reported only
+	Failed  int
+}
+
+// CleanLeftovers cleans up after a run that crashed, going by the entries of its journal. The
+// hidden temporary files of unfinished copies in the directories the run wrote to are removed;
+// while other runs are going, which may be writing theirs, only the stale ones. Destinations the journal knows as non-empty files
+// but that are now empty, because the crash came before their data reached the disk, are removed
+// while their original is still there to be organized again, and reported otherwise. Files the
+// journal doesn't know are left alone. Messages go to printer, or stdout if it is nil, as far as
+// verbosity asks for them.
+func CleanLeftovers(entries []journal.Entry, othersRunning bool, dryRun bool, verbosity Verbosity, printer Printer) LeftoverResult {
+	p := newLogger(printer, verbosity)
+
+	var result LeftoverResult
+	remove := func(path, format string, args ...any) {
+		if dryRun {
+			p.File(LevelNotice, "DRY RUN", "Would remove "+format, args...)
+			result.Removed++
+			return
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			p.File(LevelError, "ERROR", "Failed to remove '%s': %v", path, err)
+			result.Failed++
+			return
+		}
+		p.File(LevelSuccess, "CLEANED", "Removed "+format, args...)
+		result.Removed++
+	}
+
+	dirs := map[string]bool{}
+	for _, e := range entries {
+		if e.Op == journal.OpTrash || e.Op == journal.OpDelete {
+			continue // Nothing was written to the destination
+		}
+		dirs[filepath.Dir(e.Dest)] = true
+		if e.Op == journal.OpLink || e.Size == 0 {
+			continue // A link is as empty as its original
+		}
+		info, err := os.Lstat(e.Dest)
+		if err != nil || !info.Mode().IsRegular() || info.Size() > 0 {
+			continue // Moved on since, or intact
+		}
+		if src, err := os.Stat(e.Source); err == nil && src.Size() > 0 {
+			remove(e.Dest, "empty '%s', '%s' is still there", e.Dest, e.Source)
+			continue
+		}
+		p.File(LevelError, "DAMAGED", "'%s' is empty; the %s of '%s' was not on disk yet when the run crashed", e.Dest, FormatBytes(e.Size), e.Source)
+		result.Damaged++
+	}
+
+	cutoff := time.Now()
+	if othersRunning {
+		cutoff = cutoff.Add(-staleStagedAge)
+	}
+	for _, dir := range slices.Sorted(maps.Keys(dirs)) {
+		names, err := os.ReadDir(dir)
+		if err != nil {
+			continue // The destination was moved or removed since
+		}
+		for _, d := range names {
+			if !d.Type().IsRegular() || !strings.HasPrefix(d.Name(), stagedPrefix) {
+				continue
+			}
+			if info, err := d.Info(); err != nil || info.ModTime().After(cutoff) {
+				continue
+			}
+			path := filepath.Join(dir, d.Name())
+			remove(path, "temporary file '%s'", path)
+		}
+	}
+	return result
+}
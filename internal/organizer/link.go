@@ -0,0 +1,85 @@
+package organizer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+	"github.com/avizyt/org-cli/internal/journal"
+)
+
+// Modes files can get into the destination with.
+const (
+	ModeMove     = "move"     // Moved (renamed, or copied and removed across file systems)
+	ModeHardlink = "hardlink" // Hard linked, leaving the original in place at no extra space
+)
+
+// ValidMode reports whether mode is one of the supported modes.
+func ValidMode(mode string) bool {
+	switch mode {
+	case "", ModeMove, ModeHardlink:
+		return true
+	}
+	return false
+}
+
+// linkFile is the hardlink mode counterpart of moveFile: the destination gets a hard link to the
+// source, which stays where it is, so the categorized tree is a second view of the same files.
+// A destination that already is a link to the source is left alone, which makes repeated runs
+// idempotent; one holding another file gets the usual timestamp suffix.
+func linkFile(fm FileMove, cfg Config, progressChan chan<- ProgressUpdate) error {
+	fsys, p := cfg.fsys(), cfg.printer()
+	fail := func(err error) error {
+		fm.reportFailure(p, err, progressChan)
+		return err
+	}
+
+	if existing, err := fsys.Lstat(fm.DestPath); err == nil {
+		if fsys.SameFile(existing, fm.Info) {
+			p.File(LevelNotice, "LINKED", "'%s' is already linked at '%s'", fm.SourcePath, fm.DestPath)
+			progressChan <- fm.skippedUpdate(nil)
+			return nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fail(fmt.Errorf("error checking existence of '%s': %w", fm.DestPath, err))
+	}
+
+	if fm.DryRun {
+		p.File(LevelNotice, "DRY RUN", "Would link '%s' at '%s'", fm.SourcePath, fm.DestPath)
+		progressChan <- fm.movedUpdate(ActionLink, fm.DestPath)
+		return nil
+	}
+
+	if err := verifyUnchanged(fsys, fm); err != nil {
+		p.File(LevelWarn, "CHANGED", "%v. Skipping.", err)
+		progressChan <- fm.skippedUpdate(err)
+		return err
+	}
+	destDir := filepath.Dir(fm.DestPath)
+	if _, err := cfg.dirs.ensure(fsys, destDir, false); err != nil {
+		return fail(fmt.Errorf("failed to create destination directory '%s': %w", destDir, err))
+	}
+
+	// A link never replaces an existing name, so a file that appeared since the check is not lost
+	finalDestPath, err := placeUnique(fm.DestPath, fm.DestPath, "", cfg.now(), func(target string) error {
+		return fsys.Link(fm.SourcePath, target)
+	})
+	if finalDestPath != fm.DestPath {
+		p.File(LevelWarn, "COLLISION", "Renaming '%s' to '%s'", filepath.Base(fm.DestPath), filepath.Base(finalDestPath))
+	}
+	if fsutil.IsCrossDevice(err) {
+		return fail(fmt.Errorf("cannot link '%s' into '%s': hard links cannot cross file systems, the destination must be on the same one as the source", fm.SourcePath, destDir))
+	}
+	if err != nil {
+		return fail(fmt.Errorf("failed to link '%s' at '%s': %w", fm.SourcePath, finalDestPath, err))
+	}
+	cfg.persist(finalDestPath)
+	cfg.Journal.Record(journal.Entry{Op: journal.OpLink, Source: fm.SourcePath, Dest: finalDestPath, Size: fm.Info.Size(), Hash: cfg.hashes.known(fm.SourcePath, fm.Info)})
+
+	p.File(LevelSuccess, "LINKED", "Linked '%s' at '%s'", fm.SourcePath, finalDestPath)
+	cfg.fileStored(fm.SourcePath, finalDestPath, fm.Category, progressChan)
+	progressChan <- fm.movedUpdate(ActionLink, finalDestPath)
+	return nil
+}
@@ -0,0 +1,225 @@
+package organizer
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// manifestNames are the names of the checksum manifests per algorithm, those the tools that
+// check them expect: xxhsum -H64, sha256sum and b3sum, whose format they are in.
+var manifestNames = map[string]string{HashXXH64: "XXH64SUMS", HashSHA256: "SHA256SUMS", HashBLAKE3: "B3SUMS"}
+
+// ManifestName returns the name of the checksum manifests of the algorithm alg, XXH64SUMS for
+// the default.
+func ManifestName(alg string) string {
+	return manifestNames[cmp.Or(alg, HashXXH64)]
+}
+
+// IsManifest reports whether name is the name of a checksum manifest of any algorithm.
+func IsManifest(name string) bool {
+	return manifestAlgorithm(name) != ""
+}
+
+// manifestAlgorithm returns the algorithm of the manifest named name, or "" if it is none.
+func manifestAlgorithm(name string) string {
+	for alg, manifest := range manifestNames {
+		if name == manifest {
+			return alg
+		}
+	}
+	return ""
+}
+
+// Where checksum manifests are written.
+const (
+	ManifestCategory = "category" // One per folder right below the destination, the category with the default layout
+	ManifestGlobal   = "global"   // One at the root of the destination
+)
+
+// ValidManifest reports whether mode is one of the supported manifest modes.
+func ValidManifest(mode string) bool {
+	return mode == ManifestCategory || mode == ManifestGlobal
+}
+
+// checksums collects the checksum of every file stored during a run, by manifest, for Config.Manifest.
+// Workers add to it concurrently.
+type checksums struct {
+	mu   sync.Mutex
+	sums map[string]map[string]string // Manifest path -> slash separated name relative to it -> hex digest
+}
+
+// add hashes the file just stored at dest from source, reusing the checksum of source if the run
+// has it. Files staged for review are left out: they move again once approved.
+func (c *checksums) add(cfg Config, source, dest, category string) {
+	if c == nil || category == ReviewDir {
+		return
+	}
+	manifest, name := cfg.manifestFor(dest)
+	sum, err := cfg.hashes.sum(cfg.fsys(), dest, nil, source)
+	if err != nil {
+		cfg.printer().File(LevelWarn, "WARNING", "Could not checksum '%s' for %s: %v", dest, manifest, err)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sums[manifest] == nil {
+		c.sums[manifest] = make(map[string]string)
+	}
+	c.sums[manifest][name] = hex.EncodeToString(sum)
+}
+
+// manifestFor returns the manifest dest is listed in and its name there.
+func (cfg Config) manifestFor(dest string) (manifest, name string) {
+	rel, err := filepath.Rel(cfg.DestDir, dest)
+	if err != nil {
+		rel = filepath.Base(dest)
+	}
+	rel = filepath.ToSlash(rel)
+	file := ManifestName(cfg.hashes.algorithm())
+	if top, rest, nested := strings.Cut(rel, "/"); cfg.Manifest == ManifestCategory && nested {
+		return filepath.Join(cfg.DestDir, top, file), rest
+	}
+	return filepath.Join(cfg.DestDir, file), rel
+}
+
+// write merges the collected checksums into their manifests. Entries of earlier runs are kept,
+// unless the same name was stored again.
+func (c *checksums) write(cfg Config) {
+	if c == nil {
+		return
+	}
+	p := cfg.printer()
+	for _, manifest := range slices.Sorted(maps.Keys(c.sums)) {
+		sums, err := readManifest(cfg.fsys(), manifest, cfg.hashes.algorithm())
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			p.Status(LevelWarn, "⚠️", "Could not read %s, rewriting it with this run's files only: %v", manifest, err)
+			sums = nil
+		}
+		if sums == nil {
+			sums = make(map[string]string)
+		}
+		for name, sum := range c.sums[manifest] {
+			sums[name] = sum
+		}
+		if err := writeManifest(cfg.fsys(), manifest, sums); err != nil {
+			p.Status(LevelError, "❌", "%v", err)
+			continue
+		}
+		p.Status(LevelInfo, "🔏", "Checksums of %d files recorded in %s.", len(c.sums[manifest]), manifest)
+	}
+}
+
+// readManifest parses the manifest of alg checksums at path on fsys, in the sha256sum format, into
+// name -> hex digest.
+func readManifest(fsys fsutil.FS, path, alg string) (map[string]string, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sums := make(map[string]string)
+	digits := 2 * newHash(alg).Size()
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		sum, name, ok := strings.Cut(text, " ")
+		if !ok || len(sum) != digits || len(name) < 2 || (name[0] != ' ' && name[0] != '*') {
+			return nil, fmt.Errorf("%s:%d: not a %s checksum line", path, line, alg)
+		}
+		sums[name[1:]] = strings.ToLower(sum)
+	}
+	return sums, scanner.Err()
+}
+
+// writeManifest replaces the manifest at path on fsys with sums, sorted by name.
+func writeManifest(fsys fsutil.FS, path string, sums map[string]string) error {
+	var b strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(sums)) {
+		fmt.Fprintf(&b, "%s  %s\n", sums[name], name)
+	}
+	tmp, err := stage(fsys, filepath.Dir(path), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	_, err = io.WriteString(tmp, b.String())
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = fsys.Rename(tmp.path, path)
+	}
+	if err != nil {
+		tmp.abort()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// ManifestResult is the outcome of checking one entry of a manifest.
+type ManifestResult struct {
+	Name string // As listed in the manifest
+	Path string // Where the file was looked for
+	Err  error  // nil if the file is there with the listed checksum
+}
+
+// ErrChecksumMismatch is reported for files whose content no longer matches their manifest.
+var ErrChecksumMismatch = errors.New("checksum does not match")
+
+// VerifyManifest checks every file listed in the manifest at path against its checksum, workers
+// files at a time (0 means one per CPU). The algorithm is told by the name of the manifest, see
+// ManifestName; manifests named otherwise are taken for SHA-256. Names are relative to the
+// directory of the manifest.
+func VerifyManifest(path string, workers int) ([]ManifestResult, error) {
+	alg := cmp.Or(manifestAlgorithm(filepath.Base(path)), HashSHA256)
+	sums, err := readManifest(fsutil.OS, path, alg)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(path)
+	names := slices.Sorted(maps.Keys(sums))
+	results := make([]ManifestResult, len(names))
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	slots := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		slots <- struct{}{} // Only as many goroutines as files hashed at once
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			r := ManifestResult{Name: name, Path: filepath.Join(dir, filepath.FromSlash(name))}
+			info, err := fsutil.OS.Stat(r.Path)
+			var sum []byte
+			if err == nil {
+				sum, err = hashFile(fsutil.OS, r.Path, info.Size(), alg)
+			}
+			switch {
+			case err != nil:
+				r.Err = err
+			case hex.EncodeToString(sum) != sums[name]:
+				r.Err = ErrChecksumMismatch
+			}
+			results[i] = r
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
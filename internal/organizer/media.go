@@ -0,0 +1,56 @@
+package organizer
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Folders of MediaNames in the category folder of videos.
+const (
+	ShowsFolder  = "Shows"
+	MoviesFolder = "Movies"
+)
+
+var (
+	// episodeName matches release names of episodes: "Show.Name.S01E02...", "Show Name - 1x02".
+	episodeName = regexp.MustCompile(`^(.*?)[\s._-]*(?:[Ss](\d{1,2})[\s._-]?[Ee]\d{1,3}|\b(\d{1,2})[xX]\d{2}\b)`)
+	// movieName matches release names of movies: "Movie.Name.2019.1080p...", "Movie Name (2019)".
+	// The last year is the one of the movie, "Blade.Runner.2049.2017" is from 2017.
+	movieName = regexp.MustCompile(`^(.+)[\s._-]*[(\[]?\b((?:19|20)\d{2})\b[)\]]?`)
+	// trailingYear is the year some shows carry to tell them from older ones: "Doctor Who 2005".
+	trailingYear = regexp.MustCompile(`^(.+) \(?((?:19|20)\d{2})\)?$`)
+)
+
+// mediaFolder returns the folder below the category folder that MediaNames puts the video named
+// name in: Shows/<show>/Season <n> for episodes, Movies/<title> (<year>) for movies, and whether
+// the name is one of those.
+func mediaFolder(name string) (string, bool) {
+	base := strings.TrimSuffix(name, path.Ext(name))
+	if m := episodeName.FindStringSubmatch(base); m != nil {
+		show := releaseTitle(m[1])
+		if show == "" {
+			return "", false
+		}
+		if y := trailingYear.FindStringSubmatch(show); y != nil {
+			show = fmt.Sprintf("%s (%s)", y[1], y[2])
+		}
+		season, _ := strconv.Atoi(m[2] + m[3])
+		return path.Join(ShowsFolder, show, fmt.Sprintf("Season %d", season)), true
+	}
+	if m := movieName.FindStringSubmatch(base); m != nil {
+		if title := releaseTitle(m[1]); title != "" {
+			return path.Join(MoviesFolder, fmt.Sprintf("%s (%s)", title, m[2])), true
+		}
+	}
+	return "", false
+}
+
+// releaseTitle turns the title part of a release name into a folder name: dots and underscores
+// become spaces, and separators and brackets at the ends go.
+func releaseTitle(s string) string {
+	s = strings.NewReplacer(".", " ", "_", " ").Replace(s)
+	return strings.Trim(strings.Join(strings.Fields(s), " "), " -([")
+}
@@ -0,0 +1,53 @@
+package organizer
+
+import (
+	"fmt"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// fileMIME returns the MIME type of the file at path on fsys without parameters, e.g.
+// "video/mp4". It is sniffed from the first bytes; only when they don't tell is it looked up by
+// the extension ext.
+func fileMIME(fsys fsutil.FS, filePath, ext string) string {
+	sniffed := sniffMIME(fsys, filePath)
+	if byExt := extMIME(ext); (sniffed == "" || sniffed == "application/octet-stream") && byExt != "" {
+		return byExt
+	}
+	mediaType, _, _ := strings.Cut(sniffed, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// extMIME returns the MIME type registered for the extension ext without parameters, or "".
+func extMIME(ext string) string {
+	mediaType, _, _ := strings.Cut(mime.TypeByExtension(ext), ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// matchesMIME reports whether mimeType matches one of patterns, which are MIME types with
+// wildcards like "video/*" (case-insensitive).
+func matchesMIME(mimeType string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), mimeType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkMIMEPatterns returns an error for the first of patterns that is not a MIME type pattern.
+func checkMIMEPatterns(patterns []string) error {
+	for _, p := range patterns {
+		typ, sub, ok := strings.Cut(p, "/")
+		if !ok || typ == "" || sub == "" {
+			return fmt.Errorf("'%s' is not a MIME type like video/mp4 or video/*", p)
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern '%s': %w", p, err)
+		}
+	}
+	return nil
+}
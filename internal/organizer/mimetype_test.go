@@ -0,0 +1,21 @@
+package organizer
+
+import (
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+func TestFileMIME(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "picture.txt")
+	writeFile(t, path, "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	if got := fileMIME(fsutil.OS, path, ".txt"); got != "image/png" {
+		t.Errorf("fileMIME = %q, want image/png sniffed from the content", got)
+	}
+	if got := fileMIME(failing(dir, syscall.EACCES, "open"), path, ".txt"); got != "text/plain" {
+		t.Errorf("fileMIME = %q, want text/plain by the extension when the file system cannot open it", got)
+	}
+}
@@ -0,0 +1,144 @@
+package organizer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// Mirror is a secondary destination that receives a copy of every organized file, in the same
+// layout as the primary destination. Mirror failures are reported separately and never undo the
+// primary operation.
+type Mirror struct {
+	Dir    string // Local directory, which may be a mounted SMB/NFS share
+	Remote Remote // Or a WebDAV server, an S3 bucket or an SFTP server
+}
+
+// NewMirror creates the mirror for the --mirror target, a local directory or a WebDAV, S3 or
+// SFTP URL.
+func NewMirror(target string) (*Mirror, error) {
+	if IsRemoteDest(target) {
+		remote, err := NewRemote(target)
+		if err != nil {
+			return nil, configError("--mirror", err)
+		}
+		return &Mirror{Remote: remote}, nil
+	}
+	dir, err := filepath.Abs(target)
+	if err != nil {
+		return nil, configError("--mirror", fmt.Errorf("invalid mirror directory '%s': %w", target, err))
+	}
+	return &Mirror{Dir: dir}, nil
+}
+
+// String returns the mirror location for display.
+func (m *Mirror) String() string {
+	if m.Remote != nil {
+		return m.Remote.String()
+	}
+	return m.Dir
+}
+
+// mirrorFile copies the organized file at destPath to the mirror, if one is configured. With
+// replace, an existing copy is overwritten (used for archives that grow over time); otherwise
+// collisions are resolved with a timestamp suffix like in the primary destination.
+func (cfg Config) mirrorFile(destPath string, replace bool, progressChan chan<- ProgressUpdate) {
+	if cfg.Mirror == nil {
+		return
+	}
+	rel, err := filepath.Rel(cfg.DestDir, destPath)
+	if err == nil && strings.HasPrefix(rel, "..") {
+		err = fmt.Errorf("'%s' is outside of the destination", destPath)
+	}
+	if err == nil {
+		if cfg.Mirror.Remote != nil {
+			err = cfg.Mirror.upload(cfg.fsys(), destPath, filepath.ToSlash(rel), replace, cfg.now())
+		} else {
+			err = cfg.Mirror.copyLocal(cfg.fsys(), destPath, filepath.Join(cfg.Mirror.Dir, rel), replace, cfg.now())
+		}
+	}
+	if err != nil {
+		cfg.printer().File(LevelError, "MIRROR ERROR", "failed to mirror '%s' to '%s': %v", destPath, cfg.Mirror, err)
+		progressChan <- ProgressUpdate{MirrorErrored: 1}
+		return
+	}
+	cfg.printer().File(LevelSuccess, "MIRRORED", "Copied '%s' to '%s'", destPath, cfg.Mirror)
+}
+
+// copyLocal copies src, read from fsys, to dst below the mirror directory. Collisions get a
+// suffix with the time now.
+func (m *Mirror) copyLocal(fsys fsutil.FS, src, dst string, replace bool, now time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	info, err := fsys.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	// Written under a staged name and moved into place, so the mirror never holds a partial copy
+	out, err := stage(fsutil.OS, filepath.Dir(dst), 0644)
+	if err != nil {
+		return err
+	}
+	err = copyFileInto(fsys, out, src)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = out.verify(info.Size())
+	}
+	if err != nil {
+		out.abort()
+		return err
+	}
+	os.Chtimes(out.path, info.ModTime(), info.ModTime())
+	fsutil.CopyXattrs(src, out.path)
+	if replace {
+		if err := os.Rename(out.path, dst); err != nil {
+			out.abort()
+			return err
+		}
+		return nil
+	}
+	_, err = out.commitUnique(dst, "", now)
+	return err
+}
+
+// upload copies src, read from fsys, to rel on the remote mirror. Collisions get a suffix with
+// the time now.
+func (m *Mirror) upload(fsys fsutil.FS, src, rel string, replace bool, now time.Time) error {
+	if err := m.Remote.MkdirAll(path.Dir(rel)); err != nil {
+		return err
+	}
+	if replace {
+		f, err := fsys.Open(src)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		return m.Remote.Overwrite(rel, f, info.Size())
+	}
+
+	err := uploadOnce(fsys, src, m.Remote, rel)
+	if errors.Is(err, errRemoteExists) {
+		ext := path.Ext(rel)
+		name := strings.TrimSuffix(path.Base(rel), ext)
+		rel = path.Join(path.Dir(rel), fmt.Sprintf("%s_%s%s", name, now.Format("20060102_150405"), ext))
+		err = uploadOnce(fsys, src, m.Remote, rel)
+	}
+	return err
+}
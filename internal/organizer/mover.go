@@ -0,0 +1,153 @@
+package organizer
+
+import (
+	"path/filepath"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// mover is one way of placing a file at its destination, the last step of processing it after
+// hydration, the before_file hook and the duplicate check. placeFile picks the mover of every file
+// with Config.mover, by the kind of destination, the mode of the run and whether the source and
+// the destination are on the same device. A mover sends the updates of the file and reports its
+// failures itself. New transports get a mover of their own instead of another branch in one of
+// the others.
+type mover interface {
+	action(cfg Config, fm FileMove) Action // What placing fm is, for its events before it is done
+	place(fm FileMove, cfg Config, progressChan chan<- ProgressUpdate) error
+}
+
+// The movers of placeFile.
+type (
+	renameMover     struct{}                    // Renamed within a file system; copied and removed if the rename fails across devices
+	copyDeleteMover struct{}                    // Copied and removed, for a destination on another device than the source
+	hardlinkMover   struct{}                    // Hard linked, the source stays, see ModeHardlink
+	syncMover       struct{}                    // Copied if missing or different, the source stays, see Config.Sync
+	transformMover  struct{}                    // Compressed and/or encrypted into place, see Config.Compress and Config.EncryptTo
+	webdavMover     struct{ dav *WebDAVClient } // Uploaded to the WebDAV destination and removed locally
+	s3Mover         struct{ s3 *S3Client }      // Uploaded to the S3 bucket and removed locally
+	sftpMover       struct{ sftp *SFTPClient }  // Uploaded to the SFTP server and removed locally
+)
+
+// mover returns the mover that places fm.
+func (cfg Config) mover(fm FileMove) mover {
+	switch {
+	case cfg.Remote != nil:
+		return remoteMover(cfg.Remote)
+	case cfg.Sync:
+		return syncMover{}
+	case cfg.Mode == ModeHardlink:
+		return hardlinkMover{}
+	case fm.Review == "" && (cfg.shouldCompress(fm) || cfg.shouldEncrypt(fm)):
+		return transformMover{}
+	case cfg.crossDevice:
+		return copyDeleteMover{}
+	}
+	return renameMover{}
+}
+
+// moveAction is the action of the movers that move fm.
+func moveAction(fm FileMove) Action {
+	if fm.Review != "" {
+		return ActionReview
+	}
+	return ActionMove
+}
+
+func (renameMover) action(cfg Config, fm FileMove) Action { return moveAction(fm) }
+
+func (renameMover) place(fm FileMove, cfg Config, progressChan chan<- ProgressUpdate) error {
+	return moveFile(fm, cfg, progressChan, Config.renameMove)
+}
+
+// renameMove renames the source of fm to dest, falling back to copyMove where the rename cannot
+// work across devices. It fails with os.ErrExist instead of replacing a file at dest.
+func (cfg Config) renameMove(fm FileMove, dest string, action Action, progressChan chan<- ProgressUpdate) error {
+	err := fsutil.RenameNoReplace(cfg.fsys(), fm.SourcePath, dest)
+	if err == nil {
+		cfg.persist(dest, filepath.Dir(fm.SourcePath))
+	} else if fsutil.IsCrossDevice(err) {
+		err = cfg.copyMove(fm, dest, action, progressChan)
+	}
+	return err
+}
+
+func (copyDeleteMover) action(cfg Config, fm FileMove) Action { return moveAction(fm) }
+
+func (copyDeleteMover) place(fm FileMove, cfg Config, progressChan chan<- ProgressUpdate) error {
+	return moveFile(fm, cfg, progressChan, Config.copyMove)
+}
+
+func (hardlinkMover) action(cfg Config, fm FileMove) Action { return ActionLink }
+
+func (hardlinkMover) place(fm FileMove, cfg Config, progressChan chan<- ProgressUpdate) error {
+	return linkFile(fm, cfg, progressChan)
+}
+
+func (syncMover) action(cfg Config, fm FileMove) Action { return ActionCopy }
+
+func (syncMover) place(fm FileMove, cfg Config, progressChan chan<- ProgressUpdate) error {
+	return syncFile(fm, cfg, progressChan)
+}
+
+func (transformMover) action(cfg Config, fm FileMove) Action {
+	if cfg.shouldEncrypt(fm) {
+		return ActionEncrypt
+	}
+	return ActionCompress
+}
+
+func (transformMover) place(fm FileMove, cfg Config, progressChan chan<- ProgressUpdate) error {
+	return transformFile(fm, cfg, progressChan)
+}
+
+// remoteMover returns the mover of the transport of remote. Only this package implements Remote.
+func remoteMover(remote Remote) mover {
+	switch r := remote.(type) {
+	case *S3Client:
+		return s3Mover{r}
+	case *SFTPClient:
+		return sftpMover{r}
+	default:
+		return webdavMover{r.(*WebDAVClient)}
+	}
+}
+
+func (webdavMover) action(cfg Config, fm FileMove) Action { return ActionUpload }
+
+func (m webdavMover) place(fm FileMove, cfg Config, progressChan chan<- ProgressUpdate) error {
+	return uploadFile(fm, cfg, m.dav, progressChan)
+}
+
+func (s3Mover) action(cfg Config, fm FileMove) Action { return ActionUpload }
+
+func (m s3Mover) place(fm FileMove, cfg Config, progressChan chan<- ProgressUpdate) error {
+	return uploadFile(fm, cfg, m.s3, progressChan)
+}
+
+func (sftpMover) action(cfg Config, fm FileMove) Action { return ActionUpload }
+
+func (m sftpMover) place(fm FileMove, cfg Config, progressChan chan<- ProgressUpdate) error {
+	return uploadFile(fm, cfg, m.sftp, progressChan)
+}
+
+// crossDevice reports whether the source and the destination directory on fsys are known to be on
+// different devices, where no file can be renamed from one to the other. A destination that does
+// not exist yet is looked up by the directory it will be created in.
+func crossDevice(fsys fsutil.FS, source, dest string) bool {
+	srcInfo, err := fsys.Stat(source)
+	if err != nil {
+		return false
+	}
+	destInfo, err := fsys.Stat(dest)
+	for err != nil && filepath.Dir(dest) != dest {
+		dest = filepath.Dir(dest)
+		destInfo, err = fsys.Stat(dest)
+	}
+	if err != nil {
+		return false
+	}
+	src, ok := deviceOf(srcInfo)
+	dst, ok2 := deviceOf(destInfo)
+	return ok && ok2 && src != dst
+}
@@ -0,0 +1,51 @@
+package organizer
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	// isoNameDate matches year first dates in file names: 2024-03-12, 2024_03_12, 2024.03.12 and
+	// 20240312, not inside longer runs of digits.
+	isoNameDate = regexp.MustCompile(`(?:^|\D)((?:19|20)\d{2})([-_.]?)(\d{2})(\d{2}|[-_.]\d{2})(?:\D|$)`)
+	// dayFirstNameDate matches year last dates: 12.03.2024, 12-03-2024, 12_03_2024.
+	dayFirstNameDate = regexp.MustCompile(`(?:^|\D)(\d{1,2})([-_.])(\d{1,2})([-_.])((?:19|20)\d{2})(?:\D|$)`)
+)
+
+// nameDate returns the date in the file name name, as scanners and phones put it there, or
+// fallback if there is none. Dates with the year last are taken as day first (12.03.2024 is the
+// 12th of March), unless only the other way round is a valid date (03/25/2024).
+func nameDate(name string, fallback time.Time) time.Time {
+	if m := isoNameDate.FindStringSubmatch(name); m != nil && (m[2] == "") == (len(m[4]) == 2) {
+		dayPart := m[4]
+		if len(dayPart) == 3 {
+			if dayPart[0] != m[2][0] {
+				return fallback // Mixed separators, as in 2024-03_12
+			}
+			dayPart = dayPart[1:]
+		}
+		if t, ok := validDate(m[1], m[3], dayPart); ok {
+			return t
+		}
+	}
+	if m := dayFirstNameDate.FindStringSubmatch(name); m != nil && m[2] == m[4] {
+		if t, ok := validDate(m[5], m[3], m[1]); ok {
+			return t
+		}
+		if t, ok := validDate(m[5], m[1], m[3]); ok {
+			return t
+		}
+	}
+	return fallback
+}
+
+// validDate returns the date of year, month and day in local time, and whether it exists.
+func validDate(year, month, day string) (time.Time, bool) {
+	y, _ := strconv.Atoi(year)
+	m, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+	t := time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.Local)
+	return t, t.Year() == y && int(t.Month()) == m && t.Day() == d
+}
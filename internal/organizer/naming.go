@@ -0,0 +1,103 @@
+package organizer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// FolderNaming turns categories into the folder names {category} expands to in the layout.
+// Categories keep their names everywhere else: in the mappings, rules, --only and quotas.
+type FolderNaming struct {
+	Names     map[string]string // Folder name per category, e.g. "Images": "Photos"; before Language
+	Language  string            // Built-in folder names of this language for the default categories, see FolderLanguages
+	Lowercase bool              // Lowercase the folder names
+	Numbered  bool              // Prefix the folder names with their position for ordering, "01_Documents"
+	Order     []string          // Categories in the order they are numbered; the others follow by name, Others last
+}
+
+// folderNames are the built-in translations of the default categories.
+var folderNames = map[string]map[string]string{
+	"de": {"Images": "Bilder", "Videos": "Videos", "Audio": "Audio", "Documents": "Dokumente", "Archives": "Archive", "Code": "Code", "Executables": "Programme", "Others": "Sonstiges"},
+	"es": {"Images": "Imágenes", "Videos": "Vídeos", "Audio": "Audio", "Documents": "Documentos", "Archives": "Comprimidos", "Code": "Código", "Executables": "Ejecutables", "Others": "Otros"},
+	"fr": {"Images": "Images", "Videos": "Vidéos", "Audio": "Audio", "Documents": "Documents", "Archives": "Archives", "Code": "Code", "Executables": "Exécutables", "Others": "Autres"},
+	"it": {"Images": "Immagini", "Videos": "Video", "Audio": "Audio", "Documents": "Documenti", "Archives": "Archivi", "Code": "Codice", "Executables": "Eseguibili", "Others": "Altro"},
+	"nl": {"Images": "Afbeeldingen", "Videos": "Video's", "Audio": "Audio", "Documents": "Documenten", "Archives": "Archieven", "Code": "Code", "Executables": "Programma's", "Others": "Overig"},
+	"pt": {"Images": "Imagens", "Videos": "Vídeos", "Audio": "Áudio", "Documents": "Documentos", "Archives": "Compactados", "Code": "Código", "Executables": "Executáveis", "Others": "Outros"},
+}
+
+// FolderLanguages returns the languages with built-in folder names, sorted.
+func FolderLanguages() []string {
+	langs := make([]string, 0, len(folderNames))
+	for lang := range folderNames {
+		langs = append(langs, lang)
+	}
+	slices.Sort(langs)
+	return langs
+}
+
+// FolderLanguage returns the language with built-in folder names that matches the language tag
+// or locale lang ("de", "pt_BR.UTF-8"), and whether there is one.
+func FolderLanguage(lang string) (string, bool) {
+	lang, _, _ = strings.Cut(strings.ToLower(strings.ReplaceAll(lang, "_", "-")), "-")
+	lang, _, _ = strings.Cut(lang, ".")
+	_, ok := folderNames[lang]
+	return lang, ok
+}
+
+// validate checks the names and the language of n.
+func (n FolderNaming) validate() error {
+	if n.Language != "" {
+		if _, ok := folderNames[n.Language]; !ok {
+			return fmt.Errorf("no folder names for language '%s' (available: %s)", n.Language, strings.Join(FolderLanguages(), ", "))
+		}
+	}
+	for category, name := range n.Names {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return fmt.Errorf("invalid folder name '%s' for category '%s'", name, category)
+		}
+	}
+	return nil
+}
+
+// categoryFolder returns the folder name of category under cfg.Naming.
+func (cfg Config) categoryFolder(category string) string {
+	n := cfg.Naming
+	name, ok := n.Names[category]
+	if !ok {
+		if name, ok = folderNames[n.Language][category]; !ok {
+			name = category
+		}
+	}
+	if n.Lowercase {
+		name = strings.ToLower(name)
+	}
+	if n.Numbered {
+		if i := slices.Index(cfg.numberedCategories(), category); i >= 0 {
+			name = fmt.Sprintf("%02d_%s", i+1, name)
+		}
+	}
+	return name
+}
+
+// numberedCategories returns the categories Naming.Numbered numbers, in order: those of
+// Naming.Order, then the other known ones by name, then Others. Categories only a classifier
+// knows are not numbered, unless they are in Naming.Order.
+func (cfg Config) numberedCategories() []string {
+	var rest []string
+	for _, category := range cfg.CategoryMappings {
+		rest = append(rest, category)
+	}
+	for category := range cfg.Naming.Names {
+		rest = append(rest, category)
+	}
+	slices.Sort(rest)
+	rest = slices.DeleteFunc(slices.Compact(rest), func(category string) bool {
+		return category == "Others" || slices.Contains(cfg.Naming.Order, category)
+	})
+	categories := append(slices.Clone(cfg.Naming.Order), rest...)
+	if !slices.Contains(categories, "Others") {
+		categories = append(categories, "Others")
+	}
+	return categories
+}
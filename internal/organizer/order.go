@@ -0,0 +1,61 @@
+package organizer
+
+import (
+	"sort"
+	"strings"
+)
+
+// Orders files can be dispatched to the workers in.
+const (
+	OrderName     = "name"      // By file name, case-insensitive
+	OrderSizeAsc  = "size-asc"  // Smallest first, for quickly visible progress
+	OrderSizeDesc = "size-desc" // Largest first, so the long copies don't trail at the end
+	OrderMtime    = "mtime"     // Oldest first
+)
+
+// ValidOrder reports whether order is one of the supported orders.
+func ValidOrder(order string) bool {
+	switch order {
+	case OrderName, OrderSizeAsc, OrderSizeDesc, OrderMtime:
+		return true
+	}
+	return false
+}
+
+// sortFiles sorts files in place into order. Ties, and an empty order, keep the scan order.
+func sortFiles(files []FileMove, order string) {
+	var less func(a, b FileMove) bool
+	switch order {
+	case OrderName:
+		less = func(a, b FileMove) bool {
+			return strings.ToLower(a.Info.Name()) < strings.ToLower(b.Info.Name())
+		}
+	case OrderSizeAsc:
+		less = func(a, b FileMove) bool { return a.Info.Size() < b.Info.Size() }
+	case OrderSizeDesc:
+		less = func(a, b FileMove) bool { return a.Info.Size() > b.Info.Size() }
+	case OrderMtime:
+		less = func(a, b FileMove) bool { return a.Info.ModTime().Before(b.Info.ModTime()) }
+	default:
+		return
+	}
+	sort.SliceStable(files, func(i, j int) bool { return less(files[i], files[j]) })
+}
+
+// limitFiles picks the files of a run limited to maxFiles files and maxBytes bytes (either may be
+// 0 for no limit), in the order of files. A file too large for the bytes left is passed over for
+// later ones that still fit, so a single huge file doesn't hold back every later run. It returns
+// the picked files and the ones left for a later run.
+func limitFiles(files []FileMove, maxFiles int, maxBytes int64) (picked, deferred []FileMove) {
+	var total int64
+	for _, fm := range files {
+		size := fm.Info.Size()
+		if (maxFiles > 0 && len(picked) >= maxFiles) || (maxBytes > 0 && total+size > maxBytes) {
+			deferred = append(deferred, fm)
+			continue
+		}
+		picked = append(picked, fm)
+		total += size
+	}
+	return picked, deferred
+}
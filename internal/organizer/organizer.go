@@ -2,39 +2,318 @@
 package organizer
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
-	"sync"
 	"time"
 
-	"github.com/fatih/color"
+	"filippo.io/age"
+	"github.com/avizyt/org-cli/internal/fsutil"
+	"github.com/avizyt/org-cli/internal/journal"
 )
 
 // Config holds the configuration for the file organizer.
 type Config struct {
-	SourceDir        string            // Directory to scan
-	DestDir          string            // Directory where organized files will be moved
-	DryRun           bool              // If true, only print actions, don't move files
-	Recursive        bool              // If true, scan subdirectories
-	Workers          int               // Number of concurrent workers for file operations
-	CategoryMappings map[string]string // Custom or merged category mappings
-	Quiet            bool
+	SourceDir          string            // Directory to scan
+	DestDir            string            // Directory where organized files will be moved
+	DryRun             bool              // If true, only print actions, don't move files
+	AllowDangerous     bool              // Organize sources CheckSource refuses, such as / or the home folder
+	Recursive          bool              // If true, scan subdirectories
+	Files              []string          // If non-nil, only these files under SourceDir are organized instead of walking it
+	Control            *Controller       // Optional handle to pause and resume processing from another goroutine
+	Workers            int               // Number of concurrent workers for file operations; 0 picks one with AutoWorkers
+	Order              string            // Order files are dispatched to the workers in (see OrderName, ...); empty is scan order
+	MaxFiles           int               // If > 0, at most this many files are processed per run, in Order (default oldest first)
+	MaxBytes           int64             // If > 0, at most this many bytes are processed per run, in Order (default oldest first)
+	TopN               int               // If > 0, the scan reports this many of the largest files and directories per category
+	MaxMemory          int64             // If > 0, planned moves beyond about this many bytes of memory are spilled to a temporary file
+	CountExtensions    bool              // Count the files and bytes per extension found by the scan, see ExtensionStat
+	BandwidthLimit     int64             // If > 0, copies are limited to this many bytes per second, shared by all workers
+	CategoryMappings   map[string]string // Custom or merged category mappings
+	ExtGroups          ExtGroups         // Named extension groups (lower case, with the dot) for {group} in the layout and classifiers
+	Verbosity          Verbosity         // Which messages are printed; the zero value prints every file's outcome
+	OnlyMine           bool              // If true, only organize files owned by the invoking user (Unix only)
+	SkipTopDirs        []string          // First-level folder names under SourceDir to leave alone (case-insensitive)
+	IncludeMIME        []string          // If set, only files whose MIME type matches one of these patterns ("video/*") are organized
+	ExcludeMIME        []string          // Files whose MIME type matches one of these patterns are left alone
+	OnlyCategories     []string          // If set, only files classified into one of these categories are organized (case-insensitive)
+	SkipCategories     []string          // Files classified into one of these categories are left alone (case-insensitive)
+	MinCategoryFiles   int               // If > 1, categories with fewer files and no folder yet get no folder of their own, see SmallCategories
+	EventGap           time.Duration     // If > 0, photos and videos taken less than this apart go into a shared event folder
+	EventCategories    []string          // Categories EventGap groups; empty means DefaultEventCategories
+	ClipsUnder         time.Duration     // If > 0, videos shorter than this go into ClipsFolder in their category folder
+	MediaNames         bool              // Put videos named like episode or movie releases into ShowsFolder or MoviesFolder
+	SimilarImages      string            // Find near-duplicate photos: SimilarReport flags them in the results, SimilarMove also puts them into SimilarFolder
+	Vendors            map[string]string // Folder per vendor name found in document file names, e.g. "Chase": "Banking/Chase"
+	Corrupt            string            // Empty and damaged files: CorruptMove puts them into CorruptFolder, CorruptSkip leaves them in place; organized as usual if empty
+	SmallCategories    string            // What happens to the files of those: SmallCategoryOthers (default) or SmallCategoryLeave
+	Unreadable         string            // What to do with entries the scan is denied access to: UnreadableReport (default), UnreadableSkip or UnreadableFail
+	Hidden             string            // What to do with hidden files and folders: HiddenSkip (default), HiddenInclude or HiddenOnly
+	Remote             Remote            // If set, files are uploaded to this WebDAV, S3 or SFTP destination instead of moved into DestDir
+	CloudPlaceholders  PlaceholderPolicy // What to do with online-only cloud files (default: skip)
+	StripQuarantine    bool              // Drop the macOS quarantine flag of organized files instead of keeping it
+	Fsync              bool              // Flush every placed file and the directories it was added to or removed from to disk
+	LeaveSymlink       bool              // Leave a symlink to the new location at the original path of every moved file
+	Manifest           string            // If set (ManifestCategory or ManifestGlobal), checksums of stored files are written to manifests, see ManifestName
+	Hash               string            // Checksum algorithm of duplicate checks, manifests and the journal: HashXXH64 (default), HashSHA256 or HashBLAKE3
+	HashWorkers        int               // Files hashed at once, separately from Workers; 0 means one per CPU
+	FileTimeout        time.Duration     // If > 0, workers give up on files that make no progress for this long, see ErrFileTimeout
+	FileRetries        int               // Further attempts at files that failed because they were in use or their share dropped out, see DefaultFileRetries
+	ArchiveOlderThan   time.Duration     // If > 0, files older than this are packed into per-month archives instead of moved
+	ArchiveFormat      string            // Format of the per-month archives: "zip" or "tar.zst"
+	Compress           string            // If set ("gzip" or "zstd"), files are stored compressed in the destination
+	CompressCategories []string          // Categories to compress; empty means all
+	EncryptTo          []age.Recipient   // If set, files are stored encrypted to these age recipients
+	EncryptCategories  []string          // Categories to encrypt; empty means all
+	Mirror             *Mirror           // If set, every organized file is also copied here
+	Sync               bool              // Copy only files missing from the destination, leaving the source untouched
+	Mode               string            // How files get into DestDir: ModeMove (default) or ModeHardlink
+	OnConflict         string            // What to do when a destination is taken: ConflictRename (default), ConflictBackup, ConflictTrash, ConflictKeepNewest or ConflictKeepLargest
+	ConflictLoser      string            // With keep-newest and keep-largest, what happens to the other file: ConflictBackup (default), ConflictTrash or ConflictDelete
+	Asker              ConflictAsker     // Decides every collision when OnConflict is ConflictAsk
+	SkipDuplicates     bool              // Leave files in place that would be moved onto the same content, see ErrDuplicate
+	Dedupe             string            // What to do with files whose content is anywhere in DestDir already: DedupeSkip or DedupeLink; empty disables IndexFile
+	Layout             string            // Template of the folder below DestDir files are put in; empty is DefaultLayout
+	Naming             FolderNaming      // How {category} in the layout is turned into folder names
+	Tiers              []Tier            // Age bands for {tier} in the layout, put first if the layout has no {tier}
+	ReviewCategories   []string          // Categories staged in ReviewDir for approval instead of being organized
+	Quotas             []Quota           // Size limits per category, checked after the run
+	Hooks              Hooks             // Commands run before/after each file (the run-level hooks are run by the caller)
+	Classifiers        []FileClassifier  // Plugins and rules that decide category and destination folder per file, in order
+	Journal            *journal.Journal  // Records completed operations for undo; nil disables journaling
+	FS                 fsutil.FS         // File system the scan and the movers work on; nil means fsutil.OS
+	Printer            Printer           // Receives the console messages; nil prints plain lines to stdout
+	Clock              Clock             // Source of the times a run records or puts into file names; nil means time.Now
+
+	sums        *checksums           // Collects the checksums for Manifest during a run
+	hashes      *hasher              // Computes and remembers the checksums of a run
+	index       *dupIndex            // Files in DestDir by content for Dedupe
+	dirs        *dirCache            // Destination directories known to exist during a run
+	crossDevice bool                 // The source and DestDir are on different devices, files are copied rather than renamed
+	planned     func(FileMove) error // For Organizer.Plan: receives the moves as the scan plans them, the run ends with the scan
+}
+
+// fsys returns the file system cfg works on.
+func (cfg Config) fsys() fsutil.FS {
+	if cfg.FS == nil {
+		return fsutil.OS
+	}
+	return cfg.FS
+}
+
+// Validate checks cfg for missing or contradicting settings and returns a *ConfigError for the
+// first problem found. OrganizeFiles calls it before touching anything.
+func (cfg Config) Validate() error {
+	switch {
+	case cfg.SourceDir == "":
+		return configError("--source", errors.New("source directory is required"))
+	case cfg.DestDir == "":
+		return configError("--dest", errors.New("destination directory is required"))
+	case cfg.OnlyMine && !OwnershipSupported:
+		return configError("--only-mine", errors.New("not supported on this platform"))
+	case cfg.ArchiveFormat != "" && !ValidArchiveFormat(cfg.ArchiveFormat):
+		return configError("--archive-format", fmt.Errorf("unknown archive format '%s' (use zip or tar.zst)", cfg.ArchiveFormat))
+	case cfg.Compress != "" && !ValidCompression(cfg.Compress):
+		return configError("--compress", fmt.Errorf("unknown compression '%s' (use gzip or zstd)", cfg.Compress))
+	case cfg.Order != "" && !ValidOrder(cfg.Order):
+		return configError("--order", fmt.Errorf("unknown order '%s' (use name, size-asc, size-desc or mtime)", cfg.Order))
+	case cfg.MaxFiles < 0:
+		return configError("--max-files", errors.New("must not be negative"))
+	case cfg.MaxBytes < 0:
+		return configError("--max-bytes", errors.New("must not be negative"))
+	case cfg.TopN < 0:
+		return configError("--top", errors.New("must not be negative"))
+	case checkMIMEPatterns(cfg.IncludeMIME) != nil:
+		return configError("--include-mime", checkMIMEPatterns(cfg.IncludeMIME))
+	case checkMIMEPatterns(cfg.ExcludeMIME) != nil:
+		return configError("--exclude-mime", checkMIMEPatterns(cfg.ExcludeMIME))
+	case !ValidUnreadable(cfg.Unreadable):
+		return configError("unreadable", fmt.Errorf("unknown policy '%s' (use skip or fail)", cfg.Unreadable))
+	case cfg.Hidden != "" && !ValidHidden(cfg.Hidden):
+		return configError("--hidden", fmt.Errorf("unknown policy '%s' (use skip, include or only)", cfg.Hidden))
+	case cfg.MaxMemory < 0:
+		return configError("--max-memory", errors.New("must not be negative"))
+	case cfg.MaxMemory > 0 && (cfg.Order != "" || cfg.MaxFiles > 0 || cfg.MaxBytes > 0 || cfg.TopN > 0 || cfg.ArchiveOlderThan > 0 || cfg.MinCategoryFiles > 1 || cfg.EventGap > 0):
+		return configError("--max-memory", errors.New("cannot be combined with --order, --max-files, --max-bytes, --top, --archive-older-than, --min-category-files or --events, which need all planned moves in memory"))
+	case cfg.MinCategoryFiles < 0:
+		return configError("--min-category-files", errors.New("must not be negative"))
+	case cfg.EventGap < 0:
+		return configError("--events", errors.New("must not be negative"))
+	case cfg.ClipsUnder < 0:
+		return configError("--clips-under", errors.New("must not be negative"))
+	case cfg.SimilarImages != "" && !ValidSimilar(cfg.SimilarImages):
+		return configError("--similar-images", fmt.Errorf("unknown mode '%s' (use report or move)", cfg.SimilarImages))
+	case cfg.Corrupt != "" && !ValidCorrupt(cfg.Corrupt):
+		return configError("--corrupt", fmt.Errorf("unknown policy '%s' (use move or skip)", cfg.Corrupt))
+	case validateVendors(cfg.Vendors) != nil:
+		return configError("vendors", validateVendors(cfg.Vendors))
+	case cfg.Naming.validate() != nil:
+		return configError("--folder-language", cfg.Naming.validate())
+	case !ValidSmallCategory(cfg.SmallCategories):
+		return configError("--small-categories", fmt.Errorf("unknown policy '%s' (use others or leave)", cfg.SmallCategories))
+	case cfg.BandwidthLimit < 0:
+		return configError("--bwlimit", errors.New("must not be negative"))
+	case cfg.Manifest != "" && !ValidManifest(cfg.Manifest):
+		return configError("--manifest", fmt.Errorf("unknown manifest '%s' (use category or global)", cfg.Manifest))
+	case !ValidHash(cfg.Hash):
+		return configError("--hash", fmt.Errorf("unknown algorithm '%s' (use xxhash64, sha256 or blake3)", cfg.Hash))
+	case cfg.FileTimeout < 0:
+		return configError("--file-timeout", errors.New("must not be negative"))
+	case cfg.FileRetries < 0:
+		return configError("--file-retries", errors.New("must not be negative"))
+	case cfg.HashWorkers < 0:
+		return configError("--hash-workers", errors.New("must not be negative"))
+	case cfg.Dedupe != "" && !ValidDedupe(cfg.Dedupe):
+		return configError("--dedupe", fmt.Errorf("unknown policy '%s' (use skip or link)", cfg.Dedupe))
+	case !ValidMode(cfg.Mode):
+		return configError("--mode", fmt.Errorf("unknown mode '%s' (use move or hardlink)", cfg.Mode))
+	case !ValidConflict(cfg.OnConflict):
+		return configError("--on-conflict", fmt.Errorf("unknown strategy '%s' (use rename, backup, trash, keep-newest, keep-largest or ask)", cfg.OnConflict))
+	case !ValidConflictLoser(cfg.ConflictLoser):
+		return configError("--conflict-loser", fmt.Errorf("unknown policy '%s' (use backup, trash or delete)", cfg.ConflictLoser))
+	case cfg.OnConflict == ConflictAsk && cfg.Asker == nil:
+		return configError("--on-conflict", errors.New("ask needs an interactive terminal"))
+	case cfg.ConflictLoser != "" && cfg.OnConflict != ConflictKeepNewest && cfg.OnConflict != ConflictKeepLargest:
+		return configError("--conflict-loser", errors.New("only applies to --on-conflict keep-newest and keep-largest"))
+	}
+
+	if err := ValidateLayout(cfg.Layout); err != nil {
+		return configError("--layout", err)
+	}
+	if err := validateTiers(cfg.Tiers); err != nil {
+		return configError("tiers", err)
+	}
+	if len(cfg.Tiers) == 0 && strings.Contains(cfg.Layout, "{tier}") {
+		return configError("--layout", errors.New("{tier} needs tiers in the config file"))
+	}
+	if cfg.Remote != nil {
+		remoteErr := errors.New("not supported with a remote destination")
+		switch {
+		case cfg.ArchiveOlderThan > 0:
+			return configError("--archive-older-than", remoteErr)
+		case len(cfg.EncryptTo) > 0:
+			return configError("--encrypt-with", remoteErr)
+		case cfg.Sync:
+			return configError("--sync", remoteErr)
+		case len(cfg.ReviewCategories) > 0:
+			return configError("--review", remoteErr)
+		case cfg.Mirror != nil:
+			return configError("--mirror", remoteErr)
+		case cfg.Manifest != "":
+			return configError("--manifest", remoteErr)
+		case cfg.Dedupe != "":
+			return configError("--dedupe", remoteErr)
+		}
+	}
+	if IsArchiveSource(cfg.SourceDir) {
+		switch {
+		case cfg.Files != nil:
+			return configError("--files-from", errors.New("not supported with an archive as source"))
+		case cfg.MaxFiles > 0:
+			return configError("--max-files", errors.New("not supported with an archive as source"))
+		case cfg.MaxBytes > 0:
+			return configError("--max-bytes", errors.New("not supported with an archive as source"))
+		}
+	}
+	if cfg.Sync {
+		if IsArchiveSource(cfg.SourceDir) {
+			return configError("--sync", errors.New("not supported with an archive as source"))
+		}
+		if cfg.ArchiveOlderThan > 0 || cfg.Compress != "" || len(cfg.EncryptTo) > 0 {
+			return configError("--sync", errors.New("cannot be combined with --archive-older-than, --compress or --encrypt-with"))
+		}
+	}
+	if cfg.replaces() {
+		switch {
+		case cfg.Remote != nil:
+			return configError("--on-conflict", errors.New("only rename is supported with a remote destination"))
+		case cfg.Sync || cfg.Mode == ModeHardlink || cfg.ArchiveOlderThan > 0:
+			return configError("--on-conflict", errors.New("only rename can be combined with --sync, --mode hardlink or --archive-older-than"))
+		}
+	}
+	if cfg.LeaveSymlink {
+		switch {
+		case cfg.Remote != nil:
+			return configError("--leave-symlink", errors.New("not supported with a remote destination"))
+		case IsArchiveSource(cfg.SourceDir):
+			return configError("--leave-symlink", errors.New("not supported with an archive as source"))
+		case cfg.Sync || cfg.Mode == ModeHardlink:
+			return configError("--leave-symlink", errors.New("cannot be combined with --sync or --mode hardlink, which leave the source in place"))
+		}
+	}
+	if cfg.Mode == ModeHardlink {
+		switch {
+		case cfg.Remote != nil:
+			return configError("--mode", errors.New("hardlink is not supported with a remote destination"))
+		case IsArchiveSource(cfg.SourceDir):
+			return configError("--mode", errors.New("hardlink is not supported with an archive as source"))
+		case cfg.Sync || cfg.ArchiveOlderThan > 0 || cfg.Compress != "" || len(cfg.EncryptTo) > 0 || len(cfg.ReviewCategories) > 0:
+			return configError("--mode", errors.New("hardlink cannot be combined with --sync, --archive-older-than, --compress, --encrypt-with or --review"))
+		}
+	}
+	for _, q := range cfg.Quotas {
+		if !ValidQuotaPolicy(q.Policy) {
+			return configError("quotas", fmt.Errorf("unknown policy '%s' for '%s' (use warn, trash or overflow)", q.Policy, q.Category))
+		}
+	}
+	if !cfg.AllowDangerous {
+		if err := CheckSource(cfg.SourceDir, cfg.DestDir); err != nil {
+			return configError("--source", err)
+		}
+	}
+	return nil
 }
 
 // FileMove represents a single file operation task.
 type FileMove struct {
-	SourcePath string // Original path of the file
-	DestPath   string // Target path for the file
-	DryRun     bool   // Whether this is a dry run
+	SourcePath string      // Original path of the file
+	DestPath   string      // Target path for the file
+	DryRun     bool        // Whether this is a dry run
+	Info       fs.FileInfo // Lstat result captured at scan time, used to re-validate the source before moving
+	Category   string      // Category the file was classified into
+	Hydrate    bool        // Download the cloud placeholder's content before moving
+	Review     string      // For files staged in ReviewDir: the category to file them into once approved
+
+	archive      bool      // Packed into a per-month archive instead of moved, in archival mode
+	reviewFolder string    // For files staged in ReviewDir: the folder below DestDir they go to once approved
+	similarTo    string    // For near-duplicate photos, the photo of the run they resemble, see SimilarImages
+	damage       string    // For files going into CorruptFolder, what is wrong with them
+	watch        *watchdog // Progress of the file, with FileTimeout
+	started      time.Time // When a worker picked the file up
+	clock        Clock     // Clock of the run, for the duration in the result
+	worker       int       // Which worker, from 1
 }
 
 // ProgressUpdate is sent by workers to report their status.
 type ProgressUpdate struct {
 	Moved   int
 	Errored int
+	Skipped int // Files dropped at move time because they changed since the scan
+
+	Bytes    int64  // Size of the processed file, set together with Moved
+	Category string // Category of the processed file, set together with Moved
+
+	Planned int          // Number of files queued for processing, sent once when the scan completes or, streamed, with every file
+	Space   *SpaceReport // What takes up the space among the files found, sent once after the scan with Config.TopN
+	Timing  *Timing      // How long the phases of the run took, sent once at its end
+	Pool    *PoolStats   // State of the worker pool, sent every second while files are processed and once when they are done
+
+	Extensions []ExtensionStat // Files and bytes per extension, sent once after the scan with Config.CountExtensions
+
+	MirrorErrored int // Files that were organized but could not be copied to the mirror
+	Denied        int // Entries the scan was denied access to; Errored or a scan skip as well, see Config.Unreadable
+	Rotated       int // Files rotated out of a category that exceeded its quota
+	Corrupt       int // Empty or damaged files found by the scan, see Config.Corrupt
+
+	File  *FileResult // Outcome of a single file, set on the last update sent for it
+	Event *FileEvent  // How far a single file has come; set on the last update for it too, otherwise the update carries nothing else
+
+	Worker  int    // Worker (from 1) the update comes from; 0 for updates sent outside the worker pool
+	Started string // Source of the file Worker just picked up; the update carries nothing else but its PhaseStarted event
+	Copied  int64  // Bytes of its file Worker has copied so far, sent during long copies; the update carries nothing else
 }
 
 // DefaultCategoryMappings defines common file extensions and their default categories.
@@ -68,6 +347,10 @@ func DefaultCategoryMappings() map[string]string {
 		".avi":  "Videos",
 		".mkv":  "Videos",
 		".webm": "Videos",
+		".srt":  "Videos", // Subtitles, kept with their video if it is organized too
+		".ass":  "Videos",
+		".ssa":  "Videos",
+		".vtt":  "Videos",
 
 		// Audio
 		".mp3":  "Audio",
@@ -76,11 +359,16 @@ func DefaultCategoryMappings() map[string]string {
 		".aac":  "Audio",
 
 		// Archives
-		".zip": "Archives",
-		".rar": "Archives",
-		".7z":  "Archives",
-		".tar": "Archives",
-		".gz":  "Archives",
+		".zip":     "Archives",
+		".rar":     "Archives",
+		".7z":      "Archives",
+		".tar":     "Archives",
+		".gz":      "Archives",
+		".tgz":     "Archives",
+		".tar.gz":  "Archives",
+		".tar.bz2": "Archives",
+		".tar.xz":  "Archives",
+		".tar.zst": "Archives",
 
 		// Executables
 		".exe": "Executables",
@@ -93,6 +381,7 @@ func DefaultCategoryMappings() map[string]string {
 		".go":   "Code",
 		".js":   "Code",
 		".ts":   "Code",
+		".d.ts": "Code", // TypeScript declarations, not MPEG transport streams
 		".py":   "Code",
 		".java": "Code",
 		".c":    "Code",
@@ -107,177 +396,832 @@ func DefaultCategoryMappings() map[string]string {
 	}
 }
 
-// moveFile performs the actual file moving operation, including collision resolution.
-// It sends progress updates to the provided channel.
-func moveFile(fm FileMove, progressChan chan<- ProgressUpdate, quiet bool) error {
+// mappedExt returns the extension of name the mappings know, in lower case, and its category. A
+// compound extension wins over a shorter one, so "backup.tar.gz" is mapped by ".tar.gz" rather
+// than ".gz". For a name without a mapped extension it returns its last extension and false.
+func (cfg Config) mappedExt(name string) (ext, category string, ok bool) {
+	return ExtensionClassifier(cfg.CategoryMappings).lookup(name)
+}
+
+// moveFile performs the actual file moving operation, including collision resolution, and has
+// transfer get the file to its final path. It sends progress updates to the provided channel.
+func moveFile(fm FileMove, cfg Config, progressChan chan<- ProgressUpdate, transfer func(cfg Config, fm FileMove, dest string, action Action, progressChan chan<- ProgressUpdate) error) error {
+	fsys, p := cfg.fsys(), cfg.printer()
 	defer func() {
 		// Ensure a progress update is sent even if an error occurs
 		if r := recover(); r != nil {
-			fmt.Printf("Recovered from panic in moveFile: %v\n", r)
-			progressChan <- ProgressUpdate{Errored: 1}
+			p.File(LevelError, "ERROR", "Recovered from panic in moveFile: %v", r)
+			progressChan <- fm.failedUpdate(fmt.Errorf("panic: %v", r))
 		}
 	}()
 
-	// Define colors for output
-	green := color.New(color.FgGreen).SprintFunc()
-	yellow := color.New(color.FgYellow).SprintFunc()
-	// red := color.New(color.FgRed).SprintFunc()
-	cyan := color.New(color.FgCyan).SprintFunc()
-
 	// Ensure the destination directory exists
 	destDir := filepath.Dir(fm.DestPath)
-	if _, err := os.Stat(destDir); os.IsNotExist(err) {
-		if fm.DryRun {
-			fmt.Printf("    %s: Would create directory: %s\n", cyan("DRY RUN"), destDir)
-		} else {
-			err := os.MkdirAll(destDir, 0755)
-			if err != nil {
-				progressChan <- ProgressUpdate{Errored: 1}
-				return fmt.Errorf("failed to create destination directory '%s': %w", destDir, err)
-			}
-			fmt.Printf("    %s: Created directory: %s\n", green("CREATED"), destDir)
-		}
+	if created, err := cfg.dirs.ensure(fsys, destDir, fm.DryRun); err != nil {
+		err = fmt.Errorf("failed to create destination directory '%s': %w", destDir, err)
+		progressChan <- fm.failedUpdate(err)
+		return err
+	} else if created && fm.DryRun {
+		p.File(LevelNotice, "DRY RUN", "Would create directory: %s", destDir)
+	} else if created {
+		p.File(LevelSuccess, "CREATED", "Created directory: %s", destDir)
 	}
 
 	// Collision Resolution: Check if target file already exists
 	finalDestPath := fm.DestPath
-	if _, err := os.Stat(finalDestPath); err == nil {
-		// File exists, append timestamp to make it unique
-		ext := filepath.Ext(fm.DestPath)
-		name := strings.TrimSuffix(filepath.Base(fm.DestPath), ext)
-		timestamp := time.Now().Format("20060102_150405") //YYYYMMDD_HHMMSS
-		finalDestPath = filepath.Join(destDir, fmt.Sprintf("%s_%s%s", name, timestamp, ext))
-		fmt.Printf("    %s: Renaming '%s' to '%s'\n", yellow("COLLISION"), filepath.Base(fm.DestPath), filepath.Base(finalDestPath))
-	} else if !os.IsNotExist(err) {
+	existing, err := fsys.Stat(finalDestPath)
+	if err == nil {
+		if dup, err := cfg.duplicateOf(fm, finalDestPath, existing); err != nil {
+			progressChan <- fm.failedUpdate(err)
+			return err
+		} else if dup {
+			return cfg.skipDuplicate(fm, finalDestPath, progressChan)
+		}
+	}
+	if err == nil && cfg.OnConflict == ConflictAsk {
+		if cfg, err = cfg.decideConflict(fm, finalDestPath, existing); err != nil {
+			progressChan <- fm.failedUpdate(err)
+			return err
+		}
+	}
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
 		// Some other error occurred while checking file existence
-		progressChan <- ProgressUpdate{Errored: 1}
-		return fmt.Errorf("error checking existence of '%s': %w", finalDestPath, err)
+		err = fmt.Errorf("error checking existence of '%s': %w", finalDestPath, err)
+		progressChan <- fm.failedUpdate(err)
+		return err
+	case cfg.OnConflict == ConflictSkip:
+		return cfg.skipConflict(fm, finalDestPath, progressChan)
+	case cfg.replaces() && cfg.sourceWins(fm, existing):
+		// The new file becomes the canonical one and the existing one is set aside
+		if err := cfg.setAside(finalDestPath, fm.DryRun); err != nil {
+			progressChan <- fm.failedUpdate(err)
+			return err
+		}
+	case cfg.replaces() && cfg.loser() == ConflictBackup:
+		finalDestPath = backupName(fsys, fm.DestPath)
+		p.File(LevelWarn, "COLLISION", "Keeping the existing '%s', storing the new one as '%s'", filepath.Base(fm.DestPath), filepath.Base(finalDestPath))
+	case cfg.replaces():
+		return cfg.discardSource(fm, fm.DestPath, progressChan)
+	default:
+		// File exists, append timestamp (and a counter if that is taken too) to make it unique
+		finalDestPath = freeCollisionName(fsys, fm.DestPath, cfg.now())
+		p.File(LevelWarn, "COLLISION", "Renaming '%s' to '%s'", filepath.Base(fm.DestPath), filepath.Base(finalDestPath))
 	}
 
+	action := moveAction(fm)
 	if fm.DryRun {
-		if !quiet {
-			fmt.Printf("    %s: Would move '%s' to '%s'\n", cyan("DRY RUN"), fm.SourcePath, finalDestPath)
-		}
-		progressChan <- ProgressUpdate{Moved: 1} // Still count as "moved" in dry run for progress
+		p.File(LevelNotice, "DRY RUN", "Would move '%s' to '%s'", fm.SourcePath, finalDestPath)
+		progressChan <- fm.movedUpdate(action, finalDestPath) // Still count as "moved" in dry run for progress
 	} else {
-		err := os.Rename(fm.SourcePath, finalDestPath)
+		// Re-check the source right before touching it. Between the scan and now, someone with
+		// write access to the source directory (think /tmp or a shared folder) may have swapped
+		// the file for a symlink, a device node or a different file entirely.
+		if err := verifyUnchanged(fsys, fm); err != nil {
+			p.File(LevelWarn, "CHANGED", "%v. Skipping.", err)
+			progressChan <- fm.skippedUpdate(err)
+			return err
+		}
+		// Transfers never replace a file, so one that took the name since the check is kept
+		target := finalDestPath
+		finalDestPath, err = placeUnique(target, fm.DestPath, "", cfg.now(), func(dest string) error {
+			return transfer(cfg, fm, dest, action, progressChan)
+		})
+		if finalDestPath != target {
+			p.File(LevelWarn, "COLLISION", "Renaming '%s' to '%s'", filepath.Base(fm.DestPath), filepath.Base(finalDestPath))
+		}
 		if err != nil {
-			progressChan <- ProgressUpdate{Errored: 1}
-			return fmt.Errorf("failed to move '%s' to '%s': %w", fm.SourcePath, finalDestPath, err)
+			err = fmt.Errorf("failed to move '%s' to '%s': %w", fm.SourcePath, finalDestPath, err)
+			if fsutil.IsBusy(err) {
+				p.File(LevelWarn, "BUSY", "%v. In use by another program, will retry on the next run.", err)
+			}
+			progressChan <- fm.failedUpdate(err)
+			return err
 		}
-		if !quiet {
-			fmt.Printf("    %s: Moved '%s' to '%s'\n", green("MOVED"), fm.SourcePath, finalDestPath)
+		linked := cfg.leaveSymlink(fm, finalDestPath)
+		cfg.Journal.Record(journal.Entry{Op: journal.OpMove, Source: fm.SourcePath, Dest: finalDestPath, Size: fm.Info.Size(), Link: linked, Hash: cfg.hashes.known(fm.SourcePath, fm.Info)})
+		cfg.placed(finalDestPath)
+		if fm.Review != "" {
+			item := ReviewItem{Name: filepath.Base(finalDestPath), Source: fm.SourcePath, Category: fm.Review, Folder: fm.reviewFolder, Size: fm.Info.Size(), StagedAt: cfg.now()}
+			if err := recordReview(cfg.fsys(), cfg.DestDir, item); err != nil {
+				p.File(LevelWarn, "WARNING", "%v", err)
+			}
 		}
-		// fmt.Printf("    %s: Moved '%s' to '%s'\n", green("MOVED"), fm.SourcePath, finalDestPath)
-		progressChan <- ProgressUpdate{Moved: 1}
+		p.File(LevelSuccess, "MOVED", "Moved '%s' to '%s'", fm.SourcePath, finalDestPath)
+		cfg.fileStored(fm.SourcePath, finalDestPath, fm.Category, progressChan)
+		progressChan <- fm.movedUpdate(action, finalDestPath)
 	}
 	return nil
 }
 
+// processFile runs the steps needed for a single file and hands it to the matching mover.
+// moveFile and uploadFile send progress updates directly to progressChan. Failures are returned
+// as *MoveError, or *ConflictError when the destination holds a different file.
+func processFile(fm FileMove, cfg Config, progressChan chan<- ProgressUpdate) error {
+	fm.started, fm.clock = cfg.now(), cfg.Clock
+	err := placeFile(fm, cfg, progressChan)
+	cfg.printer().Debug("%s took %s", fm.SourcePath, cfg.now().Sub(fm.started).Round(time.Microsecond))
+	var conflict *ConflictError
+	if err == nil || errors.As(err, &conflict) {
+		return err
+	}
+	return &MoveError{Source: fm.SourcePath, Dest: fm.DestPath, Err: err}
+}
+
+// placeFile is processFile without the error wrapping.
+func placeFile(fm FileMove, cfg Config, progressChan chan<- ProgressUpdate) error {
+	p := cfg.printer()
+	if fm.Hydrate {
+		if fm.DryRun {
+			p.File(LevelNotice, "DRY RUN", "Would download cloud placeholder '%s'", fm.SourcePath)
+		} else if err := hydrate(cfg.fsys(), fm.SourcePath); err != nil {
+			fm.reportFailure(p, err, progressChan)
+			return err
+		}
+	}
+
+	if !cfg.beforeFileHook(fm, progressChan) {
+		return nil
+	}
+
+	if fm.Review == "" && cfg.Remote == nil {
+		if dup, err := cfg.index.find(cfg, fm); err != nil {
+			fm.reportFailure(p, err, progressChan)
+			return err
+		} else if dup != "" {
+			return cfg.storeDuplicate(fm, dup, progressChan)
+		}
+	}
+	if fm.watch.givenUp() { // Hydrating or checksumming it took too long
+		return ErrFileTimeout
+	}
+	return cfg.mover(fm).place(fm, cfg, progressChan)
+}
+
+// plannedAction returns what placeFile is going to do to fm, for its events before it is done.
+func (cfg Config) plannedAction(fm FileMove) Action {
+	return cfg.mover(fm).action(cfg, fm)
+}
+
 // OrganizeFiles scans the source directory and dispatches file moves to a worker pool.
-// It returns the total files scanned (including skipped), and the total files that will be processed (sent to workers), and any error from scanning.
-func OrganizeFiles(cfg Config, progressChan chan<- ProgressUpdate) (totalScanned int, totalToProcess int, totalSkipped int, scanErr error) {
-	// Define colors for output
-	red := color.New(color.FgRed).SprintFunc()
-	yellow := color.New(color.FgYellow).SprintFunc()
-	blue := color.New(color.FgBlue).SprintFunc()
-
-	fmt.Printf("%s Starting file organization from '%s' to '%s'...\n", blue("🚀"), cfg.SourceDir, cfg.DestDir)
+// Every update is forwarded to progressChan, which may be nil. The returned Result holds the
+// counters of the run and the outcome of each file; the error is set if the run could not complete.
+func OrganizeFiles(cfg Config, progressChan chan<- ProgressUpdate) (Result, error) {
+	var result Result
+	updates := make(chan ProgressUpdate, cfg.Workers+10)
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for update := range updates {
+			result.Stats.Add(update)
+			if update.File != nil {
+				result.Files = append(result.Files, *update.File)
+			}
+			if update.Space != nil {
+				result.Space = update.Space
+			}
+			if update.Timing != nil {
+				result.Timing = update.Timing
+			}
+			if update.Pool != nil {
+				result.Pool = update.Pool
+			}
+			if update.Extensions != nil {
+				result.Extensions = update.Extensions
+			}
+			if progressChan != nil {
+				progressChan <- update
+			}
+		}
+	}()
+
+	scanned, toProcess, scanSkipped, err := organizeFiles(cfg, updates)
+	close(updates)
+	<-collected
+	result.Scanned, result.ToProcess = scanned, toProcess
+	result.Skipped += scanSkipped
+	return result, err
+}
+
+// organizeFiles does the work of OrganizeFiles. It returns the total files scanned (including
+// skipped), the total files that will be processed (sent to workers), and any error from scanning.
+func organizeFiles(cfg Config, progressChan chan<- ProgressUpdate) (totalScanned int, totalToProcess int, totalSkipped int, scanErr error) {
+	p := cfg.printer()
+
+	p.Status(LevelInfo, "🚀", "Starting file organization from '%s' to '%s'...", cfg.SourceDir, cfg.DestDir)
 	if cfg.DryRun {
-		fmt.Println(yellow("!!! DRY RUN MODE: No files will be moved or created. !!!"))
+		p.Status(LevelWarn, "", "!!! DRY RUN MODE: No files will be moved or created. !!!")
 	}
 
+	if err := cfg.Validate(); err != nil {
+		return 0, 0, 0, err
+	}
+	// Without a worker count, pick one for the storage and adapt it as the run goes
+	var adaptive *adaptiveWorkers
 	if cfg.Workers <= 0 {
-		cfg.Workers = 1
+		var kind string
+		cfg.Workers, kind = AutoWorkers(cfg.SourceDir, cfg.DestDir)
+		adaptive = newAdaptiveWorkers(cfg.Workers, p)
+		p.Detail(LevelInfo, "⚙️", "Using %d workers for %s storage.", cfg.Workers, kind)
+	}
+	if cfg.ArchiveFormat == "" {
+		cfg.ArchiveFormat = ArchiveFormatZip
+	}
+	cfg.Journal.SetClock(cfg.Clock)
+	// Give files that another program (often an antivirus scanner) holds open a few seconds to be
+	// released before they are skipped for the next run. Network shares also get the errors they
+	// return while reconnecting retried, for longer.
+	if cfg.Remote == nil && (storageKind(cfg.SourceDir) == StorageNetwork || storageKind(cfg.DestDir) == StorageNetwork) {
+		cfg.FS = fsutil.RetryNetwork(cfg.fsys())
+		p.Detail(LevelInfo, "🌐", "Network storage: retrying operations that fail while the share reconnects.")
+	} else {
+		cfg.FS = fsutil.RetryBusy(cfg.fsys())
+	}
+	cfg.hashes = newHasher(cfg.Hash, cfg.HashWorkers)
+	if cfg.Manifest != "" && !cfg.DryRun {
+		cfg.sums = &checksums{sums: make(map[string]map[string]string)}
+	}
+	if cfg.BandwidthLimit > 0 {
+		// Every copy reads its source through cfg.FS, so they all draw from the one limiter
+		cfg.FS = fsutil.Throttle(cfg.fsys(), fsutil.NewLimiter(cfg.BandwidthLimit))
+	}
+	cfg.dirs = newDirCache()
+	if err := cfg.preflight(); err != nil {
+		if !cfg.DryRun {
+			return 0, 0, 0, err
+		}
+		p.Status(LevelWarn, "⚠️", "A real run would fail: %v", err)
+	}
+	if cfg.Remote == nil && !IsArchiveSource(cfg.SourceDir) && crossDevice(cfg.fsys(), cfg.SourceDir, cfg.DestDir) {
+		cfg.crossDevice = true
+		p.Detail(LevelInfo, "💽", "The destination is on another device than the source: files are copied and then removed.")
+	}
+	if cfg.Dedupe != "" {
+		index, files, kept, err := loadIndex(cfg)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		cfg.index = index
+		p.Detail(LevelInfo, "🗂️", "Indexed %d files in the destination, %d with their checksum from the last run.", files, kept)
+		if !cfg.DryRun {
+			defer func() {
+				if err := cfg.index.save(); err != nil {
+					p.Status(LevelWarn, "⚠️", "%v", err)
+				}
+			}()
+		}
+	}
+
+	// An archive as source is organized straight from its entries, no extract step needed
+	p.Debug("%d workers, recursive: %t, %d category mappings, %d classifiers", cfg.Workers, cfg.Recursive, len(cfg.CategoryMappings), len(cfg.Classifiers))
+
+	timer := newPhaseTimer(cfg.Clock)
+	defer func() { progressChan <- ProgressUpdate{Timing: timer.timing()} }()
+	if IsArchiveSource(cfg.SourceDir) {
+		return organizeArchive(cfg, adaptive, timer, progressChan)
 	}
 
 	// Phase 1: Scan and Collect Files
-	fmt.Printf("%s Scanning files in '%s'...\n", blue("🔍"), cfg.SourceDir)
-	var filesToMove []FileMove
+	if cfg.Files != nil {
+		p.Status(LevelInfo, "🔍", "Scanning %d listed paths in '%s'...", len(cfg.Files), cfg.SourceDir)
+	} else {
+		p.Status(LevelInfo, "🔍", "Scanning files in '%s'...", cfg.SourceDir)
+	}
+	plan := &movePlan{limit: cfg.MaxMemory, emit: cfg.planned}
+	defer plan.close()
+	var stream *streamedPlan
+	if cfg.streams() {
+		stream = &streamedPlan{pool: newWorkerPool(cfg, adaptive, progressChan), progress: progressChan}
+		plan.emit = stream.add
+		p.Detail(LevelInfo, "🌊", "Files are organized as the scan finds them.")
+	}
+	overlap := cfg.overlap()
+	if overlap.same {
+		p.Status(LevelNotice, "ℹ️", "The source is the destination: only the files directly in it are organized, its folders are left as they are.")
+	}
+	archiveCutoff := cfg.now().Add(-cfg.ArchiveOlderThan)
+	denied := 0 // Entries the scan was denied access to
+	var extensions extensionCounter
+	if cfg.CountExtensions {
+		extensions = make(extensionCounter)
+	}
 
-	err := filepath.WalkDir(cfg.SourceDir, func(path string, d fs.DirEntry, err error) error {
+	visit := func(path string, d fs.DirEntry, err error) error {
 		totalScanned++ // Increment total scanned count for every entry (file or dir)
+		if errors.Is(err, fs.ErrPermission) {
+			denied++
+			update := ProgressUpdate{Denied: 1, File: &FileResult{Source: path, Action: ActionDenied, Err: err}}
+			if cfg.Unreadable == UnreadableSkip {
+				p.Detail(LevelWarn, "🔒", "Access to %s denied. Skipping.", path)
+				totalSkipped++
+			} else {
+				p.Status(LevelError, "🔒", "Access to %s denied. Skipping.", path)
+				update.Errored = 1
+			}
+			progressChan <- update.finished()
+			return nil // A directory is left out, the walk goes on with the others
+		}
 		if err != nil {
-			fmt.Printf("%s Error accessing path %s: %v. Skipping.\n", red("❌"), path, err)
-			scanErr = fmt.Errorf("encountered error during scan: %w", err) // Store first scan error
-			return nil                                                     // Continue walking other paths
+			p.Status(LevelError, "❌", "Error accessing path %s: %v. Skipping.", path, err)
+			progressChan <- ProgressUpdate{Errored: 1, File: &FileResult{Source: path, Action: ActionFail, Err: err}}.finished()
+			if scanErr == nil {
+				scanErr = &ScanError{Path: path, Err: err} // Store first scan error
+			}
+			return nil // Continue walking other paths
 		}
 
 		if d.IsDir() {
-			if !cfg.Recursive && path != cfg.SourceDir {
+			if path == cfg.SourceDir {
+				return nil
+			}
+			if !cfg.Recursive || overlap.same {
+				return filepath.SkipDir
+			}
+			// The destination inside the source is left out as a whole, organized files aren't organized again
+			if overlap.isDest(d) {
+				p.Detail(LevelWarn, "⏩", "Skipping the destination '%s' inside the source.", path)
+				return filepath.SkipDir
+			}
+			if cfg.skipsHidden() {
+				if info, err := d.Info(); err == nil && isHidden(info) {
+					p.Detail(LevelWarn, "⏩", "Skipping hidden folder '%s'.", path)
+					return filepath.SkipDir
+				}
+			}
+			if filepath.Dir(path) == cfg.SourceDir && matchesAnyName(d.Name(), cfg.SkipTopDirs) {
+				p.Detail(LevelWarn, "⏩", "Skipping top-level folder '%s'.", d.Name())
 				return filepath.SkipDir
 			}
 			return nil
 		}
 
+		// Listed files can be anywhere, keep them to what a walk of the source would see
+		if cfg.Files != nil {
+			rel, err := filepath.Rel(cfg.SourceDir, path)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				p.Detail(LevelWarn, "⚠️", "%s is not in the source directory. Skipping.", path)
+				totalSkipped++
+				return nil
+			}
+			if top, _, nested := strings.Cut(rel, string(filepath.Separator)); nested && matchesAnyName(top, cfg.SkipTopDirs) {
+				p.Detail(LevelWarn, "⏩", "%s is in the skipped top-level folder '%s'. Skipping.", path, top)
+				totalSkipped++
+				return nil
+			}
+		}
+
 		// It's a file, process it
 		ext := strings.ToLower(filepath.Ext(path))
 		fileName := filepath.Base(path)
 
-		category, ok := cfg.CategoryMappings[ext]
+		// Only regular files are organized. Symlinks, device nodes, sockets and FIFOs are left alone:
+		// moving them is rarely what the user wants and following them is a classic race target.
+		if !d.Type().IsRegular() {
+			p.Detail(LevelWarn, "⚠️", "%s is not a regular file (%s). Skipping.", fileName, describeFileType(d.Type()))
+			totalSkipped++
+			return nil
+		}
+		if strings.HasPrefix(fileName, stagedPrefix) {
+			p.Detail(LevelWarn, "⚠️", "%s is an unfinished copy of an organizer run. Skipping.", fileName)
+			totalSkipped++
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			p.Status(LevelError, "❌", "Error reading file info for %s: %v. Skipping.", path, err)
+			totalSkipped++
+			return nil
+		}
+		if hidden := isHidden(info); hidden && cfg.skipsHidden() {
+			p.Detail(LevelWarn, "⏩", "%s is hidden. Skipping.", fileName)
+			totalSkipped++
+			return nil
+		} else if !hidden && cfg.Hidden == HiddenOnly {
+			p.Detail(LevelWarn, "⏩", "%s is not hidden. Skipping.", fileName)
+			totalSkipped++
+			return nil
+		}
+
+		// Online-only files from OneDrive/Dropbox/iCloud would end up as useless stubs when moved
+		hydrateFile := false
+		if isCloudPlaceholder(info) {
+			switch cfg.CloudPlaceholders {
+			case PlaceholderMove:
+			case PlaceholderHydrate:
+				if isICloudStub(fileName) {
+					p.Detail(LevelWarn, "☁️", "%s is an iCloud stub that cannot be downloaded from here. Skipping.", fileName)
+					totalSkipped++
+					return nil
+				}
+				hydrateFile = true
+			default:
+				p.Detail(LevelWarn, "☁️", "%s is a cloud placeholder (online-only). Skipping.", fileName)
+				totalSkipped++
+				return nil
+			}
+		}
+
+		// On shared directories, leave other people's files where they are
+		if cfg.OnlyMine && !ownedByCurrentUser(info) {
+			p.Detail(LevelWarn, "⚠️", "%s is owned by another user. Skipping.", fileName)
+			totalSkipped++
+			return nil
+		}
+
+		// MIME filters go by the content, which is only read when there are any
+		if len(cfg.IncludeMIME) > 0 || len(cfg.ExcludeMIME) > 0 {
+			mimeType := fileMIME(cfg.fsys(), path, ext)
+			if len(cfg.IncludeMIME) > 0 && !matchesMIME(mimeType, cfg.IncludeMIME) {
+				p.Detail(LevelWarn, "⏩", "%s is %s, which is not included. Skipping.", fileName, mimeType)
+				totalSkipped++
+				return nil
+			}
+			if matchesMIME(mimeType, cfg.ExcludeMIME) {
+				p.Detail(LevelWarn, "⏩", "%s is %s, which is excluded. Skipping.", fileName, mimeType)
+				totalSkipped++
+				return nil
+			}
+		}
+
+		mappedExt, category, ok := cfg.mappedExt(fileName)
 		if !ok {
 			category = "Others"
 		}
+		extensions.add(cfg, mappedExt, info.Size())
 
-		// Skip files that are already in the destination directory (or a subdirectory of it)
-		if strings.HasPrefix(path, cfg.DestDir) {
-			fmt.Printf("  %s %s is already in the destination directory. Skipping.\n", yellow("⚠️"), fileName)
+		// Skip files that are already in the destination directory (or a subdirectory of it), unless
+		// the source is in there too: then only those already where they belong stay
+		if !overlap.same && !overlap.inDest && isBelow(path, cfg.DestDir) {
+			p.Detail(LevelWarn, "⚠️", "%s is already in the destination directory. Skipping.", fileName)
+			totalSkipped++
+			return nil
+		}
+
+		// Classifier plugins and rules get the final say on where the file goes; without a folder
+		// from them, the layout decides
+		destFolder := ""
+		if len(cfg.Classifiers) > 0 {
+			req := ClassifyRequest{
+				Path: path, Name: fileName, Ext: ext, Size: info.Size(), ModTime: info.ModTime(),
+				MIME: sniffMIME(cfg.fsys(), path), Category: category, Groups: cfg.extGroups(fileName),
+			}
+			for _, classifier := range cfg.Classifiers {
+				resp, err := classifier.Classify(req)
+				if err != nil {
+					p.File(LevelWarn, "WARNING", "%v. Using category '%s' for %s.", err, req.Category, fileName)
+					continue
+				}
+				if resp.Skip {
+					p.Detail(LevelWarn, "⏩", "%s is skipped by the classifier.", fileName)
+					totalSkipped++
+					return nil
+				}
+				if resp.Category != "" {
+					req.Category, destFolder = resp.Category, ""
+				}
+				if resp.Dest != "" {
+					destFolder = filepath.FromSlash(resp.Dest)
+				}
+			}
+			category = req.Category
+		}
+		if !cfg.categorySelected(category) {
+			p.Detail(LevelWarn, "⏩", "%s is in category '%s', which is not selected. Skipping.", fileName, category)
 			totalSkipped++
 			return nil
 		}
 
-		targetCategoryDir := filepath.Join(cfg.DestDir, category)
-		targetFilePath := filepath.Join(targetCategoryDir, fileName)
+		// Empty and damaged files are kept out of the organized tree. Placeholders are not read,
+		// which would download them
+		if cfg.Corrupt != "" && !isCloudPlaceholder(info) {
+			if damage := damage(cfg.fsys(), path, info); damage != "" {
+				if cfg.Corrupt == CorruptSkip {
+					p.Detail(LevelWarn, "🩹", "%s is %s. Skipping.", fileName, damage)
+					totalSkipped++
+					progressChan <- ProgressUpdate{Corrupt: 1, File: &FileResult{Source: path, Category: category, Action: ActionSkip, Err: fmt.Errorf("%w: %s", ErrCorrupt, damage), Size: info.Size(), Damage: damage}}.finished()
+					return nil
+				}
+				p.Detail(LevelWarn, "🩹", "%s is %s, it goes into '%s'.", fileName, damage, CorruptFolder)
+				progressChan <- ProgressUpdate{Corrupt: 1}
+				return plan.add(FileMove{
+					SourcePath: path,
+					DestPath:   cfg.targetPath(CorruptFolder, fileName),
+					DryRun:     cfg.DryRun,
+					Info:       info,
+					Category:   CorruptFolder,
+					Hydrate:    hydrateFile,
+					damage:     damage,
+				})
+			}
+		}
+		if destFolder == "" {
+			destFolder = cfg.defaultFolder(path, category, info.ModTime())
+		}
+		targetFilePath := cfg.targetPath(destFolder, fileName)
+		if overlap.same || overlap.inDest {
+			if target, err := cfg.fsys().Lstat(targetFilePath); err == nil && os.SameFile(target, info) {
+				p.Detail(LevelWarn, "⏩", "%s is already where it belongs. Skipping.", fileName)
+				totalSkipped++
+				return nil
+			}
+		}
 
-		filesToMove = append(filesToMove, FileMove{
+		fm := FileMove{
 			SourcePath: path,
 			DestPath:   targetFilePath,
 			DryRun:     cfg.DryRun,
-		})
+			Info:       info,
+			Category:   category,
+			Hydrate:    hydrateFile,
+		}
 
-		return nil
-	})
+		p.Debug("%s: category '%s', destination '%s'", fileName, category, targetFilePath)
+
+		// Files of categories under review are staged until someone approves or rejects them
+		if cfg.needsReview(category) && cfg.Remote == nil && !cfg.Sync {
+			fm.DestPath = filepath.Join(cfg.DestDir, ReviewDir, fileName)
+			fm.Category = ReviewDir
+			fm.Review = category
+			fm.reviewFolder = filepath.ToSlash(destFolder)
+			return plan.add(fm)
+		}
+
+		// Archival mode: old files are packed into per-month archives instead of moved
+		if cfg.ArchiveOlderThan > 0 && info.ModTime().Before(archiveCutoff) {
+			fm.Category = ArchivalCategory
+			fm.archive = true
+		}
+
+		return plan.add(fm)
+	}
 
+	var err error
+	if cfg.Files != nil {
+		walkFileList(cfg, visit)
+	} else {
+		err = cfg.fsys().WalkDir(cfg.SourceDir, visit)
+	}
+	timer.scanDone()
+	if stream != nil {
+		if err == nil {
+			stream.finish(cfg, p)
+		}
+		timer.planDone()
+		stream.pool.wait()
+		totalToProcess = stream.planned
+	}
 	if err != nil {
-		return totalScanned, totalToProcess, totalSkipped, fmt.Errorf("error walking source directory '%s': %w", cfg.SourceDir, err)
+		return totalScanned, totalToProcess, totalSkipped, &ScanError{Path: cfg.SourceDir, Err: err}
 	}
-	if scanErr != nil { // Report if any errors were encountered during the scan
-		fmt.Printf("%s Scan completed with some errors.\n", yellow("⚠️"))
+	if denied > 0 && cfg.Unreadable == UnreadableFail {
+		return totalScanned, totalToProcess, totalSkipped, &ScanError{Path: cfg.SourceDir, Err: fmt.Errorf("access to %d entries denied, nothing was moved: %w", denied, fs.ErrPermission)}
 	}
-
-	totalToProcess = len(filesToMove)
-	if totalToProcess == 0 {
-		fmt.Printf("%s No files found to organize.\n", blue("ℹ️"))
+	if cfg.planned != nil {
+		return totalScanned, plan.len(), totalSkipped, nil // Entries that could not be read were reported, as in a run
+	}
+	if stream != nil && totalToProcess == 0 {
+		p.Status(LevelInfo, "ℹ️", "No files found to organize.")
 		return totalScanned, totalToProcess, totalSkipped, nil
 	}
+	filesToMove := plan.moves // All of them, unless they were spilled, which MaxMemory allows only without the steps below that need them all
+	if plan.spilled > 0 {
+		p.Detail(LevelInfo, "💾", "The planned moves outgrew --max-memory, %d of them were written to a temporary file.", plan.spilled)
+	}
+	if scanErr != nil { // Report if any errors were encountered during the scan
+		p.Status(LevelWarn, "⚠️", "Scan completed with some errors.")
+	}
 
-	fmt.Printf("%s Found %d files to process.\n", blue("✅"), totalToProcess)
+	if extensions != nil {
+		progressChan <- ProgressUpdate{Extensions: extensions.stats()}
+	}
+	// What fills the disk is reported before run limits leave anything out
+	if cfg.TopN > 0 {
+		space := spaceReport(filesToMove, cfg.TopN)
+		printSpaceReport(p, space)
+		progressChan <- ProgressUpdate{Space: space}
+	}
 
-	// Phase 2: Process Files with Worker Pool
-	workQueue := make(chan FileMove, cfg.Workers*2)
-	var wg sync.WaitGroup
+	if cfg.MinCategoryFiles > 1 {
+		var dropped int
+		filesToMove, dropped = foldSmallCategories(cfg, filesToMove, p)
+		totalSkipped += dropped
+	}
+	if cfg.EventGap > 0 {
+		groupEvents(cfg, filesToMove, p)
+	}
+	pairSubtitles(cfg, filesToMove, p)
+	if cfg.SimilarImages != "" {
+		findSimilarImages(cfg, filesToMove, p)
+	}
 
-	// Start worker goroutines
-	for i := 0; i < cfg.Workers; i++ {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
-			for fm := range workQueue {
-				// moveFile sends progress updates directly to progressChan
-				_ = moveFile(fm, progressChan, cfg.Quiet) // Ignore error here, it's handled and reported by moveFile
+	order := cfg.Order
+	if order == "" && (cfg.MaxFiles > 0 || cfg.MaxBytes > 0) {
+		order = OrderMtime // A limited run works through the backlog oldest first
+	}
+	sortFiles(filesToMove, order)
+	if cfg.MaxFiles > 0 || cfg.MaxBytes > 0 {
+		var deferred []FileMove
+		filesToMove, deferred = limitFiles(filesToMove, cfg.MaxFiles, cfg.MaxBytes)
+		if len(deferred) > 0 {
+			var deferredBytes int64
+			for _, fm := range deferred {
+				deferredBytes += fm.Info.Size()
 			}
-		}(i)
+			p.Status(LevelNotice, "⏩", "Run limit reached: %d files (%s) are left for a later run.", len(deferred), FormatBytes(deferredBytes))
+		}
 	}
 
-	// Dispatch tasks to the worker pool
+	// Files old enough for archival mode are packed by month once the others are done
+	toArchive := make(map[string][]FileMove)
+	archiveCount := 0
+	n := 0
 	for _, fm := range filesToMove {
-		workQueue <- fm
+		if fm.archive {
+			period := archivePeriod(fm)
+			toArchive[period] = append(toArchive[period], fm)
+			archiveCount++
+			continue
+		}
+		filesToMove[n] = fm
+		n++
+	}
+	filesToMove = filesToMove[:n]
+
+	var replayErr error
+	if stream == nil {
+		totalToProcess = plan.spilled + len(filesToMove) + archiveCount
+		if totalToProcess == 0 {
+			p.Status(LevelInfo, "ℹ️", "No files found to organize.")
+			return totalScanned, totalToProcess, totalSkipped, nil
+		}
+
+		p.Status(LevelInfo, "✅", "Found %d files to process.", totalToProcess)
+		if archiveCount > 0 {
+			p.Status(LevelInfo, "🗄️", "%d of them are older than %.0f days and will be archived into %d monthly archives.", archiveCount, cfg.ArchiveOlderThan.Hours()/24, len(toArchive))
+		}
+		progressChan <- ProgressUpdate{Planned: totalToProcess}
+		timer.planDone()
+
+		// Phase 2: Process Files with Worker Pool
+		pool := newWorkerPool(cfg, adaptive, progressChan)
+
+		// Dispatch tasks to the worker pool, the spilled ones first as they were scanned first
+		stopped := false
+		replayErr = plan.replay(cfg.fsys(), cfg.DryRun, func(fm FileMove, err error) bool {
+			if err != nil {
+				p.File(LevelWarn, "CHANGED", "%v. Skipping.", err)
+				progressChan <- fm.skippedUpdate(err)
+				return true
+			}
+			stopped = !pool.dispatch(fm)
+			return !stopped
+		})
+		for _, fm := range filesToMove {
+			if stopped || replayErr != nil || !pool.dispatch(fm) {
+				break
+			}
+		}
+
+		// Wait for the dispatched files, and their retries, to be done with.
+		pool.drain()
+
+		// Phase 3: Pack old files into their monthly archives
+		if len(toArchive) > 0 && !cfg.Control.Stopped() {
+			archiveOldFiles(cfg, pool, toArchive, progressChan)
+		}
+		pool.wait()
 	}
-	close(workQueue) // Close the work queue after all files have been dispatched.
 
-	// Wait for all worker goroutines to finish their tasks.
-	wg.Wait()
+	// Phase 4: Record the checksums of the stored files
+	cfg.sums.write(cfg)
+
+	// Phase 5: Rotate old files out of categories that outgrew their quota
+	if len(cfg.Quotas) > 0 && cfg.Remote == nil && !cfg.Control.Stopped() {
+		enforceQuotas(cfg, progressChan)
+	}
 	// Do NOT close progressChan here. It's closed by main.go after its progress collection goroutine finishes.
 
-	return totalScanned, totalToProcess, totalSkipped, nil
+	if cfg.Control.Stopped() {
+		return totalScanned, totalToProcess, totalSkipped, ErrAborted
+	}
+	return totalScanned, totalToProcess, totalSkipped, replayErr
+}
+
+// walkFileList calls fn for every entry of cfg.Files like WalkDir would, once per path. Listed
+// directories are passed on but not descended into: only the listed files are organized.
+func walkFileList(cfg Config, fn fs.WalkDirFunc) {
+	fsys := cfg.fsys()
+	seen := make(map[string]bool, len(cfg.Files))
+	for _, path := range cfg.Files {
+		path = filepath.Clean(path)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		info, err := fsys.Lstat(path)
+		if err != nil {
+			_ = fn(path, nil, err)
+			continue
+		}
+		_ = fn(path, fs.FileInfoToDirEntry(info), nil)
+	}
+}
+
+// verifyUnchanged re-stats the source of fm without following symlinks and makes sure it is still
+// the same regular file that was seen during the scan (same inode/device and size).
+func verifyUnchanged(fsys fsutil.FS, fm FileMove) error {
+	if fm.Info == nil {
+		return nil // Nothing recorded at scan time, nothing to compare against
+	}
+	current, err := fsys.Lstat(fm.SourcePath)
+	if err != nil {
+		return fmt.Errorf("'%s' can no longer be read: %w", fm.SourcePath, err)
+	}
+	if !current.Mode().IsRegular() {
+		return fmt.Errorf("'%s' is no longer a regular file (now a %s)", fm.SourcePath, describeFileType(current.Mode()))
+	}
+	if !fsys.SameFile(current, fm.Info) {
+		return fmt.Errorf("'%s' was replaced by a different file since it was scanned", fm.SourcePath)
+	}
+	if current.Size() != fm.Info.Size() {
+		return fmt.Errorf("'%s' changed size since it was scanned (%d -> %d bytes)", fm.SourcePath, fm.Info.Size(), current.Size())
+	}
+	return nil
+}
+
+// describeFileType returns a human readable name for the type bits of m.
+func describeFileType(m fs.FileMode) string {
+	switch {
+	case m&fs.ModeSymlink != 0:
+		return "symlink"
+	case m&fs.ModeDevice != 0:
+		return "device"
+	case m&fs.ModeNamedPipe != 0:
+		return "named pipe"
+	case m&fs.ModeSocket != 0:
+		return "socket"
+	case m.IsDir():
+		return "directory"
+	case m.IsRegular():
+		return "regular file"
+	default:
+		return "irregular file"
+	}
+}
+
+// defaultFolder returns the folder below DestDir the layout puts the file at path of category in.
+func (cfg Config) defaultFolder(path, category string, modTime time.Time) string {
+	srcRel := ""
+	if rel, err := filepath.Rel(cfg.SourceDir, filepath.Dir(path)); err == nil && rel != "." {
+		srcRel = filepath.ToSlash(rel)
+	}
+	f := layoutFields{Category: category, Name: filepath.Base(path), SrcRel: srcRel, ModTime: modTime}
+	if category == VendorCategory && len(cfg.Vendors) > 0 {
+		if folder, ok := cfg.vendorFolder(f.Name); ok {
+			return filepath.Join(cfg.categoryFolder(category), filepath.FromSlash(folder))
+		}
+	}
+	if cfg.MediaNames && category == "Videos" {
+		if folder, ok := mediaFolder(f.Name); ok {
+			return filepath.Join(cfg.categoryFolder(category), filepath.FromSlash(folder))
+		}
+	}
+	if cfg.readsVideos() {
+		if v, ok := probeVideo(cfg.fsys(), path); ok {
+			if v.Duration > 0 && v.Duration < cfg.ClipsUnder {
+				return filepath.Join(cfg.categoryFolder(category), ClipsFolder)
+			}
+			f.Video = v.resolution()
+		}
+	}
+	return cfg.layoutFolder(f)
+}
+
+// targetPath returns where a file named fileName goes in destFolder below DestDir, or below the
+// location of the remote destination.
+func (cfg Config) targetPath(destFolder, fileName string) string {
+	if cfg.Remote != nil {
+		return filepath.ToSlash(filepath.Join(destFolder, fileName)) // Relative to the remote location
+	}
+	return filepath.Join(cfg.DestDir, destFolder, fileName)
+}
+
+// categorySelected reports whether files of category are organized under OnlyCategories and
+// SkipCategories.
+func (cfg Config) categorySelected(category string) bool {
+	if len(cfg.OnlyCategories) > 0 && !matchesAnyName(category, cfg.OnlyCategories) {
+		return false
+	}
+	return !matchesAnyName(category, cfg.SkipCategories)
+}
+
+// matchesAnyName reports whether name equals one of names, ignoring case.
+func matchesAnyName(name string, names []string) bool {
+	for _, n := range names {
+		if strings.EqualFold(name, n) {
+			return true
+		}
+	}
+	return false
 }
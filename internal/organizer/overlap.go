@@ -0,0 +1,49 @@
+package organizer
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// overlap is how the source and the destination of a run lie in each other. They are compared
+// as files, not by their paths, so that symlinks and bind mounts are seen through.
+type overlap struct {
+	dest   fs.FileInfo // The destination, to recognize it in the walk; nil if it doesn't exist yet or is remote
+	same   bool        // The source is the destination
+	inDest bool        // The source is a folder inside the destination
+}
+
+// overlap finds out how the source and the destination of cfg overlap.
+func (cfg Config) overlap() overlap {
+	var o overlap
+	if cfg.Remote != nil || IsArchiveSource(cfg.SourceDir) {
+		return o
+	}
+	dest, err := cfg.fsys().Stat(cfg.DestDir)
+	if err != nil {
+		return o
+	}
+	o.dest = dest
+	if src, err := cfg.fsys().Stat(cfg.SourceDir); err == nil && os.SameFile(src, dest) {
+		o.same = true
+		return o
+	}
+	for dir := resolvePath(cfg.SourceDir); filepath.Dir(dir) != dir; {
+		dir = filepath.Dir(dir)
+		if info, err := cfg.fsys().Stat(dir); err == nil && os.SameFile(info, dest) {
+			o.inDest = true
+			break
+		}
+	}
+	return o
+}
+
+// isDest reports whether the directory d of the walk is the destination.
+func (o overlap) isDest(d fs.DirEntry) bool {
+	if o.dest == nil {
+		return false
+	}
+	info, err := d.Info()
+	return err == nil && os.SameFile(info, o.dest)
+}
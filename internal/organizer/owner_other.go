@@ -0,0 +1,13 @@
+//go:build !unix
+
+package organizer
+
+import "io/fs"
+
+// OwnershipSupported reports whether file ownership can be checked on this platform.
+const OwnershipSupported = false
+
+// ownedByCurrentUser always returns false where ownership cannot be determined.
+func ownedByCurrentUser(info fs.FileInfo) bool {
+	return false
+}
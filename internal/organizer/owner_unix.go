@@ -0,0 +1,21 @@
+//go:build unix
+
+package organizer
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// OwnershipSupported reports whether file ownership can be checked on this platform.
+const OwnershipSupported = true
+
+// ownedByCurrentUser reports whether info belongs to the user running the organizer.
+func ownedByCurrentUser(info fs.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false // Unknown owner: err on the side of leaving the file alone
+	}
+	return int(st.Uid) == os.Getuid()
+}
@@ -0,0 +1,59 @@
+package organizer
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// PlaceholderPolicy decides what happens to cloud placeholder (online-only) files found in the source.
+type PlaceholderPolicy string
+
+const (
+	PlaceholderSkip    PlaceholderPolicy = "skip"    // Leave placeholders where they are (default)
+	PlaceholderHydrate PlaceholderPolicy = "hydrate" // Download the content first, then organize the real file
+	PlaceholderMove    PlaceholderPolicy = "move"    // Move the placeholder itself, e.g. when organizing inside the synced folder
+)
+
+// ParsePlaceholderPolicy validates a --cloud-placeholders value. An empty value selects PlaceholderSkip.
+func ParsePlaceholderPolicy(s string) (PlaceholderPolicy, error) {
+	switch p := PlaceholderPolicy(strings.ToLower(s)); p {
+	case "":
+		return PlaceholderSkip, nil
+	case PlaceholderSkip, PlaceholderHydrate, PlaceholderMove:
+		return p, nil
+	default:
+		return "", configError("--cloud-placeholders", fmt.Errorf("invalid policy '%s' (use skip, hydrate or move)", s))
+	}
+}
+
+// isCloudPlaceholder reports whether the file is a OneDrive/Dropbox/iCloud placeholder whose
+// content lives only in the cloud. Moving such a file out of the synced folder produces a stub
+// that can no longer be opened.
+func isCloudPlaceholder(info fs.FileInfo) bool {
+	return isICloudStub(info.Name()) || isDatalessFile(info)
+}
+
+// isICloudStub recognizes the ".Name.ext.icloud" stand-in files older macOS versions leave in
+// place of evicted iCloud Drive documents.
+func isICloudStub(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".icloud") && len(name) > len("..icloud")
+}
+
+// hydrate forces the cloud provider to download the content of the file at path on fsys by reading
+// it completely.
+// Both the Windows Cloud Files API and macOS File Provider materialize dataless files on read.
+func hydrate(fsys fsutil.FS, path string) error {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open placeholder '%s': %w", path, err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(io.Discard, f); err != nil {
+		return fmt.Errorf("failed to download placeholder '%s': %w", path, err)
+	}
+	return nil
+}
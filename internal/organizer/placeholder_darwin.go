@@ -0,0 +1,21 @@
+//go:build darwin
+
+package organizer
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// sfDataless is the st_flags bit File Provider (iCloud Drive, Dropbox, OneDrive) sets on files
+// whose content has been evicted to the cloud.
+const sfDataless = 0x40000000
+
+// isDatalessFile reports whether the file content has been evicted to the cloud.
+func isDatalessFile(info fs.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false
+	}
+	return st.Flags&sfDataless != 0
+}
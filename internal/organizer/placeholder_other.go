@@ -0,0 +1,11 @@
+//go:build !windows && !darwin
+
+package organizer
+
+import "io/fs"
+
+// isDatalessFile always returns false: there is no common placeholder marker on this platform
+// (sparse-file heuristics misfire on filesystems that store small files inline).
+func isDatalessFile(info fs.FileInfo) bool {
+	return false
+}
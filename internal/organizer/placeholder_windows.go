@@ -0,0 +1,24 @@
+//go:build windows
+
+package organizer
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// File attributes set by the Cloud Files API (OneDrive, Dropbox, ...) on online-only files.
+const (
+	fileAttributeOffline            = 0x00001000
+	fileAttributeRecallOnOpen       = 0x00040000
+	fileAttributeRecallOnDataAccess = 0x00400000
+)
+
+// isDatalessFile reports whether the file content has to be recalled from the cloud.
+func isDatalessFile(info fs.FileInfo) bool {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return false
+	}
+	return data.FileAttributes&(fileAttributeOffline|fileAttributeRecallOnOpen|fileAttributeRecallOnDataAccess) != 0
+}
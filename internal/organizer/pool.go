@@ -0,0 +1,358 @@
+package organizer
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// DefaultFileRetries is how often New has the worker pool try a file again after it failed
+// because another program held it or the share it is on dropped out.
+const DefaultFileRetries = 2
+
+const (
+	retryBackoff    = 5 * time.Second // Before the first retry of a file, doubled on every further one
+	poolReportEvery = time.Second     // How often the pool sends its PoolStats while it runs
+)
+
+// PoolStats is the state of the worker pool of a run. It is sent with ProgressUpdate every second
+// while files are processed and once more when they are all done.
+type PoolStats struct {
+	Workers   int           `json:"workers"`
+	Busy      int           `json:"busy"`     // Workers processing a file right now
+	Queued    int           `json:"queued"`   // Files dispatched and not picked up by a worker yet
+	Retrying  int           `json:"retrying"` // Files waiting for another attempt, see Config.FileRetries
+	Retried   int           `json:"retried"`  // Further attempts made at files so far
+	PerWorker []WorkerStats `json:"per_worker"`
+}
+
+// WorkerStats is what one worker of the pool has done.
+type WorkerStats struct {
+	Worker int   `json:"worker"`  // From 1
+	Files  int   `json:"files"`   // Files it is done with, whatever the outcome
+	Errors int   `json:"errors"`  // Of those, the ones that failed
+	Bytes  int64 `json:"bytes"`   // Size of the files it organized
+	BusyMS int64 `json:"busy_ms"` // Time it spent on files
+}
+
+// workerPool processes the jobs of a run, its planned files and the other work on files, on
+// cfg.Workers goroutines. Jobs wait in a bounded queue, so dispatch blocks while the workers are
+// behind. A job that fails because a file is in use or its share dropped out goes back to the end
+// of the queue after a backoff, up to cfg.FileRetries times, before its failure is reported.
+// Stopping the run's Controller leaves the queued jobs in place and ends the waits for a retry.
+type workerPool struct {
+	cfg      Config
+	progress chan<- ProgressUpdate
+	adaptive *adaptiveWorkers
+	queue    chan poolJob
+	workers  sync.WaitGroup
+	pending  sync.WaitGroup // Jobs dispatched and not done with, those waiting for a retry included
+	stop     chan struct{}  // Closed to end the reports
+	reported chan struct{}  // Closed once the last report is sent
+
+	mu    sync.Mutex
+	stats PoolStats
+}
+
+// poolJob is one piece of work for the pool: a planned file, an archive entry to extract or the
+// files of a period archive. The updates it sends that carry the outcome of a file are held back
+// until the job is done with, so that a failed attempt goes uncounted when it is retried.
+type poolJob struct {
+	name    string                                                // What the job works on, for the worker's current file and the retry message
+	size    int64                                                 // Bytes it processes, for adaptive workers
+	event   func(phase Phase) *FileEvent                          // Its queued and started events; nil if it has none
+	run     func(worker int, updates chan<- ProgressUpdate) error // Does the job for worker, sending its updates on updates
+	done    func()                                                // Called once the pool is done with the job, whatever its outcome; may be nil
+	attempt int                                                   // Retries made so far, see Config.FileRetries
+}
+
+// fileJob is the job processing the planned file fm.
+func (cfg Config) fileJob(fm FileMove) poolJob {
+	job := poolJob{
+		name:  fm.SourcePath,
+		event: func(phase Phase) *FileEvent { return fm.event(phase, cfg.plannedAction(fm), "") },
+		run: func(worker int, updates chan<- ProgressUpdate) error {
+			fm.worker = worker
+			return cfg.processWatched(fm, updates)
+		},
+	}
+	if fm.Info != nil {
+		job.size = fm.Info.Size()
+	}
+	return job
+}
+
+// newWorkerPool starts the workers of cfg; adaptive may be nil.
+func newWorkerPool(cfg Config, adaptive *adaptiveWorkers, progress chan<- ProgressUpdate) *workerPool {
+	pool := &workerPool{
+		cfg:      cfg,
+		progress: progress,
+		adaptive: adaptive,
+		queue:    make(chan poolJob, cfg.Workers*2),
+		stop:     make(chan struct{}),
+		reported: make(chan struct{}),
+		stats:    PoolStats{Workers: cfg.Workers, PerWorker: make([]WorkerStats, cfg.Workers)},
+	}
+	for i := range cfg.Workers {
+		pool.stats.PerWorker[i].Worker = i + 1
+		pool.workers.Add(1)
+		go pool.work(i + 1)
+	}
+	go pool.report()
+	return pool
+}
+
+// dispatch queues the planned file fm, see dispatchJob.
+func (pool *workerPool) dispatch(fm FileMove) bool {
+	return pool.dispatchJob(pool.cfg.fileJob(fm))
+}
+
+// dispatchJob queues job, blocking while the queue is full or processing is paused. It returns
+// false once the run is stopped.
+func (pool *workerPool) dispatchJob(job poolJob) bool {
+	if !pool.cfg.Control.wait() {
+		pool.cfg.printer().Status(LevelWarn, "⚠️", "Stop requested, not dispatching the remaining files.")
+		if job.done != nil {
+			job.done()
+		}
+		return false
+	}
+	pool.pending.Add(1)
+	pool.enqueue(job)
+	return true
+}
+
+// enqueue puts job at the end of the queue; it must be pending.
+func (pool *workerPool) enqueue(job poolJob) {
+	if job.event != nil {
+		pool.progress <- ProgressUpdate{Event: job.event(PhaseQueued)}
+	}
+	pool.count(func(s *PoolStats) { s.Queued++ })
+	pool.queue <- job
+}
+
+// drain waits until every dispatched job is done with. Jobs can still be dispatched after it.
+func (pool *workerPool) drain() {
+	pool.pending.Wait()
+}
+
+// wait waits until every dispatched job is done with, stops the workers and sends the final
+// PoolStats.
+func (pool *workerPool) wait() {
+	pool.drain()
+	close(pool.queue)
+	pool.workers.Wait()
+	close(pool.stop)
+	<-pool.reported
+}
+
+// work is worker id: it processes the queued jobs until the queue is closed.
+func (pool *workerPool) work(id int) {
+	defer pool.workers.Done()
+	cfg := pool.cfg
+	for job := range pool.queue {
+		pool.count(func(s *PoolStats) { s.Queued-- })
+		if !cfg.Control.wait() { // Queued jobs wait out a pause too, and stay put on stop
+			pool.finish(job)
+			continue
+		}
+		pool.adaptive.acquire()
+		pool.count(func(s *PoolStats) { s.Busy++ })
+		update := ProgressUpdate{Worker: id, Started: job.name}
+		if job.event != nil {
+			update.Event = job.event(PhaseStarted)
+		}
+		pool.progress <- update
+		start := time.Now()
+		finals, err := pool.process(id, job)
+		elapsed := time.Since(start)
+		pool.adaptive.release(job.size, elapsed)
+		pool.count(func(s *PoolStats) {
+			s.Busy--
+			s.PerWorker[id-1].BusyMS += elapsed.Milliseconds()
+		})
+		if err != nil && pool.retry(id, job, finals, err) {
+			continue // Still pending until the retry is done with
+		}
+		for _, final := range finals {
+			pool.forward(id, final)
+		}
+		pool.finish(job)
+	}
+}
+
+// finish marks job as done with.
+func (pool *workerPool) finish(job poolJob) {
+	if job.done != nil {
+		job.done()
+	}
+	pool.pending.Done()
+}
+
+// process runs job on worker id with its updates forwarded but those carrying the outcome of a
+// file, which are returned instead so that a failed job can be retried without being counted.
+func (pool *workerPool) process(id int, job poolJob) ([]ProgressUpdate, error) {
+	updates := make(chan ProgressUpdate)
+	forwarded := make(chan struct{})
+	var finals []ProgressUpdate
+	go func() {
+		defer close(forwarded)
+		for update := range updates {
+			if update.File != nil {
+				finals = append(finals, update)
+				continue
+			}
+			pool.forward(id, update)
+		}
+	}()
+	err := job.run(id, updates)
+	close(updates)
+	<-forwarded
+	return finals, err
+}
+
+// retry puts job back at the end of the queue after a backoff if the error err it failed with on
+// worker id is worth another attempt, and reports whether it did. Its final updates are sent if
+// the run stops before the retry.
+func (pool *workerPool) retry(id int, job poolJob, finals []ProgressUpdate, err error) bool {
+	cfg := pool.cfg
+	if job.attempt >= cfg.FileRetries || cfg.Control.Stopped() || !retryable(err) {
+		return false
+	}
+	delay := retryBackoff << job.attempt
+	job.attempt++
+	cfg.printer().File(LevelWarn, "RETRY", "Trying '%s' again in %s (attempt %d of %d).", job.name, delay, job.attempt+1, cfg.FileRetries+1)
+	pool.count(func(s *PoolStats) { s.Retrying++ })
+	go func() {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-cfg.Control.done():
+		}
+		pool.count(func(s *PoolStats) { s.Retrying-- })
+		if !cfg.Control.wait() {
+			for _, final := range finals {
+				pool.forward(id, final)
+			}
+			pool.finish(job)
+			return
+		}
+		pool.count(func(s *PoolStats) { s.Retried++ })
+		pool.enqueue(job) // Still pending, so the queue is open
+	}()
+	return true
+}
+
+// retryable reports whether a file that failed with err may succeed when tried again a little
+// later in the run: it was in use by another program, or its network share dropped out.
+func retryable(err error) bool {
+	return !errors.Is(err, ErrFileTimeout) && (fsutil.IsBusy(err) || fsutil.IsTransient(err))
+}
+
+// forward counts the outcome of a file of worker id, if update carries one, and sends update on
+// as coming from the worker.
+func (pool *workerPool) forward(id int, update ProgressUpdate) {
+	update.Worker = id
+	if update.File != nil {
+		pool.count(func(s *PoolStats) {
+			w := &s.PerWorker[id-1]
+			w.Files++
+			w.Errors += update.Errored
+			w.Bytes += update.Bytes
+		})
+	}
+	pool.progress <- update
+}
+
+// count changes the stats of the pool with fn.
+func (pool *workerPool) count(fn func(s *PoolStats)) {
+	pool.mu.Lock()
+	fn(&pool.stats)
+	pool.mu.Unlock()
+}
+
+// report sends the PoolStats every poolReportEvery until the pool is done, and once more then.
+func (pool *workerPool) report() {
+	defer close(pool.reported)
+	ticker := time.NewTicker(poolReportEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			pool.progress <- ProgressUpdate{Pool: pool.snapshot()}
+		case <-pool.stop:
+			pool.progress <- ProgressUpdate{Pool: pool.snapshot()}
+			return
+		}
+	}
+}
+
+// snapshot returns a copy of the stats of the pool.
+func (pool *workerPool) snapshot() *PoolStats {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	stats := pool.stats
+	stats.PerWorker = append([]WorkerStats(nil), pool.stats.PerWorker...)
+	return &stats
+}
+
+// streams reports whether the moves of the scan go to the workers as they are found, so that the
+// scan waits while the workers are behind instead of planning every file first. That takes a run
+// without a step that needs the whole plan: an order, run limits, --top, archival, folding small
+// categories, events and similar images. With UnreadableFail nothing may be moved before the scan
+// is known to be complete, and Organizer.Plan only lists the moves.
+func (cfg Config) streams() bool {
+	return cfg.planned == nil && cfg.Order == "" && cfg.MaxFiles == 0 && cfg.MaxBytes == 0 && cfg.TopN == 0 &&
+		cfg.ArchiveOlderThan == 0 && cfg.MinCategoryFiles <= 1 && cfg.EventGap == 0 && cfg.SimilarImages == "" &&
+		cfg.Unreadable != UnreadableFail
+}
+
+// streamedPlan hands the moves of a streamed scan (see Config.streams) to the pool as they are
+// found. Subtitles are held back until the scan is done, to be paired with their videos.
+type streamedPlan struct {
+	pool      *workerPool
+	progress  chan<- ProgressUpdate
+	videos    []FileMove // Dispatched already, kept for pairSubtitles
+	subtitles []FileMove
+	planned   int
+	stopped   bool
+}
+
+// add dispatches fm and reports how many moves are planned so far. It returns fs.SkipAll to end
+// the walk once the run is stopped.
+func (s *streamedPlan) add(fm FileMove) error {
+	if s.stopped {
+		return fs.SkipAll
+	}
+	s.planned++
+	s.progress <- ProgressUpdate{Planned: s.planned}
+	switch ext := strings.ToLower(filepath.Ext(fm.SourcePath)); {
+	case slices.Contains(subtitleExts, ext):
+		s.subtitles = append(s.subtitles, fm)
+		return nil
+	case slices.Contains(videoExts, ext):
+		s.videos = append(s.videos, fm)
+	}
+	if s.stopped = !s.pool.dispatch(fm); s.stopped {
+		return fs.SkipAll
+	}
+	return nil
+}
+
+// finish pairs the held back subtitles with their videos and dispatches them.
+func (s *streamedPlan) finish(cfg Config, p logger) {
+	files := append(s.videos, s.subtitles...)
+	pairSubtitles(cfg, files, p)
+	for _, fm := range files[len(s.videos):] {
+		if s.stopped || !s.pool.dispatch(fm) {
+			return
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package organizer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+func TestScanStreamsToWorkers(t *testing.T) {
+	source, dest := t.TempDir(), t.TempDir()
+	cfg := testConfig(t, source, dest)
+	first, last := filepath.Join(source, "a.txt"), filepath.Join(source, "z.txt")
+	writeFile(t, first, "a")
+	writeFile(t, last, "z")
+	writeFile(t, filepath.Join(source, "movie.mkv"), "video")
+	writeFile(t, filepath.Join(source, "movie.en.srt"), "subtitle")
+
+	// The walk only goes on to the last file once the first is organized
+	movedEarly := false
+	cfg.FS = fsutil.Faulty(fsutil.OS, func(op, path string) error {
+		if op == "walk" && path == last {
+			for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline) && !movedEarly; time.Sleep(10 * time.Millisecond) {
+				_, err := os.Lstat(first)
+				movedEarly = errors.Is(err, os.ErrNotExist)
+			}
+		}
+		return nil
+	})
+	result, err := OrganizeFiles(cfg, nil)
+	if err != nil || result.Processed != 4 {
+		t.Fatalf("OrganizeFiles = %d processed, %v; want 4", result.Processed, err)
+	}
+	if !movedEarly {
+		t.Error("the first file was not organized before the scan was done")
+	}
+	if _, err := os.Stat(filepath.Join(dest, "Videos", "movie.en.srt")); err != nil {
+		t.Errorf("subtitle not kept with its video: %v", err)
+	}
+}
+
+func TestScanPlansFirstWithOrder(t *testing.T) {
+	source, dest := t.TempDir(), t.TempDir()
+	cfg := testConfig(t, source, dest)
+	cfg.Order = OrderName
+	first, last := filepath.Join(source, "a.txt"), filepath.Join(source, "z.txt")
+	writeFile(t, first, "a")
+	writeFile(t, last, "z")
+
+	stillThere := false
+	cfg.FS = fsutil.Faulty(fsutil.OS, func(op, path string) error {
+		if op == "walk" && path == last {
+			_, err := os.Lstat(first)
+			stillThere = !errors.Is(err, os.ErrNotExist)
+		}
+		return nil
+	})
+	result, err := OrganizeFiles(cfg, nil)
+	if err != nil || result.Processed != 2 {
+		t.Fatalf("OrganizeFiles = %d processed, %v; want 2", result.Processed, err)
+	}
+	if !stillThere {
+		t.Error("a file was organized before an ordered scan was done")
+	}
+}
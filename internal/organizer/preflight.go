@@ -0,0 +1,97 @@
+package organizer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"syscall"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// preflight checks that every place the run may write to can be written to, before the scan, so
+// a read-only or missing destination fails the run once instead of every file failing on its own.
+// It returns a *ConfigError naming the setting of the first place that cannot be written to.
+func (cfg Config) preflight() error {
+	if cfg.Remote != nil {
+		if err := cfg.Remote.check(); err != nil {
+			return configError("--dest", err)
+		}
+	} else if err := checkWritable(cfg.fsys(), cfg.DestDir); err != nil {
+		return configError("--dest", err)
+	}
+	if m := cfg.Mirror; m != nil {
+		err := error(nil)
+		if m.Remote != nil {
+			err = m.Remote.check()
+		} else {
+			err = checkWritable(fsutil.OS, m.Dir)
+		}
+		if err != nil {
+			return configError("--mirror", err)
+		}
+	}
+	for _, q := range cfg.Quotas {
+		if q.Policy != QuotaOverflow || cfg.Remote != nil {
+			continue // Quotas are not enforced on remote destinations
+		}
+		if err := checkWritable(fsutil.OS, q.Overflow); err != nil {
+			return configError("quotas", fmt.Errorf("overflow of '%s': %w", q.Category, err))
+		}
+	}
+	return nil
+}
+
+// checkWritable checks that files can be created in dir or, when it does not exist yet, that it
+// can be created: its nearest existing parent must be a directory files can be created in. A
+// staged file is created and removed again to find out.
+func checkWritable(fsys fsutil.FS, dir string) error {
+	existing := dir
+	for {
+		info, err := fsys.Stat(existing)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("'%s' is not a directory", existing)
+			}
+			break
+		}
+		parent := filepath.Dir(existing)
+		if !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, syscall.ENOTDIR) || parent == existing {
+			return err
+		}
+		existing = parent
+	}
+	probe, err := stage(fsys, existing, 0644)
+	if err != nil {
+		if pathErr := (*fs.PathError)(nil); errors.As(err, &pathErr) {
+			err = pathErr.Err // The name of the probe means nothing to the user
+		}
+		if existing != dir {
+			return fmt.Errorf("'%s' does not exist and cannot be created: %w", dir, err)
+		}
+		return fmt.Errorf("'%s' is not writable: %w", dir, err)
+	}
+	probe.Close()
+	probe.abort()
+	return nil
+}
+
+// check makes sure the base collection of the server can be reached with the credentials given.
+func (c *WebDAVClient) check() error {
+	resp, err := c.do("PROPFIND", "", nil, -1, map[string]string{"Depth": "0"})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("'%s' does not exist on the server", c)
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("access to '%s' denied: %s", c, resp.Status)
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("PROPFIND '%s' failed: %s", c, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package organizer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Level is the kind of a message; printers typically map it to a colour.
+type Level int
+
+const (
+	LevelInfo    Level = iota // Progress of the run
+	LevelSuccess              // A file was organized, restored, ...
+	LevelNotice               // Nothing changed: dry run, already in sync
+	LevelWarn                 // Skipped files, collisions, non-fatal problems
+	LevelError                // A file or step failed
+)
+
+// Printer receives the messages the engine writes while it works. Implementations must be safe
+// for concurrent use, as workers print in parallel.
+type Printer interface {
+	// Status reports a step of the run, e.g. "Scanning files in ...". icon is an emoji the
+	// printer may show in front of the message; it is empty for banner lines.
+	Status(level Level, icon, format string, args ...any)
+	// Detail reports something about a single entry found during the scan, e.g. why it was
+	// skipped.
+	Detail(level Level, icon, format string, args ...any)
+	// File reports what happened to a single file. label is an upper-case tag such as MOVED,
+	// DRY RUN or ERROR.
+	File(level Level, label, format string, args ...any)
+}
+
+// PlainPrinter returns a Printer that writes uncoloured lines to w. It is used when Config has no
+// Printer, with w set to stdout.
+func PlainPrinter(w io.Writer) Printer {
+	return &plainPrinter{w: w}
+}
+
+type plainPrinter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (p *plainPrinter) Status(level Level, icon, format string, args ...any) {
+	p.line("", icon, format, args)
+}
+
+func (p *plainPrinter) Detail(level Level, icon, format string, args ...any) {
+	p.line("  ", icon, format, args)
+}
+
+func (p *plainPrinter) File(level Level, label, format string, args ...any) {
+	p.line("    ", label+":", format, args)
+}
+
+func (p *plainPrinter) line(indent, prefix, format string, args []any) {
+	if prefix != "" {
+		prefix += " "
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, "%s%s%s\n", indent, prefix, fmt.Sprintf(format, args...))
+}
+
+// defaultPrinter is used by functions that are not given a Printer.
+var defaultPrinter = PlainPrinter(os.Stdout)
+
+// Verbosity controls which messages reach the Printer. The zero value is VerbosityNormal.
+type Verbosity int
+
+const (
+	VerbosityQuiet   Verbosity = iota - 1 // Steps of the run and failures only
+	VerbosityNormal                       // Also the outcome of every file and collision notices
+	VerbosityVerbose                      // Also why entries were skipped during the scan
+	VerbosityDebug                        // Also how every file was classified and how long it took
+)
+
+var verbosityNames = map[Verbosity]string{
+	VerbosityQuiet:   "quiet",
+	VerbosityNormal:  "normal",
+	VerbosityVerbose: "verbose",
+	VerbosityDebug:   "debug",
+}
+
+func (v Verbosity) String() string {
+	if name, ok := verbosityNames[v]; ok {
+		return name
+	}
+	return fmt.Sprintf("Verbosity(%d)", int(v))
+}
+
+// ParseVerbosity parses the value of --verbosity: quiet, normal, verbose or debug.
+func ParseVerbosity(s string) (Verbosity, error) {
+	for v, name := range verbosityNames {
+		if s == name {
+			return v, nil
+		}
+	}
+	return VerbosityNormal, fmt.Errorf("unknown verbosity '%s' (use quiet, normal, verbose or debug)", s)
+}
+
+// logger is the Printer the engine writes to. It drops the messages the configured verbosity does
+// not ask for, so the code printing them doesn't have to check:
+//
+//   - Status lines and per-file errors are always printed.
+//   - File lines are printed from VerbosityNormal on.
+//   - Detail lines, the reasons entries were skipped during the scan, from VerbosityVerbose on.
+//   - Debug lines only at VerbosityDebug.
+type logger struct {
+	Printer
+	verbosity Verbosity
+}
+
+func newLogger(p Printer, v Verbosity) logger {
+	if p == nil {
+		p = defaultPrinter
+	}
+	return logger{Printer: p, verbosity: v}
+}
+
+func (l logger) Detail(level Level, icon, format string, args ...any) {
+	if l.verbosity >= VerbosityVerbose {
+		l.Printer.Detail(level, icon, format, args...)
+	}
+}
+
+func (l logger) File(level Level, label, format string, args ...any) {
+	if level == LevelError || l.verbosity >= VerbosityNormal {
+		l.Printer.File(level, label, format, args...)
+	}
+}
+
+// Debug prints a message meant for troubleshooting, as a detail line.
+func (l logger) Debug(format string, args ...any) {
+	if l.verbosity >= VerbosityDebug {
+		l.Printer.Detail(LevelInfo, "🐞", format, args...)
+	}
+}
+
+// printer returns the Printer cfg reports to, filtered by cfg.Verbosity.
+func (cfg Config) printer() logger {
+	return newLogger(cfg.Printer, cfg.Verbosity)
+}
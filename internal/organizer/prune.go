@@ -0,0 +1,108 @@
+package organizer
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/journal"
+	"github.com/avizyt/org-cli/internal/trash"
+)
+
+// Retention actions.
+const (
+	RetentionTrash  = "trash"  // Move expired files to the trash
+	RetentionDelete = "delete" // Remove expired files permanently
+)
+
+// RetentionRule expires files below a category folder of the destination.
+type RetentionRule struct {
+	Category  string        // Category folder, optionally with subfolders ("Downloads/Installers")
+	OlderThan time.Duration // Files last modified longer ago than this are expired
+	Action    string        // RetentionTrash or RetentionDelete
+}
+
+// ValidRetentionAction reports whether action is a supported retention action.
+func ValidRetentionAction(action string) bool {
+	return action == RetentionTrash || action == RetentionDelete
+}
+
+// PruneResult counts what a prune did (or would do in a dry run).
+type PruneResult struct {
+	Pruned int
+	Bytes  int64
+	Failed int
+}
+
+// Prune applies the retention rules to destDir. Every trashed or deleted file is recorded in j,
+// so that trashed files can be brought back with undo. Messages go to printer, or stdout if it is
+// nil, as far as verbosity asks for them.
+func Prune(destDir string, rules []RetentionRule, dryRun bool, verbosity Verbosity, j *journal.Journal, now time.Time, printer Printer) PruneResult {
+	p := newLogger(printer, verbosity)
+
+	var result PruneResult
+	for _, rule := range rules {
+		root := filepath.Join(destDir, filepath.FromSlash(rule.Category))
+		cutoff := now.Add(-rule.OlderThan)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == root && os.IsNotExist(err) {
+					return nil // Nothing organized into this category yet
+				}
+				p.File(LevelError, "ERROR", "%v", err)
+				result.Failed++
+				return nil
+			}
+			// Hidden files and the organizer's own bookkeeping files are never expired
+			if !d.Type().IsRegular() || strings.HasPrefix(d.Name(), ".") || d.Name() == archiveIndexFile || d.Name() == reviewManifestFile {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil || !info.ModTime().Before(cutoff) {
+				return nil
+			}
+
+			if dryRun {
+				p.File(LevelNotice, "DRY RUN", "Would %s '%s' (last modified %s)", rule.Action, path, info.ModTime().Format("2006-01-02"))
+				result.Pruned++
+				result.Bytes += info.Size()
+				return nil
+			}
+
+			entry := journal.Entry{Source: path, Size: info.Size()}
+			if rule.Action == RetentionTrash {
+				trashed, err := trash.Move(path)
+				if err != nil {
+					p.File(LevelError, "ERROR", "%v", err)
+					result.Failed++
+					return nil
+				}
+				entry.Op, entry.Dest = journal.OpTrash, trashed
+			} else {
+				if err := os.Remove(path); err != nil {
+					p.File(LevelError, "ERROR", "failed to delete '%s': %v", path, err)
+					result.Failed++
+					return nil
+				}
+				entry.Op = journal.OpDelete
+			}
+			j.Record(entry)
+
+			label := "TRASHED"
+			if rule.Action == RetentionDelete {
+				label = "DELETED"
+			}
+			p.File(LevelSuccess, label, "'%s' (last modified %s)", path, info.ModTime().Format("2006-01-02"))
+			result.Pruned++
+			result.Bytes += info.Size()
+			return nil
+		})
+		if err != nil {
+			p.File(LevelError, "ERROR", "%v", err)
+			result.Failed++
+		}
+	}
+	return result
+}
@@ -0,0 +1,24 @@
+package organizer
+
+import "github.com/avizyt/org-cli/internal/fsutil"
+
+// placed applies StripQuarantine to a file that was moved to dst. Moves keep the extended
+// attributes on their own.
+func (cfg Config) placed(dst string) {
+	if !cfg.StripQuarantine {
+		return
+	}
+	if err := fsutil.RemoveQuarantine(dst); err != nil {
+		cfg.printer().File(LevelWarn, "WARNING", "Could not remove the quarantine flag of '%s': %v", dst, err)
+	}
+}
+
+// copied gives dst, a copy of src, the extended attributes of src, so that a downloaded app or
+// installer keeps its quarantine flag and a file its Finder comment as if it had been moved
+// (macOS only). Then StripQuarantine is applied.
+func (cfg Config) copied(src, dst string) {
+	if err := fsutil.CopyXattrs(src, dst); err != nil {
+		cfg.printer().File(LevelWarn, "WARNING", "Could not copy the extended attributes of '%s': %v", src, err)
+	}
+	cfg.placed(dst)
+}
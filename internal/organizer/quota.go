@@ -0,0 +1,151 @@
+package organizer
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+	"github.com/avizyt/org-cli/internal/journal"
+	"github.com/avizyt/org-cli/internal/trash"
+)
+
+// Quota policies, applied when a category grows beyond its quota.
+const (
+	QuotaWarn     = "warn"     // Only report the excess
+	QuotaTrash    = "trash"    // Move the oldest files to the trash until the category fits
+	QuotaOverflow = "overflow" // Move the oldest files to an overflow directory until the category fits
+)
+
+// Quota limits the total size of a category folder in the destination.
+type Quota struct {
+	Category string
+	MaxBytes int64
+	Policy   string // QuotaWarn, QuotaTrash or QuotaOverflow
+	Overflow string // Directory for QuotaOverflow; files keep their path relative to the category
+}
+
+// ValidQuotaPolicy reports whether policy is a supported quota policy.
+func ValidQuotaPolicy(policy string) bool {
+	return policy == QuotaWarn || policy == QuotaTrash || policy == QuotaOverflow
+}
+
+// quotaFile is a file counted against a quota.
+type quotaFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// enforceQuotas checks every quota after a run and rotates out the oldest files of categories
+// that are over their limit, according to the quota's policy.
+func enforceQuotas(cfg Config, progressChan chan<- ProgressUpdate) {
+	p := cfg.printer()
+
+	for _, q := range cfg.Quotas {
+		root := filepath.Join(cfg.DestDir, cfg.categoryFolder(q.Category))
+		files, total, err := categoryFiles(cfg.fsys(), root)
+		if err != nil {
+			p.Status(LevelError, "❌", "Could not check quota of '%s': %v", q.Category, err)
+			continue
+		}
+		if total <= q.MaxBytes {
+			continue
+		}
+		p.Status(LevelWarn, "⚠️", "Category '%s' uses %s, over its quota of %s.", q.Category, FormatBytes(total), FormatBytes(q.MaxBytes))
+		if q.Policy == QuotaWarn {
+			continue
+		}
+
+		// Oldest first, until the category fits again
+		sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+		for _, f := range files {
+			if total <= q.MaxBytes {
+				break
+			}
+			if cfg.DryRun {
+				p.File(LevelNotice, "DRY RUN", "Would rotate '%s' out of '%s' (%s)", f.path, q.Category, q.Policy)
+				total -= f.size
+				continue
+			}
+
+			var entry journal.Entry
+			var err error
+			if q.Policy == QuotaTrash {
+				entry, err = rotateToTrash(f.path)
+			} else {
+				entry, err = rotateToOverflow(cfg.fsys(), f.path, root, q.Overflow, cfg.now())
+			}
+			if err != nil {
+				p.File(LevelError, "ERROR", "%v", err)
+				continue
+			}
+			entry.Size = f.size
+			cfg.Journal.Record(entry)
+			total -= f.size
+			p.File(LevelSuccess, "ROTATED", "Rotated '%s' to '%s'", f.path, entry.Dest)
+			progressChan <- ProgressUpdate{Rotated: 1}
+		}
+	}
+}
+
+// categoryFiles lists the regular files below root on fsys and their total size. Hidden files are
+// counted but never rotated.
+func categoryFiles(fsys fsutil.FS, root string) ([]quotaFile, int64, error) {
+	var files []quotaFile
+	var total int64
+	err := fsys.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root && os.IsNotExist(err) {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		total += info.Size()
+		if !strings.HasPrefix(d.Name(), ".") && d.Name() != archiveIndexFile && d.Name() != reviewManifestFile {
+			files = append(files, quotaFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		}
+		return nil
+	})
+	return files, total, err
+}
+
+// rotateToTrash moves path to the trash.
+func rotateToTrash(path string) (journal.Entry, error) {
+	trashed, err := trash.Move(path)
+	if err != nil {
+		return journal.Entry{}, err
+	}
+	return journal.Entry{Op: journal.OpTrash, Source: path, Dest: trashed}, nil
+}
+
+// rotateToOverflow moves path on fsys from the category folder root to the same relative location
+// below overflow, under a collision name (see collisionName) if that is taken.
+func rotateToOverflow(fsys fsutil.FS, path, root, overflow string, now time.Time) (journal.Entry, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return journal.Entry{}, err
+	}
+	target := filepath.Join(overflow, rel)
+	if err := fsys.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return journal.Entry{}, fmt.Errorf("failed to create overflow directory '%s': %w", filepath.Dir(target), err)
+	}
+	target, err = placeUnique(target, target, "", now, func(target string) error {
+		return fsutil.MoveFile(fsys, path, target)
+	})
+	if err != nil {
+		return journal.Entry{}, fmt.Errorf("failed to move '%s' to '%s': %w", path, target, err)
+	}
+	return journal.Entry{Op: journal.OpMove, Source: path, Dest: target}, nil
+}
@@ -0,0 +1,39 @@
+package organizer
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+func TestRotateToOverflowKeepsEveryFile(t *testing.T) {
+	now := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	for name, fsys := range map[string]fsutil.FS{
+		"rename":       fsutil.OS,
+		"cross-device": failing("", syscall.EXDEV, "link", "rename"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			root, overflow := t.TempDir(), t.TempDir()
+			writeFile(t, filepath.Join(overflow, "a.txt"), "first")
+			writeFile(t, filepath.Join(overflow, "a_20000101_000000.txt"), "second")
+			writeFile(t, filepath.Join(root, "a.txt"), "third")
+
+			entry, err := rotateToOverflow(fsys, filepath.Join(root, "a.txt"), root, overflow, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.Join(overflow, "a_20000101_000000_2.txt"); entry.Dest != want {
+				t.Errorf("rotated to %s, want %s", entry.Dest, want)
+			}
+			for name, content := range map[string]string{"a.txt": "first", "a_20000101_000000.txt": "second", "a_20000101_000000_2.txt": "third"} {
+				if data, err := os.ReadFile(filepath.Join(overflow, name)); err != nil || string(data) != content {
+					t.Errorf("%s = %q, %v; want %q", name, data, err, content)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package organizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"filippo.io/age"
+	"github.com/avizyt/org-cli/internal/fsutil"
+	"github.com/avizyt/org-cli/internal/journal"
+	"github.com/avizyt/org-cli/internal/trash"
+)
+
+// redo replays the operations undo recorded on fsys, reporting to p and stopping before the next
+// one once ctx is cancelled. undo records them most recent first, so they are replayed back to
+// front. Each replayed operation is recorded in j as done this time, e.g. with the new location in
+// the trash, which is that of the operating system whatever fsys is.
+func redo(ctx context.Context, fsys fsutil.FS, entries []journal.Entry, recipients []age.Recipient, dryRun bool, j *journal.Journal, p Printer) (redone int, failed int, err error) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return redone, failed, err
+		}
+		e := entries[i]
+		if alreadyRedone(fsys, e) {
+			// Left over from an earlier, partially failed redo of the same run
+			p.File(LevelWarn, "SKIPPED", "'%s' is already at '%s'", e.Source, e.Dest)
+			continue
+		}
+		if dryRun {
+			p.File(LevelNotice, "DRY RUN", "Would redo %s of '%s' to '%s'", e.Op, e.Source, e.Dest)
+			redone++
+			continue
+		}
+		done, err := redoEntry(fsys, e, recipients)
+		if err != nil {
+			p.File(LevelError, "ERROR", "%v", err)
+			failed++
+			continue
+		}
+		done.Time = time.Time{}
+		if err := j.Record(done); err != nil {
+			p.File(LevelWarn, "WARNING", "Failed to journal '%s': %v", done.Dest, err)
+		}
+		p.File(LevelSuccess, "REDONE", "Redid %s of '%s' to '%s'", e.Op, e.Source, done.Dest)
+		redone++
+	}
+	return redone, failed, nil
+}
+
+// alreadyRedone reports whether e's file is at its destination again and, unless it was copied or
+// linked, gone from its original location (or only a symlink to the destination is left there).
+// Trashed files only have to be gone: the trash names them anew.
+func alreadyRedone(fsys fsutil.FS, e journal.Entry) bool {
+	if e.Op == journal.OpTrash {
+		_, err := fsys.Lstat(e.Source)
+		return errors.Is(err, os.ErrNotExist)
+	}
+	if _, err := fsys.Lstat(e.Dest); err != nil {
+		return false
+	}
+	if e.Op == journal.OpCopy || e.Op == journal.OpLink {
+		return true
+	}
+	info, err := fsys.Lstat(e.Source)
+	if errors.Is(err, os.ErrNotExist) {
+		return true
+	}
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return false
+	}
+	target, err := fsys.Readlink(e.Source)
+	return err == nil && target == e.Dest
+}
+
+// redoEntry does a single operation again on fsys and returns its journal entry. Like undo, it
+// never overwrites a file that appeared at the destination in the meantime.
+func redoEntry(fsys fsutil.FS, e journal.Entry, recipients []age.Recipient) (journal.Entry, error) {
+	if _, err := fsys.Lstat(e.Source); err != nil {
+		return e, fmt.Errorf("cannot redo %s of '%s': %w", e.Op, e.Source, err)
+	}
+	if e.Op == journal.OpTrash {
+		// The trash picks the name anew
+		trashed, err := trash.Move(e.Source)
+		if err != nil {
+			return e, fmt.Errorf("failed to move '%s' to the trash: %w", e.Source, err)
+		}
+		e.Dest = trashed
+		return e, nil
+	}
+
+	if _, err := fsys.Lstat(e.Dest); err == nil {
+		return e, &ConflictError{Path: e.Dest, Reason: "already exists, not redoing over it"}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return e, fmt.Errorf("cannot redo %s to '%s': %w", e.Op, e.Dest, err)
+	}
+	if err := fsys.MkdirAll(filepath.Dir(e.Dest), 0755); err != nil {
+		return e, fmt.Errorf("failed to recreate directory for '%s': %w", e.Dest, err)
+	}
+
+	switch e.Op {
+	case journal.OpMove:
+		if err := fsutil.MoveFile(fsys, e.Source, e.Dest); err != nil {
+			return e, fmt.Errorf("failed to move '%s' again: %w", e.Source, err)
+		}
+		if e.Link {
+			if err := fsys.Symlink(e.Dest, e.Source); err != nil {
+				return e, fmt.Errorf("moved '%s' but failed to leave a symlink at its original location: %w", e.Dest, err)
+			}
+		}
+		return e, nil
+	case journal.OpLink:
+		if err := fsys.Link(e.Source, e.Dest); err != nil {
+			return e, fmt.Errorf("failed to link '%s' again: %w", e.Source, err)
+		}
+		return e, nil
+	case journal.OpCopy:
+		return e, redoTransformed(fsys, e, nil)
+	case journal.OpEncrypt:
+		if len(recipients) == 0 {
+			return e, fmt.Errorf("cannot encrypt '%s' again: no age recipients given (use --encrypt-with)", e.Source)
+		}
+		return e, redoTransformed(fsys, e, recipients)
+	case journal.OpCompress:
+		return e, redoTransformed(fsys, e, nil)
+	default:
+		return e, fmt.Errorf("don't know how to redo '%s' of '%s'", e.Op, e.Source)
+	}
+}
+
+// redoTransformed writes the file at e.Source on fsys to e.Dest again, compressed with e.Codec and
+// encrypted to recipients if there are any, and removes the source unless e was a copy.
+func redoTransformed(fsys fsutil.FS, e journal.Entry, recipients []age.Recipient) error {
+	info, err := fsys.Stat(e.Source)
+	if err != nil {
+		return err
+	}
+	tmp, err := stage(fsys, filepath.Dir(e.Dest), info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	err = transformInto(fsys, tmp, e.Source, e.Codec, recipients)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = tmp.commit(e.Dest)
+	} else {
+		tmp.abort()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write '%s': %w", e.Dest, err)
+	}
+	fsys.Chtimes(e.Dest, info.ModTime(), info.ModTime())
+	if e.Op == journal.OpCopy {
+		return nil
+	}
+	if err := fsys.Remove(e.Source); err != nil {
+		return fmt.Errorf("wrote '%s' but failed to remove '%s': %w", e.Dest, e.Source, err)
+	}
+	return nil
+}
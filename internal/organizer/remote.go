@@ -0,0 +1,133 @@
+package organizer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// errRemoteExists is returned by Remote.Put when the target already exists on the remote.
+var errRemoteExists = errors.New("remote file already exists")
+
+// Remote is a destination files are uploaded to instead of moved into a local directory: a
+// WebDAV server, an S3 bucket or an SFTP server. Paths given to it are slash separated and
+// relative to the location it was created for.
+type Remote interface {
+	// String returns the location without credentials, safe for printing. A path appended to it
+	// names an uploaded file in results and the journal.
+	String() string
+	// MkdirAll creates the directory rel and all of its parents that don't exist yet, on
+	// remotes that have directories.
+	MkdirAll(rel string) error
+	// Put uploads size bytes from r to rel. It returns errRemoteExists instead of replacing a
+	// file already present at rel.
+	Put(rel string, r io.Reader, size int64) error
+	// Overwrite uploads size bytes from r to rel, replacing any file already present there.
+	Overwrite(rel string, r io.Reader, size int64) error
+
+	// check makes sure the location can be reached with the credentials given, see preflight.
+	check() error
+}
+
+// IsRemoteDest reports whether dest is the URL of a remote destination (WebDAV, S3 or SFTP)
+// rather than a local directory.
+func IsRemoteDest(dest string) bool {
+	lower := strings.ToLower(dest)
+	for _, scheme := range []string{"http://", "https://", "s3://", "sftp://"} {
+		if strings.HasPrefix(lower, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRemote creates the client for a remote destination URL: http(s):// for WebDAV, s3:// for S3
+// and sftp:// for SFTP. No connection is made until the first use.
+func NewRemote(rawURL string) (Remote, error) {
+	var remote Remote
+	var err error
+	switch lower := strings.ToLower(rawURL); {
+	case strings.HasPrefix(lower, "s3://"):
+		remote, err = NewS3Client(rawURL)
+	case strings.HasPrefix(lower, "sftp://"):
+		remote, err = NewSFTPClient(rawURL)
+	case strings.HasPrefix(lower, "http://"), strings.HasPrefix(lower, "https://"):
+		remote, err = NewWebDAVClient(rawURL)
+	default:
+		err = fmt.Errorf("unsupported remote destination '%s': expected an http(s)://, s3:// or sftp:// URL", rawURL)
+	}
+	if err != nil {
+		return nil, err // Not the typed nil client
+	}
+	return remote, nil
+}
+
+// uploadFile moves a local file to the remote destination: the file is uploaded into its category
+// and the local copy is removed only after the remote accepted it. fm.DestPath is the slash
+// separated path relative to the remote.
+func uploadFile(fm FileMove, cfg Config, remote Remote, progressChan chan<- ProgressUpdate) error {
+	p := cfg.printer()
+
+	if fm.DryRun {
+		p.File(LevelNotice, "DRY RUN", "Would upload '%s' to '%s%s'", fm.SourcePath, remote, fm.DestPath)
+		progressChan <- fm.movedUpdate(ActionUpload, remote.String()+fm.DestPath)
+		return nil
+	}
+
+	fail := func(err error) error {
+		fm.reportFailure(p, err, progressChan)
+		return err
+	}
+
+	if err := verifyUnchanged(cfg.fsys(), fm); err != nil {
+		p.File(LevelWarn, "CHANGED", "%v. Skipping.", err)
+		progressChan <- fm.skippedUpdate(err)
+		return err
+	}
+	if err := remote.MkdirAll(path.Dir(fm.DestPath)); err != nil {
+		return fail(fmt.Errorf("failed to create remote directory for '%s': %w", fm.DestPath, err))
+	}
+
+	finalDestPath := fm.DestPath
+	for attempt := 0; ; attempt++ {
+		err := uploadOnce(cfg.fsys(), fm.SourcePath, remote, finalDestPath)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errRemoteExists) || attempt > 0 {
+			return fail(fmt.Errorf("failed to upload '%s' to '%s': %w", fm.SourcePath, finalDestPath, err))
+		}
+		// Collision Resolution: same timestamp suffix scheme as local moves
+		ext := path.Ext(fm.DestPath)
+		name := strings.TrimSuffix(path.Base(fm.DestPath), ext)
+		timestamp := cfg.now().Format("20060102_150405")
+		finalDestPath = path.Join(path.Dir(fm.DestPath), fmt.Sprintf("%s_%s%s", name, timestamp, ext))
+		p.File(LevelWarn, "COLLISION", "Renaming '%s' to '%s'", path.Base(fm.DestPath), path.Base(finalDestPath))
+	}
+
+	if err := cfg.fsys().Remove(fm.SourcePath); err != nil {
+		return fail(fmt.Errorf("uploaded '%s' but failed to remove the local file: %w", fm.SourcePath, err))
+	}
+	p.File(LevelSuccess, "UPLOADED", "Uploaded '%s' to '%s%s'", fm.SourcePath, remote, finalDestPath)
+	cfg.fileStored(fm.SourcePath, remote.String()+finalDestPath, fm.Category, progressChan)
+	progressChan <- fm.movedUpdate(ActionUpload, remote.String()+finalDestPath)
+	return nil
+}
+
+// uploadOnce streams the file at src, read from fsys, to rel.
+func uploadOnce(fsys fsutil.FS, src string, remote Remote, rel string) error {
+	f, err := fsys.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return remote.Put(rel, f, info.Size())
+}
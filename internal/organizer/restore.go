@@ -0,0 +1,72 @@
+package organizer
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/avizyt/org-cli/internal/journal"
+)
+
+// SelectEntries returns the entries of a journal whose file matches one of patterns, for
+// restoring part of a run. Patterns are slash separated globs where ** matches any number of
+// folders, e.g. "Documents/**" or "**/*.pdf". A relative pattern is matched against where the file
+// was put relative to destDir, the destination of the run; an absolute one against where it was
+// put and where it came from.
+func SelectEntries(entries []journal.Entry, destDir string, patterns []string) ([]journal.Entry, error) {
+	for _, p := range patterns {
+		if _, err := path.Match(strings.ReplaceAll(p, "**", "*"), ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", p, err)
+		}
+	}
+	var selected []journal.Entry
+	for _, e := range entries {
+		for _, p := range patterns {
+			if entryMatches(e, destDir, p) {
+				selected = append(selected, e)
+				break
+			}
+		}
+	}
+	return selected, nil
+}
+
+// entryMatches reports whether pattern selects e, see SelectEntries.
+func entryMatches(e journal.Entry, destDir, pattern string) bool {
+	if strings.HasPrefix(pattern, "/") || filepath.IsAbs(pattern) {
+		return matchGlob(filepath.ToSlash(pattern), filepath.ToSlash(e.Dest)) || matchGlob(filepath.ToSlash(pattern), filepath.ToSlash(e.Source))
+	}
+	rel, err := filepath.Rel(destDir, e.Dest)
+	if destDir == "" || err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	return matchGlob(pattern, filepath.ToSlash(rel))
+}
+
+// matchGlob reports whether the slash separated name matches pattern, in which ** stands for any
+// number of path segments and the other segments follow path.Match.
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(strings.Trim(name, "/"), "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
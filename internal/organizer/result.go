@@ -0,0 +1,236 @@
+package organizer
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// Action is what happened to a single file. The values appear in reports and porcelain output
+// and are kept stable.
+type Action string
+
+const (
+	ActionMove     Action = "move"     // Moved into its category
+	ActionReview   Action = "review"   // Staged in ReviewDir for approval
+	ActionCopy     Action = "copy"     // Copied in sync mode, the source was left in place
+	ActionLink     Action = "link"     // Hard linked in hardlink mode, the source was left in place
+	ActionCompress Action = "compress" // Stored compressed
+	ActionEncrypt  Action = "encrypt"  // Stored encrypted (and compressed, if requested)
+	ActionUpload   Action = "upload"   // Uploaded to the remote destination
+	ActionExtract  Action = "extract"  // Extracted from an archive source
+	ActionArchive  Action = "archive"  // Packed into a per-month archive
+	ActionDiscard  Action = "discard"  // Lost a keep-newest or keep-largest conflict and was trashed or deleted
+	ActionSkip     Action = "skip"     // Left in place (changed since the scan, vetoed by a hook, in sync, ...)
+	ActionDenied   Action = "denied"   // The scan was denied access to the file or directory, see Err
+	ActionFail     Action = "error"    // Could not be processed, see Err
+)
+
+// Phase is how far a file has come in a run. The values appear in the events of ProgressUpdate
+// and are kept stable.
+type Phase string
+
+const (
+	PhaseQueued   Phase = "queued"   // Handed to the worker pool
+	PhaseStarted  Phase = "started"  // Picked up by a worker
+	PhaseCopied   Phase = "copied"   // Its content was written next to the destination, not yet in place
+	PhaseVerified Phase = "verified" // The copy was synced and checked against the size of the source
+	PhaseDone     Phase = "done"     // Finished, see FileResult for what happened
+	PhaseFailed   Phase = "failed"   // Could not be processed, see FileResult for why
+)
+
+// FileEvent tells where a single file is in a run, so UIs and logs can show what each worker is
+// doing. A file is queued and started, copied and verified only if its content is copied rather
+// than renamed, and ends with PhaseDone or PhaseFailed.
+type FileEvent struct {
+	Phase  Phase  `json:"phase"`
+	Source string `json:"source"`
+	Dest   string `json:"dest,omitempty"` // Final location, once known
+	Action Action `json:"action"`         // What is done to the file; from PhaseDone on, what was done
+	Bytes  int64  `json:"bytes"`          // Size of the file
+}
+
+// FileResult is the outcome of a single file. In a dry run it describes what would have happened.
+type FileResult struct {
+	Source    string        `json:"source"`
+	Dest      string        `json:"dest,omitempty"` // Final location, after collision renaming and added suffixes
+	Category  string        `json:"category,omitempty"`
+	Action    Action        `json:"action"`
+	Err       error         `json:"-"` // Why the file failed or was skipped, if known
+	Size      int64         `json:"size"`
+	Duration  time.Duration `json:"-"`                    // Time spent on the file by its worker
+	SimilarTo string        `json:"similar_to,omitempty"` // For near-duplicate photos, the photo of the run they resemble
+	Damage    string        `json:"damage,omitempty"`     // For empty and damaged files, what is wrong with them, see Config.Corrupt
+}
+
+// PlannedMove is a file the scan of Organizer.Plan found to organize, and where it goes.
+type PlannedMove struct {
+	Source   string    `json:"source"`
+	Dest     string    `json:"dest"` // Where the file goes; a run may still add a suffix to avoid a collision
+	Category string    `json:"category"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mod_time"`
+	Review   string    `json:"review,omitempty"`  // For files staged in ReviewDir, the category they are filed into once approved
+	Archive  bool      `json:"archive,omitempty"` // Packed into a per-month archive, see Config.ArchiveOlderThan
+	Damage   string    `json:"damage,omitempty"`  // For empty and damaged files going into CorruptFolder, what is wrong with them
+
+	Err error `json:"-"` // Set on the last move only, which carries nothing else, if the scan failed partway
+}
+
+// planned returns fm as the PlannedMove of Organizer.Plan.
+func (fm FileMove) planned() PlannedMove {
+	m := PlannedMove{Source: fm.SourcePath, Dest: fm.DestPath, Category: fm.Category, Review: fm.Review, Archive: fm.archive, Damage: fm.damage}
+	if fm.Info != nil {
+		m.Size, m.ModTime = fm.Info.Size(), fm.Info.ModTime()
+	}
+	return m
+}
+
+// MarshalJSON adds the error message and the duration in milliseconds.
+func (r FileResult) MarshalJSON() ([]byte, error) {
+	type plain FileResult
+	out := struct {
+		plain
+		Error      string `json:"error,omitempty"`
+		DurationMS int64  `json:"duration_ms"`
+	}{plain: plain(r), DurationMS: r.Duration.Milliseconds()}
+	if r.Err != nil {
+		out.Error = r.Err.Error()
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON reads what MarshalJSON writes; the error comes back as its message.
+func (r *FileResult) UnmarshalJSON(data []byte) error {
+	type plain FileResult
+	var in struct {
+		plain
+		Error      string `json:"error"`
+		DurationMS int64  `json:"duration_ms"`
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*r = FileResult(in.plain)
+	r.Duration = time.Duration(in.DurationMS) * time.Millisecond
+	if in.Error != "" {
+		r.Err = errors.New(in.Error)
+	}
+	return nil
+}
+
+// porcelainEscaper keeps every FileResult on one porcelain line, whatever its paths contain.
+var porcelainEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// Porcelain returns r as a line for scripts: the action, source, destination and category,
+// separated by tabs, without a trailing newline. Backslashes, tabs and line breaks in the fields
+// are escaped as \\, \t, \n and \r; empty fields stay empty. The fields and their order are
+// stable: new information is only ever added as further fields at the end.
+func (r FileResult) Porcelain() string {
+	fields := []string{string(r.Action), r.Source, r.Dest, r.Category}
+	for i, f := range fields {
+		fields[i] = porcelainEscaper.Replace(f)
+	}
+	return strings.Join(fields, "\t")
+}
+
+// Result is what OrganizeFiles returns: the counters of the run and the outcome of every file
+// that was processed.
+type Result struct {
+	Stats
+	Files  []FileResult // In completion order
+	Space  *SpaceReport // With Config.TopN, what takes up the space among the files found
+	Timing *Timing      // How long the phases of the run took
+	Pool   *PoolStats   // What the worker pool did, as of its last report
+
+	Extensions []ExtensionStat // With Config.CountExtensions, the files and bytes per extension found
+}
+
+// Count returns the number of files that ended with action.
+func (r Result) Count(action Action) int {
+	n := 0
+	for _, f := range r.Files {
+		if f.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// result builds the outcome of fm.
+func (fm FileMove) result(action Action, dest string, err error) *FileResult {
+	r := &FileResult{Source: fm.SourcePath, Dest: dest, Category: fm.Category, Action: action, Err: err, SimilarTo: fm.similarTo, Damage: fm.damage}
+	if fm.Info != nil {
+		r.Size = fm.Info.Size()
+	}
+	if !fm.started.IsZero() {
+		r.Duration = fm.clock.now().Sub(fm.started)
+	}
+	return r
+}
+
+// event builds the event of fm reaching phase.
+func (fm FileMove) event(phase Phase, action Action, dest string) *FileEvent {
+	e := &FileEvent{Phase: phase, Source: fm.SourcePath, Dest: dest, Action: action}
+	if fm.Info != nil {
+		e.Bytes = fm.Info.Size()
+	}
+	return e
+}
+
+// eventUpdate builds the progress update carrying nothing but the event of fm reaching phase.
+func (fm FileMove) eventUpdate(phase Phase, action Action) ProgressUpdate {
+	return ProgressUpdate{Worker: fm.worker, Event: fm.event(phase, action, "")}
+}
+
+// finished attaches the final event of its file, matching its result, to update.
+func (update ProgressUpdate) finished() ProgressUpdate {
+	f, phase := update.File, PhaseDone
+	if f.Action == ActionFail || f.Action == ActionDenied {
+		phase = PhaseFailed
+	}
+	update.Event = &FileEvent{Phase: phase, Source: f.Source, Dest: f.Dest, Action: f.Action, Bytes: f.Size}
+	return update
+}
+
+// movedUpdate builds the progress update reported once fm has been processed and now lives at dest.
+func (fm FileMove) movedUpdate(action Action, dest string) ProgressUpdate {
+	update := ProgressUpdate{Moved: 1, Category: fm.Category, File: fm.result(action, dest, nil), Worker: fm.worker}
+	if fm.Info != nil {
+		update.Bytes = fm.Info.Size()
+	}
+	return update.finished()
+}
+
+// skippedUpdate builds the progress update for a file left in place; reason may be nil.
+func (fm FileMove) skippedUpdate(reason error) ProgressUpdate {
+	return ProgressUpdate{Skipped: 1, File: fm.result(ActionSkip, "", reason), Worker: fm.worker}.finished()
+}
+
+// discardedUpdate builds the progress update for a file that lost a conflict and was trashed, to
+// trashed, or deleted.
+func (fm FileMove) discardedUpdate(trashed string) ProgressUpdate {
+	return ProgressUpdate{Skipped: 1, File: fm.result(ActionDiscard, trashed, nil), Worker: fm.worker}.finished()
+}
+
+// failedUpdate builds the progress update for a file that could not be processed. A file that is
+// busy (see fsutil.IsBusy) is left in place for the next run, so it counts as skipped instead.
+func (fm FileMove) failedUpdate(err error) ProgressUpdate {
+	if fsutil.IsBusy(err) {
+		return fm.skippedUpdate(err)
+	}
+	return ProgressUpdate{Errored: 1, File: fm.result(ActionFail, "", err), Worker: fm.worker}.finished()
+}
+
+// reportFailure prints why fm could not be processed and sends its update.
+func (fm FileMove) reportFailure(p logger, err error, progressChan chan<- ProgressUpdate) {
+	if fsutil.IsBusy(err) {
+		p.File(LevelWarn, "BUSY", "%v. In use by another program, will retry on the next run.", err)
+	} else {
+		p.File(LevelError, "ERROR", "%v", err)
+	}
+	progressChan <- fm.failedUpdate(err)
+}
@@ -0,0 +1,195 @@
+package organizer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// ReviewDir is the staging folder in the destination for files that need a human decision
+// before they are filed into their category.
+const ReviewDir = "Review"
+
+// reviewManifestFile lists the staged files with their original paths, one JSON object per line.
+const reviewManifestFile = "manifest.jsonl"
+
+// reviewMu serializes manifest updates from concurrent workers.
+var reviewMu sync.Mutex
+
+// ReviewItem is a file waiting in the review staging area.
+type ReviewItem struct {
+	Name     string    `json:"name"`             // File name inside ReviewDir
+	Source   string    `json:"source"`           // Where the file was before it was staged
+	Category string    `json:"category"`         // Category it is filed into when approved
+	Folder   string    `json:"folder,omitempty"` // Slash separated folder below the destination it goes to; empty is Category
+	Size     int64     `json:"size"`
+	StagedAt time.Time `json:"staged_at"`
+}
+
+// needsReview reports whether files of category are staged for review instead of organized.
+func (cfg Config) needsReview(category string) bool {
+	return matchesAnyName(category, cfg.ReviewCategories)
+}
+
+// reviewManifestPath returns the location of the review manifest below destDir.
+func reviewManifestPath(destDir string) string {
+	return filepath.Join(destDir, ReviewDir, reviewManifestFile)
+}
+
+// recordReview adds a staged file to the manifest on fsys.
+func recordReview(fsys fsutil.FS, destDir string, item ReviewItem) error {
+	line, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	reviewMu.Lock()
+	defer reviewMu.Unlock()
+	f, err := fsys.OpenFile(reviewManifestPath(destDir), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open review manifest: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to update review manifest: %w", err)
+	}
+	return nil
+}
+
+// LoadReview returns the files staged for review in destDir, oldest first.
+func LoadReview(destDir string) ([]ReviewItem, error) {
+	return loadReview(fsutil.OS, destDir)
+}
+
+// SaveReview replaces the manifest of destDir with items.
+func SaveReview(destDir string, items []ReviewItem) error {
+	return saveReview(fsutil.OS, destDir, items)
+}
+
+// ApproveReview files a staged item into its folder and returns where it ended up. Name
+// collisions are resolved with a timestamp suffix, as for regular moves.
+func ApproveReview(destDir string, item ReviewItem) (string, error) {
+	return approveReview(fsutil.OS, destDir, item, time.Now())
+}
+
+// RejectReview puts a staged item back at its original location. It refuses to overwrite a file
+// that has appeared there in the meantime, with a *ConflictError.
+func RejectReview(destDir string, item ReviewItem) error {
+	return rejectReview(fsutil.OS, destDir, item)
+}
+
+// LoadReview is LoadReview for the destination of o, on its file system.
+func (o *Organizer) LoadReview() ([]ReviewItem, error) {
+	return loadReview(o.cfg.fsys(), o.cfg.DestDir)
+}
+
+// SaveReview is SaveReview for the destination of o, on its file system.
+func (o *Organizer) SaveReview(items []ReviewItem) error {
+	return saveReview(o.cfg.fsys(), o.cfg.DestDir, items)
+}
+
+// ApproveReview is ApproveReview for the destination of o, on its file system and by its clock.
+func (o *Organizer) ApproveReview(item ReviewItem) (string, error) {
+	return approveReview(o.cfg.fsys(), o.cfg.DestDir, item, o.cfg.now())
+}
+
+// RejectReview is RejectReview for the destination of o, on its file system.
+func (o *Organizer) RejectReview(item ReviewItem) error {
+	return rejectReview(o.cfg.fsys(), o.cfg.DestDir, item)
+}
+
+func loadReview(fsys fsutil.FS, destDir string) ([]ReviewItem, error) {
+	f, err := fsys.Open(reviewManifestPath(destDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open review manifest: %w", err)
+	}
+	defer f.Close()
+
+	var items []ReviewItem
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var item ReviewItem
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read review manifest: %w", err)
+	}
+	return items, nil
+}
+
+func saveReview(fsys fsutil.FS, destDir string, items []ReviewItem) error {
+	reviewMu.Lock()
+	defer reviewMu.Unlock()
+	path := reviewManifestPath(destDir)
+	tmp, err := stage(fsys, filepath.Dir(path), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to update review manifest: %w", err)
+	}
+	enc := json.NewEncoder(tmp)
+	for _, item := range items {
+		if err = enc.Encode(item); err != nil {
+			break
+		}
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = fsys.Rename(tmp.path, path) // Replaces the old manifest
+	}
+	if err != nil {
+		tmp.abort()
+		return fmt.Errorf("failed to update review manifest: %w", err)
+	}
+	return nil
+}
+
+func approveReview(fsys fsutil.FS, destDir string, item ReviewItem, now time.Time) (string, error) {
+	staged := filepath.Join(destDir, ReviewDir, item.Name)
+	folder := item.Folder
+	if folder == "" {
+		folder = item.Category // Staged before layouts
+	}
+	target := filepath.Join(destDir, filepath.FromSlash(folder), item.Name)
+	if err := fsys.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", fmt.Errorf("failed to create destination directory '%s': %w", filepath.Dir(target), err)
+	}
+	// Reserve the target name exclusively, then move the staged file over the placeholder
+	out, final, err := createUnique(fsys, target, now)
+	if err != nil {
+		return "", err
+	}
+	out.Close()
+	if err := fsys.Rename(staged, final); err != nil {
+		fsys.Remove(final)
+		return "", fmt.Errorf("failed to move '%s' to '%s': %w", staged, final, err)
+	}
+	return final, nil
+}
+
+func rejectReview(fsys fsutil.FS, destDir string, item ReviewItem) error {
+	staged := filepath.Join(destDir, ReviewDir, item.Name)
+	if err := fsys.MkdirAll(filepath.Dir(item.Source), 0755); err != nil {
+		return fmt.Errorf("failed to recreate directory for '%s': %w", item.Source, err)
+	}
+	// A file that appeared at the original location is never replaced, not even one created
+	// a moment ago
+	if err := fsutil.RenameNoReplace(fsys, staged, item.Source); errors.Is(err, os.ErrExist) {
+		return &ConflictError{Path: item.Source, Reason: "already exists, not restoring over it"}
+	} else if err != nil {
+		return fmt.Errorf("failed to move '%s' back to '%s': %w", staged, item.Source, err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package organizer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+func TestRejectReviewKeepsNewFile(t *testing.T) {
+	dest := t.TempDir()
+	source := filepath.Join(t.TempDir(), "notes.txt")
+	staged := filepath.Join(dest, ReviewDir, "notes.txt")
+	writeFile(t, staged, "staged")
+
+	// Another program creates a file at the original location just before it is restored to
+	fsys := fsutil.Faulty(fsutil.OS, func(op, path string) error {
+		if (op == "link" || op == "rename") && path == source {
+			writeFile(t, source, "new")
+		}
+		return nil
+	})
+	var conflict *ConflictError
+	if err := rejectReview(fsys, dest, ReviewItem{Name: "notes.txt", Source: source}); !errors.As(err, &conflict) {
+		t.Fatalf("rejectReview = %v, want a conflict", err)
+	}
+	if content, err := os.ReadFile(source); err != nil || string(content) != "new" {
+		t.Errorf("file at the original location = %q, %v; want it kept", content, err)
+	}
+	if _, err := os.Stat(staged); err != nil {
+		t.Errorf("staged file gone: %v", err)
+	}
+}
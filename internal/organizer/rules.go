@@ -0,0 +1,117 @@
+package organizer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	starlarktime "go.starlark.net/lib/time"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// DefaultRuleTimeout bounds a single evaluation of the rules script.
+const DefaultRuleTimeout = 250 * time.Millisecond
+
+// ruleMaxSteps caps the work of one evaluation independently of the machine's speed.
+const ruleMaxSteps = 10_000_000
+
+// StarlarkRules is a classifier written as a Starlark script. The script must define
+//
+//	def classify(file):
+//
+// which receives the file's path, name, ext, size, mtime (a time.time), mime, category and groups
+// (the extension groups it is in) as attributes and returns None to keep the current choice, a folder below the destination
+// ("Finance/2024") or a dict with any of "category", "dest" and "skip". Scripts run sandboxed:
+// there is no file or network access, load() is disabled, and each call is cancelled after the
+// timeout.
+type StarlarkRules struct {
+	name     string
+	classify *starlark.Function
+	timeout  time.Duration
+}
+
+// NewStarlarkRules compiles the rules script src; name is used in error messages.
+func NewStarlarkRules(name, src string, timeout time.Duration) (*StarlarkRules, error) {
+	if timeout <= 0 {
+		timeout = DefaultRuleTimeout
+	}
+	thread := &starlark.Thread{Name: name}
+	thread.SetMaxExecutionSteps(ruleMaxSteps)
+	predeclared := starlark.StringDict{"time": starlarktime.Module}
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{While: true, Set: true, TopLevelControl: true}, thread, name, src, predeclared)
+	if err != nil {
+		return nil, fmt.Errorf("rules '%s': %w", name, err)
+	}
+	fn, ok := globals["classify"].(*starlark.Function)
+	if !ok {
+		return nil, fmt.Errorf("rules '%s' must define a function classify(file)", name)
+	}
+	globals.Freeze()
+	return &StarlarkRules{name: name, classify: fn, timeout: timeout}, nil
+}
+
+// Classify evaluates the script's classify function for one file.
+func (r *StarlarkRules) Classify(req ClassifyRequest) (ClassifyResponse, error) {
+	groups := make([]starlark.Value, len(req.Groups))
+	for i, g := range req.Groups {
+		groups[i] = starlark.String(g)
+	}
+	file := starlarkstruct.FromStringDict(starlark.String("file"), starlark.StringDict{
+		"path":     starlark.String(req.Path),
+		"name":     starlark.String(req.Name),
+		"ext":      starlark.String(req.Ext),
+		"size":     starlark.MakeInt64(req.Size),
+		"mtime":    starlarktime.Time(req.ModTime),
+		"mime":     starlark.String(req.MIME),
+		"category": starlark.String(req.Category),
+		"groups":   starlark.NewList(groups),
+	})
+
+	thread := &starlark.Thread{Name: r.name}
+	thread.SetMaxExecutionSteps(ruleMaxSteps)
+	timer := time.AfterFunc(r.timeout, func() { thread.Cancel("time limit exceeded") })
+	defer timer.Stop()
+
+	result, err := starlark.Call(thread, r.classify, starlark.Tuple{file}, nil)
+	if err != nil {
+		var evalErr *starlark.EvalError
+		if errors.As(err, &evalErr) {
+			return ClassifyResponse{}, fmt.Errorf("rules '%s': %s: %s", r.name, evalErr.CallStack.At(0).Pos, evalErr.Msg)
+		}
+		return ClassifyResponse{}, fmt.Errorf("rules '%s': %w", r.name, err)
+	}
+
+	var resp ClassifyResponse
+	switch v := result.(type) {
+	case starlark.NoneType:
+	case starlark.String:
+		resp.Dest = string(v)
+		resp.Category, _, _ = strings.Cut(strings.ReplaceAll(resp.Dest, "\\", "/"), "/")
+	case *starlark.Dict:
+		for _, item := range v.Items() {
+			key, ok := starlark.AsString(item[0])
+			if !ok {
+				return ClassifyResponse{}, fmt.Errorf("rules '%s': classify returned a dict with a non-string key", r.name)
+			}
+			switch key {
+			case "category":
+				resp.Category, _ = starlark.AsString(item[1])
+			case "dest":
+				resp.Dest, _ = starlark.AsString(item[1])
+			case "skip":
+				resp.Skip = bool(item[1].Truth())
+			default:
+				return ClassifyResponse{}, fmt.Errorf("rules '%s': classify returned unknown key '%s'", r.name, key)
+			}
+		}
+	default:
+		return ClassifyResponse{}, fmt.Errorf("rules '%s': classify must return None, a string or a dict, not %s", r.name, result.Type())
+	}
+	if resp.Dest, err = cleanDest(resp.Dest); err != nil {
+		return ClassifyResponse{}, fmt.Errorf("rules '%s': %w", r.name, err)
+	}
+	return resp, nil
+}
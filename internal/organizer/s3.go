@@ -0,0 +1,367 @@
+package organizer
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3 credentials and settings are taken from the standard AWS environment variables. Without
+// keys in the environment, those of the AWS_PROFILE profile ("default" if unset) in
+// ~/.aws/credentials are used.
+const (
+	S3AccessKeyEnv    = "AWS_ACCESS_KEY_ID"
+	S3SecretKeyEnv    = "AWS_SECRET_ACCESS_KEY"
+	S3SessionTokenEnv = "AWS_SESSION_TOKEN"
+	S3RegionEnv       = "AWS_REGION"          // Or AWS_DEFAULT_REGION; us-east-1 if neither is set
+	S3EndpointEnv     = "AWS_ENDPOINT_URL_S3" // Or AWS_ENDPOINT_URL, for S3 compatible stores such as MinIO
+)
+
+// Limits of S3 uploads.
+const (
+	s3MaxPut   = 5 << 30  // Largest object a single PUT can upload; larger ones go in parts
+	s3PartSize = 64 << 20 // Smallest part of multipart uploads, raised to stay within s3MaxParts
+	s3MaxParts = 10000
+)
+
+// S3Client uploads organized files to an S3 bucket or an S3 compatible store, under a key prefix.
+// Uploads are conditional (If-None-Match: *) so that an existing object is never overwritten;
+// stores that ignore the condition replace it. Buckets have no directories, so MkdirAll does
+// nothing.
+type S3Client struct {
+	bucket    string
+	prefix    string   // Prepended to every key, "" or ending in "/"
+	region    string   // Part of the signature
+	endpoint  *url.URL // Scheme and host requests go to
+	pathStyle bool     // Bucket in the path rather than the host name, for custom endpoints
+	accessKey string
+	secretKey string
+	token     string // Session token of temporary credentials
+
+	client   *http.Client
+	now      func() time.Time // Time of signatures
+	maxPut   int64            // s3MaxPut, and s3PartSize below, lowered by tests
+	partSize int64
+}
+
+// NewS3Client creates a client for an s3://bucket/prefix URL. The region and the endpoint can be
+// given as ?region= and ?endpoint= query parameters instead of the environment.
+func NewS3Client(rawURL string) (*S3Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 URL '%s': %w", rawURL, err)
+	}
+	if u.Scheme != "s3" || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 URL '%s': expected s3://bucket/prefix", rawURL)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 2 * time.Minute // Large uploads take as long as they take, answers don't
+	c := &S3Client{
+		bucket:   u.Host,
+		prefix:   strings.Trim(u.Path, "/"),
+		client:   &http.Client{Transport: transport},
+		now:      time.Now,
+		maxPut:   s3MaxPut,
+		partSize: s3PartSize,
+	}
+	if c.prefix != "" {
+		c.prefix += "/"
+	}
+	query := u.Query()
+	c.region = cmp.Or(query.Get("region"), os.Getenv(S3RegionEnv), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1")
+	if endpoint := cmp.Or(query.Get("endpoint"), os.Getenv(S3EndpointEnv), os.Getenv("AWS_ENDPOINT_URL")); endpoint != "" {
+		if c.endpoint, err = url.Parse(endpoint); err != nil || c.endpoint.Host == "" {
+			return nil, fmt.Errorf("invalid S3 endpoint '%s': expected http(s)://host[:port]", endpoint)
+		}
+		c.pathStyle = true
+	} else {
+		c.endpoint = &url.URL{Scheme: "https", Host: "s3." + c.region + ".amazonaws.com"}
+		c.pathStyle = strings.Contains(c.bucket, ".") // Dotted names don't match the certificate of virtual hosts
+	}
+
+	c.accessKey, c.secretKey, c.token = os.Getenv(S3AccessKeyEnv), os.Getenv(S3SecretKeyEnv), os.Getenv(S3SessionTokenEnv)
+	if c.accessKey == "" {
+		c.accessKey, c.secretKey, c.token = sharedS3Credentials()
+	}
+	if c.accessKey == "" || c.secretKey == "" {
+		return nil, fmt.Errorf("no S3 credentials for '%s': set %s and %s or add them to ~/.aws/credentials", c, S3AccessKeyEnv, S3SecretKeyEnv)
+	}
+	return c, nil
+}
+
+// sharedS3Credentials returns the keys of the AWS_PROFILE profile ("default" if unset) in the
+// shared credentials file, AWS_SHARED_CREDENTIALS_FILE or ~/.aws/credentials.
+func sharedS3Credentials() (accessKey, secretKey, token string) {
+	file := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", ""
+		}
+		file = filepath.Join(home, ".aws", "credentials")
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", "", ""
+	}
+	profile, section := cmp.Or(os.Getenv("AWS_PROFILE"), "default"), ""
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || section != profile {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "aws_access_key_id":
+			accessKey = strings.TrimSpace(value)
+		case "aws_secret_access_key":
+			secretKey = strings.TrimSpace(value)
+		case "aws_session_token":
+			token = strings.TrimSpace(value)
+		}
+	}
+	return accessKey, secretKey, token
+}
+
+// String returns the bucket and prefix as an s3:// URL.
+func (c *S3Client) String() string {
+	return "s3://" + c.bucket + "/" + c.prefix
+}
+
+// MkdirAll does nothing: keys with slashes need no directories.
+func (c *S3Client) MkdirAll(rel string) error {
+	return nil
+}
+
+// Put uploads size bytes from r to rel. It returns errRemoteExists if an object already exists
+// at rel, as reported by S3 through 412 Precondition Failed.
+func (c *S3Client) Put(rel string, r io.Reader, size int64) error {
+	return c.put(c.prefix+rel, r, size, map[string]string{"If-None-Match": "*"})
+}
+
+// Overwrite uploads size bytes from r to rel, replacing any object already present there.
+func (c *S3Client) Overwrite(rel string, r io.Reader, size int64) error {
+	return c.put(c.prefix+rel, r, size, nil)
+}
+
+// put uploads the object at key with the given headers, in parts if it is too large for one PUT.
+func (c *S3Client) put(key string, r io.Reader, size int64, headers map[string]string) error {
+	if size > c.maxPut {
+		return c.putMultipart(key, r, size, headers)
+	}
+	resp, err := c.do(http.MethodPut, key, nil, r, size, headers)
+	if err != nil {
+		return err
+	}
+	return s3Decode("PUT", key, resp, nil)
+}
+
+// putMultipart uploads the object at key in parts of at least c.partSize. The headers go with the
+// request that completes the upload, which is aborted if anything fails so that no parts are
+// left behind to be paid for.
+func (c *S3Client) putMultipart(key string, r io.Reader, size int64, headers map[string]string) (err error) {
+	resp, err := c.do(http.MethodPost, key, url.Values{"uploads": {""}}, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := s3Decode("POST", key, resp, &initiated); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if resp, abortErr := c.do(http.MethodDelete, key, url.Values{"uploadId": {initiated.UploadID}}, nil, 0, nil); abortErr == nil {
+				resp.Body.Close()
+			}
+		}
+	}()
+
+	type part struct {
+		Number int `xml:"PartNumber"`
+		ETag   string
+	}
+	var parts []part
+	partSize := max(c.partSize, (size+s3MaxParts-1)/s3MaxParts)
+	for offset, number := int64(0), 1; offset < size; offset, number = offset+partSize, number+1 {
+		length := min(partSize, size-offset)
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {initiated.UploadID}}
+		resp, err := c.do(http.MethodPut, key, query, io.LimitReader(r, length), length, nil)
+		if err != nil {
+			return err
+		}
+		etag := resp.Header.Get("ETag")
+		if err := s3Decode("PUT", key, resp, nil); err != nil {
+			return err
+		}
+		parts = append(parts, part{Number: number, ETag: etag})
+	}
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []part   `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	resp, err = c.do(http.MethodPost, key, url.Values{"uploadId": {initiated.UploadID}}, bytes.NewReader(body), int64(len(body)), headers)
+	if err != nil {
+		return err
+	}
+	return s3Decode("POST", key, resp, nil)
+}
+
+// s3Decode closes resp after decoding its XML body into v, if v is not nil, or returns the error
+// it reports: errRemoteExists for a failed If-None-Match, otherwise the code and message S3 sent.
+// Completing a multipart upload can fail after S3 already answered 200 OK, with the error as body.
+func s3Decode(op, key string, resp *http.Response, v any) error {
+	defer resp.Body.Close()
+	var failure struct {
+		XMLName xml.Name
+		Code    string
+		Message string
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if len(data) > 0 && xml.Unmarshal(data, &failure) == nil && failure.XMLName.Local != "Error" {
+		failure.Code = ""
+	}
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed || failure.Code == "PreconditionFailed":
+		return errRemoteExists
+	case failure.Code != "":
+		return fmt.Errorf("%s '%s' failed: %s: %s", op, key, failure.Code, failure.Message)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("%s '%s' failed: %s", op, key, resp.Status)
+	case v != nil:
+		return xml.Unmarshal(data, v)
+	}
+	return nil
+}
+
+// do sends a signed request for key, escaped the way S3 expects, with the query parameters and
+// headers given.
+func (c *S3Client) do(method, key string, query url.Values, body io.Reader, size int64, headers map[string]string) (*http.Response, error) {
+	target := *c.endpoint
+	target.Path = "/" + key
+	if c.pathStyle {
+		target.Path = "/" + c.bucket
+		if key != "" {
+			target.Path += "/" + key
+		}
+	} else {
+		target.Host = c.bucket + "." + target.Host
+	}
+	target.RawPath = s3Escape(target.Path, true)
+	var params []string
+	for _, name := range slices.Sorted(maps.Keys(query)) {
+		params = append(params, s3Escape(name, false)+"="+s3Escape(query.Get(name), false))
+	}
+	target.RawQuery = strings.Join(params, "&")
+
+	req, err := http.NewRequest(method, target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody // An empty file is not a body of unknown length
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	c.sign(req, "UNSIGNED-PAYLOAD", c.now())
+	req.Header.Set("User-Agent", "org-cli") // Unsigned: proxies may change it
+	return c.client.Do(req)
+}
+
+// sign adds the AWS Signature Version 4 of req, made at now, to its headers. It covers the host
+// and every header set so far. The payload is left out of the signature with UNSIGNED-PAYLOAD,
+// which spares reading every file twice; TLS protects it on the way.
+func (c *S3Client) sign(req *http.Request, payloadHash string, now time.Time) {
+	stamp := now.UTC().Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.token != "" {
+		req.Header.Set("X-Amz-Security-Token", c.token)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := slices.Sorted(maps.Keys(headers))
+	canonical := req.Method + "\n" + req.URL.EscapedPath() + "\n" + req.URL.RawQuery + "\n"
+	for _, name := range names {
+		canonical += name + ":" + headers[name] + "\n"
+	}
+	signed := strings.Join(names, ";")
+	canonical += "\n" + signed + "\n" + payloadHash
+
+	scope := stamp[:8] + "/" + c.region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonical))
+	key := []byte("AWS4" + c.secretKey)
+	for _, data := range []string{stamp[:8], c.region, "s3", "aws4_request", "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hex.EncodeToString(hash[:])} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(data))
+		key = mac.Sum(nil)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s,SignedHeaders=%s,Signature=%x", c.accessKey, scope, signed, key))
+}
+
+// s3Escape percent-encodes every byte of s but the unreserved characters of RFC 3986 and, with
+// keepSlash, slashes, as signatures expect.
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case 'A' <= ch && ch <= 'Z', 'a' <= ch && ch <= 'z', '0' <= ch && ch <= '9', strings.IndexByte("-_.~", ch) >= 0, ch == '/' && keepSlash:
+			b.WriteByte(ch)
+		default:
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+// check makes sure the bucket exists and can be reached with the credentials given.
+func (c *S3Client) check() error {
+	resp, err := c.do(http.MethodHead, "", nil, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch region := resp.Header.Get("X-Amz-Bucket-Region"); {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("bucket '%s' does not exist", c.bucket)
+	case region != "" && region != c.region:
+		return fmt.Errorf("bucket '%s' is in region %s: add ?region=%[2]s to the URL or set %s", c.bucket, region, S3RegionEnv)
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("access to bucket '%s' denied: %s", c.bucket, resp.Status)
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("HEAD bucket '%s' failed: %s", c.bucket, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,707 @@
+package organizer
+
+import (
+	"cmp"
+	"crypto/ed25519"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SFTP credentials and settings can be supplied through these environment variables.
+const (
+	SFTPPasswordEnv   = "ORG_CLI_SFTP_PASSWORD"    // Unless the URL has one
+	SFTPKeyEnv        = "ORG_CLI_SFTP_KEY"         // Private key file, instead of ssh-agent and ~/.ssh/id_*
+	SFTPKnownHostsEnv = "ORG_CLI_SFTP_KNOWN_HOSTS" // Instead of ~/.ssh/known_hosts
+)
+
+// Packet types, open flags and status codes of version 3 of the SFTP protocol, the one every
+// server speaks (draft-ietf-secsh-filexfer-02).
+const (
+	sftpInit     = 1
+	sftpVersion  = 2
+	sftpOpen     = 3
+	sftpClose    = 4
+	sftpWrite    = 6
+	sftpRemove   = 13
+	sftpMkdir    = 14
+	sftpStat     = 17
+	sftpRename   = 18
+	sftpStatus   = 101
+	sftpHandle   = 102
+	sftpAttrs    = 105
+	sftpExtended = 200
+
+	sftpOpenWrite  = 0x02
+	sftpOpenCreate = 0x08
+	sftpOpenExcl   = 0x20
+
+	sftpOK               = 0
+	sftpNoSuchFile       = 2
+	sftpPermissionDenied = 3
+)
+
+// Sizes of SFTP uploads.
+const (
+	sftpChunk     = 32 << 10 // Data per write request, the most every server accepts
+	sftpInFlight  = 64       // Write requests sent before waiting for the answer to the first
+	sftpMaxPacket = 256 << 10
+)
+
+// sftpPosixRename is the OpenSSH extension that renames over an existing file.
+const sftpPosixRename = "posix-rename@openssh.com"
+
+// SFTPClient uploads organized files to a directory on an SSH server. Files are written under a
+// staged name and renamed into place, which SFTP refuses for an existing target, so a remote file
+// is never overwritten and never seen half-written. The host key must be in known_hosts. One SSH
+// connection is shared by all workers and opened again if it breaks.
+type SFTPClient struct {
+	addr    string // host:port
+	dir     string // Directory uploads go under; relative ones are in the login directory
+	display string
+	config  *ssh.ClientConfig
+
+	mu   sync.Mutex
+	conn *sftpConn // nil until the first use
+
+	dirMu   sync.Mutex
+	created map[string]bool // Directories known to exist, to avoid repeated MKDIRs
+}
+
+// NewSFTPClient creates a client for an sftp://[user[:password]@]host[:port]/path URL. The path
+// is absolute; /~/path is relative to the login directory. The user defaults to the local one
+// and the password to ORG_CLI_SFTP_PASSWORD; keys are taken from ORG_CLI_SFTP_KEY or else from
+// ssh-agent and ~/.ssh. No connection is made until the first use.
+func NewSFTPClient(rawURL string) (*SFTPClient, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SFTP URL '%s': %w", rawURL, err)
+	}
+	if u.Scheme != "sftp" || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid SFTP URL '%s': expected sftp://user@host/path", rawURL)
+	}
+
+	user, password := cmp.Or(os.Getenv("USER"), os.Getenv("USERNAME")), os.Getenv(SFTPPasswordEnv)
+	if u.User != nil {
+		user = u.User.Username()
+		if p, ok := u.User.Password(); ok {
+			password = p
+		}
+	}
+	c := &SFTPClient{
+		addr:    net.JoinHostPort(u.Hostname(), cmp.Or(u.Port(), "22")),
+		dir:     path.Clean(u.Path),
+		created: map[string]bool{},
+	}
+	if u.Path == "" || u.Path == "/~" || strings.HasPrefix(u.Path, "/~/") {
+		c.dir = path.Clean("." + strings.TrimPrefix(u.Path, "/~"))
+	}
+	display := url.URL{Scheme: "sftp", User: url.User(user), Host: u.Host, Path: strings.TrimSuffix(u.Path, "/") + "/"}
+	c.display = display.String()
+
+	hostKeys, algorithms, err := sftpHostKeys(c.addr)
+	if err != nil {
+		return nil, err
+	}
+	c.config = &ssh.ClientConfig{
+		User:              user,
+		Auth:              []ssh.AuthMethod{ssh.PublicKeysCallback(sftpSigners)},
+		HostKeyCallback:   hostKeys,
+		HostKeyAlgorithms: algorithms,
+		Timeout:           30 * time.Second,
+	}
+	if password != "" {
+		c.config.Auth = append(c.config.Auth, ssh.Password(password))
+	}
+	return c, nil
+}
+
+// sftpHostKeys returns the check of the host key of addr against known_hosts and the host key
+// algorithms to ask for: those of the keys known for addr, so that a server with several keys
+// proves itself with one that can be checked. A host with no known key is refused right away.
+func sftpHostKeys(addr string) (ssh.HostKeyCallback, []string, error) {
+	file := os.Getenv(SFTPKnownHostsEnv)
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot check the host key of %s: %w", addr, err)
+		}
+		file = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	callback, err := knownhosts.New(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot check the host key of %s: %w", addr, err)
+	}
+
+	// A key nobody knows makes the check list the keys it knows for addr
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	probe, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil, nil, err
+	}
+	var keyErr *knownhosts.KeyError
+	if err := callback(addr, &net.TCPAddr{}, probe); !errors.As(err, &keyErr) || len(keyErr.Want) == 0 {
+		return nil, nil, fmt.Errorf("host key of %s is not in %s: add it first, e.g. with ssh-keyscan", addr, file)
+	}
+	var algorithms []string
+	for _, known := range keyErr.Want {
+		names := []string{known.Key.Type()}
+		if names[0] == ssh.KeyAlgoRSA {
+			names = []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+		}
+		for _, name := range names {
+			if !slices.Contains(algorithms, name) {
+				algorithms = append(algorithms, name)
+			}
+		}
+	}
+	return callback, algorithms, nil
+}
+
+// sftpSigners returns the keys to log in with: the key file of ORG_CLI_SFTP_KEY or else the keys
+// of ssh-agent and the unencrypted ones of ~/.ssh/id_ed25519, id_ecdsa and id_rsa.
+func sftpSigners() ([]ssh.Signer, error) {
+	if file := os.Getenv(SFTPKeyEnv); file != "" {
+		signer, err := sftpKey(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", SFTPKeyEnv, err)
+		}
+		return []ssh.Signer{signer}, nil
+	}
+
+	var signers []ssh.Signer
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			if keys, err := agent.NewClient(conn).Signers(); err == nil {
+				signers = append(signers, keys...)
+			}
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+			// Missing keys and ones with a passphrase are left to the agent
+			if signer, err := sftpKey(filepath.Join(home, ".ssh", name)); err == nil {
+				signers = append(signers, signer)
+			}
+		}
+	}
+	return signers, nil
+}
+
+// sftpKey reads the unencrypted private key in file.
+func sftpKey(file string) (ssh.Signer, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(data)
+	if _, ok := err.(*ssh.PassphraseMissingError); ok {
+		return nil, fmt.Errorf("'%s' is protected by a passphrase: add it to ssh-agent instead", file)
+	}
+	return signer, err
+}
+
+// String returns the URL without the password, safe for printing.
+func (c *SFTPClient) String() string {
+	return c.display
+}
+
+// path returns the path on the server of rel.
+func (c *SFTPClient) path(rel string) string {
+	return path.Join(c.dir, rel)
+}
+
+// session returns the SFTP connection, opening it first if there is none yet or the last one broke.
+func (c *SFTPClient) session() (*sftpConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil {
+		if c.conn.broken() == nil {
+			return c.conn, nil
+		}
+		c.conn.close()
+		c.conn = nil
+	}
+
+	client, err := ssh.Dial("tcp", c.addr, c.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", c.addr, err)
+	}
+	conn, err := startSFTP(client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to start SFTP on %s: %w", c.addr, err)
+	}
+	c.conn = conn
+	return conn, nil
+}
+
+// startSFTP starts the SFTP subsystem in a new session of client.
+func startSFTP(client *ssh.Client) (*sftpConn, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	w, err := session.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	r, err := session.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		return nil, err
+	}
+	conn, err := newSFTPConn(r, w)
+	if err != nil {
+		return nil, err
+	}
+	conn.closer = client
+	return conn, nil
+}
+
+// MkdirAll creates the directory rel and all of its parents that don't exist yet.
+func (c *SFTPClient) MkdirAll(rel string) error {
+	rel = strings.Trim(rel, "/")
+	if rel == "" || rel == "." {
+		return nil
+	}
+	conn, err := c.session()
+	if err != nil {
+		return err
+	}
+
+	c.dirMu.Lock()
+	defer c.dirMu.Unlock() // Serialize MKDIRs so concurrent workers don't race on the same parents
+
+	current := ""
+	for _, part := range strings.Split(rel, "/") {
+		current = path.Join(current, part)
+		if c.created[current] {
+			continue
+		}
+		if err := conn.mkdir(c.path(current)); err != nil {
+			// Servers fail MKDIR for an existing directory like for any other reason
+			if isDir, statErr := conn.stat(c.path(current)); statErr != nil || !isDir {
+				return err
+			}
+		}
+		c.created[current] = true
+	}
+	return nil
+}
+
+// Put uploads size bytes from r to rel. It returns errRemoteExists if a file is already present
+// at rel, which makes the server refuse the rename of the staged upload.
+func (c *SFTPClient) Put(rel string, r io.Reader, size int64) error {
+	conn, staged, err := c.upload(rel, r, size)
+	if err != nil {
+		return err
+	}
+	target := c.path(rel)
+	if err := conn.rename(staged, target); err != nil {
+		conn.remove(staged)
+		if _, statErr := conn.stat(target); statErr == nil {
+			return errRemoteExists
+		}
+		return err
+	}
+	return nil
+}
+
+// Overwrite uploads size bytes from r to rel, replacing any file already present there. Servers
+// without the posix-rename extension have the old file removed first.
+func (c *SFTPClient) Overwrite(rel string, r io.Reader, size int64) error {
+	conn, staged, err := c.upload(rel, r, size)
+	if err != nil {
+		return err
+	}
+	target := c.path(rel)
+	if _, ok := conn.extensions[sftpPosixRename]; ok {
+		err = conn.posixRename(staged, target)
+	} else if err = conn.remove(target); err == nil || errors.Is(err, fs.ErrNotExist) {
+		err = conn.rename(staged, target)
+	}
+	if err != nil {
+		conn.remove(staged)
+	}
+	return err
+}
+
+// upload writes size bytes from r to a new staged file in the directory of rel and returns the
+// connection it is on and its path on the server.
+func (c *SFTPClient) upload(rel string, r io.Reader, size int64) (*sftpConn, string, error) {
+	conn, err := c.session()
+	if err != nil {
+		return nil, "", err
+	}
+	staged := path.Join(path.Dir(c.path(rel)), stagedPrefix+strconv.FormatUint(rand.Uint64(), 36))
+	handle, err := conn.create(staged)
+	if err != nil {
+		return nil, "", err
+	}
+	written, err := conn.write(handle, staged, r)
+	if closeErr := conn.closeHandle(handle, staged); err == nil {
+		err = closeErr
+	}
+	if err == nil && written != size {
+		err = fmt.Errorf("uploaded %d bytes of '%s', expected %d", written, rel, size)
+	}
+	if err != nil {
+		conn.remove(staged)
+		return nil, "", err
+	}
+	return conn, staged, nil
+}
+
+// check makes sure the directory can be reached with the credentials given.
+func (c *SFTPClient) check() error {
+	conn, err := c.session()
+	if err != nil {
+		return err
+	}
+	isDir, err := conn.stat(c.dir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("'%s' does not exist on the server", c)
+	case err != nil:
+		return err
+	case !isDir:
+		return fmt.Errorf("'%s' is not a directory", c)
+	}
+	return nil
+}
+
+// sftpConn is a client connection of the SFTP protocol. Requests carry ids, so the workers can
+// have theirs in flight at the same time; a goroutine hands every reply to the request it
+// answers.
+type sftpConn struct {
+	w          io.Writer
+	closer     io.Closer         // Of the SSH connection, if any
+	extensions map[string]string // Announced by the server, by name
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	nextID  uint32
+	pending map[uint32]chan sftpReply
+	err     error // Why the connection broke, once it did
+}
+
+// sftpReply is the reply to a request: its type and the data after the id.
+type sftpReply struct {
+	typ  byte
+	data []byte
+	err  error
+}
+
+// newSFTPConn negotiates version 3 of the protocol with the server at the other end of r and w.
+func newSFTPConn(r io.Reader, w io.Writer) (*sftpConn, error) {
+	c := &sftpConn{w: w, extensions: map[string]string{}, pending: map[uint32]chan sftpReply{}}
+	if err := c.writePacket(sftpBuf{sftpInit}.u32(3)); err != nil {
+		return nil, err
+	}
+	typ, data, err := readSFTPPacket(r)
+	if err != nil {
+		return nil, err
+	}
+	if typ != sftpVersion {
+		return nil, fmt.Errorf("unexpected SFTP packet %d instead of the version", typ)
+	}
+	d := sftpData{b: data}
+	d.u32()
+	for len(d.b) > 0 && d.err == nil {
+		name, value := d.str(), d.str()
+		c.extensions[name] = value
+	}
+	go c.read(r)
+	return c, nil
+}
+
+// read hands every reply from r to the request it answers, until the connection breaks.
+func (c *sftpConn) read(r io.Reader) {
+	for {
+		typ, data, err := readSFTPPacket(r)
+		if err == nil && len(data) < 4 {
+			err = fmt.Errorf("short SFTP packet %d", typ)
+		}
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		id := binary.BigEndian.Uint32(data)
+		c.mu.Lock()
+		reply := c.pending[id]
+		delete(c.pending, id)
+		c.mu.Unlock()
+		if reply != nil {
+			reply <- sftpReply{typ: typ, data: data[4:]}
+		}
+	}
+}
+
+// fail marks the connection broken by err and fails the requests waiting for a reply.
+func (c *sftpConn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.err = fmt.Errorf("SFTP connection lost: %w", err)
+	}
+	for id, reply := range c.pending {
+		reply <- sftpReply{err: c.err}
+		delete(c.pending, id)
+	}
+}
+
+// broken returns why the connection broke, or nil while it works.
+func (c *sftpConn) broken() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// close closes the SSH connection.
+func (c *sftpConn) close() {
+	if c.closer != nil {
+		c.closer.Close()
+	}
+}
+
+// readSFTPPacket reads a packet from r and returns its type and the data after it.
+func readSFTPPacket(r io.Reader) (byte, []byte, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(length[:])
+	if n == 0 || n > sftpMaxPacket {
+		return 0, nil, fmt.Errorf("invalid SFTP packet length %d", n)
+	}
+	packet := make([]byte, n)
+	if _, err := io.ReadFull(r, packet); err != nil {
+		return 0, nil, err
+	}
+	return packet[0], packet[1:], nil
+}
+
+// writePacket sends packet, its type and data, with its length in front.
+func (c *sftpConn) writePacket(packet sftpBuf) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := c.w.Write(append(sftpBuf{}.u32(uint32(len(packet))), packet...))
+	return err
+}
+
+// send sends a request of type typ with the next id and payload, and returns where its reply
+// arrives.
+func (c *sftpConn) send(typ byte, payload sftpBuf) <-chan sftpReply {
+	reply := make(chan sftpReply, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		reply <- sftpReply{err: c.err}
+		return reply
+	}
+	c.nextID++
+	id := c.nextID
+	c.pending[id] = reply
+	c.mu.Unlock()
+	if err := c.writePacket(append(sftpBuf{typ}.u32(id), payload...)); err != nil {
+		c.fail(err)
+	}
+	return reply
+}
+
+// request sends a request and waits for its reply.
+func (c *sftpConn) request(typ byte, payload sftpBuf) sftpReply {
+	return <-c.send(typ, payload)
+}
+
+// status returns the error of a reply that should be a status: nil for SSH_FX_OK. The error is
+// an *fs.PathError for op on p.
+func (r sftpReply) status(op, p string) error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.typ != sftpStatus {
+		return fmt.Errorf("%s '%s': unexpected SFTP reply %d", op, p, r.typ)
+	}
+	d := sftpData{b: r.data}
+	code, msg := d.u32(), d.str()
+	if d.err != nil {
+		return fmt.Errorf("%s '%s': %w", op, p, d.err)
+	}
+	if code == sftpOK {
+		return nil
+	}
+	return &fs.PathError{Op: op, Path: p, Err: sftpStatusError{code: code, msg: msg}}
+}
+
+// sftpStatusError is a status other than SSH_FX_OK.
+type sftpStatusError struct {
+	code uint32
+	msg  string
+}
+
+func (e sftpStatusError) Error() string {
+	if e.msg != "" {
+		return e.msg
+	}
+	return "SFTP status " + strconv.FormatUint(uint64(e.code), 10)
+}
+
+// Is makes the statuses for missing files and denied access match fs.ErrNotExist and
+// fs.ErrPermission.
+func (e sftpStatusError) Is(target error) bool {
+	return target == fs.ErrNotExist && e.code == sftpNoSuchFile || target == fs.ErrPermission && e.code == sftpPermissionDenied
+}
+
+// stat reports whether p exists and is a directory.
+func (c *sftpConn) stat(p string) (bool, error) {
+	reply := c.request(sftpStat, sftpBuf{}.str(p))
+	if reply.err != nil || reply.typ != sftpAttrs {
+		return false, reply.status("stat", p)
+	}
+	d := sftpData{b: reply.data}
+	flags := d.u32()
+	if flags&0x1 != 0 { // Size
+		d.skip(8)
+	}
+	if flags&0x2 != 0 { // Owner and group
+		d.skip(8)
+	}
+	if flags&0x4 == 0 || d.err != nil {
+		return false, fmt.Errorf("stat '%s': no permissions in the reply", p)
+	}
+	return d.u32()&0o170000 == 0o040000, nil
+}
+
+// mkdir creates the directory p.
+func (c *sftpConn) mkdir(p string) error {
+	return c.request(sftpMkdir, sftpBuf{}.str(p).u32(0)).status("mkdir", p)
+}
+
+// remove removes the file p.
+func (c *sftpConn) remove(p string) error {
+	return c.request(sftpRemove, sftpBuf{}.str(p)).status("remove", p)
+}
+
+// rename renames oldPath to newPath, which fails if newPath exists.
+func (c *sftpConn) rename(oldPath, newPath string) error {
+	return c.request(sftpRename, sftpBuf{}.str(oldPath).str(newPath)).status("rename", newPath)
+}
+
+// posixRename renames oldPath to newPath, replacing any file at newPath.
+func (c *sftpConn) posixRename(oldPath, newPath string) error {
+	return c.request(sftpExtended, sftpBuf{}.str(sftpPosixRename).str(oldPath).str(newPath)).status("rename", newPath)
+}
+
+// create creates the file p, which must not exist yet, for writing and returns its handle.
+func (c *sftpConn) create(p string) (string, error) {
+	reply := c.request(sftpOpen, sftpBuf{}.str(p).u32(sftpOpenWrite|sftpOpenCreate|sftpOpenExcl).u32(0))
+	if reply.err != nil || reply.typ != sftpHandle {
+		return "", reply.status("create", p)
+	}
+	d := sftpData{b: reply.data}
+	handle := d.str()
+	return handle, d.err
+}
+
+// closeHandle closes the handle of the file p.
+func (c *sftpConn) closeHandle(handle, p string) error {
+	return c.request(sftpClose, sftpBuf{}.str(handle)).status("close", p)
+}
+
+// write writes everything read from r to the file p open at handle and returns how many bytes
+// that were. Up to sftpInFlight writes are sent ahead of their replies, so that the upload is
+// not slowed down to one chunk per round trip.
+func (c *sftpConn) write(handle, p string, r io.Reader) (int64, error) {
+	var inFlight []<-chan sftpReply
+	var offset int64
+	var err error
+	buf := make([]byte, sftpChunk)
+	for err == nil {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			inFlight = append(inFlight, c.send(sftpWrite, sftpBuf{}.str(handle).u64(uint64(offset)).data(buf[:n])))
+			offset += int64(n)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		err = readErr
+		if len(inFlight) == sftpInFlight {
+			err = cmp.Or(err, (<-inFlight[0]).status("write", p))
+			inFlight = inFlight[1:]
+		}
+	}
+	for _, reply := range inFlight {
+		err = cmp.Or(err, (<-reply).status("write", p))
+	}
+	return offset, err
+}
+
+// sftpBuf builds the payload of a packet.
+type sftpBuf []byte
+
+func (b sftpBuf) u32(v uint32) sftpBuf { return binary.BigEndian.AppendUint32(b, v) }
+func (b sftpBuf) u64(v uint64) sftpBuf { return binary.BigEndian.AppendUint64(b, v) }
+func (b sftpBuf) str(s string) sftpBuf { return append(b.u32(uint32(len(s))), s...) }
+func (b sftpBuf) data(p []byte) sftpBuf {
+	return append(b.u32(uint32(len(p))), p...)
+}
+
+// sftpData reads the fields of a packet. Reading past its end sets err and returns zero values.
+type sftpData struct {
+	b   []byte
+	err error
+}
+
+func (d *sftpData) u32() uint32 {
+	if len(d.b) < 4 {
+		d.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	v := binary.BigEndian.Uint32(d.b)
+	d.b = d.b[4:]
+	return v
+}
+
+func (d *sftpData) str() string {
+	n := d.u32()
+	if uint32(len(d.b)) < n {
+		d.err = io.ErrUnexpectedEOF
+		return ""
+	}
+	s := string(d.b[:n])
+	d.b = d.b[n:]
+	return s
+}
+
+func (d *sftpData) skip(n int) {
+	if len(d.b) < n {
+		d.err = io.ErrUnexpectedEOF
+		return
+	}
+	d.b = d.b[n:]
+}
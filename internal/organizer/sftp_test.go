@@ -0,0 +1,230 @@
+package organizer
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// writeKnownHosts makes key the known host key of addr, in a known_hosts file of the test.
+func writeKnownHosts(t *testing.T, addr string, key ssh.PublicKey) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(file, []byte(knownhosts.Line([]string{knownhosts.Normalize(addr)}, key)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(SFTPKnownHostsEnv, file)
+}
+
+// sftpServer starts an SSH server on localhost that serves SFTP in root to alice with the password
+// "secret", and returns its address. Its host key is made known and no keys of the user are used.
+func sftpServer(t *testing.T, root string) string {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{PasswordCallback: func(c ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+		if c.User() != "alice" || string(password) != "secret" {
+			return nil, errors.New("access denied")
+		}
+		return nil, nil
+	}}
+	config.AddHostKey(hostKey)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSH(conn, config, root)
+		}
+	}()
+
+	writeKnownHosts(t, l.Addr().String(), hostKey.PublicKey())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SSH_AUTH_SOCK", "")
+	t.Setenv(SFTPKeyEnv, "")
+	return l.Addr().String()
+}
+
+// serveSSH serves the sftp subsystem on the sessions of conn.
+func serveSSH(conn net.Conn, config *ssh.ServerConfig, root string) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range requests {
+				sftp := req.Type == "subsystem" && string(req.Payload) == string(sftpBuf{}.str("sftp"))
+				req.Reply(sftp, nil)
+				if sftp {
+					go func() {
+						serveSFTP(channel, root)
+						channel.Close()
+					}()
+				}
+			}
+		}()
+	}
+}
+
+// serveSFTP answers the SFTP requests the client makes, on the files in root. Like OpenSSH, it
+// refuses to rename over an existing file unless asked with posix-rename.
+func serveSFTP(rw io.ReadWriter, root string) {
+	send := func(packet sftpBuf) { rw.Write(append(sftpBuf{}.u32(uint32(len(packet))), packet...)) }
+	status := func(id uint32, err error) {
+		code := uint32(sftpOK)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			code = sftpNoSuchFile
+		case err != nil:
+			code = 4 // SSH_FX_FAILURE
+		}
+		send(sftpBuf{sftpStatus}.u32(id).u32(code).str(fmt.Sprint(err)).str(""))
+	}
+	local := func(d *sftpData) string { return filepath.Join(root, filepath.FromSlash(d.str())) }
+	files := map[string]*os.File{}
+	for {
+		typ, data, err := readSFTPPacket(rw)
+		if err != nil {
+			return
+		}
+		d := &sftpData{b: data}
+		if typ == sftpInit {
+			send(sftpBuf{sftpVersion}.u32(3).str(sftpPosixRename).str("1"))
+			continue
+		}
+		id := d.u32()
+		switch typ {
+		case sftpStat:
+			info, err := os.Stat(local(d))
+			if err != nil {
+				status(id, err)
+				continue
+			}
+			mode := uint32(0o100644)
+			if info.IsDir() {
+				mode = 0o040755
+			}
+			send(sftpBuf{sftpAttrs}.u32(id).u32(0x1 | 0x4).u64(uint64(info.Size())).u32(mode))
+		case sftpMkdir:
+			status(id, os.Mkdir(local(d), 0755))
+		case sftpOpen:
+			path := local(d)
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+			if err != nil {
+				status(id, err)
+				continue
+			}
+			files[path] = f
+			send(sftpBuf{sftpHandle}.u32(id).str(path))
+		case sftpWrite:
+			f := files[d.str()]
+			offset := int64(d.u32())<<32 | int64(d.u32())
+			_, err := f.WriteAt([]byte(d.str()), offset)
+			status(id, err)
+		case sftpClose:
+			handle := d.str()
+			status(id, files[handle].Close())
+			delete(files, handle)
+		case sftpRemove:
+			status(id, os.Remove(local(d)))
+		case sftpRename:
+			oldPath, newPath := local(d), local(d)
+			err := os.Link(oldPath, newPath)
+			if err == nil {
+				err = os.Remove(oldPath)
+			}
+			status(id, err)
+		case sftpExtended:
+			if d.str() != sftpPosixRename {
+				status(id, errors.New("unsupported"))
+				continue
+			}
+			status(id, os.Rename(local(d), local(d)))
+		default:
+			status(id, errors.New("unsupported"))
+		}
+	}
+}
+
+func TestSFTPUpload(t *testing.T) {
+	root := t.TempDir()
+	addr := sftpServer(t, root)
+	client, err := NewSFTPClient("sftp://alice:wrong@" + addr + "/~")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.check(); err == nil {
+		t.Error("check passed with a wrong password")
+	}
+	if client, err = NewSFTPClient("sftp://alice:secret@" + addr + "/~/Sorted"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.check(); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("check of a missing directory = %v", err)
+	}
+	dest := filepath.Join(root, "Sorted")
+	writeFile(t, filepath.Join(dest, "Documents", "a.txt"), "old")
+
+	source := t.TempDir()
+	files := map[string]string{"a.txt": "new", "b.txt": strings.Repeat("b", 3*sftpChunk+1), "c.pdf": "c"}
+	for name, content := range files {
+		writeFile(t, filepath.Join(source, name), content)
+	}
+	cfg := testConfig(t, source, client.String())
+	cfg.Remote = client
+	result, err := OrganizeFiles(cfg, nil)
+	if err != nil || result.Processed != len(files) {
+		t.Fatalf("OrganizeFiles = %d processed, %v; want %d", result.Processed, err, len(files))
+	}
+	for name, content := range map[string]string{"a.txt": "old", "b.txt": files["b.txt"], "c.pdf": "c"} {
+		if got, err := os.ReadFile(filepath.Join(dest, "Documents", name)); err != nil || string(got) != content {
+			t.Errorf("%s = %d bytes, %v; want %d", name, len(got), err, len(content))
+		}
+	}
+	entries, err := os.ReadDir(filepath.Join(dest, "Documents"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(files)+1 {
+		t.Errorf("uploaded %d files, want a.txt renamed next to the existing one and nothing staged left", len(entries))
+	}
+
+	if err := client.Overwrite("Documents/a.txt", strings.NewReader("over"), 4); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(filepath.Join(dest, "Documents", "a.txt")); err != nil || string(got) != "over" {
+		t.Errorf("overwritten a.txt = %q, %v", got, err)
+	}
+}
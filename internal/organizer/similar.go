@@ -0,0 +1,219 @@
+package organizer
+
+import (
+	"bytes"
+	"cmp"
+	"image"
+	_ "image/gif" // Decoders for image.Decode
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math/bits"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// Modes of Config.SimilarImages.
+const (
+	SimilarReport = "report" // Near-duplicate photos are only flagged in the results, see FileResult.SimilarTo
+	SimilarMove   = "move"   // They also go into SimilarFolder for review instead of next to the original
+)
+
+// ValidSimilar reports whether mode is one of the supported modes of SimilarImages.
+func ValidSimilar(mode string) bool {
+	return mode == SimilarReport || mode == SimilarMove
+}
+
+// SimilarFolder is the folder in the category folder of images that SimilarMove puts near
+// duplicates into.
+const SimilarFolder = "Duplicates"
+
+// similarDistance is how many of the 64 bits of their difference hashes two photos may differ in
+// and still count as the same picture: resaves and small edits stay well below, different shots
+// of the same scene mostly above.
+const similarDistance = 8
+
+// picture is a photo of the run with its difference hash.
+type picture struct {
+	i      int    // Index in the planned moves
+	hash   uint64 // Difference hash
+	pixels int    // Width times height
+}
+
+// findSimilarImages applies SimilarImages to the planned moves: the photos among them that are
+// resaves or slightly edited copies of another one are flagged with the one they resemble, the
+// original, which is the largest of them. With SimilarMove they go into SimilarFolder. JPEG, PNG
+// and GIF files are compared; others cannot be decoded and are left alone.
+func findSimilarImages(cfg Config, files []FileMove, p logger) {
+	var candidates []int
+	for i, fm := range files {
+		if fm.Review == "" && !fm.archive && fm.Category == "Images" {
+			candidates = append(candidates, i)
+		}
+	}
+	pictures := hashPictures(cfg, files, candidates)
+	if len(pictures) < 2 {
+		return
+	}
+
+	// Photos within similarDistance of each other, directly or through others, are one group
+	group := make([]int, len(pictures))
+	for i := range group {
+		group[i] = i
+	}
+	root := func(i int) int {
+		for group[i] != i {
+			group[i] = group[group[i]]
+			i = group[i]
+		}
+		return i
+	}
+	for i := range pictures {
+		for j := i + 1; j < len(pictures); j++ {
+			if bits.OnesCount64(pictures[i].hash^pictures[j].hash) <= similarDistance {
+				group[root(j)] = root(i)
+			}
+		}
+	}
+	groups := make(map[int][]picture)
+	for i, pic := range pictures {
+		groups[root(i)] = append(groups[root(i)], pic)
+	}
+
+	similar, originals := 0, 0
+	for _, members := range groups {
+		if len(members) < 2 {
+			continue
+		}
+		// The original has the most pixels, then the most bytes, then is the oldest
+		slices.SortFunc(members, func(a, b picture) int {
+			fa, fb := files[a.i], files[b.i]
+			return cmp.Or(
+				cmp.Compare(b.pixels, a.pixels),
+				cmp.Compare(fb.Info.Size(), fa.Info.Size()),
+				fa.Info.ModTime().Compare(fb.Info.ModTime()),
+				cmp.Compare(fa.SourcePath, fb.SourcePath),
+			)
+		})
+		original := files[members[0].i].SourcePath
+		originals++
+		for _, pic := range members[1:] {
+			fm := &files[pic.i]
+			fm.similarTo = original
+			if cfg.SimilarImages == SimilarMove {
+				folder := filepath.Join(cfg.categoryFolder(fm.Category), SimilarFolder)
+				fm.DestPath = cfg.targetPath(folder, filepath.Base(fm.DestPath))
+			}
+			similar++
+			p.Detail(LevelInfo, "🖼️", "'%s' looks like '%s'.", fm.SourcePath, original)
+		}
+	}
+	if similar > 0 {
+		p.Status(LevelNotice, "🖼️", "Found %d near-duplicates of %d photos.", similar, originals)
+	}
+}
+
+// hashPictures decodes the images at the indexes of files and returns their difference hashes,
+// HashWorkers at a time, in the order of indexes.
+func hashPictures(cfg Config, files []FileMove, indexes []int) []picture {
+	workers := cfg.HashWorkers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	results := make([]*picture, len(indexes))
+	slots := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for n, i := range indexes {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if hash, pixels, ok := differenceHash(cfg.fsys(), files[i].SourcePath, cfg.printer()); ok {
+				results[n] = &picture{i: i, hash: hash, pixels: pixels}
+			}
+		}()
+	}
+	wg.Wait()
+	var pictures []picture
+	for _, pic := range results {
+		if pic != nil {
+			pictures = append(pictures, *pic)
+		}
+	}
+	return pictures
+}
+
+// maxHashPixels is the size of the largest image differenceHash decodes. A decoded image takes 4
+// to 8 bytes per pixel, and --hash-workers of them are decoded at once; a crafted PNG of a few
+// kilobytes can claim billions of pixels.
+const maxHashPixels = 64 << 20
+
+// differenceHash returns the difference hash of the image at path on fsys and its size in pixels:
+// the image is shrunk to 9x8 gray cells, and every bit tells whether a cell is brighter than the
+// one to its right. Resizing, recompression and small edits barely change it. Images larger than
+// maxHashPixels are left out.
+func differenceHash(fsys fsutil.FS, path string, p logger) (hash uint64, pixels int, ok bool) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return 0, 0, false
+	}
+	defer f.Close()
+	var header bytes.Buffer // What DecodeConfig read, for Decode to start over with
+	config, _, err := image.DecodeConfig(io.TeeReader(f, &header))
+	if err != nil {
+		return 0, 0, false
+	}
+	if int64(config.Width)*int64(config.Height) > maxHashPixels {
+		p.Debug("%s: %dx%d pixels, too large to compare", path, config.Width, config.Height)
+		return 0, 0, false
+	}
+	img, _, err := image.Decode(io.MultiReader(&header, f))
+	if err != nil {
+		return 0, 0, false
+	}
+	b := img.Bounds()
+	if b.Dx() < 9 || b.Dy() < 8 {
+		return 0, 0, false
+	}
+	var cells [8][9]uint64
+	for y := range 8 {
+		for x := range 9 {
+			cells[y][x] = cellBrightness(img, image.Rect(
+				b.Min.X+x*b.Dx()/9, b.Min.Y+y*b.Dy()/8,
+				b.Min.X+(x+1)*b.Dx()/9, b.Min.Y+(y+1)*b.Dy()/8,
+			))
+		}
+	}
+	for y := range 8 {
+		for x := range 8 {
+			hash <<= 1
+			if cells[y][x] > cells[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash, b.Dx() * b.Dy(), true
+}
+
+// cellSamples is how many pixels per side of a cell cellBrightness averages at most.
+const cellSamples = 16
+
+// cellBrightness returns the average brightness of the pixels in r of img, from up to
+// cellSamples by cellSamples of them spread evenly over it.
+func cellBrightness(img image.Image, r image.Rectangle) uint64 {
+	stepX, stepY := max(r.Dx()/cellSamples, 1), max(r.Dy()/cellSamples, 1)
+	var sum, n uint64
+	for y := r.Min.Y; y < r.Max.Y; y += stepY {
+		for x := r.Min.X; x < r.Max.X; x += stepX {
+			cr, cg, cb, _ := img.At(x, y).RGBA()
+			sum += (299*uint64(cr) + 587*uint64(cg) + 114*uint64(cb)) / 1000
+			n++
+		}
+	}
+	return sum / n
+}
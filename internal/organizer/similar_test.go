@@ -0,0 +1,49 @@
+package organizer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+func TestDifferenceHash(t *testing.T) {
+	dir := t.TempDir()
+	img := image.NewGray(image.Rect(0, 0, 90, 80))
+	for x := range 90 {
+		for y := range 80 {
+			img.SetGray(x, y, color.Gray{Y: uint8(x * 2)})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	gradient := filepath.Join(dir, "gradient.png")
+	writeFile(t, gradient, buf.String())
+
+	// A GIF header claiming 65535x65535 pixels, about 16 GB once decoded
+	huge := filepath.Join(dir, "huge.gif")
+	header := []byte("GIF89a")
+	header = binary.LittleEndian.AppendUint16(header, 65535)
+	header = binary.LittleEndian.AppendUint16(header, 65535)
+	header = append(header, 0, 0, 0, ',', 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0)
+	if err := os.WriteFile(huge, header, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var log bytes.Buffer
+	p := newLogger(PlainPrinter(&log), VerbosityDebug)
+	if hash, pixels, ok := differenceHash(fsutil.OS, gradient, p); !ok || pixels != 90*80 || hash != 0 {
+		t.Errorf("gradient: hash %x of %d pixels, ok %t; want 0 (brighter to the right) of 7200", hash, pixels, ok)
+	}
+	if _, _, ok := differenceHash(fsutil.OS, huge, p); ok || !bytes.Contains(log.Bytes(), []byte("too large")) {
+		t.Errorf("huge image hashed (%t) or not left out for its size: %q", ok, log.String())
+	}
+}
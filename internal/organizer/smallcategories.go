@@ -0,0 +1,72 @@
+package organizer
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// What happens to the files of a category with fewer than MinCategoryFiles files.
+const (
+	SmallCategoryOthers = "others" // They are organized into Others instead
+	SmallCategoryLeave  = "leave"  // They are left where they are
+)
+
+// ValidSmallCategory reports whether policy is a supported policy for small categories.
+func ValidSmallCategory(policy string) bool {
+	return policy == "" || policy == SmallCategoryOthers || policy == SmallCategoryLeave
+}
+
+// foldSmallCategories applies MinCategoryFiles to the planned moves: the files of a category with
+// fewer of them, none of which goes to a folder that exists already, are planned for Others or
+// dropped, as SmallCategories says. It returns the moves left and how many were dropped.
+func foldSmallCategories(cfg Config, files []FileMove, p logger) ([]FileMove, int) {
+	counts := make(map[string]int)
+	existing := make(map[string]bool) // Categories with a destination folder that exists
+	for _, fm := range files {
+		if fm.Review != "" || fm.archive || fm.Category == "Others" {
+			continue
+		}
+		counts[fm.Category]++
+		if !existing[fm.Category] && cfg.Remote == nil {
+			if info, err := cfg.fsys().Stat(filepath.Dir(fm.DestPath)); err == nil && info.IsDir() {
+				existing[fm.Category] = true
+			}
+		}
+	}
+	var small []string
+	for category, n := range counts {
+		if n < cfg.MinCategoryFiles && !existing[category] {
+			small = append(small, category)
+		}
+	}
+	if len(small) == 0 {
+		return files, 0
+	}
+	slices.Sort(small)
+
+	dropped := 0
+	n := 0
+	for _, fm := range files {
+		if fm.Review != "" || fm.archive || !slices.Contains(small, fm.Category) {
+			files[n] = fm
+			n++
+			continue
+		}
+		if cfg.SmallCategories == SmallCategoryLeave {
+			p.Detail(LevelWarn, "⏩", "%s is one of only %d files in category '%s'. Skipping.", filepath.Base(fm.SourcePath), counts[fm.Category], fm.Category)
+			dropped++
+			continue
+		}
+		fm.Category = "Others"
+		fm.DestPath = cfg.targetPath(cfg.defaultFolder(fm.SourcePath, fm.Category, fm.Info.ModTime()), filepath.Base(fm.SourcePath))
+		files[n] = fm
+		n++
+	}
+	if cfg.SmallCategories == SmallCategoryLeave {
+		p.Status(LevelNotice, "⏩", "Leaving %d files in place, their categories have fewer than %d files: %s", dropped, cfg.MinCategoryFiles, strings.Join(small, ", "))
+	} else {
+		p.Status(LevelNotice, "📦", "Putting the files of categories with fewer than %d files into Others: %s", cfg.MinCategoryFiles, strings.Join(small, ", "))
+	}
+	return files[:n], dropped
+}
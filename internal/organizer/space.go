@@ -0,0 +1,87 @@
+package organizer
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// SpaceReport shows what takes up the space among the files a run found, before any of them
+// is touched.
+type SpaceReport struct {
+	Largest []SpaceEntry            `json:"largest"` // Largest files, largest first
+	Dirs    map[string][]SpaceEntry `json:"dirs"`    // Per category, the source directories holding the most bytes of it
+}
+
+// SpaceEntry is a file or a directory of a SpaceReport.
+type SpaceEntry struct {
+	Path     string `json:"path"`
+	Category string `json:"category,omitempty"` // For files
+	Files    int    `json:"files,omitempty"`    // For directories: how many files of the category they hold
+	Bytes    int64  `json:"bytes"`
+}
+
+// spaceReport builds the SpaceReport of files with the n largest files and the n directories with
+// the most bytes per category. Directories count the files directly in them.
+func spaceReport(files []FileMove, n int) *SpaceReport {
+	report := &SpaceReport{Dirs: make(map[string][]SpaceEntry)}
+	dirs := make(map[string]map[string]*SpaceEntry) // Category -> directory -> entry
+	for _, fm := range files {
+		size := fm.Info.Size()
+		report.Largest = append(report.Largest, SpaceEntry{Path: fm.SourcePath, Category: fm.Category, Bytes: size})
+		category := fm.Category
+		if fm.Review != "" {
+			category = fm.Review
+		}
+		if dirs[category] == nil {
+			dirs[category] = make(map[string]*SpaceEntry)
+		}
+		dir := filepath.Dir(fm.SourcePath)
+		e := dirs[category][dir]
+		if e == nil {
+			e = &SpaceEntry{Path: dir}
+			dirs[category][dir] = e
+		}
+		e.Files++
+		e.Bytes += size
+	}
+	report.Largest = topSpace(report.Largest, n)
+	for category, byDir := range dirs {
+		entries := make([]SpaceEntry, 0, len(byDir))
+		for _, e := range byDir {
+			entries = append(entries, *e)
+		}
+		report.Dirs[category] = topSpace(entries, n)
+	}
+	return report
+}
+
+// topSpace returns the n entries with the most bytes, largest first; ties by path.
+func topSpace(entries []SpaceEntry, n int) []SpaceEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Bytes != entries[j].Bytes {
+			return entries[i].Bytes > entries[j].Bytes
+		}
+		return entries[i].Path < entries[j].Path
+	})
+	return entries[:min(n, len(entries))]
+}
+
+// printSpaceReport prints r after the scan.
+func printSpaceReport(p logger, r *SpaceReport) {
+	if len(r.Largest) == 0 {
+		return
+	}
+	p.Status(LevelInfo, "📊", "Largest files:")
+	for _, e := range r.Largest {
+		p.Status(LevelInfo, "", "  %10s  %s (%s)", FormatBytes(e.Bytes), e.Path, e.Category)
+	}
+	p.Status(LevelInfo, "📊", "Directories with the most bytes per category:")
+	for _, category := range slices.Sorted(maps.Keys(r.Dirs)) {
+		p.Status(LevelInfo, "", "  %s:", category)
+		for _, e := range r.Dirs[category] {
+			p.Status(LevelInfo, "", "    %10s  %s (%d files)", FormatBytes(e.Bytes), e.Path, e.Files)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package organizer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// moveOverhead is roughly what a FileMove takes in memory besides its strings: the struct itself
+// and the FileInfo of the scan.
+const moveOverhead = 400
+
+// movePlan collects the moves planned by the scan. With a limit, the moves are kept in memory
+// until they take more than limit bytes and are then written to a temporary file as a batch, to
+// be read back one at a time when they are dispatched.
+type movePlan struct {
+	limit   int64
+	moves   []FileMove // Not spilled, in scan order after the spilled ones
+	size    int64      // Memory taken by moves
+	spill   *os.File
+	w       *bufio.Writer
+	spilled int
+	emit    func(FileMove) error // Receives the moves instead of the plan, see Config.planned
+	emitted int
+}
+
+// spilledMove is a FileMove as written to the spill file. The FileInfo of the scan cannot be
+// written, so what is needed to tell whether the file changed or was replaced since is kept
+// instead. Device and inode are 0 where the platform does not tell them.
+type spilledMove struct {
+	Source       string      `json:"source"`
+	Dest         string      `json:"dest"`
+	Category     string      `json:"category"`
+	Hydrate      bool        `json:"hydrate,omitempty"`
+	Review       string      `json:"review,omitempty"`
+	ReviewFolder string      `json:"review_folder,omitempty"`
+	Damage       string      `json:"damage,omitempty"`
+	Size         int64       `json:"size"`
+	ModTime      time.Time   `json:"mod_time"`
+	Mode         fs.FileMode `json:"mode"`
+	Device       uint64      `json:"device,omitempty"`
+	Inode        uint64      `json:"inode,omitempty"`
+}
+
+// add plans fm, spilling the moves in memory once they take more than the limit, or hands it to
+// emit.
+func (plan *movePlan) add(fm FileMove) error {
+	if plan.emit != nil {
+		plan.emitted++
+		return plan.emit(fm)
+	}
+	plan.moves = append(plan.moves, fm)
+	plan.size += moveOverhead + int64(len(fm.SourcePath)+len(fm.DestPath)+len(fm.Category)+len(fm.Review)+len(fm.reviewFolder)+len(fm.damage))
+	if plan.limit <= 0 || plan.size <= plan.limit {
+		return nil
+	}
+	if plan.spill == nil {
+		f, err := os.CreateTemp("", "organizer-plan-*.jsonl")
+		if err != nil {
+			return fmt.Errorf("failed to create a file for the planned moves: %w", err)
+		}
+		plan.spill, plan.w = f, bufio.NewWriter(f)
+	}
+	enc := json.NewEncoder(plan.w)
+	for _, fm := range plan.moves {
+		m := spilledMove{Source: fm.SourcePath, Dest: fm.DestPath, Category: fm.Category, Hydrate: fm.Hydrate, Review: fm.Review, ReviewFolder: fm.reviewFolder, Damage: fm.damage}
+		if fm.Info != nil {
+			m.Size, m.ModTime, m.Mode = fm.Info.Size(), fm.Info.ModTime(), fm.Info.Mode()
+			m.Device, _ = deviceOf(fm.Info)
+			m.Inode, _ = inodeOf(fm.Info)
+		}
+		if err := enc.Encode(m); err != nil {
+			return fmt.Errorf("failed to write the planned moves to '%s': %w", plan.spill.Name(), err)
+		}
+	}
+	plan.spilled += len(plan.moves)
+	plan.moves, plan.size = nil, 0 // Let the batch go instead of reusing its memory
+	return nil
+}
+
+// len returns the number of planned moves.
+func (plan *movePlan) len() int {
+	return plan.spilled + len(plan.moves) + plan.emitted
+}
+
+// replay calls fn with the spilled moves in the order they were planned, until fn returns false.
+// The source of each is looked at again: fn gets an error instead of the move for a file that
+// changed or was replaced by another since the scan. The move carries the FileInfo of this look,
+// for verifyUnchanged to hold the file to until it is moved.
+func (plan *movePlan) replay(fsys fsutil.FS, dryRun bool, fn func(fm FileMove, err error) bool) error {
+	if plan.spill == nil {
+		return nil
+	}
+	if err := plan.w.Flush(); err != nil {
+		return fmt.Errorf("failed to write the planned moves to '%s': %w", plan.spill.Name(), err)
+	}
+	if _, err := plan.spill.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bufio.NewReader(plan.spill))
+	for {
+		var m spilledMove
+		if err := dec.Decode(&m); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read the planned moves from '%s': %w", plan.spill.Name(), err)
+		}
+		fm := FileMove{SourcePath: m.Source, DestPath: m.Dest, DryRun: dryRun, Category: m.Category, Hydrate: m.Hydrate, Review: m.Review, reviewFolder: m.ReviewFolder, damage: m.Damage}
+		info, err := fsys.Lstat(m.Source)
+		if err == nil && !sameSpilledFile(m, info) {
+			err = fmt.Errorf("'%s' was replaced by a different file since it was scanned", m.Source)
+		} else if err == nil && (info.Size() != m.Size || !info.ModTime().Equal(m.ModTime) || info.Mode() != m.Mode) {
+			err = fmt.Errorf("'%s' changed since it was scanned", m.Source)
+		}
+		fm.Info = info
+		if !fn(fm, err) {
+			return nil
+		}
+	}
+}
+
+// sameSpilledFile reports whether info describes the file m was planned for, as far as its device
+// and inode tell.
+func sameSpilledFile(m spilledMove, info fs.FileInfo) bool {
+	dev, ok := deviceOf(info)
+	ino, ok2 := inodeOf(info)
+	if !ok || !ok2 || m.Inode == 0 {
+		return true
+	}
+	return dev == m.Device && ino == m.Inode
+}
+
+// close removes the spill file.
+func (plan *movePlan) close() {
+	if plan.spill != nil {
+		plan.spill.Close()
+		os.Remove(plan.spill.Name())
+	}
+}
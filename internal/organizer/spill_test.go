@@ -0,0 +1,57 @@
+package organizer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+func TestReplayFindsReplacedFiles(t *testing.T) {
+	dir := t.TempDir()
+	kept, replaced := filepath.Join(dir, "kept.txt"), filepath.Join(dir, "replaced.txt")
+	writeFile(t, kept, "same")
+	writeFile(t, replaced, "same")
+	plan := &movePlan{limit: 1} // Spills every move
+	defer plan.close()
+	for _, path := range []string{kept, replaced} {
+		info, err := os.Lstat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := inodeOf(info); !ok {
+			t.Skip("files have no inode here")
+		}
+		if err := plan.add(FileMove{SourcePath: path, Info: info}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Another file of the same size and time takes the name
+	info, _ := os.Lstat(replaced)
+	other := filepath.Join(dir, "other.txt")
+	writeFile(t, other, "same")
+	if err := os.Chtimes(other, time.Time{}, info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(other, replaced); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(map[string]error)
+	if err := plan.replay(fsutil.OS, false, func(fm FileMove, err error) bool {
+		errs[fm.SourcePath] = err
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err, ok := errs[kept]; !ok || err != nil {
+		t.Errorf("kept file replayed %t with %v, want no error", ok, err)
+	}
+	if err := errs[replaced]; err == nil || !strings.Contains(err.Error(), "replaced") {
+		t.Errorf("replaced file replayed with %v, want it reported as replaced", err)
+	}
+}
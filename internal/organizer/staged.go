@@ -0,0 +1,134 @@
+package organizer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// stagedPrefix starts the hidden names copies are written under in the destination directory
+// until they are complete, e.g. .orgtmp-3k9x2m1qa.
+const stagedPrefix = ".orgtmp-"
+
+// staleStagedAge is how long a staged file must have gone without a write before it is taken for
+// the leftover of an interrupted run rather than a copy still in progress.
+const staleStagedAge = time.Hour
+
+// sweptDirs holds the directories this process has cleared of stale staged files.
+var sweptDirs sync.Map
+
+// stagedFile is a copy being written under a hidden name in the directory of its destination, so
+// that nobody sees it half-written. Once it is written, synced and closed, commit or commitUnique
+// moves it into place; on failure abort removes it.
+type stagedFile struct {
+	fsutil.File
+	fsys  fsutil.FS
+	path  string
+	watch *watchdog // Of the file being copied, with Config.FileTimeout
+}
+
+// stage creates a staged file with permissions perm for a copy into dir. The first time the
+// process stages into dir, staged files that interrupted runs left there are removed.
+func stage(fsys fsutil.FS, dir string, perm fs.FileMode) (*stagedFile, error) {
+	if _, swept := sweptDirs.LoadOrStore(dir, true); !swept {
+		sweepStaged(fsys, dir)
+	}
+	for {
+		path := filepath.Join(dir, stagedPrefix+strconv.FormatUint(rand.Uint64(), 36))
+		f, err := fsys.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create '%s': %w", path, err)
+		}
+		return &stagedFile{File: f, fsys: fsys, path: path}, nil
+	}
+}
+
+// sweepStaged removes the stale staged files directly in dir.
+func sweepStaged(fsys fsutil.FS, dir string) {
+	fsys.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return nil
+		case d.IsDir():
+			if path != dir {
+				return fs.SkipDir
+			}
+			return nil
+		case !strings.HasPrefix(d.Name(), stagedPrefix):
+			return nil
+		}
+		if info, err := d.Info(); err == nil && time.Since(info.ModTime()) > staleStagedAge {
+			fsys.Remove(path)
+		}
+		return nil
+	})
+}
+
+// Write writes to the staged file, once its file is not given up on, and counts as progress.
+func (s *stagedFile) Write(b []byte) (int, error) {
+	if s.watch.givenUp() {
+		return 0, ErrFileTimeout
+	}
+	n, err := s.File.Write(b)
+	s.watch.touch()
+	return n, err
+}
+
+// verify checks that the closed staged file holds size bytes, the size of what was copied.
+// Network file systems have been known to acknowledge writes they then lose.
+func (s *stagedFile) verify(size int64) error {
+	info, err := s.fsys.Stat(s.path)
+	if err == nil && info.Size() != size {
+		err = fmt.Errorf("copy has %d bytes instead of %d", info.Size(), size)
+	}
+	return err
+}
+
+// commit moves the closed staged file to target, which must not exist.
+func (s *stagedFile) commit(target string) error {
+	if s.watch.givenUp() {
+		s.abort()
+		return ErrFileTimeout
+	}
+	err := fsutil.RenameNoReplace(s.fsys, s.path, target)
+	if err != nil {
+		s.abort()
+	}
+	return err
+}
+
+// commitUnique moves the closed staged file to destPath+suffix. If that is taken, a timestamp is
+// put before the original extension, as for moved files (report.pdf + .gz ->
+// report_20250704_220740.pdf.gz), and a counter if that is taken too. It returns where the file
+// ended up.
+func (s *stagedFile) commitUnique(destPath, suffix string, now time.Time) (string, error) {
+	if s.watch.givenUp() {
+		s.abort()
+		return "", ErrFileTimeout
+	}
+	target, err := placeUnique(destPath+suffix, destPath, suffix, now, func(target string) error {
+		return fsutil.RenameNoReplace(s.fsys, s.path, target)
+	})
+	if err != nil {
+		s.abort()
+		return "", fmt.Errorf("failed to move the copy into place: %w", err)
+	}
+	return target, nil
+}
+
+// abort removes the staged file.
+func (s *stagedFile) abort() {
+	s.fsys.Remove(s.path)
+}
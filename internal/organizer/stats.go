@@ -0,0 +1,102 @@
+package organizer
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Stats are the counters of a run. The engine keeps them from the progress updates it sends, and
+// callers showing the progress of a run fold the updates in with Add too, so the counts seen
+// while the run goes on, in its Result and in its Summary always agree.
+type Stats struct {
+	Scanned      int            // Entries seen during the scan, including skipped ones
+	ToProcess    int            // Files handed to the workers
+	Processed    int            // Files organized, or that would have been in a dry run
+	Skipped      int            // Entries skipped during the scan and files the workers left in place
+	Errors       int            // Entries that could not be read during the scan and files that could not be processed
+	AccessDenied int            // Entries the scan was denied access to, also counted as Errors or Skipped by Config.Unreadable
+	MirrorErrors int            // Files organized but missing from the mirror
+	Rotated      int            // Files rotated out of a category that exceeded its quota
+	Corrupt      int            // Empty or damaged files, also counted as Skipped or Processed by Config.Corrupt
+	Bytes        int64          // Total size of the processed files
+	Categories   map[string]int // Processed files per category
+}
+
+// Add folds update into the counters. Entries skipped during the scan are not sent as updates:
+// they only show up in the Stats of the Result.
+func (s *Stats) Add(update ProgressUpdate) {
+	if update.Planned > 0 {
+		s.ToProcess = update.Planned
+	}
+	s.Processed += update.Moved
+	s.Skipped += update.Skipped
+	s.Errors += update.Errored
+	s.AccessDenied += update.Denied
+	s.MirrorErrors += update.MirrorErrored
+	s.Rotated += update.Rotated
+	s.Corrupt += update.Corrupt
+	s.Bytes += update.Bytes
+	if update.Moved > 0 {
+		if s.Categories == nil {
+			s.Categories = make(map[string]int)
+		}
+		s.Categories[update.Category] += update.Moved
+	}
+}
+
+// Finished returns the number of files the workers are done with, whatever the outcome.
+func (s Stats) Finished() int {
+	return s.Processed + s.Skipped + s.Errors
+}
+
+// Timing is how long the phases of a run took, to tell a slow scan of the source from slow moves.
+// Where files are organized as the scan finds them, the scan includes the moves made while it
+// walked the source.
+type Timing struct {
+	ScanMS    int64 `json:"scan_ms"`    // Walking the source and classifying what was found
+	PlanMS    int64 `json:"plan_ms"`    // Ordering and limiting the files found, setting aside those to archive
+	ProcessMS int64 `json:"process_ms"` // Moving the files, packing the archives and rotating quotas
+}
+
+// phaseTimer takes the Timing of a run by its Clock. A phase a run ends before is counted as
+// taking no time.
+type phaseTimer struct {
+	clock                   Clock
+	start, scanned, planned time.Time
+}
+
+func newPhaseTimer(clock Clock) *phaseTimer {
+	return &phaseTimer{clock: clock, start: clock.now()}
+}
+
+// scanDone marks the end of the scan.
+func (t *phaseTimer) scanDone() {
+	t.scanned = t.clock.now()
+}
+
+// planDone marks the end of the planning, once the files are handed to the workers.
+func (t *phaseTimer) planDone() {
+	t.planned = t.clock.now()
+}
+
+// timing returns the Timing of the run, which ends now.
+func (t *phaseTimer) timing() *Timing {
+	end := t.clock.now()
+	scanned := cmp.Or(t.scanned, end)
+	planned := cmp.Or(t.planned, end)
+	return &Timing{
+		ScanMS:    scanned.Sub(t.start).Milliseconds(),
+		PlanMS:    planned.Sub(scanned).Milliseconds(),
+		ProcessMS: end.Sub(planned).Milliseconds(),
+	}
+}
+
+// SlowestFiles returns the n files of files that took their worker the longest, slowest first.
+func SlowestFiles(files []FileResult, n int) []FileResult {
+	slowest := slices.Clone(files)
+	slices.SortStableFunc(slowest, func(a, b FileResult) int {
+		return cmp.Compare(b.Duration, a.Duration)
+	})
+	return slowest[:min(n, len(slowest))]
+}
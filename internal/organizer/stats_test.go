@@ -0,0 +1,72 @@
+package organizer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+func TestStatsAgree(t *testing.T) {
+	source, dest := t.TempDir(), t.TempDir()
+	cfg := testConfig(t, source, dest)
+	cfg.SkipDuplicates = true
+	cfg.FS = failing("denied", syscall.EACCES, "rename", "link", "create")
+
+	writeFile(t, filepath.Join(source, "report.pdf"), "moved")
+	writeFile(t, filepath.Join(source, "photo.jpg"), "moved too")
+	writeFile(t, filepath.Join(source, "copy.txt"), "a duplicate, left in place by the worker")
+	writeFile(t, filepath.Join(dest, "Documents", "copy.txt"), "a duplicate, left in place by the worker")
+	writeFile(t, filepath.Join(source, "denied.txt"), "fails")
+	if err := os.Symlink("report.pdf", filepath.Join(source, "link.pdf")); err != nil {
+		t.Fatal(err) // Skipped by the scan; the source directory counts as scanned as well
+	}
+
+	updates := make(chan ProgressUpdate)
+	var folded Stats
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for update := range updates {
+			folded.Add(update)
+		}
+	}()
+	result, err := OrganizeFiles(cfg, updates)
+	close(updates)
+	<-done
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Stats{Scanned: 6, ToProcess: 4, Processed: 2, Skipped: 2, Errors: 1, Bytes: int64(len("moved") + len("moved too")),
+		Categories: map[string]int{"Documents": 1, "Images": 1}}
+	if !reflect.DeepEqual(result.Stats, want) {
+		t.Errorf("Result.Stats = %+v, want %+v", result.Stats, want)
+	}
+	// The scan skip is not sent as an update, the rest must match
+	folded.Scanned, folded.Skipped = result.Scanned, folded.Skipped+1
+	if !reflect.DeepEqual(folded, result.Stats) {
+		t.Errorf("Stats folded from the updates = %+v, Result.Stats = %+v", folded, result.Stats)
+	}
+
+	counts := map[Action]int{}
+	for _, f := range result.Files {
+		counts[f.Action]++
+	}
+	if counts[ActionMove] != result.Processed || counts[ActionFail] != result.Errors || counts[ActionSkip] != 1 {
+		t.Errorf("file results %v do not match %+v", counts, result.Stats)
+	}
+
+	var summary Summary
+	summary.SetStats(result.Stats)
+	got := Stats{Scanned: summary.Scanned, ToProcess: summary.ToProcess, Processed: summary.Processed, Skipped: summary.Skipped,
+		Errors: summary.Errors, AccessDenied: summary.AccessDenied, MirrorErrors: summary.MirrorErrors, Rotated: summary.Rotated,
+		Corrupt: summary.Corrupt, Bytes: summary.Bytes, Categories: summary.Categories}
+	if !reflect.DeepEqual(got, result.Stats) {
+		t.Errorf("Summary counters = %+v, Result.Stats = %+v", got, result.Stats)
+	}
+	if summary.Finish(summary.StartedAt); summary.Status != StatusPartial {
+		t.Errorf("Status = %s, want %s", summary.Status, StatusPartial)
+	}
+}
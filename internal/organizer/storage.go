@@ -0,0 +1,55 @@
+package organizer
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Kinds of storage a path can live on, as far as the worker count is concerned.
+const (
+	StorageUnknown = "unknown"
+	StorageSSD     = "ssd"
+	StorageHDD     = "hdd"     // Spinning disk: seeks make parallel I/O slower, not faster
+	StorageNetwork = "network" // NFS, SMB and other network file systems
+)
+
+// AutoWorkers picks a worker count for organizing sourceDir into destDir: parallel on SSDs and
+// unknown local storage, close to serial when either side is a spinning disk or a network share.
+// It also returns the slower of the two storage kinds, which decided the count.
+func AutoWorkers(sourceDir, destDir string) (int, string) {
+	kind := slowerStorage(storageKind(sourceDir), storageKind(destDir))
+	if IsRemoteDest(destDir) {
+		kind = StorageNetwork
+	}
+	switch kind {
+	case StorageHDD, StorageNetwork:
+		return 2, kind
+	}
+	// File operations are mostly waiting on I/O, so more workers than CPUs pay off up to a point
+	return min(max(2*runtime.NumCPU(), 2), 16), kind
+}
+
+// slowerStorage returns the kind of a and b that calls for fewer workers.
+func slowerStorage(a, b string) string {
+	rank := map[string]int{StorageSSD: 0, StorageUnknown: 1, StorageNetwork: 2, StorageHDD: 3}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// storageKind reports what kind of storage path is on. A path that doesn't exist yet (such as a
+// new destination) is looked up by its closest existing parent.
+func storageKind(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return pathStorageKind(path)
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return StorageUnknown
+		}
+		path = parent
+	}
+}
@@ -0,0 +1,20 @@
+package organizer
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// networkFilesystems are the names macOS reports for network file systems.
+var networkFilesystems = map[string]bool{"nfs": true, "smbfs": true, "afpfs": true, "webdav": true, "cifs": true}
+
+// pathStorageKind tells network file systems apart; local disks are not told apart on macOS.
+func pathStorageKind(path string) string {
+	var statfs unix.Statfs_t
+	if err := unix.Statfs(path, &statfs); err != nil {
+		return StorageUnknown
+	}
+	if networkFilesystems[unix.ByteSliceToString(statfs.Fstypename[:])] {
+		return StorageNetwork
+	}
+	return StorageUnknown
+}
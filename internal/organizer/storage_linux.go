@@ -0,0 +1,54 @@
+package organizer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// networkFilesystems are the statfs magic numbers of network file systems.
+var networkFilesystems = map[uint32]bool{
+	0x6969:     true, // NFS
+	0x517B:     true, // SMB
+	0xFF534D42: true, // CIFS
+	0xFE534D42: true, // SMB2
+	0x00C36400: true, // Ceph
+	0x47504653: true, // GPFS
+	0x5346414F: true, // AFS
+}
+
+// pathStorageKind looks up the file system of path and, for block devices, whether the disk
+// behind it rotates.
+func pathStorageKind(path string) string {
+	var statfs unix.Statfs_t
+	if err := unix.Statfs(path, &statfs); err == nil && networkFilesystems[uint32(statfs.Type)] {
+		return StorageNetwork
+	}
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return StorageUnknown
+	}
+	major, minor := unix.Major(uint64(st.Dev)), unix.Minor(uint64(st.Dev))
+	if major == 0 {
+		return StorageUnknown // tmpfs, overlayfs and other virtual file systems
+	}
+	dev, err := filepath.EvalSymlinks(fmt.Sprintf("/sys/dev/block/%d:%d", major, minor))
+	if err != nil {
+		return StorageUnknown
+	}
+	// Partitions have no queue of their own, their disk is the parent directory
+	for _, dir := range []string{dev, filepath.Dir(dev)} {
+		data, err := os.ReadFile(filepath.Join(dir, "queue", "rotational"))
+		if err != nil {
+			continue
+		}
+		if strings.TrimSpace(string(data)) == "1" {
+			return StorageHDD
+		}
+		return StorageSSD
+	}
+	return StorageUnknown
+}
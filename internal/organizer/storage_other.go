@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package organizer
+
+// pathStorageKind is not implemented on this platform; AutoWorkers then assumes fast local storage.
+func pathStorageKind(path string) string {
+	return StorageUnknown
+}
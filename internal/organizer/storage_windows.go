@@ -0,0 +1,30 @@
+package organizer
+
+import (
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+// pathStorageKind tells network shares (UNC paths and mapped drives) apart; local disks are not
+// told apart on Windows.
+func pathStorageKind(path string) string {
+	volume := filepath.VolumeName(path)
+	switch {
+	case strings.HasPrefix(volume, `\\?\UNC\`):
+		return StorageNetwork
+	case strings.HasPrefix(volume, `\\?\`), strings.HasPrefix(volume, `\\.\`):
+		volume = volume[4:] // A long or device path of a drive
+	case strings.HasPrefix(volume, `\\`):
+		return StorageNetwork
+	}
+	root, err := windows.UTF16PtrFromString(volume + `\`)
+	if err != nil {
+		return StorageUnknown
+	}
+	if windows.GetDriveType(root) == windows.DRIVE_REMOTE {
+		return StorageNetwork
+	}
+	return StorageUnknown
+}
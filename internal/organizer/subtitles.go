@@ -0,0 +1,106 @@
+package organizer
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	// subtitleExts are the extensions of subtitle files, which are paired with their video.
+	subtitleExts = []string{".srt", ".ass", ".ssa", ".vtt", ".sub", ".idx"}
+	// videoExts are the extensions of the videos subtitles are paired with.
+	videoExts = []string{".mp4", ".m4v", ".mov", ".avi", ".mkv", ".webm", ".wmv", ".flv", ".mpg", ".mpeg", ".ts", ".m2ts"}
+	// subtitleTags are the marks players know after the language: "Movie.en.forced.srt".
+	subtitleTags = []string{"forced", "sdh", "hi", "cc", "default"}
+	// languageCode matches language codes with an optional region: "en", "pt-BR", "pt_br", "eng".
+	languageCode = regexp.MustCompile(`^([a-zA-Z]{2,3})(?:[-_]([a-zA-Z]{2}|\d{3}))?$`)
+)
+
+// languages are the ISO 639-1 codes and the ISO 639-2 codes of the more common languages a
+// subtitle name may end in. Other short words in names ("Part.One.srt") are no language.
+var languages = map[string]bool{
+	"ar": true, "bg": true, "bn": true, "ca": true, "cs": true, "cy": true, "da": true, "de": true, "el": true, "en": true,
+	"es": true, "et": true, "eu": true, "fa": true, "fi": true, "fr": true, "ga": true, "gl": true, "he": true, "hi": true,
+	"hr": true, "hu": true, "hy": true, "id": true, "is": true, "it": true, "ja": true, "ka": true, "kk": true, "ko": true,
+	"lt": true, "lv": true, "mk": true, "ms": true, "mt": true, "nb": true, "nl": true, "nn": true, "no": true, "pl": true,
+	"pt": true, "ro": true, "ru": true, "sk": true, "sl": true, "sq": true, "sr": true, "sv": true, "sw": true, "ta": true,
+	"te": true, "th": true, "tl": true, "tr": true, "uk": true, "ur": true, "vi": true, "zh": true,
+	"ara": true, "chi": true, "zho": true, "cze": true, "ces": true, "dan": true, "dut": true, "nld": true, "eng": true,
+	"fin": true, "fre": true, "fra": true, "ger": true, "deu": true, "gre": true, "ell": true, "heb": true, "hin": true,
+	"hun": true, "ind": true, "ita": true, "jpn": true, "kor": true, "nor": true, "pol": true, "por": true, "rum": true,
+	"ron": true, "rus": true, "spa": true, "swe": true, "tha": true, "tur": true, "ukr": true, "vie": true,
+}
+
+// splitSubtitle splits the name of a subtitle file into the name of its video without extension
+// and the suffix to keep: the language, normalized to "en" or "pt-BR", tags like "forced" and the
+// extension. "Movie.PT_br.forced.SRT" gives "Movie" and ".pt-BR.forced.srt".
+func splitSubtitle(name string) (base, suffix string) {
+	ext := path.Ext(name)
+	base = strings.TrimSuffix(name, ext)
+	suffix = strings.ToLower(ext)
+	var tags []string
+	for {
+		tag := strings.TrimPrefix(path.Ext(base), ".")
+		if !slices.Contains(subtitleTags, strings.ToLower(tag)) {
+			break
+		}
+		tags = append([]string{strings.ToLower(tag)}, tags...)
+		base = strings.TrimSuffix(base, "."+tag)
+	}
+	lang := strings.TrimPrefix(path.Ext(base), ".")
+	if m := languageCode.FindStringSubmatch(lang); m != nil && languages[strings.ToLower(m[1])] && base != "."+lang {
+		base = strings.TrimSuffix(base, "."+lang)
+		lang = strings.ToLower(m[1])
+		if m[2] != "" {
+			lang += "-" + strings.ToUpper(m[2])
+		}
+		tags = append([]string{lang}, tags...)
+	}
+	for i := len(tags) - 1; i >= 0; i-- {
+		suffix = "." + tags[i] + suffix
+	}
+	return base, suffix
+}
+
+// pairSubtitles plans the subtitle files among the planned moves next to the video they belong
+// to, the video of the same name in the same folder, and names them after it as players expect:
+// "Movie.en.srt" follows "Movie.mkv" to "Videos/Movie.en.srt", whatever folder the layout or
+// --media chose for the video. Subtitles without their video are left to the mappings.
+func pairSubtitles(cfg Config, files []FileMove, p logger) {
+	videos := make(map[string]*FileMove) // By source path without extension
+	for i, fm := range files {
+		if fm.Review == "" && !fm.archive && slices.Contains(videoExts, strings.ToLower(filepath.Ext(fm.SourcePath))) {
+			videos[strings.TrimSuffix(fm.SourcePath, filepath.Ext(fm.SourcePath))] = &files[i]
+		}
+	}
+	if len(videos) == 0 {
+		return
+	}
+	paired := 0
+	for i := range files {
+		fm := &files[i]
+		name := filepath.Base(fm.SourcePath)
+		if fm.Review != "" || fm.archive || !slices.Contains(subtitleExts, strings.ToLower(filepath.Ext(name))) {
+			continue
+		}
+		base, suffix := splitSubtitle(name)
+		video, ok := videos[filepath.Join(filepath.Dir(fm.SourcePath), base)]
+		if !ok {
+			continue
+		}
+		fm.Category = video.Category
+		if cfg.Remote != nil { // Relative to the remote location, slash separated
+			fm.DestPath = strings.TrimSuffix(video.DestPath, path.Ext(video.DestPath)) + suffix
+		} else {
+			fm.DestPath = strings.TrimSuffix(video.DestPath, filepath.Ext(video.DestPath)) + suffix
+		}
+		p.Debug("%s: subtitle of %s, destination '%s'", name, filepath.Base(video.SourcePath), fm.DestPath)
+		paired++
+	}
+	if paired > 0 {
+		p.Detail(LevelInfo, "🎬", "Keeping %d subtitles with their videos.", paired)
+	}
+}
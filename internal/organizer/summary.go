@@ -0,0 +1,91 @@
+package organizer
+
+import (
+	"fmt"
+	"time"
+)
+
+// Run outcomes reported in Summary.Status.
+const (
+	StatusOK      = "ok"      // Everything that was planned was processed
+	StatusPartial = "partial" // The run completed but some files (or their mirror copies) failed
+	StatusFailed  = "failed"  // The run could not complete (bad config, unreadable source, ...)
+	StatusAborted = "aborted" // The run was stopped (signal, before-run hook) before it completed
+)
+
+// Summary is the machine-readable outcome of a single organizer run. It is what gets sent to
+// notification targets and what integrations should rely on instead of parsing console output.
+type Summary struct {
+	RunID      string    `json:"run_id,omitempty"`
+	Journal    string    `json:"journal,omitempty"` // Where the run's operations were journaled, for undo
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	Host       string    `json:"host,omitempty"`
+	SourceDir  string    `json:"source_dir"`
+	DestDir    string    `json:"dest_dir"`
+	DryRun     bool      `json:"dry_run"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMS int64     `json:"duration_ms"`
+	Scanned    int       `json:"scanned"`
+	ToProcess  int       `json:"to_process"`
+	Processed  int       `json:"processed"`
+	Skipped    int       `json:"skipped"`
+	Errors     int       `json:"errors"`
+
+	AccessDenied int `json:"access_denied,omitempty"` // Entries the scan could not read, see FileResult with ActionDenied
+
+	Mirror       string `json:"mirror,omitempty"`        // Secondary destination every file was copied to
+	MirrorErrors int    `json:"mirror_errors,omitempty"` // Files organized into DestDir but missing from Mirror
+	Rotated      int    `json:"rotated,omitempty"`       // Files rotated out of categories over their quota
+	Corrupt      int    `json:"corrupt,omitempty"`       // Empty or damaged files, see FileResult.Damage
+
+	Bytes      int64          `json:"bytes"`                // Total size of the processed files
+	Categories map[string]int `json:"categories,omitempty"` // Processed files per category
+	Timing     *Timing        `json:"timing,omitempty"`     // How long the scan, the planning and the processing took
+	Pool       *PoolStats     `json:"pool,omitempty"`       // What the worker pool and each of its workers did
+
+	Files []FileResult `json:"-"` // Outcome of every file; kept out of the history and webhooks
+	Space *SpaceReport `json:"-"` // Largest files and directories, with --top; only in reports
+
+	Extensions []ExtensionStat `json:"-"` // Files and bytes per extension, with --analyze-out
+}
+
+// SetStats copies the counters of a run into s.
+func (s *Summary) SetStats(stats Stats) {
+	s.Scanned, s.ToProcess = stats.Scanned, stats.ToProcess
+	s.Processed, s.Skipped, s.Errors = stats.Processed, stats.Skipped, stats.Errors
+	s.AccessDenied = stats.AccessDenied
+	s.MirrorErrors, s.Rotated, s.Corrupt = stats.MirrorErrors, stats.Rotated, stats.Corrupt
+	s.Bytes, s.Categories = stats.Bytes, stats.Categories
+}
+
+// Finish stamps the end time and duration and derives Status from the counters unless the run
+// has already been marked as failed or aborted.
+func (s *Summary) Finish(now time.Time) {
+	s.FinishedAt = now
+	s.DurationMS = now.Sub(s.StartedAt).Milliseconds()
+	switch {
+	case s.Status == StatusFailed, s.Status == StatusAborted:
+	case s.Error != "":
+		s.Status = StatusFailed
+	case s.Errors > 0, s.MirrorErrors > 0:
+		s.Status = StatusPartial
+	default:
+		s.Status = StatusOK
+	}
+}
+
+// FormatBytes renders a byte count using binary units (KiB, MiB, ...).
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,29 @@
+package organizer
+
+import (
+	"path/filepath"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// leaveSymlink puts a symlink to dst at the original path of fm, which was just moved there, when
+// LeaveSymlink is set, and reports whether it did. Files staged for review get none: approving
+// them moves them again, which would leave the link dangling. A link that cannot be created (on
+// Windows without the symlink privilege, say) is reported as a warning; the file is moved anyway.
+func (cfg Config) leaveSymlink(fm FileMove, dst string) bool {
+	if !cfg.LeaveSymlink || fm.Review != "" {
+		return false
+	}
+	fsys := cfg.fsys()
+	if err := fsys.Symlink(dst, fm.SourcePath); err != nil {
+		cfg.printer().File(LevelWarn, "WARNING", "Could not leave a symlink to '%s' at '%s': %v", dst, fm.SourcePath, err)
+		return false
+	}
+	if cfg.Fsync {
+		dir := filepath.Dir(fm.SourcePath)
+		if err := fsutil.SyncPath(fsys, dir); err != nil {
+			cfg.printer().File(LevelWarn, "WARNING", "Could not flush '%s' to disk: %v", dir, err)
+		}
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package organizer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+	"github.com/avizyt/org-cli/internal/journal"
+)
+
+// syncFile is the sync mode counterpart of moveFile. The destination is the source of truth: a
+// file whose layout path already holds the same content is left alone, one holding different
+// content is reported as a conflict, and only missing files are copied in. The source is never
+// modified, so repeated runs are idempotent instead of producing _timestamp copies.
+func syncFile(fm FileMove, cfg Config, progressChan chan<- ProgressUpdate) error {
+	p := cfg.printer()
+
+	fsys := cfg.fsys()
+	fail := func(err error) error {
+		fm.reportFailure(p, err, progressChan)
+		return err
+	}
+
+	if existing, err := fsys.Stat(fm.DestPath); err == nil {
+		same, err := sameContent(fsys, cfg.hashes, fm.SourcePath, fm.Info, fm.DestPath, existing)
+		if err != nil {
+			return fail(fmt.Errorf("failed to compare '%s' with '%s': %w", fm.SourcePath, fm.DestPath, err))
+		}
+		if same {
+			p.File(LevelNotice, "IN SYNC", "'%s' is already in '%s'", fm.SourcePath, fm.DestPath)
+		} else {
+			p.File(LevelWarn, "CONFLICT", "'%s' differs from '%s' in the destination. Skipping.", fm.SourcePath, fm.DestPath)
+			err := &ConflictError{Path: fm.DestPath, Reason: fmt.Sprintf("differs from '%s'", fm.SourcePath)}
+			progressChan <- fm.skippedUpdate(err)
+			return err
+		}
+		progressChan <- fm.skippedUpdate(nil)
+		return nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fail(fmt.Errorf("error checking existence of '%s': %w", fm.DestPath, err))
+	}
+
+	if fm.DryRun {
+		p.File(LevelNotice, "DRY RUN", "Would copy '%s' to '%s'", fm.SourcePath, fm.DestPath)
+		progressChan <- fm.movedUpdate(ActionCopy, fm.DestPath)
+		return nil
+	}
+
+	if err := verifyUnchanged(fsys, fm); err != nil {
+		p.File(LevelWarn, "CHANGED", "%v. Skipping.", err)
+		progressChan <- fm.skippedUpdate(err)
+		return err
+	}
+	if _, err := cfg.dirs.ensure(fsys, filepath.Dir(fm.DestPath), false); err != nil {
+		return fail(fmt.Errorf("failed to create destination directory '%s': %w", filepath.Dir(fm.DestPath), err))
+	}
+
+	out, err := stage(fsys, filepath.Dir(fm.DestPath), 0644)
+	if err != nil {
+		return fail(err)
+	}
+	out.watch = fm.watch
+	w, hashed := cfg.teeHash(out, fm.SourcePath, fm.Info)
+	err = copyFileInto(fsys, w, fm.SourcePath)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		progressChan <- fm.eventUpdate(PhaseCopied, ActionCopy)
+		err = out.verify(fm.Info.Size())
+	}
+	if err != nil {
+		out.abort() // Don't leave a truncated file behind
+		return fail(fmt.Errorf("failed to copy '%s' to '%s': %w", fm.SourcePath, fm.DestPath, err))
+	}
+	hashed()
+	progressChan <- fm.eventUpdate(PhaseVerified, ActionCopy)
+	fsys.Chtimes(out.path, fm.Info.ModTime(), fm.Info.ModTime())
+	cfg.copied(fm.SourcePath, out.path)
+	if err := out.commit(fm.DestPath); err != nil {
+		return fail(fmt.Errorf("failed to copy '%s' to '%s': %w", fm.SourcePath, fm.DestPath, err))
+	}
+	cfg.persist(fm.DestPath)
+	cfg.Journal.Record(journal.Entry{Op: journal.OpCopy, Source: fm.SourcePath, Dest: fm.DestPath, Size: fm.Info.Size(), Hash: cfg.hashes.known(fm.SourcePath, fm.Info)})
+
+	p.File(LevelSuccess, "COPIED", "Copied '%s' to '%s'", fm.SourcePath, fm.DestPath)
+	cfg.fileStored(fm.SourcePath, fm.DestPath, fm.Category, progressChan)
+	progressChan <- fm.movedUpdate(ActionCopy, fm.DestPath)
+	return nil
+}
+
+// sameContent reports whether the file at a (described by aInfo) and the file at b have identical
+// content. Sizes are compared first so that hashing is only needed for likely matches; the two
+// files are then hashed at the same time.
+func sameContent(fsys fsutil.FS, h *hasher, a string, aInfo fs.FileInfo, b string, bInfo fs.FileInfo) (bool, error) {
+	if !bInfo.Mode().IsRegular() || aInfo.Size() != bInfo.Size() {
+		return false, nil
+	}
+	var hb []byte
+	var errB error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		hb, errB = h.sum(fsys, b, bInfo, "")
+	}()
+	ha, err := h.sum(fsys, a, aInfo, "")
+	<-done
+	if err != nil {
+		return false, err
+	}
+	if errB != nil {
+		return false, errB
+	}
+	return bytes.Equal(ha, hb), nil
+}
@@ -0,0 +1,58 @@
+package organizer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Tier is an age band of files, e.g. Hot for files modified in the last 30 days. Tiers are used
+// in order: a file goes to the first one it is younger than. The last tier has no age limit and
+// takes everything older, so every file has a tier.
+type Tier struct {
+	Name        string        // Folder of the tier, the value of {tier} in the layout
+	YoungerThan time.Duration // Upper age limit; 0 for the last tier
+}
+
+// validateTiers checks that tiers have names, grow older in order and end with a tier without
+// an age limit.
+func validateTiers(tiers []Tier) error {
+	for i, t := range tiers {
+		last := i == len(tiers)-1
+		switch {
+		case t.Name == "":
+			return fmt.Errorf("tier %d: name is required", i+1)
+		case last && t.YoungerThan != 0:
+			return fmt.Errorf("tier '%s': the last tier takes all older files and has no younger_than", t.Name)
+		case !last && t.YoungerThan <= 0:
+			return fmt.Errorf("tier '%s': younger_than is required for all but the last tier", t.Name)
+		case i > 0 && !last && t.YoungerThan <= tiers[i-1].YoungerThan:
+			return fmt.Errorf("tier '%s': younger_than must be longer than that of tier '%s'", t.Name, tiers[i-1].Name)
+		}
+	}
+	return nil
+}
+
+// tier returns the name of the tier of a file last modified at modTime, or "" without tiers.
+func (cfg Config) tier(modTime time.Time) string {
+	age := cfg.now().Sub(modTime)
+	for _, t := range cfg.Tiers {
+		if t.YoungerThan == 0 || age < t.YoungerThan {
+			return t.Name
+		}
+	}
+	return ""
+}
+
+// layout returns the layout of cfg, with the tier folder put first when there are tiers but the
+// layout doesn't say where they go.
+func (cfg Config) layout() string {
+	layout := cfg.Layout
+	if layout == "" {
+		layout = DefaultLayout
+	}
+	if len(cfg.Tiers) > 0 && !strings.Contains(layout, "{tier}") {
+		layout = "{tier}/" + layout
+	}
+	return layout
+}
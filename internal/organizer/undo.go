@@ -0,0 +1,189 @@
+package organizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"filippo.io/age"
+	"github.com/avizyt/org-cli/internal/fsutil"
+	"github.com/avizyt/org-cli/internal/journal"
+	"github.com/avizyt/org-cli/internal/trash"
+)
+
+// Undo reverts the journaled operations of a run, most recent first, putting every file back at
+// its original location. identities are needed to decrypt files that were encrypted on move. It
+// returns how many operations were reverted and how many failed.
+func Undo(entries []journal.Entry, identities []age.Identity, dryRun bool) (undone int, failed int) {
+	undone, failed, _ = undo(context.Background(), fsutil.OS, entries, identities, dryRun, nil, defaultPrinter)
+	return undone, failed
+}
+
+// undo is Undo on fsys that reports to p and stops before the next operation once ctx is
+// cancelled. The operations it reverts are recorded in redo, for Redo. Trashed files are restored
+// from the trash of the operating system whatever fsys is.
+func undo(ctx context.Context, fsys fsutil.FS, entries []journal.Entry, identities []age.Identity, dryRun bool, redo *journal.Journal, p Printer) (undone int, failed int, err error) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return undone, failed, err
+		}
+		e := entries[i]
+		if e.Op == journal.OpDelete {
+			p.File(LevelWarn, "SKIPPED", "'%s' was deleted permanently and cannot be restored", e.Source)
+			continue
+		}
+		if alreadyRestored(fsys, e) {
+			// Left over from an earlier, partially failed undo of the same run
+			p.File(LevelWarn, "SKIPPED", "'%s' was already restored", e.Source)
+			continue
+		}
+		if dryRun {
+			p.File(LevelNotice, "DRY RUN", "Would restore '%s' to '%s'", e.Dest, e.Source)
+			undone++
+			continue
+		}
+		if err := undoEntry(fsys, e, identities); err != nil {
+			p.File(LevelError, "ERROR", "%v", err)
+			failed++
+			continue
+		}
+		if err := redo.Record(e); err != nil {
+			p.File(LevelWarn, "WARNING", "Failed to record the undo of '%s' for redo: %v", e.Source, err)
+		}
+		switch e.Op {
+		case journal.OpCopy:
+			p.File(LevelSuccess, "RESTORED", "Removed copy '%s' of '%s'", e.Dest, e.Source)
+		case journal.OpLink:
+			p.File(LevelSuccess, "RESTORED", "Removed link '%s' to '%s'", e.Dest, e.Source)
+		default:
+			p.File(LevelSuccess, "RESTORED", "Restored '%s' to '%s'", e.Dest, e.Source)
+		}
+		undone++
+	}
+	return undone, failed, nil
+}
+
+// alreadyRestored reports whether e's file is back at its original location on fsys and gone from
+// the destination (for copies and links: whether the copy or link is gone).
+func alreadyRestored(fsys fsutil.FS, e journal.Entry) bool {
+	if _, err := fsys.Lstat(e.Dest); e.Op == journal.OpCopy || e.Op == journal.OpLink {
+		return errors.Is(err, os.ErrNotExist)
+	}
+	if _, err := fsys.Lstat(e.Dest); !errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	_, err := fsys.Lstat(e.Source)
+	return err == nil
+}
+
+// undoEntry reverts a single operation on fsys. The original location must be free again: undo
+// never overwrites a file that appeared there in the meantime.
+func undoEntry(fsys fsutil.FS, e journal.Entry, identities []age.Identity) error {
+	switch e.Op {
+	case journal.OpCopy:
+		// The original never left, so undoing only drops the copy, unless it was changed since
+		if e.Hash != "" {
+			if same, err := matchesHash(fsys, e.Dest, e.Hash); err == nil && !same {
+				return &ConflictError{Path: e.Dest, Reason: "changed since it was copied, not removing it"}
+			}
+		}
+		if err := fsys.Remove(e.Dest); err != nil {
+			return fmt.Errorf("failed to remove copy '%s': %w", e.Dest, err)
+		}
+		return nil
+	case journal.OpLink:
+		if err := fsys.Remove(e.Dest); err != nil {
+			return fmt.Errorf("failed to remove link '%s': %w", e.Dest, err)
+		}
+		return nil
+	}
+
+	if e.Link {
+		// The symlink left at the original location has to make way for the file again
+		if target, err := fsys.Readlink(e.Source); err == nil && target == e.Dest {
+			if err := fsys.Remove(e.Source); err != nil {
+				return fmt.Errorf("failed to remove symlink '%s': %w", e.Source, err)
+			}
+		}
+	}
+	if _, err := fsys.Lstat(e.Source); err == nil {
+		return &ConflictError{Path: e.Source, Reason: "already exists, not restoring over it"}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("cannot restore '%s': %w", e.Source, err)
+	}
+	if err := fsys.MkdirAll(filepath.Dir(e.Source), 0755); err != nil {
+		return fmt.Errorf("failed to recreate directory for '%s': %w", e.Source, err)
+	}
+
+	switch e.Op {
+	case journal.OpMove:
+		if err := fsutil.MoveFile(fsys, e.Dest, e.Source); err != nil {
+			return fmt.Errorf("failed to move '%s' back: %w", e.Dest, err)
+		}
+		return nil
+	case journal.OpTrash:
+		return trash.Restore(e.Dest, e.Source)
+	case journal.OpCompress, journal.OpEncrypt:
+		return restoreTransformed(fsys, e, identities)
+	default:
+		return fmt.Errorf("don't know how to undo '%s' of '%s'", e.Op, e.Source)
+	}
+}
+
+// restoreTransformed restores a file on fsys stored compressed and/or encrypted by transformFile.
+func restoreTransformed(fsys fsutil.FS, e journal.Entry, identities []age.Identity) error {
+	in, err := fsys.Open(e.Dest)
+	if err != nil {
+		return fmt.Errorf("failed to open '%s': %w", e.Dest, err)
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = in
+	if e.Op == journal.OpEncrypt {
+		if len(identities) == 0 {
+			return fmt.Errorf("cannot decrypt '%s': no age identity given (use --identity or %s)", e.Dest, IdentityEnv)
+		}
+		if r, err = age.Decrypt(r, identities...); err != nil {
+			return fmt.Errorf("failed to decrypt '%s': %w", e.Dest, err)
+		}
+	}
+	if e.Codec != "" {
+		dr, err := newDecompressor(e.Codec, r)
+		if err != nil {
+			return fmt.Errorf("failed to read '%s': %w", e.Dest, err)
+		}
+		defer dr.Close()
+		r = dr
+	}
+
+	perm := e.Mode.Perm()
+	if perm == 0 {
+		perm = 0644 // Recorded before the permissions were
+	}
+	out, err := fsys.OpenFile(e.Source, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return fmt.Errorf("failed to create '%s': %w", e.Source, err)
+	}
+	_, err = io.Copy(out, r)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fsys.Remove(e.Source)
+		return fmt.Errorf("failed to restore '%s': %w", e.Dest, err)
+	}
+	fsys.Chtimes(e.Source, info.ModTime(), info.ModTime())
+
+	in.Close()
+	if err := fsys.Remove(e.Dest); err != nil {
+		return fmt.Errorf("restored '%s' but failed to remove '%s': %w", e.Source, e.Dest, err)
+	}
+	return nil
+}
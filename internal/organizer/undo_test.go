@@ -0,0 +1,83 @@
+package organizer
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"github.com/avizyt/org-cli/internal/journal"
+)
+
+func TestUndoUsesFS(t *testing.T) {
+	dir := t.TempDir()
+	moved := journal.Entry{Op: journal.OpMove, Source: filepath.Join(dir, "restored", "a.txt"), Dest: filepath.Join(dir, "Documents", "a.txt")}
+	writeFile(t, moved.Dest, "a")
+
+	org, err := New(WithFS(failing("restored", syscall.EACCES, "rename", "link", "create")), WithPrinter(PlainPrinter(io.Discard)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	undone, failed, err := org.Undo(context.Background(), []journal.Entry{moved})
+	if err != nil || undone != 0 || failed != 1 {
+		t.Errorf("Undo = %d undone, %d failed, %v; want the file system to refuse the restore", undone, failed, err)
+	}
+	if _, err := os.Stat(moved.Dest); err != nil {
+		t.Errorf("file left its destination: %v", err)
+	}
+
+	org, err = New(WithPrinter(PlainPrinter(io.Discard)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if undone, failed, err := org.Undo(context.Background(), []journal.Entry{moved}); undone != 1 || failed != 0 || err != nil {
+		t.Errorf("Undo = %d undone, %d failed, %v; want it restored", undone, failed, err)
+	}
+	if _, err := os.Stat(moved.Source); err != nil {
+		t.Errorf("file not restored: %v", err)
+	}
+}
+
+func TestCompressKeepsPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no permission bits on Windows")
+	}
+	source, dest := t.TempDir(), t.TempDir()
+	secret := filepath.Join(source, "secret.txt")
+	writeFile(t, secret, "secret")
+	if err := os.Chmod(secret, 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := testConfig(t, source, dest)
+	cfg.Compress = "gzip"
+	j, err := journal.Create(t.TempDir(), "run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Journal = j
+	if result, err := OrganizeFiles(cfg, nil); err != nil || result.Processed != 1 {
+		t.Fatalf("OrganizeFiles = %d processed, %v; want 1", result.Processed, err)
+	}
+	j.Close()
+	if info, err := os.Stat(filepath.Join(dest, "Documents", "secret.txt.gz")); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("compressed file %v, %v; want mode 0600", info, err)
+	}
+
+	entries, err := journal.Load(j.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	org, err := New(WithPrinter(PlainPrinter(io.Discard)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if undone, failed, err := org.Undo(context.Background(), entries); undone != 1 || failed != 0 || err != nil {
+		t.Fatalf("Undo = %d undone, %d failed, %v; want it restored", undone, failed, err)
+	}
+	if info, err := os.Stat(secret); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("restored file %v, %v; want mode 0600", info, err)
+	}
+}
@@ -0,0 +1,51 @@
+package organizer
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// VendorCategory is the category whose files Config.Vendors files into per-vendor folders.
+const VendorCategory = "Documents"
+
+// vendorWords makes vendor and file names compare ignoring how words are separated.
+var vendorWords = strings.NewReplacer("_", " ", "-", " ", ".", " ")
+
+// vendorFolder returns the folder below the category folder of documents that cfg.Vendors files
+// the document named name in, and whether a vendor name is in it. Vendor names match anywhere in
+// the file name, ignoring case and whether words are separated by spaces, dots, dashes or
+// underscores; the longest one wins, so "Chase Sapphire" beats "Chase".
+func (cfg Config) vendorFolder(name string) (string, bool) {
+	lower := vendorWords.Replace(strings.ToLower(name))
+	best := ""
+	for vendor := range cfg.Vendors {
+		v := vendorWords.Replace(strings.ToLower(vendor))
+		if !strings.Contains(lower, v) {
+			continue
+		}
+		if len(v) > len(best) || len(v) == len(best) && vendor < best {
+			best = vendor
+		}
+	}
+	if best == "" {
+		return "", false
+	}
+	return cfg.Vendors[best], true
+}
+
+// validateVendors checks that the folders of vendors are relative paths that stay in the
+// category folder.
+func validateVendors(vendors map[string]string) error {
+	for vendor, folder := range vendors {
+		if strings.TrimSpace(vendor) == "" {
+			return fmt.Errorf("empty vendor name for folder '%s'", folder)
+		}
+		clean := path.Clean(filepath.ToSlash(folder))
+		if folder == "" || path.IsAbs(clean) || filepath.IsAbs(folder) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("invalid folder '%s' for vendor '%s' (use a relative path like Banking/Chase)", folder, vendor)
+		}
+	}
+	return nil
+}
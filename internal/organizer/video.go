@@ -0,0 +1,341 @@
+package organizer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// ClipsFolder is the folder in the category folder that videos shorter than Config.ClipsUnder
+// go into.
+const ClipsFolder = "Clips"
+
+// videoInfo is what the container of a video says about it; zero values are unknown.
+type videoInfo struct {
+	Width, Height int
+	Duration      time.Duration
+}
+
+// resolution returns the resolution class of v for {resolution} in the layout: 4K, 1080p, 720p
+// or SD, by the shorter side so that portrait videos class like landscape ones, or "" if the
+// size is unknown.
+func (v videoInfo) resolution() string {
+	short, long := min(v.Width, v.Height), max(v.Width, v.Height)
+	switch {
+	case short <= 0:
+		return ""
+	case short >= 2160 || long >= 3840:
+		return "4K"
+	case short >= 1080 || long >= 1920:
+		return "1080p"
+	case short >= 720 || long >= 1280:
+		return "720p"
+	}
+	return "SD"
+}
+
+// readsVideos reports whether the layout or ClipsUnder need the containers of videos read.
+func (cfg Config) readsVideos() bool {
+	return cfg.ClipsUnder > 0 || strings.Contains(cfg.layout(), "{resolution}")
+}
+
+// probeVideo reads the size and duration of the video at path on fsys from its MP4, QuickTime,
+// Matroska or WebM container, and reports whether it is one of those.
+func probeVideo(fsys fsutil.FS, path string) (videoInfo, bool) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return videoInfo{}, false
+	}
+	defer f.Close()
+	var head [12]byte
+	if _, err := io.ReadFull(f, head[:]); err != nil {
+		return videoInfo{}, false
+	}
+	switch {
+	case isMovie(head[:]):
+		return movieInfo(f), true
+	case bytes.HasPrefix(head[:], []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return matroskaInfo(f), true
+	}
+	return videoInfo{}, false
+}
+
+// isMovie reports whether a file starting with head is an MP4 or QuickTime file.
+func isMovie(head []byte) bool {
+	switch string(head[4:8]) {
+	case "ftyp", "moov", "wide", "mdat", "free":
+		return true
+	}
+	return false
+}
+
+// movieInfo reads the duration from the movie header and the size from the header of the first
+// track with one, the video track.
+func movieInfo(f fsutil.File) videoInfo {
+	var v videoInfo
+	if mvhd := findBox(f, "moov", "mvhd"); len(mvhd) >= 32 {
+		var timescale, duration uint64
+		if mvhd[0] == 1 {
+			timescale, duration = uint64(binary.BigEndian.Uint32(mvhd[20:])), binary.BigEndian.Uint64(mvhd[24:])
+		} else {
+			timescale, duration = uint64(binary.BigEndian.Uint32(mvhd[12:])), uint64(binary.BigEndian.Uint32(mvhd[16:]))
+		}
+		if timescale > 0 {
+			v.Duration = time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+		}
+	}
+	moov, size, ok := boxAt(f, 0, fileSize(f), "moov")
+	if !ok {
+		return v
+	}
+	eachBox(f, moov, moov+size, func(typ string, off, size int64) bool {
+		if typ != "trak" {
+			return true
+		}
+		tkhdOff, tkhdSize, ok := boxAt(f, off, off+size, "tkhd")
+		if !ok || tkhdSize < 84 {
+			return true
+		}
+		var dims [8]byte // Width and height, 16.16 fixed point, end the track header
+		if _, err := f.ReadAt(dims[:], tkhdOff+tkhdSize-8); err != nil {
+			return true
+		}
+		v.Width, v.Height = int(binary.BigEndian.Uint32(dims[:4])>>16), int(binary.BigEndian.Uint32(dims[4:])>>16)
+		return v.Width == 0 // Audio tracks have no size
+	})
+	return v
+}
+
+// maxBoxRead is the most findBox reads into memory of the box it looks for.
+const maxBoxRead = 1 << 20
+
+// findBox returns the content of the box at the end of path, a path of nested ISO base media
+// boxes ("moov", "mvhd") starting at the top level of f, or nil if there is none.
+func findBox(f fsutil.File, path ...string) []byte {
+	off, size := int64(0), fileSize(f)
+	for _, typ := range path {
+		var ok bool
+		if off, size, ok = boxAt(f, off, off+size, typ); !ok {
+			return nil
+		}
+	}
+	data := make([]byte, min(size, maxBoxRead))
+	if _, err := f.ReadAt(data, off); err != nil {
+		return nil
+	}
+	return data
+}
+
+// boxAt returns the offset and size of the content of the first box of type typ between start
+// and end of r.
+func boxAt(r io.ReaderAt, start, end int64, typ string) (off, size int64, ok bool) {
+	eachBox(r, start, end, func(t string, o, s int64) bool {
+		if t == typ {
+			off, size, ok = o, s, true
+		}
+		return !ok
+	})
+	return off, size, ok
+}
+
+// eachBox calls fn with the type and the offset and size of the content of the boxes between
+// start and end of r, until fn returns false.
+func eachBox(r io.ReaderAt, start, end int64, fn func(typ string, off, size int64) bool) {
+	var header [16]byte
+	for pos := start; pos+8 <= end; {
+		if _, err := r.ReadAt(header[:8], pos); err != nil {
+			return
+		}
+		size, headerSize := int64(binary.BigEndian.Uint32(header[:4])), int64(8)
+		switch size {
+		case 1: // 64-bit size after the type
+			if _, err := r.ReadAt(header[8:16], pos+8); err != nil {
+				return
+			}
+			size, headerSize = int64(binary.BigEndian.Uint64(header[8:16])), 16
+		case 0: // Up to the end
+			size = end - pos
+		}
+		if size < headerSize || pos+size > end {
+			return
+		}
+		if !fn(string(header[4:8]), pos+headerSize, size-headerSize) {
+			return
+		}
+		pos += size
+	}
+}
+
+// fileSize returns the size of f, or 0 if it cannot be told.
+func fileSize(f fsutil.File) int64 {
+	info, err := f.Stat()
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// Matroska element IDs, with their length marker bits.
+const (
+	mkvSegment       = 0x18538067
+	mkvInfo          = 0x1549A966
+	mkvTimecodeScale = 0x2AD7B1
+	mkvDuration      = 0x4489
+	mkvTracks        = 0x1654AE6B
+	mkvTrackEntry    = 0xAE
+	mkvVideo         = 0xE0
+	mkvPixelWidth    = 0xB0
+	mkvPixelHeight   = 0xBA
+	mkvCluster       = 0x1F43B675
+)
+
+// matroskaInfo reads the duration from the segment info and the size from the first video track
+// of a Matroska or WebM file. They come before the clusters with the frames, where it stops.
+func matroskaInfo(f fsutil.File) videoInfo {
+	var v videoInfo
+	end := fileSize(f)
+	var segment, segmentEnd int64 = -1, end
+	eachElement(f, 0, end, func(id uint64, off, size int64) bool {
+		if id == mkvSegment {
+			segment, segmentEnd = off, min(off+size, end)
+		}
+		return segment < 0
+	})
+	if segment < 0 {
+		return v
+	}
+	eachElement(f, segment, segmentEnd, func(id uint64, off, size int64) bool {
+		switch id {
+		case mkvInfo:
+			data := readElement(f, off, size)
+			scale, duration := uint64(1000000), 0.0 // Timestamps count in milliseconds by default
+			eachElement(bytes.NewReader(data), 0, int64(len(data)), func(id uint64, off, size int64) bool {
+				switch value := data[off : off+size]; id {
+				case mkvTimecodeScale:
+					scale = ebmlUint(value)
+				case mkvDuration:
+					duration = ebmlFloat(value)
+				}
+				return true
+			})
+			v.Duration = time.Duration(duration * float64(scale))
+		case mkvTracks:
+			data := readElement(f, off, size)
+			tracks := bytes.NewReader(data)
+			eachElement(tracks, 0, int64(len(data)), func(id uint64, off, size int64) bool {
+				if id != mkvTrackEntry {
+					return true
+				}
+				eachElement(tracks, off, off+size, func(id uint64, off, size int64) bool {
+					if id != mkvVideo {
+						return true
+					}
+					eachElement(tracks, off, off+size, func(id uint64, off, size int64) bool {
+						switch id {
+						case mkvPixelWidth:
+							v.Width = int(ebmlUint(data[off : off+size]))
+						case mkvPixelHeight:
+							v.Height = int(ebmlUint(data[off : off+size]))
+						}
+						return true
+					})
+					return false
+				})
+				return v.Width == 0
+			})
+		case mkvCluster:
+			return false
+		}
+		return true
+	})
+	return v
+}
+
+// readElement returns the content of the element at off of r, up to maxBoxRead bytes of it.
+func readElement(r io.ReaderAt, off, size int64) []byte {
+	data := make([]byte, min(size, maxBoxRead))
+	n, _ := r.ReadAt(data, off)
+	return data[:n]
+}
+
+// eachElement calls fn with the ID and the offset and size of the content of the EBML elements
+// between start and end of r, until fn returns false. An element of unknown size, as streamed
+// segments and clusters are, gets the rest up to end and is the last.
+func eachElement(r io.ReaderAt, start, end int64, fn func(id uint64, off, size int64) bool) {
+	var buf [8]byte
+	for pos := start; pos < end; {
+		id, n := readVint(r, pos, buf[:], false)
+		if n == 0 {
+			return
+		}
+		size, m := readVint(r, pos+int64(n), buf[:], true)
+		off := pos + int64(n+m)
+		if m == 0 || size != math.MaxUint64 && off+int64(size) > end {
+			return
+		}
+		if size == math.MaxUint64 {
+			fn(id, off, end-off)
+			return
+		}
+		if !fn(id, off, int64(size)) {
+			return
+		}
+		pos = off + int64(size)
+	}
+}
+
+// readVint reads the EBML variable length integer at off of r and returns it and its length in
+// bytes, 0 if there is none. Sizes lose their length marker, all ones being math.MaxUint64
+// (unknown); IDs keep it.
+func readVint(r io.ReaderAt, off int64, buf []byte, isSize bool) (uint64, int) {
+	if _, err := r.ReadAt(buf[:1], off); err != nil || buf[0] == 0 {
+		return 0, 0
+	}
+	n := 1
+	for buf[0]&(0x80>>(n-1)) == 0 {
+		n++
+	}
+	if n > 1 {
+		if _, err := r.ReadAt(buf[1:n], off+1); err != nil {
+			return 0, 0
+		}
+	}
+	value := uint64(buf[0])
+	if isSize {
+		value &^= 0x80 >> (n - 1)
+	}
+	ones := value == uint64(0x7F>>(n-1))
+	for _, b := range buf[1:n] {
+		value = value<<8 | uint64(b)
+		ones = ones && b == 0xFF
+	}
+	if isSize && ones {
+		return math.MaxUint64, n
+	}
+	return value, n
+}
+
+// ebmlUint decodes an EBML unsigned integer.
+func ebmlUint(b []byte) uint64 {
+	var value uint64
+	for _, c := range b {
+		value = value<<8 | uint64(c)
+	}
+	return value
+}
+
+// ebmlFloat decodes an EBML float of 4 or 8 bytes.
+func ebmlFloat(b []byte) float64 {
+	switch len(b) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
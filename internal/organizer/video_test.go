@@ -0,0 +1,49 @@
+package organizer
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// box returns the ISO base media box typ with content.
+func box(typ string, content ...[]byte) []byte {
+	var size int
+	for _, c := range content {
+		size += len(c)
+	}
+	b := binary.BigEndian.AppendUint32(nil, uint32(8+size))
+	b = append(b, typ...)
+	for _, c := range content {
+		b = append(b, c...)
+	}
+	return b
+}
+
+func TestProbeVideo(t *testing.T) {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000) // Timescale
+	binary.BigEndian.PutUint32(mvhd[16:], 4500) // Duration
+	tkhd := make([]byte, 84)
+	binary.BigEndian.PutUint32(tkhd[76:], 1080<<16)
+	binary.BigEndian.PutUint32(tkhd[80:], 1920<<16)
+	movie := append(box("ftyp", []byte("isom\x00\x00\x02\x00")), box("moov", box("mvhd", mvhd), box("trak", box("tkhd", tkhd)))...)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "clip.mp4")
+	writeFile(t, path, string(movie))
+	v, ok := probeVideo(fsutil.OS, path)
+	if want := (videoInfo{Width: 1080, Height: 1920, Duration: 4500 * time.Millisecond}); !ok || v != want {
+		t.Errorf("probeVideo = %+v, %t; want %+v", v, ok, want)
+	}
+	if v.resolution() != "1080p" {
+		t.Errorf("resolution = %q, want 1080p", v.resolution())
+	}
+	if _, ok := probeVideo(failing(dir, syscall.EACCES, "open"), path); ok {
+		t.Error("probeVideo did not read through the given file system")
+	}
+}
@@ -0,0 +1,87 @@
+package organizer
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrFileTimeout is the reason of files a worker gave up on because they made no progress for
+// Config.FileTimeout.
+var ErrFileTimeout = errors.New("no progress, gave up on the file")
+
+// watchdog tracks the progress of a single file for Config.FileTimeout. A nil watchdog never
+// gives up.
+type watchdog struct {
+	last      atomic.Int64 // When the file last made progress, in Unix nanoseconds
+	abandoned atomic.Bool
+}
+
+// touch records progress.
+func (w *watchdog) touch() {
+	if w != nil {
+		w.last.Store(time.Now().UnixNano())
+	}
+}
+
+// givenUp reports whether the worker gave up on the file. What is still working on it stops at
+// the next write or before anything is put in place.
+func (w *watchdog) givenUp() bool {
+	return w != nil && w.abandoned.Load()
+}
+
+// processWatched is processFile, given up on once the file makes no progress for cfg.FileTimeout:
+// the worker reports it as failed and goes on with the next file, while a write or a rename that
+// hangs on a dying disk or an unreachable share is left to return whenever it does. The updates
+// of the file count as progress, and so does every write of a copy; those of a file given up on
+// are dropped, and its staged copy is removed instead of put in place.
+func (cfg Config) processWatched(fm FileMove, progressChan chan<- ProgressUpdate) error {
+	if cfg.FileTimeout <= 0 {
+		return processFile(fm, cfg, progressChan)
+	}
+	fm.started, fm.clock = cfg.now(), cfg.Clock
+	fm.watch = &watchdog{}
+	fm.watch.touch()
+	updates := make(chan ProgressUpdate, 16)
+	done := make(chan error, 1)
+	go func() { done <- processFile(fm, cfg, updates) }()
+
+	timer := time.NewTimer(cfg.FileTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case update := <-updates:
+			fm.watch.touch()
+			progressChan <- update
+		case err := <-done:
+			for {
+				select {
+				case update := <-updates:
+					progressChan <- update
+				default:
+					return err
+				}
+			}
+		case <-timer.C:
+			idle := time.Since(time.Unix(0, fm.watch.last.Load()))
+			if idle < cfg.FileTimeout {
+				timer.Reset(cfg.FileTimeout - idle)
+				continue
+			}
+			fm.watch.abandoned.Store(true)
+			go func() { // Until the file is done with, whenever that is
+				for {
+					select {
+					case <-updates:
+					case <-done:
+						return
+					}
+				}
+			}()
+			err := fmt.Errorf("'%s': %w after %s", fm.SourcePath, ErrFileTimeout, cfg.FileTimeout)
+			fm.reportFailure(cfg.printer(), err, progressChan)
+			return &MoveError{Source: fm.SourcePath, Dest: fm.DestPath, Err: err}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package organizer
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebDAV credentials can be supplied through these environment variables instead of the URL.
+const (
+	WebDAVUserEnv     = "ORG_CLI_WEBDAV_USER"
+	WebDAVPasswordEnv = "ORG_CLI_WEBDAV_PASSWORD"
+)
+
+// WebDAVClient uploads organized files to a WebDAV server such as Nextcloud or ownCloud.
+// Category directories are created with MKCOL and uploads use If-None-Match so that an existing
+// remote file is never overwritten.
+type WebDAVClient struct {
+	base     *url.URL // Collection all categories are created under, always ending in "/"
+	user     string
+	password string
+	client   *http.Client
+
+	mu      sync.Mutex
+	created map[string]bool // Collections known to exist, to avoid repeated MKCOLs
+}
+
+// NewWebDAVClient creates a client for rawURL. Credentials are taken from the URL's user info or,
+// if absent, from ORG_CLI_WEBDAV_USER and ORG_CLI_WEBDAV_PASSWORD.
+func NewWebDAVClient(rawURL string) (*WebDAVClient, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WebDAV URL '%s': %w", rawURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid WebDAV URL '%s': expected http(s)://host/path", rawURL)
+	}
+
+	c := &WebDAVClient{
+		client:  &http.Client{Timeout: 10 * time.Minute},
+		created: map[string]bool{"": true},
+	}
+	if u.User != nil {
+		c.user = u.User.Username()
+		c.password, _ = u.User.Password()
+		u.User = nil
+	} else {
+		c.user = os.Getenv(WebDAVUserEnv)
+		c.password = os.Getenv(WebDAVPasswordEnv)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	c.base = u
+	return c, nil
+}
+
+// String returns the base URL without credentials, safe for printing.
+func (c *WebDAVClient) String() string {
+	return c.base.String()
+}
+
+// MkdirAll creates the collection at rel (slash separated, relative to the base URL) and all of
+// its parents that don't exist yet.
+func (c *WebDAVClient) MkdirAll(rel string) error {
+	rel = strings.Trim(rel, "/")
+	if rel == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock() // Serialize MKCOLs so concurrent workers don't race on the same parents
+
+	current := ""
+	for _, part := range strings.Split(rel, "/") {
+		current = path.Join(current, part)
+		if c.created[current] {
+			continue
+		}
+		resp, err := c.do("MKCOL", current+"/", nil, -1, nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		// 201 Created, or 405 Method Not Allowed when the collection already exists
+		if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+			return fmt.Errorf("MKCOL '%s' failed: %s", current, resp.Status)
+		}
+		c.created[current] = true
+	}
+	return nil
+}
+
+// Put uploads size bytes from r to rel. It returns errRemoteExists if a file is already present
+// at rel, as reported by the server through 412 Precondition Failed.
+func (c *WebDAVClient) Put(rel string, r io.Reader, size int64) error {
+	return c.put(rel, r, size, map[string]string{"If-None-Match": "*"})
+}
+
+// Overwrite uploads size bytes from r to rel, replacing any file already present there.
+func (c *WebDAVClient) Overwrite(rel string, r io.Reader, size int64) error {
+	return c.put(rel, r, size, nil)
+}
+
+// put sends a PUT request for rel with the given headers.
+func (c *WebDAVClient) put(rel string, r io.Reader, size int64, headers map[string]string) error {
+	resp, err := c.do(http.MethodPut, rel, r, size, headers)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		return errRemoteExists
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	default:
+		return fmt.Errorf("PUT '%s' failed: %s", rel, resp.Status)
+	}
+}
+
+// do sends a request for rel, which is escaped segment by segment and resolved against the base URL.
+func (c *WebDAVClient) do(method, rel string, body io.Reader, size int64, headers map[string]string) (*http.Response, error) {
+	segments := strings.Split(rel, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	target := *c.base
+	target.Path = c.base.Path + rel
+	target.RawPath = c.base.EscapedPath() + strings.Join(segments, "/")
+
+	req, err := http.NewRequest(method, target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 {
+		req.ContentLength = size
+	}
+	if c.user != "" || c.password != "" {
+		req.SetBasicAuth(c.user, c.password)
+	}
+	req.Header.Set("User-Agent", "org-cli")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return c.client.Do(req)
+}
@@ -0,0 +1,8 @@
+//go:build !(windows && (amd64 || arm64))
+
+package trash
+
+// recycle is only implemented for 64-bit Windows.
+func recycle(abs string) (string, error) {
+	return "", errNoRecycleBin
+}
@@ -0,0 +1,136 @@
+//go:build windows && (amd64 || arm64)
+
+package trash
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var procSHFileOperationW = windows.NewLazySystemDLL("shell32.dll").NewProc("SHFileOperationW")
+
+const (
+	foDelete          = 0x0003
+	fofSilent         = 0x0004
+	fofNoConfirmation = 0x0010
+	fofAllowUndo      = 0x0040
+	fofNoErrorUI      = 0x0400
+)
+
+// shFileOpStruct is SHFILEOPSTRUCTW with the natural alignment of 64-bit Windows; the 32-bit
+// header packs it to single bytes, which is why this file is limited to 64-bit builds.
+type shFileOpStruct struct {
+	hwnd                  uintptr
+	wFunc                 uint32
+	pFrom                 *uint16
+	pTo                   *uint16
+	fFlags                uint16
+	fAnyOperationsAborted int32
+	hNameMappings         uintptr
+	lpszProgressTitle     *uint16
+}
+
+// recycle deletes abs through the shell so it lands in the Recycle Bin, and returns where the bin
+// keeps it ($Recycle.Bin\<SID>\$R...). Only fixed drives have a Recycle Bin; for anything else
+// the shell would delete the file for good, so errNoRecycleBin is returned without touching it.
+func recycle(abs string) (string, error) {
+	volume := filepath.VolumeName(abs) + `\`
+	root, err := windows.UTF16PtrFromString(volume)
+	if err != nil {
+		return "", err
+	}
+	if windows.GetDriveType(root) != windows.DRIVE_FIXED {
+		return "", errNoRecycleBin
+	}
+	token, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return "", errNoRecycleBin
+	}
+	bin := filepath.Join(volume, "$Recycle.Bin", token.User.Sid.String())
+
+	from, err := syscall.UTF16FromString(abs)
+	if err != nil {
+		return "", err
+	}
+	op := shFileOpStruct{
+		wFunc:  foDelete,
+		pFrom:  &append(from, 0)[0], // A list of paths, ended by an empty one
+		fFlags: fofAllowUndo | fofNoConfirmation | fofSilent | fofNoErrorUI,
+	}
+	if ret, _, _ := procSHFileOperationW.Call(uintptr(unsafe.Pointer(&op))); ret != 0 {
+		// Mostly plain Windows error codes, so sharing violations are recognized as busy files
+		return "", fmt.Errorf("failed to move '%s' to the Recycle Bin: %w", abs, syscall.Errno(ret))
+	}
+	if op.fAnyOperationsAborted != 0 {
+		return "", fmt.Errorf("moving '%s' to the Recycle Bin was cancelled", abs)
+	}
+
+	trashed, err := findRecycled(bin, abs)
+	if err != nil {
+		return "", fmt.Errorf("moved '%s' to the Recycle Bin but cannot restore it with undo: %w", abs, err)
+	}
+	return trashed, nil
+}
+
+// findRecycled returns the $R file in bin holding the most recently deleted file that was at
+// original. Every $R file has a $I record next to it with its original path and deletion time.
+func findRecycled(bin, original string) (string, error) {
+	records, err := filepath.Glob(filepath.Join(bin, "$I*"))
+	if err != nil {
+		return "", err
+	}
+	var found string
+	var latest uint64
+	for _, record := range records {
+		path, deleted, err := readRecycleRecord(record)
+		if err != nil || !strings.EqualFold(path, original) || deleted < latest {
+			continue
+		}
+		found, latest = filepath.Join(bin, "$R"+filepath.Base(record)[2:]), deleted
+	}
+	if found == "" {
+		return "", errors.New("no Recycle Bin record found")
+	}
+	return found, nil
+}
+
+// readRecycleRecord parses a $I record: version, file size and deletion time (a FILETIME), then
+// the original path as UTF-16, in a fixed MAX_PATH field (version 1) or with its length in front
+// (version 2, Windows 10 and later).
+func readRecycleRecord(name string) (path string, deleted uint64, err error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(data) < 24 {
+		return "", 0, errors.New("truncated record")
+	}
+	deleted = binary.LittleEndian.Uint64(data[16:24])
+	raw := data[24:]
+	if binary.LittleEndian.Uint64(data[0:8]) == 2 {
+		if len(raw) < 4 {
+			return "", 0, errors.New("truncated record")
+		}
+		raw = raw[4:]
+	}
+	chars := make([]uint16, len(raw)/2)
+	for i := range chars {
+		chars[i] = binary.LittleEndian.Uint16(raw[2*i:])
+	}
+	for i, c := range chars {
+		if c == 0 {
+			chars = chars[:i]
+			break
+		}
+	}
+	return string(utf16.Decode(chars)), deleted, nil
+}
@@ -0,0 +1,131 @@
+// Package trash moves files into the desktop trash instead of deleting them, so that cleanup
+// operations stay recoverable with the user's normal tools.
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avizyt/org-cli/internal/fsutil"
+)
+
+// errNoRecycleBin is returned by recycle when the file cannot go to the Windows Recycle Bin.
+var errNoRecycleBin = errors.New("no Recycle Bin")
+
+// Move puts the file at path into the trash and returns its new location. On Linux and other
+// Unix desktops the freedesktop.org trash ($XDG_DATA_HOME/Trash) is used, including the
+// .trashinfo record file managers need to offer "Restore"; on macOS it is ~/.Trash. On Windows
+// files on fixed drives go to the Recycle Bin, where Explorer can restore them; files on network
+// and removable drives, which have no Recycle Bin, go to an org-cli Trash folder in the user's
+// local app data.
+func Move(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate the trash: %w", err)
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		return moveInto(abs, filepath.Join(home, ".Trash"), nil)
+	case "windows":
+		if trashed, err := recycle(abs); !errors.Is(err, errNoRecycleBin) {
+			return trashed, err
+		}
+		dir := os.Getenv("LocalAppData")
+		if dir == "" {
+			dir = filepath.Join(home, "AppData", "Local")
+		}
+		return moveInto(abs, filepath.Join(dir, "org-cli", "Trash"), nil)
+	default:
+		dataHome := os.Getenv("XDG_DATA_HOME")
+		if dataHome == "" {
+			dataHome = filepath.Join(home, ".local", "share")
+		}
+		trashDir := filepath.Join(dataHome, "Trash")
+		return moveInto(abs, filepath.Join(trashDir, "files"), func(name string) error {
+			return writeTrashInfo(filepath.Join(trashDir, "info"), name, abs)
+		})
+	}
+}
+
+// moveInto moves abs into dir under a name that is not taken yet. reserve, if set, is called
+// with each candidate name and must fail with os.ErrExist when the name is in use; the record it
+// created is removed again if the name turns out to be taken in dir.
+func moveInto(abs, dir string, reserve func(name string) error) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create trash directory '%s': %w", dir, err)
+	}
+
+	ext := filepath.Ext(abs)
+	stem := strings.TrimSuffix(filepath.Base(abs), ext)
+	for i := 0; ; i++ {
+		name := filepath.Base(abs)
+		if i > 0 {
+			name = stem + "." + strconv.Itoa(i) + ext
+		}
+		target := filepath.Join(dir, name)
+		if reserve != nil {
+			if err := reserve(name); errors.Is(err, os.ErrExist) {
+				continue
+			} else if err != nil {
+				return "", err
+			}
+		}
+		err := fsutil.MoveFile(fsutil.OS, abs, target)
+		if err == nil {
+			return target, nil
+		}
+		if reserve != nil {
+			// Left behind, the record would describe the file that holds the name
+			os.Remove(filepath.Join(filepath.Dir(dir), "info", name+".trashinfo"))
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", fmt.Errorf("failed to move '%s' to the trash: %w", abs, err)
+		}
+	}
+}
+
+// writeTrashInfo creates the freedesktop.org .trashinfo record for a trashed file exclusively.
+func writeTrashInfo(infoDir, name, original string) error {
+	if err := os.MkdirAll(infoDir, 0700); err != nil {
+		return fmt.Errorf("failed to create trash directory '%s': %w", infoDir, err)
+	}
+	f, err := os.OpenFile(filepath.Join(infoDir, name+".trashinfo"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	escaped := (&url.URL{Path: original}).EscapedPath()
+	_, err = fmt.Fprintf(f, "[Trash Info]\nPath=%s\nDeletionDate=%s\n", escaped, time.Now().Format("2006-01-02T15:04:05"))
+	return err
+}
+
+// Restore moves a file that Move put into the trash back to original. original must not exist.
+func Restore(trashed, original string) error {
+	if _, err := os.Lstat(original); err == nil {
+		return fmt.Errorf("cannot restore '%s': a file already exists there", original)
+	}
+	if err := os.MkdirAll(filepath.Dir(original), 0755); err != nil {
+		return err
+	}
+	if err := fsutil.MoveFile(fsutil.OS, trashed, original); err != nil {
+		return fmt.Errorf("failed to restore '%s' from the trash: %w", original, err)
+	}
+	// Drop the freedesktop.org record or the $I record of the Recycle Bin, if there is one
+	os.Remove(filepath.Join(filepath.Dir(filepath.Dir(trashed)), "info", filepath.Base(trashed)+".trashinfo"))
+	if name := filepath.Base(trashed); runtime.GOOS == "windows" && strings.HasPrefix(name, "$R") {
+		os.Remove(filepath.Join(filepath.Dir(trashed), "$I"+name[2:]))
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMoveIntoSkipsTakenNames(t *testing.T) {
+	trashDir, source := t.TempDir(), t.TempDir()
+	files, info := filepath.Join(trashDir, "files"), filepath.Join(trashDir, "info")
+	if err := os.MkdirAll(files, 0700); err != nil {
+		t.Fatal(err)
+	}
+	// A file in the trash without its record, as left by another program
+	if err := os.WriteFile(filepath.Join(files, "a.txt"), []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	abs := filepath.Join(source, "a.txt")
+	if err := os.WriteFile(abs, []byte("new"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	target, err := moveInto(abs, files, func(name string) error {
+		return writeTrashInfo(info, name, abs)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(files, "a.1.txt"); target != want {
+		t.Errorf("trashed as %s, want %s", target, want)
+	}
+	entries, err := os.ReadDir(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []string
+	for _, e := range entries {
+		records = append(records, e.Name())
+	}
+	if !slices.Equal(records, []string{"a.1.txt.trashinfo"}) {
+		t.Errorf("records %v, want only the one of a.1.txt", records)
+	}
+	if data, err := os.ReadFile(filepath.Join(files, "a.txt")); err != nil || string(data) != "old" {
+		t.Errorf("file in the trash = %q, %v; want it untouched", data, err)
+	}
+}